@@ -0,0 +1,140 @@
+// jwtkeys 管理 JWT 签名密钥目录。
+//
+// 零停机轮换流程：
+//
+//	jwtkeys generate            # 生成新密钥，此时仅作为验证密钥发布
+//	# 等待所有实例重新加载密钥目录（默认每分钟一次）
+//	jwtkeys activate <kid>      # 切换为签名密钥
+//	# 等待至少一个 access token 有效期（JWT_EXPIRE_HOURS）
+//	jwtkeys retire <old-kid>    # 删除旧密钥
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ysmmc/backend/internal/config"
+	"github.com/ysmmc/backend/pkg/auth"
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, `Usage: jwtkeys [-dir path] <command> [args]
+
+Commands:
+  list                      list keys in the key directory
+  generate [-alg EdDSA|RS256] [-activate]
+                            generate a new key (verification only unless -activate)
+  activate <kid>            make <kid> the signing key
+  rotate [-alg EdDSA|RS256] generate a new key and activate it immediately
+  retire <kid>              delete a key that is no longer used for signing
+
+The key directory defaults to JWT_KEY_DIR (./keys).
+`)
+}
+
+func main() {
+	config.LoadConfig()
+
+	dir := flag.String("dir", config.AppConfig.JWTKeyDir, "key directory")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, args := flag.Arg(0), flag.Args()[1:]
+	var err error
+	switch cmd {
+	case "list":
+		err = list(*dir)
+	case "generate":
+		err = generate(*dir, args, false)
+	case "rotate":
+		err = generate(*dir, args, true)
+	case "activate":
+		if len(args) != 1 {
+			usage()
+			os.Exit(2)
+		}
+		err = auth.SetActiveKey(*dir, args[0])
+		if err == nil {
+			fmt.Printf("Activated %s\n", args[0])
+		}
+	case "retire":
+		if len(args) != 1 {
+			usage()
+			os.Exit(2)
+		}
+		err = auth.RetireKey(*dir, args[0])
+		if err == nil {
+			fmt.Printf("Retired %s\n", args[0])
+		}
+	default:
+		usage()
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "jwtkeys %s: %v\n", cmd, err)
+		os.Exit(1)
+	}
+}
+
+func list(dir string) error {
+	ks, err := auth.LoadKeySet(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, k := range ks.Keys() {
+		status := "verify"
+		if k.ID == ks.Active().ID {
+			status = "active"
+		} else if !k.CanSign() {
+			status = "verify (public only)"
+		}
+		fmt.Printf("%-24s %-6s %s\n", k.ID, k.Algorithm, status)
+	}
+	return nil
+}
+
+func generate(dir string, args []string, activate bool) error {
+	fs := flag.NewFlagSet("generate", flag.ExitOnError)
+	alg := fs.String("alg", auth.AlgEdDSA, "signing algorithm (EdDSA or RS256)")
+	fs.BoolVar(&activate, "activate", activate, "activate the key immediately")
+	fs.Parse(args)
+
+	// 记录当前签名密钥，避免新增私钥后无法判断哪一把在用；目录中还没有密钥时直接启用新密钥
+	if current, err := auth.LoadKeySet(dir); err == nil {
+		if err := auth.SetActiveKey(dir, current.Active().ID); err != nil {
+			return err
+		}
+	} else if existing, _ := filepath.Glob(filepath.Join(dir, "*.pem")); len(existing) > 0 {
+		return err
+	} else {
+		activate = true
+	}
+
+	key, err := auth.GenerateSigningKey(*alg)
+	if err != nil {
+		return err
+	}
+	if err := auth.SaveSigningKey(dir, key); err != nil {
+		return err
+	}
+	fmt.Printf("Generated %s key %s\n", key.Algorithm, key.ID)
+
+	if !activate {
+		fmt.Println("The key is published for verification only. Run `jwtkeys activate` once all instances have reloaded.")
+		return nil
+	}
+	if err := auth.SetActiveKey(dir, key.ID); err != nil {
+		return err
+	}
+	fmt.Printf("Activated %s\n", key.ID)
+	return nil
+}
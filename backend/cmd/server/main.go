@@ -1,49 +1,229 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/ysmmc/backend/internal/config"
 	"github.com/ysmmc/backend/internal/database"
+	"github.com/ysmmc/backend/internal/middleware"
 	"github.com/ysmmc/backend/internal/router"
 	"github.com/ysmmc/backend/internal/service"
+	"github.com/ysmmc/backend/pkg/auth"
+	"github.com/ysmmc/backend/pkg/lifecycle"
+	"github.com/ysmmc/backend/pkg/logging"
+	"github.com/ysmmc/backend/pkg/metrics"
+)
+
+const (
+	// keyReloadInterval 定期重读 JWT 密钥目录，使密钥轮换无需重启
+	keyReloadInterval        = time.Minute
+	rateLimitCleanupInterval = time.Minute
+	// readHeaderTimeout 限制读取请求头的时间，防止慢速连接占满服务
+	readHeaderTimeout = 10 * time.Second
 )
 
 func main() {
+	logging.Setup(os.Stdout, slog.LevelInfo)
+
 	if err := config.LoadConfig(); err != nil {
-		log.Fatalf("Failed to load config: %v", err)
+		fatal("Failed to load config", err)
 	}
 
 	if err := config.Validate(); err != nil {
-		log.Fatalf("Config validation failed: %v", err)
+		fatal("Config validation failed", err)
+	}
+	logLevel, _ := logging.ParseLevel(config.AppConfig.LogLevel)
+	logging.Setup(os.Stdout, logLevel)
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := database.Connect(); err != nil {
+			fatal("Failed to connect to database", err)
+		}
+		if err := runMigrateCommand(os.Args[2:]); err != nil {
+			fatal("migrate", err)
+		}
+		return
 	}
 
+	// 开发环境下密钥目录为空时自动生成，生产环境必须预先用 jwtkeys 生成
+	if err := auth.InitKeys(config.AppConfig.JWTKeyDir, config.AppConfig.GinMode != gin.ReleaseMode); err != nil {
+		fatal("Failed to load JWT signing keys", err)
+	}
+	keyDir := config.AppConfig.JWTKeyDir
+	lifecycle.Every("jwt-key-reload", keyReloadInterval, func(context.Context) {
+		reloadSigningKeys(keyDir)
+	})
+
 	if err := database.Connect(); err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		fatal("Failed to connect to database", err)
 	}
 
-	if err := database.Migrate(); err != nil {
-		log.Fatalf("Failed to migrate database: %v", err)
+	if config.AppConfig.AutoMigrate {
+		if err := database.Migrate(); err != nil {
+			fatal("Failed to migrate database", err)
+		}
+	} else if err := database.CheckMigrations(); err != nil {
+		fatal("Database schema is not up to date", err)
 	}
 
 	if err := database.Seed(); err != nil {
-		log.Fatalf("Failed to seed database: %v", err)
+		fatal("Failed to seed database", err)
 	}
 
 	storageService := service.NewStorageService()
 	if err := storageService.Initialize(); err != nil {
-		log.Fatalf("Failed to initialize storage: %v", err)
+		fatal("Failed to initialize storage", err)
+	}
+	// 每次抓取 /metrics 时重新读取磁盘用量，不依赖最近是否有上传
+	metrics.Default.OnCollect(func() { storageService.CheckDiskSpace() })
+
+	// 限流计数与签名密钥是进程内状态，每个实例各自定期处理；其余维护工作由任务队列执行
+	lifecycle.Every("rate-limit-cleanup", rateLimitCleanupInterval, middleware.CleanupVisitors)
+
+	if config.AppConfig.JobWorkers > 0 {
+		worker := service.NewJobWorker(config.AppConfig.JobWorkers, config.AppConfig.JobPollInterval)
+		if err := service.RegisterJobs(worker); err != nil {
+			fatal("Failed to register background jobs", err)
+		}
+		lifecycle.Go("job-worker", worker.Run)
 	}
 
 	gin.SetMode(config.AppConfig.GinMode)
 
-	r := gin.Default()
+	r := gin.New()
 
 	router.Setup(r)
 
-	log.Printf("Server starting on port %s...", config.AppConfig.ServerPort)
-	if err := r.Run(":" + config.AppConfig.ServerPort); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	srv := &http.Server{
+		Addr:              ":" + config.AppConfig.ServerPort,
+		Handler:           r,
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       config.AppConfig.HTTPReadTimeout,
+		WriteTimeout:      config.AppConfig.HTTPWriteTimeout,
+		IdleTimeout:       config.AppConfig.HTTPIdleTimeout,
+	}
+	if err := serve(srv, config.AppConfig.ShutdownTimeout); err != nil {
+		fatal("Server stopped with error", err)
+	}
+	slog.Info("Server stopped")
+}
+
+// serve 运行 HTTP 服务直到收到 SIGINT 或 SIGTERM，然后依次停止接受新连接并等待进行中的请求、
+// 取消并等待后台任务、关闭数据库连接，全部步骤共用 timeout 期限。再次收到信号时立即退出
+func serve(srv *http.Server, timeout time.Duration) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() {
+		slog.Info("Server starting", "addr", srv.Addr)
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+	stop()
+
+	slog.Info("Shutting down, waiting for in-flight requests and background tasks", "timeout", timeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var errs []error
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		errs = append(errs, fmt.Errorf("http server: %w", err))
+	}
+	if err := lifecycle.Shutdown(shutdownCtx); err != nil {
+		errs = append(errs, err)
+	}
+	if err := database.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("database: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// fatal 记录启动失败的原因并退出
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+func reloadSigningKeys(dir string) {
+	changed, err := auth.ReloadKeys(dir)
+	if err != nil {
+		slog.Error("Failed to reload JWT signing keys, keeping current keys", "error", err)
+		return
+	}
+	if changed {
+		slog.Info("JWT signing keys reloaded")
+	}
+}
+
+// runMigrateCommand 处理 migrate up | down [N] | status 子命令
+func runMigrateCommand(args []string) error {
+	migrator, err := database.NewMigrator()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	if len(args) == 0 {
+		return fmt.Errorf("usage: server migrate up | down [N] | status")
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			fmt.Printf("applied  %s\n", m)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("database is up to date")
+		}
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps <= 0 {
+				return fmt.Errorf("invalid number of steps %q", args[1])
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		for _, m := range reverted {
+			fmt.Printf("reverted %s\n", m)
+		}
+		return err
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			state := "pending"
+			switch {
+			case s.Missing:
+				state = "unknown to this build"
+			case s.Modified:
+				state = "MODIFIED after apply"
+			case s.Applied:
+				state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%-32s %s\n", s.Migration, state)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate command %q, expected up, down or status", args[0])
 	}
 }
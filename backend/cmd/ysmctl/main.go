@@ -0,0 +1,484 @@
+// ysmctl 运维命令行工具，与服务端读取相同的配置（环境变量与 .env）并直接调用服务层。
+//
+//	ysmctl create-admin -email admin@example.com -username admin
+//	ysmctl reset-password admin@example.com
+//	ysmctl gc-orphans -dry-run
+//
+// 封禁、审核等操作以 -actor 指定的账号（默认第一个超级管理员）记入管理操作日志。
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ysmmc/backend/internal/config"
+	"github.com/ysmmc/backend/internal/database"
+	"github.com/ysmmc/backend/internal/model"
+	"github.com/ysmmc/backend/internal/service"
+	"github.com/ysmmc/backend/pkg/lifecycle"
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, `Usage: ysmctl <command> [flags] [args]
+
+Commands:
+  create-admin -email <email> -username <name> [-role admin|super_admin] [-password-stdin]
+                            create an account with a verified email
+  reset-password [-password-stdin] <user>
+                            set a new password, unlock the account and sign out all sessions
+  ban -reason <text> [-hours N] [-actor <user>] <user>
+                            ban a user (permanently unless -hours is given)
+  unban [-actor <user>] <user>
+                            lift a ban
+  approve [-actor <user>] <model-id>
+                            approve a pending model or pending model update
+  gc-orphans [-min-age 24h] [-dry-run]
+                            delete uploaded files no longer referenced by any record
+  reindex-search            rebuild the model title search index
+  verify-storage            check that every referenced model file exists with the recorded size
+  export [-o file]          export roles, registration settings and announcements as JSON
+  import [-actor <user>] <file>
+                            import a file written by export ("-" reads stdin)
+
+<user> is a user ID or email address. -actor defaults to the first super admin.
+Passwords are generated and printed unless -password-stdin is given.
+Configuration is read from the environment and .env, the same as the server.
+`)
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, args := flag.Arg(0), flag.Args()[1:]
+	commands := map[string]func([]string) error{
+		"create-admin":   createAdmin,
+		"reset-password": resetPassword,
+		"ban":            ban,
+		"unban":          unban,
+		"approve":        approve,
+		"gc-orphans":     gcOrphans,
+		"reindex-search": reindexSearch,
+		"verify-storage": verifyStorage,
+		"export":         exportConfig,
+		"import":         importConfig,
+	}
+	run, ok := commands[cmd]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+
+	if err := setup(); err != nil {
+		fmt.Fprintf(os.Stderr, "ysmctl: %v\n", err)
+		os.Exit(1)
+	}
+	err := run(args)
+
+	// 等待命令触发的后台任务（如通知邮件）完成后再退出
+	ctx, cancel := context.WithTimeout(context.Background(), config.AppConfig.ShutdownTimeout)
+	defer cancel()
+	if shutdownErr := lifecycle.Shutdown(ctx); shutdownErr != nil {
+		fmt.Fprintf(os.Stderr, "ysmctl: %v\n", shutdownErr)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ysmctl %s: %v\n", cmd, err)
+		os.Exit(1)
+	}
+}
+
+// setup 加载配置并连接数据库；数据库结构不是最新时拒绝执行，避免旧版本工具写入新结构
+func setup() error {
+	if err := config.LoadConfig(); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("config validation failed: %w", err)
+	}
+	if err := database.Connect(); err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	if err := database.CheckMigrations(); err != nil {
+		return fmt.Errorf("database schema is not up to date: %w", err)
+	}
+	return nil
+}
+
+// parseFlags 解析子命令参数，要求恰好 nargs 个位置参数
+func parseFlags(fs *flag.FlagSet, args []string, nargs int) error {
+	fs.Usage = usage
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != nargs {
+		usage()
+		os.Exit(2)
+	}
+	return nil
+}
+
+// findUser 按 ID 或邮箱查找用户
+func findUser(ref string) (*model.User, error) {
+	userService := service.NewUserService()
+	var (
+		user *model.User
+		err  error
+	)
+	if id, parseErr := uuid.Parse(ref); parseErr == nil {
+		user, err = userService.GetByID(context.Background(), id)
+	} else {
+		user, err = userService.GetByEmail(context.Background(), ref)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("user %q not found", ref)
+	}
+	return user, nil
+}
+
+// findActor 返回记入管理日志的操作者，要求拥有 perm 权限
+func findActor(ref, perm string) (*model.User, error) {
+	var (
+		actor *model.User
+		err   error
+	)
+	if ref == "" {
+		actor, err = service.NewUserService().GetSuperAdmin(context.Background())
+	} else {
+		actor, err = findUser(ref)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !service.HasPermission(context.Background(), actor.Role, perm) {
+		return nil, fmt.Errorf("actor %s does not have the %s permission", actor.Email, perm)
+	}
+	return actor, nil
+}
+
+func readPassword(fromStdin bool) (string, error) {
+	if !fromStdin {
+		return "", nil
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return "", errors.New("no password on stdin")
+	}
+	return password, nil
+}
+
+func createAdmin(args []string) error {
+	fs := flag.NewFlagSet("create-admin", flag.ExitOnError)
+	email := fs.String("email", "", "email address")
+	username := fs.String("username", "", "username")
+	role := fs.String("role", model.RoleAdmin, "role to assign")
+	passwordStdin := fs.Bool("password-stdin", false, "read the password from stdin")
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	if *email == "" || *username == "" {
+		return errors.New("-email and -username are required")
+	}
+
+	password, err := readPassword(*passwordStdin)
+	if err != nil {
+		return err
+	}
+	user, password, err := service.NewUserService().CreateAccount(context.Background(), &service.CreateAccountRequest{
+		Email:    *email,
+		Username: *username,
+		Role:     *role,
+		Password: password,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Created %s %s (%s)\n", user.Role, user.Email, user.ID)
+	if !*passwordStdin {
+		fmt.Printf("Password: %s\n", password)
+		fmt.Println("The password must be changed after the first login.")
+	}
+	return nil
+}
+
+func resetPassword(args []string) error {
+	fs := flag.NewFlagSet("reset-password", flag.ExitOnError)
+	passwordStdin := fs.Bool("password-stdin", false, "read the new password from stdin")
+	if err := parseFlags(fs, args, 1); err != nil {
+		return err
+	}
+
+	user, err := findUser(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	password, err := readPassword(*passwordStdin)
+	if err != nil {
+		return err
+	}
+	password, err = service.NewUserService().SetPassword(context.Background(), user.ID, password)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Password reset for %s; all sessions have been signed out\n", user.Email)
+	if !*passwordStdin {
+		fmt.Printf("Password: %s\n", password)
+		fmt.Println("The password must be changed after the next login.")
+	}
+	return nil
+}
+
+func ban(args []string) error {
+	fs := flag.NewFlagSet("ban", flag.ExitOnError)
+	reason := fs.String("reason", "", "reason shown to the user")
+	hours := fs.Int("hours", 0, "ban duration in hours (0 = permanent)")
+	actorRef := fs.String("actor", "", "account recorded as the moderator")
+	if err := parseFlags(fs, args, 1); err != nil {
+		return err
+	}
+	if *reason == "" {
+		return errors.New("-reason is required")
+	}
+	if *hours < 0 {
+		return errors.New("-hours must not be negative")
+	}
+
+	actor, err := findActor(*actorRef, model.PermUsersBan)
+	if err != nil {
+		return err
+	}
+	user, err := findUser(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	req := &service.IssueSanctionRequest{Type: model.SanctionBan, Reason: *reason}
+	if *hours > 0 {
+		req.DurationHours = hours
+	}
+	sanction, err := service.NewSanctionService().Issue(context.Background(), user.ID, actor.ID, actor.Role, req)
+	if err != nil {
+		return err
+	}
+	service.NewModerationService().Record(context.Background(), actor.ID, "user.ban", "user", user.ID, *reason)
+
+	if sanction.ExpiresAt != nil {
+		fmt.Printf("Banned %s until %s\n", user.Email, sanction.ExpiresAt.Format(time.RFC3339))
+	} else {
+		fmt.Printf("Banned %s permanently\n", user.Email)
+	}
+	return nil
+}
+
+func unban(args []string) error {
+	fs := flag.NewFlagSet("unban", flag.ExitOnError)
+	actorRef := fs.String("actor", "", "account recorded as the moderator")
+	if err := parseFlags(fs, args, 1); err != nil {
+		return err
+	}
+
+	actor, err := findActor(*actorRef, model.PermUsersBan)
+	if err != nil {
+		return err
+	}
+	user, err := findUser(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if err := service.NewSanctionService().Unban(context.Background(), user.ID, actor.ID, actor.Role); err != nil {
+		return err
+	}
+	service.NewModerationService().Record(context.Background(), actor.ID, "user.unban", "user", user.ID, "")
+
+	fmt.Printf("Unbanned %s\n", user.Email)
+	return nil
+}
+
+func approve(args []string) error {
+	fs := flag.NewFlagSet("approve", flag.ExitOnError)
+	actorRef := fs.String("actor", "", "account recorded as the moderator")
+	if err := parseFlags(fs, args, 1); err != nil {
+		return err
+	}
+
+	id, err := uuid.Parse(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("invalid model id %q", fs.Arg(0))
+	}
+	actor, err := findActor(*actorRef, model.PermModelsApprove)
+	if err != nil {
+		return err
+	}
+
+	modelService := service.NewModelService()
+	m, err := modelService.GetByID(context.Background(), id)
+	if err != nil {
+		return errors.New("model not found")
+	}
+	if m.Status == "approved" && m.UpdateStatus != "pending_review" {
+		return errors.New("model has nothing pending review")
+	}
+	if err := modelService.Approve(context.Background(), id); err != nil {
+		return err
+	}
+	service.NewModerationService().Record(context.Background(), actor.ID, "model.approve", "model", id, "")
+
+	fmt.Printf("Approved %q (%s)\n", m.Title, m.ID)
+	return nil
+}
+
+func gcOrphans(args []string) error {
+	fs := flag.NewFlagSet("gc-orphans", flag.ExitOnError)
+	minAge := fs.Duration("min-age", 24*time.Hour, "only consider files uploaded longer ago than this")
+	dryRun := fs.Bool("dry-run", false, "list orphaned files without deleting them")
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+
+	report, err := service.NewMaintenanceService().CollectOrphans(context.Background(), *minAge, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	var bytes int64
+	for _, f := range report.Files {
+		fmt.Printf("file %s %-10s %8d  %s\n", f.ID, f.Category, f.Size, f.Name)
+		bytes += f.Size
+	}
+	for _, f := range report.DiskFiles {
+		fmt.Printf("disk %s %8d\n", f.Path, f.Size)
+		bytes += f.Size
+	}
+
+	verb := "Deleted"
+	files := int64(len(report.Files))
+	if *dryRun {
+		verb = "Would delete"
+	} else {
+		files = report.Removed
+	}
+	fmt.Printf("%s %d stored files and %d files on disk (%d bytes)\n", verb, files, len(report.DiskFiles), bytes)
+	return nil
+}
+
+func reindexSearch(args []string) error {
+	fs := flag.NewFlagSet("reindex-search", flag.ExitOnError)
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	if err := service.NewMaintenanceService().ReindexSearch(context.Background()); err != nil {
+		return err
+	}
+	fmt.Printf("Search index rebuilt in %s\n", time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+func verifyStorage(args []string) error {
+	fs := flag.NewFlagSet("verify-storage", flag.ExitOnError)
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+
+	checked, problems, err := service.NewMaintenanceService().VerifyStorage(context.Background())
+	for _, p := range problems {
+		fmt.Printf("%-13s %s  %s: %s\n", p.Kind, p.ID, p.Path, p.Problem)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Checked %d files, %d problems\n", checked, len(problems))
+	if len(problems) > 0 {
+		return errors.New("storage verification failed")
+	}
+	return nil
+}
+
+func exportConfig(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	output := fs.String("o", "-", "output file (- for stdout)")
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+
+	cfg, err := service.NewSiteConfigService().Export(context.Background())
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "-" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(cfg); err != nil {
+		return err
+	}
+
+	if *output != "-" {
+		fmt.Printf("Exported %d roles and %d announcements to %s\n", len(cfg.Roles), len(cfg.Announcements), *output)
+	}
+	return nil
+}
+
+func importConfig(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	actorRef := fs.String("actor", "", "super admin recorded as the author of the changes")
+	if err := parseFlags(fs, args, 1); err != nil {
+		return err
+	}
+
+	var r io.Reader = os.Stdin
+	if fs.Arg(0) != "-" {
+		f, err := os.Open(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	var cfg service.SiteConfig
+	if err := json.NewDecoder(r).Decode(&cfg); err != nil {
+		return fmt.Errorf("invalid site configuration: %w", err)
+	}
+
+	actor, err := findActor(*actorRef, model.PermAll)
+	if err != nil {
+		return err
+	}
+	result, err := service.NewSiteConfigService().Import(context.Background(), actor, &cfg)
+	if result != nil {
+		fmt.Printf("Roles: %d created, %d updated\n", result.RolesCreated, result.RolesUpdated)
+		fmt.Printf("Announcements: %d created, %d updated\n", result.AnnouncementsCreated, result.AnnouncementsUpdated)
+	}
+	return err
+}
@@ -2,10 +2,14 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/ysmmc/backend/pkg/logging"
 )
 
 type Config struct {
@@ -15,12 +19,30 @@ type Config struct {
 	DBPassword string
 	DBName     string
 
-	JWTSecret            string
+	// AutoMigrate 启动时自动执行未执行的迁移；关闭后需先运行 migrate up
+	AutoMigrate bool
+	// DBSlowQueryThreshold 超过该耗时的 SQL 以 WARN 级别记录，0 表示不记录慢查询
+	DBSlowQueryThreshold time.Duration
+
+	JWTKeyDir            string
+	JWTAudience          string
 	JWTExpireHours       int
 	JWTRefreshExpireDays int
 
 	ServerPort string
 	GinMode    string
+	// LogLevel 日志级别：debug、info、warn、error；debug 时记录每条 SQL
+	LogLevel string
+	// MetricsToken 与 MetricsAllowedIPs 控制 /metrics 的访问，满足其一即可；都为空时不提供 /metrics
+	MetricsToken      string
+	MetricsAllowedIPs []string
+
+	// HTTP 服务超时；读写超时需覆盖最大文件的上传与下载时间
+	HTTPReadTimeout  time.Duration
+	HTTPWriteTimeout time.Duration
+	HTTPIdleTimeout  time.Duration
+	// ShutdownTimeout 收到 SIGTERM 后等待进行中的请求与后台任务完成的最长时间
+	ShutdownTimeout time.Duration
 
 	UploadPath          string
 	MaxFileSize         int64
@@ -32,9 +54,40 @@ type Config struct {
 	SMTPUser     string
 	SMTPPassword string
 	SMTPFrom     string
+	// EmailTemplateDir 覆盖内置邮件模板的目录，为空时只使用内置模板
+	EmailTemplateDir string
 
 	FrontendURL    string
 	AllowedOrigins []string
+
+	ReportAutoHideThreshold int
+
+	OIDCProviders   []OIDCProviderConfig
+	OIDCRedirectURL string
+
+	PowDifficulty    int
+	PowMaxDifficulty int
+
+	EmailVerificationExpireHours int
+	RequireVerifiedEmail         []string
+
+	AccountDeletionGraceDays int
+	DataExportExpireHours    int
+
+	// JobWorkers 本实例并发执行后台任务的数量，0 表示本实例不执行任务（仅提供 API）
+	JobWorkers int
+	// JobPollInterval 队列为空时查询新任务的间隔
+	JobPollInterval time.Duration
+}
+
+// OIDCProviderConfig 单个 OpenID Connect 登录提供方，由 OIDC_<NAME>_* 环境变量配置
+type OIDCProviderConfig struct {
+	Name         string
+	DisplayName  string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
 }
 
 var AppConfig *Config
@@ -48,6 +101,17 @@ func LoadConfig() error {
 	maxDiskUsage, _ := strconv.Atoi(getEnv("MAX_DISK_USAGE", "90"))
 	enableDatePartition := getEnv("ENABLE_DATE_PARTITION", "false") == "true"
 	smtpPort, _ := strconv.Atoi(getEnv("SMTP_PORT", "587"))
+	reportAutoHideThreshold, _ := strconv.Atoi(getEnv("REPORT_AUTO_HIDE_THRESHOLD", "5"))
+	frontendURL := getEnv("FRONTEND_URL", "http://localhost:5173")
+	powDifficulty, _ := strconv.Atoi(getEnv("POW_DIFFICULTY", "18"))
+	powMaxDifficulty, _ := strconv.Atoi(getEnv("POW_MAX_DIFFICULTY", "24"))
+	emailVerificationExpireHours, _ := strconv.Atoi(getEnv("EMAIL_VERIFICATION_EXPIRE_HOURS", "48"))
+	accountDeletionGraceDays, _ := strconv.Atoi(getEnv("ACCOUNT_DELETION_GRACE_DAYS", "14"))
+	dataExportExpireHours, _ := strconv.Atoi(getEnv("DATA_EXPORT_EXPIRE_HOURS", "72"))
+	jobWorkers, err := strconv.Atoi(getEnv("JOB_WORKERS", "2"))
+	if err != nil {
+		jobWorkers = -1
+	}
 
 	AppConfig = &Config{
 		DBHost:     getEnv("DB_HOST", "localhost"),
@@ -56,12 +120,25 @@ func LoadConfig() error {
 		DBPassword: getEnv("DB_PASSWORD", ""),
 		DBName:     getEnv("DB_NAME", "ysmmc"),
 
-		JWTSecret:            getEnv("JWT_SECRET", ""),
+		AutoMigrate:          getEnv("AUTO_MIGRATE", "true") == "true",
+		DBSlowQueryThreshold: getEnvDuration("DB_SLOW_QUERY_THRESHOLD", 200*time.Millisecond),
+
+		JWTKeyDir:            getEnv("JWT_KEY_DIR", "./keys"),
+		JWTAudience:          getEnv("JWT_AUDIENCE", "ysmmc-api"),
 		JWTExpireHours:       jwtExpireHours,
 		JWTRefreshExpireDays: jwtRefreshExpireDays,
 
 		ServerPort: getEnv("SERVER_PORT", "8080"),
 		GinMode:    getEnv("GIN_MODE", "debug"),
+		LogLevel:   getEnv("LOG_LEVEL", "info"),
+
+		MetricsToken:      getEnv("METRICS_TOKEN", ""),
+		MetricsAllowedIPs: parseList(getEnv("METRICS_ALLOWED_IPS", "")),
+
+		HTTPReadTimeout:  getEnvDuration("HTTP_READ_TIMEOUT", 5*time.Minute),
+		HTTPWriteTimeout: getEnvDuration("HTTP_WRITE_TIMEOUT", 10*time.Minute),
+		HTTPIdleTimeout:  getEnvDuration("HTTP_IDLE_TIMEOUT", 2*time.Minute),
+		ShutdownTimeout:  getEnvDuration("SHUTDOWN_TIMEOUT", 30*time.Second),
 
 		UploadPath:          getEnv("UPLOAD_PATH", "./uploads"),
 		MaxFileSize:         maxFileSize,
@@ -74,8 +151,27 @@ func LoadConfig() error {
 		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
 		SMTPFrom:     getEnv("SMTP_FROM", ""),
 
-		FrontendURL:    getEnv("FRONTEND_URL", "http://localhost:5173"),
+		EmailTemplateDir: getEnv("EMAIL_TEMPLATE_DIR", ""),
+
+		FrontendURL:    frontendURL,
 		AllowedOrigins: parseAllowedOrigins(getEnv("ALLOWED_ORIGINS", "http://localhost:5173")),
+
+		ReportAutoHideThreshold: reportAutoHideThreshold,
+
+		OIDCProviders:   parseOIDCProviders(getEnv("OIDC_PROVIDERS", "")),
+		OIDCRedirectURL: getEnv("OIDC_REDIRECT_URL", frontendURL+"/oauth/callback"),
+
+		PowDifficulty:    powDifficulty,
+		PowMaxDifficulty: powMaxDifficulty,
+
+		EmailVerificationExpireHours: emailVerificationExpireHours,
+		RequireVerifiedEmail:         parseVerifiedEmailActions(getEnv("REQUIRE_VERIFIED_EMAIL", "upload,report")),
+
+		AccountDeletionGraceDays: accountDeletionGraceDays,
+		DataExportExpireHours:    dataExportExpireHours,
+
+		JobWorkers:      jobWorkers,
+		JobPollInterval: getEnvDuration("JOB_POLL_INTERVAL", 5*time.Second),
 	}
 
 	return nil
@@ -85,15 +181,132 @@ func Validate() error {
 	if AppConfig.DBPassword == "" {
 		return fmt.Errorf("DB_PASSWORD environment variable is required")
 	}
-	if AppConfig.JWTSecret == "" {
-		return fmt.Errorf("JWT_SECRET environment variable is required")
+	for _, p := range AppConfig.OIDCProviders {
+		if p.Issuer == "" || p.ClientID == "" {
+			return fmt.Errorf("OIDC provider %s requires OIDC_%s_ISSUER and OIDC_%s_CLIENT_ID", p.Name, oidcEnvPrefix(p.Name), oidcEnvPrefix(p.Name))
+		}
+	}
+	if AppConfig.PowDifficulty > 0 && AppConfig.PowMaxDifficulty < AppConfig.PowDifficulty {
+		return fmt.Errorf("POW_MAX_DIFFICULTY must not be lower than POW_DIFFICULTY")
+	}
+	if AppConfig.EmailVerificationExpireHours <= 0 {
+		return fmt.Errorf("EMAIL_VERIFICATION_EXPIRE_HOURS must be positive")
+	}
+	for _, action := range AppConfig.RequireVerifiedEmail {
+		if !verifiedEmailActions[action] {
+			return fmt.Errorf("unknown REQUIRE_VERIFIED_EMAIL action %q", action)
+		}
+	}
+	if AppConfig.AccountDeletionGraceDays < 1 {
+		return fmt.Errorf("ACCOUNT_DELETION_GRACE_DAYS must be at least 1")
+	}
+	if AppConfig.DataExportExpireHours <= 0 {
+		return fmt.Errorf("DATA_EXPORT_EXPIRE_HOURS must be positive")
+	}
+	if _, err := logging.ParseLevel(AppConfig.LogLevel); err != nil {
+		return fmt.Errorf("LOG_LEVEL must be one of debug, info, warn, error")
+	}
+	if AppConfig.DBSlowQueryThreshold < 0 {
+		return fmt.Errorf("DB_SLOW_QUERY_THRESHOLD must not be negative")
+	}
+	if _, err := ParseNetworks(AppConfig.MetricsAllowedIPs); err != nil {
+		return fmt.Errorf("METRICS_ALLOWED_IPS: %w", err)
+	}
+	if AppConfig.JobWorkers < 0 {
+		return fmt.Errorf("JOB_WORKERS must be a non-negative integer")
 	}
-	if len(AppConfig.JWTSecret) < 32 {
-		return fmt.Errorf("JWT_SECRET must be at least 32 characters long")
+	timeouts := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"HTTP_READ_TIMEOUT", AppConfig.HTTPReadTimeout},
+		{"HTTP_WRITE_TIMEOUT", AppConfig.HTTPWriteTimeout},
+		{"HTTP_IDLE_TIMEOUT", AppConfig.HTTPIdleTimeout},
+		{"SHUTDOWN_TIMEOUT", AppConfig.ShutdownTimeout},
+		{"JOB_POLL_INTERVAL", AppConfig.JobPollInterval},
+	}
+	for _, t := range timeouts {
+		if t.value <= 0 {
+			return fmt.Errorf("%s must be a positive duration such as 30s or 5m", t.name)
+		}
 	}
 	return nil
 }
 
+// parseOIDCProviders 读取 OIDC_PROVIDERS 中列出的提供方，例如 OIDC_PROVIDERS=google,gitlab
+// 对应 OIDC_GOOGLE_ISSUER、OIDC_GOOGLE_CLIENT_ID 等变量
+func parseOIDCProviders(names string) []OIDCProviderConfig {
+	result := []OIDCProviderConfig{}
+	for _, name := range strings.Split(names, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + oidcEnvPrefix(name) + "_"
+		result = append(result, OIDCProviderConfig{
+			Name:         name,
+			DisplayName:  getEnv(prefix+"DISPLAY_NAME", name),
+			Issuer:       getEnv(prefix+"ISSUER", ""),
+			ClientID:     getEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret: getEnv(prefix+"CLIENT_SECRET", ""),
+			Scopes:       strings.Fields(getEnv(prefix+"SCOPES", "openid email profile")),
+		})
+	}
+	return result
+}
+
+// verifiedEmailActions REQUIRE_VERIFIED_EMAIL 可选的操作类别
+var verifiedEmailActions = map[string]bool{"upload": true, "report": true}
+
+// parseVerifiedEmailActions 解析需要已验证邮箱的操作类别，none 表示不限制
+func parseVerifiedEmailActions(value string) []string {
+	result := []string{}
+	for _, action := range strings.Split(value, ",") {
+		action = strings.ToLower(strings.TrimSpace(action))
+		if action == "" || action == "none" {
+			continue
+		}
+		result = append(result, action)
+	}
+	return result
+}
+
+// parseList 解析逗号分隔的列表，忽略空项
+func parseList(value string) []string {
+	result := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// ParseNetworks 解析 IP 地址或 CIDR 网段，单个 IP 视为只包含该地址的网段
+func ParseNetworks(values []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(values))
+	for _, value := range values {
+		if ip := net.ParseIP(value); ip != nil {
+			bits := 8 * len(ip.To16())
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 32
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid IP address or CIDR %q", value)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+func oidcEnvPrefix(name string) string {
+	return strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
 func parseAllowedOrigins(origins string) []string {
 	if origins == "" {
 		return []string{}
@@ -118,3 +331,16 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvDuration 读取 time.ParseDuration 格式的时长（如 30s、5m），格式错误时返回 0，由 Validate 报错
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0
+	}
+	return d
+}
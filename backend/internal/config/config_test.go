@@ -1,8 +1,10 @@
 package config
 
 import (
+	"net"
 	"os"
 	"testing"
+	"time"
 )
 
 func TestLoadConfig_DefaultValues(t *testing.T) {
@@ -22,46 +24,35 @@ func TestLoadConfig_DefaultValues(t *testing.T) {
 	if AppConfig.JWTRefreshExpireDays != 7 {
 		t.Errorf("expected default JWTRefreshExpireDays 7, got %d", AppConfig.JWTRefreshExpireDays)
 	}
-}
-
-func TestValidate_MissingDBPassword(t *testing.T) {
-	os.Clearenv()
-	LoadConfig()
-	
-	err := Validate()
-	if err == nil {
-		t.Error("expected error for missing DB_PASSWORD")
+	if AppConfig.JWTKeyDir != "./keys" {
+		t.Errorf("expected default JWTKeyDir ./keys, got %s", AppConfig.JWTKeyDir)
 	}
-	if err != nil && err.Error() != "DB_PASSWORD environment variable is required" {
-		t.Errorf("unexpected error message: %s", err.Error())
+	if AppConfig.JWTAudience != "ysmmc-api" {
+		t.Errorf("expected default JWTAudience ysmmc-api, got %s", AppConfig.JWTAudience)
 	}
-}
-
-func TestValidate_MissingJWTSecret(t *testing.T) {
-	os.Clearenv()
-	os.Setenv("DB_PASSWORD", "test_password")
-	LoadConfig()
-	
-	err := Validate()
-	if err == nil {
-		t.Error("expected error for missing JWT_SECRET")
+	if AppConfig.PowDifficulty != 18 || AppConfig.PowMaxDifficulty != 24 {
+		t.Errorf("expected default pow difficulty 18/24, got %d/%d", AppConfig.PowDifficulty, AppConfig.PowMaxDifficulty)
 	}
-	if err != nil && err.Error() != "JWT_SECRET environment variable is required" {
-		t.Errorf("unexpected error message: %s", err.Error())
+	if AppConfig.EmailVerificationExpireHours != 48 {
+		t.Errorf("expected default EmailVerificationExpireHours 48, got %d", AppConfig.EmailVerificationExpireHours)
+	}
+	if len(AppConfig.RequireVerifiedEmail) != 2 {
+		t.Errorf("expected upload and report to require a verified email by default, got %v", AppConfig.RequireVerifiedEmail)
+	}
+	if AppConfig.AccountDeletionGraceDays != 14 || AppConfig.DataExportExpireHours != 72 {
+		t.Errorf("expected default deletion grace 14 days and export expiry 72 hours, got %d/%d", AppConfig.AccountDeletionGraceDays, AppConfig.DataExportExpireHours)
 	}
 }
 
-func TestValidate_ShortJWTSecret(t *testing.T) {
+func TestValidate_MissingDBPassword(t *testing.T) {
 	os.Clearenv()
-	os.Setenv("DB_PASSWORD", "test_password")
-	os.Setenv("JWT_SECRET", "short")
 	LoadConfig()
 	
 	err := Validate()
 	if err == nil {
-		t.Error("expected error for short JWT_SECRET")
+		t.Error("expected error for missing DB_PASSWORD")
 	}
-	if err != nil && err.Error() != "JWT_SECRET must be at least 32 characters long" {
+	if err != nil && err.Error() != "DB_PASSWORD environment variable is required" {
 		t.Errorf("unexpected error message: %s", err.Error())
 	}
 }
@@ -69,7 +60,6 @@ func TestValidate_ShortJWTSecret(t *testing.T) {
 func TestValidate_Success(t *testing.T) {
 	os.Clearenv()
 	os.Setenv("DB_PASSWORD", "test_password_123")
-	os.Setenv("JWT_SECRET", "this_is_a_very_long_jwt_secret_key_for_testing")
 	LoadConfig()
 	
 	err := Validate()
@@ -117,3 +107,179 @@ func TestGetEnv(t *testing.T) {
 		t.Errorf("expected actual_value, got %s", result)
 	}
 }
+
+func TestParseOIDCProviders(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("OIDC_GOOGLE_ISSUER", "https://accounts.google.com")
+	os.Setenv("OIDC_GOOGLE_CLIENT_ID", "google-client")
+	os.Setenv("OIDC_GOOGLE_DISPLAY_NAME", "Google")
+	os.Setenv("OIDC_MY_IDP_ISSUER", "https://idp.example.com")
+	os.Setenv("OIDC_MY_IDP_SCOPES", "openid email")
+
+	providers := parseOIDCProviders(" Google , my-idp,")
+	if len(providers) != 2 {
+		t.Fatalf("expected 2 providers, got %d", len(providers))
+	}
+
+	google := providers[0]
+	if google.Name != "google" || google.DisplayName != "Google" || google.ClientID != "google-client" {
+		t.Errorf("unexpected google provider: %+v", google)
+	}
+	if len(google.Scopes) != 3 {
+		t.Errorf("expected default scopes, got %v", google.Scopes)
+	}
+
+	idp := providers[1]
+	if idp.Issuer != "https://idp.example.com" || idp.DisplayName != "my-idp" || len(idp.Scopes) != 2 {
+		t.Errorf("unexpected my-idp provider: %+v", idp)
+	}
+}
+
+func TestValidate_IncompleteOIDCProvider(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("DB_PASSWORD", "test_password_123")
+	os.Setenv("OIDC_PROVIDERS", "google")
+	os.Setenv("OIDC_GOOGLE_ISSUER", "https://accounts.google.com")
+	LoadConfig()
+
+	if err := Validate(); err == nil {
+		t.Error("expected error for provider without client id")
+	}
+}
+
+func TestValidate_PowMaxBelowBase(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("DB_PASSWORD", "test_password_123")
+	os.Setenv("POW_DIFFICULTY", "20")
+	os.Setenv("POW_MAX_DIFFICULTY", "16")
+	LoadConfig()
+
+	if err := Validate(); err == nil {
+		t.Error("expected error when POW_MAX_DIFFICULTY is lower than POW_DIFFICULTY")
+	}
+}
+
+func TestParseVerifiedEmailActions(t *testing.T) {
+	if got := parseVerifiedEmailActions("none"); len(got) != 0 {
+		t.Errorf("expected none to disable the policy, got %v", got)
+	}
+	if got := parseVerifiedEmailActions(" Upload , report,"); len(got) != 2 || got[0] != "upload" || got[1] != "report" {
+		t.Errorf("unexpected actions: %v", got)
+	}
+}
+
+func TestValidate_UnknownVerifiedEmailAction(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("DB_PASSWORD", "test_password_123")
+	os.Setenv("REQUIRE_VERIFIED_EMAIL", "upload,download")
+	LoadConfig()
+
+	if err := Validate(); err == nil {
+		t.Error("expected error for unknown REQUIRE_VERIFIED_EMAIL action")
+	}
+}
+
+func TestValidate_ZeroDeletionGracePeriod(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("DB_PASSWORD", "test_password_123")
+	os.Setenv("ACCOUNT_DELETION_GRACE_DAYS", "0")
+	LoadConfig()
+
+	if err := Validate(); err == nil {
+		t.Error("expected error when ACCOUNT_DELETION_GRACE_DAYS is 0")
+	}
+}
+
+func TestLoadConfig_Timeouts(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("DB_PASSWORD", "test_password_123")
+	os.Setenv("HTTP_WRITE_TIMEOUT", "15m")
+	LoadConfig()
+
+	if AppConfig.HTTPWriteTimeout != 15*time.Minute {
+		t.Errorf("expected write timeout 15m, got %s", AppConfig.HTTPWriteTimeout)
+	}
+	if AppConfig.ShutdownTimeout != 30*time.Second {
+		t.Errorf("expected default shutdown timeout 30s, got %s", AppConfig.ShutdownTimeout)
+	}
+	if err := Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
+
+func TestValidate_InvalidTimeout(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("DB_PASSWORD", "test_password_123")
+	os.Setenv("HTTP_READ_TIMEOUT", "300")
+	LoadConfig()
+
+	if err := Validate(); err == nil {
+		t.Error("expected error for a timeout without a unit")
+	}
+}
+
+func TestLoadConfig_JobWorkers(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("DB_PASSWORD", "test_password_123")
+	os.Setenv("JOB_WORKERS", "0")
+	LoadConfig()
+
+	if AppConfig.JobWorkers != 0 {
+		t.Errorf("expected JOB_WORKERS=0 to disable workers, got %d", AppConfig.JobWorkers)
+	}
+	if err := Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+
+	os.Setenv("JOB_WORKERS", "two")
+	LoadConfig()
+	if err := Validate(); err == nil {
+		t.Error("expected error for a non-numeric JOB_WORKERS")
+	}
+}
+
+func TestValidate_LogLevel(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("DB_PASSWORD", "test_password_123")
+	LoadConfig()
+
+	if AppConfig.LogLevel != "info" {
+		t.Errorf("expected default log level info, got %s", AppConfig.LogLevel)
+	}
+	if AppConfig.DBSlowQueryThreshold != 200*time.Millisecond {
+		t.Errorf("expected default slow query threshold 200ms, got %s", AppConfig.DBSlowQueryThreshold)
+	}
+
+	os.Setenv("LOG_LEVEL", "verbose")
+	LoadConfig()
+	if err := Validate(); err == nil {
+		t.Error("expected error for an unknown LOG_LEVEL")
+	}
+}
+
+func TestValidate_MetricsAllowedIPs(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("DB_PASSWORD", "test_password_123")
+	os.Setenv("METRICS_ALLOWED_IPS", "127.0.0.1, 10.0.0.0/8,,::1")
+	LoadConfig()
+
+	if len(AppConfig.MetricsAllowedIPs) != 3 {
+		t.Errorf("expected 3 allowed networks, got %v", AppConfig.MetricsAllowedIPs)
+	}
+	if err := Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	networks, _ := ParseNetworks(AppConfig.MetricsAllowedIPs)
+	if !networks[0].Contains(net.ParseIP("127.0.0.1")) || networks[0].Contains(net.ParseIP("127.0.0.2")) {
+		t.Error("expected a single IP to match only itself")
+	}
+	if !networks[1].Contains(net.ParseIP("10.1.2.3")) {
+		t.Error("expected CIDR to match addresses in range")
+	}
+
+	os.Setenv("METRICS_ALLOWED_IPS", "10.0.0.0/33")
+	LoadConfig()
+	if err := Validate(); err == nil {
+		t.Error("expected error for an invalid METRICS_ALLOWED_IPS entry")
+	}
+}
@@ -1,18 +1,21 @@
 package database
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
-	"log"
+	"log/slog"
 
 	"github.com/google/uuid"
 	"github.com/ysmmc/backend/internal/config"
 	"github.com/ysmmc/backend/internal/model"
+	"github.com/ysmmc/backend/migrations"
+	"github.com/ysmmc/backend/pkg/migrate"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
+	"gorm.io/gorm/clause"
 )
 
 var DB *gorm.DB
@@ -26,99 +29,90 @@ func Connect() error {
 
 	var err error
 	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+		Logger: newGormLogger(cfg.DBSlowQueryThreshold),
 	})
 	if err != nil {
 		return fmt.Errorf("failed to connect database: %w", err)
 	}
+	if err := DB.Use(metricsPlugin{}); err != nil {
+		return fmt.Errorf("failed to register database metrics: %w", err)
+	}
 
-	log.Println("Database connected successfully")
+	slog.Info("Database connected")
 	return nil
 }
 
-func Migrate() error {
-	if err := migrateSuperAdminRole(); err != nil {
-		log.Printf("Warning: failed to migrate super admin role: %v", err)
-	}
-
-	err := DB.AutoMigrate(
-		&model.File{},
-		&model.User{},
-		&model.Model{},
-		&model.ModelVersion{},
-		&model.ModelImage{},
-		&model.Favorite{},
-		&model.Announcement{},
-		&model.Session{},
-	)
+// Close 关闭连接池，在 HTTP 服务与后台任务都已停止后调用
+func Close() error {
+	if DB == nil {
+		return nil
+	}
+	sqlDB, err := DB.DB()
 	if err != nil {
-		log.Printf("Warning: auto migrate error: %v", err)
+		return err
 	}
+	return sqlDB.Close()
+}
 
-	if err := migrateModelVersions(); err != nil {
-		log.Printf("Warning: failed to migrate model versions: %v", err)
+// NewMigrator 基于当前连接创建迁移器，迁移脚本见 migrations 目录
+func NewMigrator() (*migrate.Migrator, error) {
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return nil, err
 	}
-
-	log.Println("Database migrated successfully")
-	return nil
+	return migrate.New(sqlDB, migrations.FS)
 }
 
-func migrateSuperAdminRole() error {
-	result := DB.Exec("UPDATE users SET role = 'super_admin' WHERE email = 'admin@ysmmc.local' AND role = 'admin'")
-	if result.Error != nil {
-		return result.Error
+// Migrate 执行全部未执行的迁移；多个实例同时启动时由 advisory lock 保证只执行一次
+func Migrate() error {
+	migrator, err := NewMigrator()
+	if err != nil {
+		return err
 	}
 
-	if result.RowsAffected > 0 {
-		log.Println("Migrated admin user role to super_admin")
+	applied, err := migrator.Up(context.Background())
+	for _, m := range applied {
+		slog.Info("Applied migration", "migration", m)
+	}
+	if err != nil {
+		return err
 	}
 
+	slog.Info("Database migrated")
 	return nil
 }
 
-func migrateModelVersions() error {
-	var models []model.Model
-	if err := DB.Where("current_version_id IS NULL").Find(&models).Error; err != nil {
+// CheckMigrations 关闭自动迁移时确认数据库已是最新版本
+func CheckMigrations() error {
+	migrator, err := NewMigrator()
+	if err != nil {
 		return err
 	}
 
-	if len(models) == 0 {
-		return nil
+	pending, err := migrator.Pending(context.Background())
+	if err != nil {
+		return err
 	}
+	if len(pending) > 0 {
+		return fmt.Errorf("database has %d pending migrations (next: %s), run `server migrate up` first", len(pending), pending[0])
+	}
+	return nil
+}
 
-	log.Printf("Migrating %d models to version system...", len(models))
-
-	for _, m := range models {
-		version := model.ModelVersion{
-			ModelID:       m.ID,
-			VersionNumber: "1.0.0",
-			Description:   m.Description,
-			FilePath:      m.FilePath,
-			FileSize:      m.FileSize,
-			ImageID:       m.ImageID,
-			ImageURL:      m.ImageURL,
-			IsCurrent:     true,
-			Downloads:     m.Downloads,
-		}
-
-		if err := DB.Create(&version).Error; err != nil {
-			log.Printf("Failed to create version for model %s: %v", m.ID, err)
-			continue
-		}
-
-		if err := DB.Model(&m).Updates(map[string]interface{}{
-			"current_version_id": version.ID,
-			"version_count":      1,
-		}).Error; err != nil {
-			log.Printf("Failed to update model %s: %v", m.ID, err)
+func seedRoles() error {
+	for _, role := range model.DefaultRoles() {
+		if err := DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&role).Error; err != nil {
+			return err
 		}
 	}
-
-	log.Printf("Migrated %d models to version system", len(models))
 	return nil
 }
 
 func Seed() error {
+	if err := seedRoles(); err != nil {
+		return fmt.Errorf("failed to seed roles: %w", err)
+	}
+
 	var count int64
 	DB.Model(&model.User{}).Count(&count)
 	if count > 0 {
@@ -136,7 +130,7 @@ func Seed() error {
 		Email:             "admin@ysmmc.local",
 		PasswordHash:      string(passwordHash),
 		Username:          "admin",
-		Role:              "super_admin",
+		Role:              model.RoleSuperAdmin,
 		ProfileStatus:     "approved",
 		EmailVerified:     true,
 		MustChangePassword: true,
@@ -146,12 +140,9 @@ func Seed() error {
 		return fmt.Errorf("failed to create super admin user: %w", err)
 	}
 
-	log.Println("==========================================")
-	log.Println("SUPER ADMIN CREDENTIALS (SAVE THIS!)")
-	log.Printf("Email: %s", superAdmin.Email)
-	log.Printf("Password: %s", randomPassword)
-	log.Println("Please change the password after first login!")
-	log.Println("==========================================")
+	// 初始密码只输出这一次，需在首次登录后修改；丢失时运行 ysmctl reset-password admin@ysmmc.local
+	slog.Warn("SUPER ADMIN CREDENTIALS (SAVE THIS!), change the password after first login",
+		"email", superAdmin.Email, "password", randomPassword)
 
 	announcement := model.Announcement{
 		ID:       uuid.New(),
@@ -164,14 +155,14 @@ func Seed() error {
 		return fmt.Errorf("failed to create announcement: %w", err)
 	}
 
-	log.Println("Database seeded successfully (super_admin created)")
+	slog.Info("Database seeded, super_admin created")
 	return nil
 }
 
 func generateRandomPassword(length int) string {
 	bytes := make([]byte, length)
 	if _, err := rand.Read(bytes); err != nil {
-		log.Printf("Warning: failed to generate random password, using fallback: %v", err)
+		slog.Warn("Failed to generate random password, using fallback", "error", err)
 		return "Ch@ng3Th1sP@ssw0rd!"
 	}
 	return hex.EncodeToString(bytes)[:length]
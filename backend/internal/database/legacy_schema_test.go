@@ -0,0 +1,290 @@
+package database
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/ysmmc/backend/migrations"
+	"github.com/ysmmc/backend/pkg/migrate"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+// 以下为引入版本化迁移之前首个发布版（d530c55）中 AutoMigrate 使用的模型，
+// 用于验证由该版本创建的数据库执行迁移后与新建数据库结构一致
+
+type legacyFile struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Name      string     `gorm:"not null;size:255"`
+	MimeType  string     `gorm:"not null;size:100"`
+	Size      int64      `gorm:"not null"`
+	Data      []byte     `gorm:"type:bytea;not null"`
+	Category  string     `gorm:"not null;size:50;index"`
+	UserID    *uuid.UUID `gorm:"type:uuid;index"`
+	CreatedAt time.Time  `gorm:"index"`
+}
+
+func (legacyFile) TableName() string { return "files" }
+
+type legacyUser struct {
+	ID                 uuid.UUID   `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Email              string      `gorm:"uniqueIndex;not null;size:255"`
+	PasswordHash       string      `gorm:"not null;size:255"`
+	Username           string      `gorm:"uniqueIndex;not null;size:50"`
+	AvatarID           *uuid.UUID  `gorm:"type:uuid"`
+	Avatar             *legacyFile `gorm:"foreignKey:AvatarID"`
+	AvatarURL          *string     `gorm:"type:text"`
+	Bio                *string     `gorm:"type:text"`
+	Role               string      `gorm:"size:20;default:user"`
+	ProfileStatus      string      `gorm:"size:20;default:approved"`
+	PendingChanges     *string     `gorm:"type:jsonb"`
+	EmailVerified      bool        `gorm:"default:false"`
+	VerificationToken  *string     `gorm:"column:verification_token;size:255"`
+	ResetToken         *string     `gorm:"size:255"`
+	ResetExpires       *time.Time  `gorm:"column:reset_token_expires"`
+	NewEmail           *string     `gorm:"size:255"`
+	EmailChangeToken   *string     `gorm:"size:255"`
+	EmailChangeExpires *time.Time  `gorm:"column:email_change_token_expires"`
+	MustChangePassword bool        `gorm:"default:false"`
+	IsBanned           bool        `gorm:"default:false"`
+	BannedAt           *time.Time
+	BannedReason       *string `gorm:"type:text"`
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+}
+
+func (legacyUser) TableName() string { return "users" }
+
+type legacyModel struct {
+	ID               uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID           uuid.UUID      `gorm:"type:uuid;not null;index"`
+	Title            string         `gorm:"not null;size:255"`
+	Description      *string        `gorm:"type:text"`
+	FilePath         string         `gorm:"not null;size:500"`
+	FileSize         int64          `gorm:"default:0"`
+	ImageID          *uuid.UUID     `gorm:"type:uuid"`
+	Image            *legacyFile    `gorm:"foreignKey:ImageID"`
+	ImageURL         *string        `gorm:"type:text"`
+	Tags             pq.StringArray `gorm:"type:text[]"`
+	IsPublic         bool           `gorm:"default:true"`
+	Status           string         `gorm:"size:20;default:pending;index"`
+	UpdateStatus     string         `gorm:"size:20;default:idle"`
+	PendingChanges   *string        `gorm:"type:jsonb"`
+	Downloads        int            `gorm:"default:0"`
+	RejectionReason  *string        `gorm:"type:text"`
+	CurrentVersionID *uuid.UUID     `gorm:"type:uuid"`
+	VersionCount     int            `gorm:"default:1"`
+	CreatedAt        time.Time      `gorm:"index"`
+	UpdatedAt        time.Time
+
+	User           *legacyUser          `gorm:"foreignKey:UserID"`
+	CurrentVersion *legacyModelVersion  `gorm:"foreignKey:CurrentVersionID"`
+	Versions       []legacyModelVersion `gorm:"foreignKey:ModelID"`
+	Images         []legacyModelImage   `gorm:"foreignKey:ModelID"`
+}
+
+func (legacyModel) TableName() string { return "models" }
+
+type legacyModelVersion struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ModelID       uuid.UUID  `gorm:"type:uuid;not null;index;uniqueIndex:idx_model_version"`
+	VersionNumber string     `gorm:"size:50;not null;uniqueIndex:idx_model_version"`
+	Description   *string    `gorm:"type:text"`
+	FilePath      string     `gorm:"size:500;not null"`
+	FileSize      int64      `gorm:"default:0"`
+	ImageID       *uuid.UUID `gorm:"type:uuid"`
+	ImageURL      *string    `gorm:"type:text"`
+	Changelog     *string    `gorm:"type:text"`
+	IsCurrent     bool       `gorm:"default:false;index"`
+	Downloads     int        `gorm:"default:0"`
+	CreatedAt     time.Time  `gorm:"index"`
+	UpdatedAt     time.Time
+
+	Model *legacyModel `gorm:"foreignKey:ModelID"`
+	Image *legacyFile  `gorm:"foreignKey:ImageID"`
+}
+
+func (legacyModelVersion) TableName() string { return "model_versions" }
+
+type legacyModelImage struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ModelID   uuid.UUID `gorm:"type:uuid;not null;index;uniqueIndex:idx_model_file"`
+	FileID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_model_file"`
+	SortOrder int       `gorm:"default:0;index"`
+	CreatedAt time.Time
+
+	Model *legacyModel `gorm:"foreignKey:ModelID"`
+	File  *legacyFile  `gorm:"foreignKey:FileID"`
+}
+
+func (legacyModelImage) TableName() string { return "model_images" }
+
+type legacyFavorite struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_user_model"`
+	ModelID   uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_user_model;index"`
+	CreatedAt time.Time
+
+	Model *legacyModel `gorm:"foreignKey:ModelID"`
+}
+
+func (legacyFavorite) TableName() string { return "favorites" }
+
+type legacyAnnouncement struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Title     string    `gorm:"not null;size:255"`
+	Content   string    `gorm:"not null;type:text"`
+	IsActive  bool      `gorm:"default:true"`
+	CreatedAt time.Time
+}
+
+func (legacyAnnouncement) TableName() string { return "announcements" }
+
+type legacySession struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index"`
+	TokenHash string    `gorm:"not null;size:255"`
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+func (legacySession) TableName() string { return "sessions" }
+
+var legacyModels = []interface{}{
+	&legacyFile{}, &legacyUser{}, &legacyModel{}, &legacyModelVersion{}, &legacyModelImage{},
+	&legacyFavorite{}, &legacyAnnouncement{}, &legacySession{},
+}
+
+// legacyColumns 首个发布版 AutoMigrate 生成的每张表的列
+func legacyColumns(t *testing.T) map[string]map[string]bool {
+	t.Helper()
+	cache := &sync.Map{}
+	tables := map[string]map[string]bool{}
+	for _, m := range legacyModels {
+		s, err := schema.Parse(m, cache, schema.NamingStrategy{})
+		if err != nil {
+			t.Fatalf("failed to parse %T: %v", m, err)
+		}
+		columns := map[string]bool{}
+		for _, field := range s.Fields {
+			if field.DBName != "" {
+				columns[field.DBName] = true
+			}
+		}
+		tables[s.Table] = columns
+	}
+	return tables
+}
+
+func TestMigrationsUpgradeLegacySchema(t *testing.T) {
+	fresh := migratedColumns(t)
+	upgraded := replayMigrations(t, legacyColumns(t))
+	compareColumns(t, upgraded, fresh)
+}
+
+// TestMigrationsUpgradeLegacyDatabase 在独立 schema 中用首个发布版的模型 AutoMigrate 后执行全部迁移，
+// 结果应与空库迁移后的结构一致
+func TestMigrationsUpgradeLegacyDatabase(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	const schemaName = "legacy_upgrade_test"
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("failed to get connection pool: %v", err)
+	}
+	// 单连接保证 search_path 对 AutoMigrate 与迁移器都生效
+	sqlDB.SetMaxOpenConns(1)
+	sqlDB.SetMaxIdleConns(1)
+	t.Cleanup(func() {
+		db.Exec("DROP SCHEMA IF EXISTS " + schemaName + " CASCADE")
+		sqlDB.Close()
+	})
+
+	for _, stmt := range []string{
+		"DROP SCHEMA IF EXISTS " + schemaName + " CASCADE",
+		"CREATE SCHEMA " + schemaName,
+		"SET search_path TO " + schemaName + ", public",
+	} {
+		if err := db.Exec(stmt).Error; err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+	if err := db.AutoMigrate(legacyModels...); err != nil {
+		t.Fatalf("failed to create legacy schema: %v", err)
+	}
+
+	migrator, err := migrate.New(sqlDB, migrations.FS)
+	if err != nil {
+		t.Fatalf("failed to load migrations: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("Up() on a legacy database error = %v", err)
+	}
+
+	var rows []struct {
+		TableName  string
+		ColumnName string
+	}
+	err = db.Raw("SELECT table_name, column_name FROM information_schema.columns WHERE table_schema = ? AND table_name <> ?",
+		schemaName, migrator.Table).Scan(&rows).Error
+	if err != nil {
+		t.Fatalf("failed to read columns: %v", err)
+	}
+	upgraded := map[string]map[string]bool{}
+	for _, r := range rows {
+		if upgraded[r.TableName] == nil {
+			upgraded[r.TableName] = map[string]bool{}
+		}
+		upgraded[r.TableName][r.ColumnName] = true
+	}
+	compareColumns(t, upgraded, migratedColumns(t))
+}
+
+// compareColumns 报告两份表结构之间缺少或多出的列
+func compareColumns(t *testing.T, got, want map[string]map[string]bool) {
+	t.Helper()
+	if reflect.DeepEqual(got, want) {
+		return
+	}
+	tables := map[string]bool{}
+	for table := range got {
+		tables[table] = true
+	}
+	for table := range want {
+		tables[table] = true
+	}
+	names := make([]string, 0, len(tables))
+	for table := range tables {
+		names = append(names, table)
+	}
+	sort.Strings(names)
+
+	for _, table := range names {
+		for column := range want[table] {
+			if !got[table][column] {
+				t.Errorf("column %s.%s is missing after upgrading a legacy database", table, column)
+			}
+		}
+		for column := range got[table] {
+			if !want[table][column] {
+				t.Errorf("column %s.%s exists after upgrading a legacy database but not in a fresh one", table, column)
+			}
+		}
+	}
+}
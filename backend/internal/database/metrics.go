@@ -0,0 +1,88 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/ysmmc/backend/pkg/metrics"
+	"gorm.io/gorm"
+)
+
+var (
+	dbQueryDuration = metrics.NewHistogramVec("ysmmc_db_query_duration_seconds",
+		"Database statement duration by GORM operation.", metrics.DefaultBuckets, "operation")
+	dbQueryErrors = metrics.NewCounterVec("ysmmc_db_query_errors_total",
+		"Database statements that failed, by GORM operation.", "operation")
+)
+
+func init() {
+	metrics.NewGaugeFunc("ysmmc_db_open_connections", "Open database connections, in use or idle.",
+		func() float64 { return float64(poolStats().OpenConnections) })
+	metrics.NewGaugeFunc("ysmmc_db_in_use_connections", "Database connections currently in use.",
+		func() float64 { return float64(poolStats().InUse) })
+	metrics.NewGaugeFunc("ysmmc_db_idle_connections", "Idle database connections.",
+		func() float64 { return float64(poolStats().Idle) })
+	metrics.NewCounterFunc("ysmmc_db_wait_count_total", "Times a query waited for a free connection.",
+		func() float64 { return float64(poolStats().WaitCount) })
+	metrics.NewCounterFunc("ysmmc_db_wait_duration_seconds_total", "Total time spent waiting for a free connection.",
+		func() float64 { return poolStats().WaitDuration.Seconds() })
+}
+
+// poolStats 返回连接池统计，未连接数据库时返回零值
+func poolStats() sql.DBStats {
+	if DB == nil {
+		return sql.DBStats{}
+	}
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return sql.DBStats{}
+	}
+	return sqlDB.Stats()
+}
+
+const metricsStartKey = "metrics:start"
+
+// metricsPlugin 通过 GORM 回调记录每条语句的耗时与失败次数
+type metricsPlugin struct{}
+
+func (metricsPlugin) Name() string {
+	return "metrics"
+}
+
+func (metricsPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	steps := []error{
+		cb.Create().Before("gorm:create").Register("metrics:before_create", startTimer),
+		cb.Create().After("gorm:create").Register("metrics:after_create", observe("create")),
+		cb.Query().Before("gorm:query").Register("metrics:before_query", startTimer),
+		cb.Query().After("gorm:query").Register("metrics:after_query", observe("query")),
+		cb.Update().Before("gorm:update").Register("metrics:before_update", startTimer),
+		cb.Update().After("gorm:update").Register("metrics:after_update", observe("update")),
+		cb.Delete().Before("gorm:delete").Register("metrics:before_delete", startTimer),
+		cb.Delete().After("gorm:delete").Register("metrics:after_delete", observe("delete")),
+		cb.Row().Before("gorm:row").Register("metrics:before_row", startTimer),
+		cb.Row().After("gorm:row").Register("metrics:after_row", observe("row")),
+		cb.Raw().Before("gorm:raw").Register("metrics:before_raw", startTimer),
+		cb.Raw().After("gorm:raw").Register("metrics:after_raw", observe("raw")),
+	}
+	return errors.Join(steps...)
+}
+
+func startTimer(db *gorm.DB) {
+	db.InstanceSet(metricsStartKey, time.Now())
+}
+
+func observe(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(metricsStartKey)
+		if !ok {
+			return
+		}
+		dbQueryDuration.Observe(time.Since(value.(time.Time)).Seconds(), operation)
+		if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) && !errors.Is(db.Error, context.Canceled) {
+			dbQueryErrors.Inc(operation)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package database
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ysmmc/backend/internal/model"
+	"github.com/ysmmc/backend/migrations"
+	"github.com/ysmmc/backend/pkg/migrate"
+	"gorm.io/gorm/schema"
+)
+
+// schemaModels 所有持久化模型；新增模型时需同时添加迁移脚本并加入此列表
+var schemaModels = []interface{}{
+	&model.File{}, &model.User{}, &model.Model{}, &model.ModelVersion{}, &model.ModelImage{},
+	&model.Favorite{}, &model.Announcement{}, &model.Session{}, &model.ConsumedRefreshToken{},
+	&model.AdminAction{}, &model.Report{}, &model.ReportEntry{}, &model.UserSanction{}, &model.Role{},
+	&model.RecoveryCode{}, &model.APIToken{}, &model.SecurityEvent{}, &model.Notification{},
+	&model.UserIdentity{}, &model.OIDCState{}, &model.Setting{}, &model.InviteCode{}, &model.DataExport{},
+	&model.Job{}, &model.JobSchedule{}, &model.DailyStat{}, &model.EmailOutbox{}, &model.NotificationSettings{},
+	&model.ConsumedChallenge{},
+}
+
+var (
+	createTablePattern = regexp.MustCompile(`(?s)CREATE TABLE IF NOT EXISTS (\w+) \((.*?)\n\);`)
+	addColumnPattern   = regexp.MustCompile(`ALTER TABLE (\w+) ADD COLUMN (?:IF NOT EXISTS )?(\w+)`)
+	dropColumnPattern  = regexp.MustCompile(`ALTER TABLE (\w+) DROP COLUMN (?:IF EXISTS )?(\w+)`)
+)
+
+// migratedColumns 按版本顺序回放 up 脚本，得到空库迁移完成后每张表的列
+func migratedColumns(t *testing.T) map[string]map[string]bool {
+	t.Helper()
+	return replayMigrations(t, map[string]map[string]bool{})
+}
+
+// replayMigrations 在已有表结构上回放 up 脚本；与 CREATE TABLE IF NOT EXISTS 一致，已存在的表保持不变
+func replayMigrations(t *testing.T, tables map[string]map[string]bool) map[string]map[string]bool {
+	t.Helper()
+	all, err := migrate.Load(migrations.FS)
+	if err != nil {
+		t.Fatalf("failed to load migrations: %v", err)
+	}
+
+	for _, m := range all {
+		for _, match := range createTablePattern.FindAllStringSubmatch(m.Up, -1) {
+			if _, ok := tables[match[1]]; ok {
+				continue
+			}
+			columns := map[string]bool{}
+			for _, line := range strings.Split(match[2], "\n") {
+				if fields := strings.Fields(line); len(fields) > 0 {
+					columns[fields[0]] = true
+				}
+			}
+			tables[match[1]] = columns
+		}
+		for _, match := range addColumnPattern.FindAllStringSubmatch(m.Up, -1) {
+			tables[match[1]][match[2]] = true
+		}
+		for _, match := range dropColumnPattern.FindAllStringSubmatch(m.Up, -1) {
+			delete(tables[match[1]], match[2])
+		}
+	}
+	return tables
+}
+
+func TestMigrationsCoverModels(t *testing.T) {
+	tables := migratedColumns(t)
+	cache := &sync.Map{}
+
+	for _, m := range schemaModels {
+		s, err := schema.Parse(m, cache, schema.NamingStrategy{})
+		if err != nil {
+			t.Fatalf("failed to parse %T: %v", m, err)
+		}
+
+		columns, ok := tables[s.Table]
+		if !ok {
+			t.Errorf("table %s (%T) is not created by any migration", s.Table, m)
+			continue
+		}
+		for _, field := range s.Fields {
+			if field.DBName == "" {
+				continue
+			}
+			if !columns[field.DBName] {
+				t.Errorf("column %s.%s is missing from migrations", s.Table, field.DBName)
+			}
+		}
+	}
+}
@@ -2,7 +2,6 @@ package handler
 
 import (
 	"strconv"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -13,16 +12,34 @@ import (
 )
 
 type AdminHandler struct {
-	modelService        *service.ModelService
-	userService         *service.UserService
-	announcementService *service.AnnouncementService
+	modelService         *service.ModelService
+	userService          *service.UserService
+	announcementService  *service.AnnouncementService
+	moderationService    *service.ModerationService
+	reportService        *service.ReportService
+	sanctionService      *service.SanctionService
+	roleService          *service.RoleService
+	sessionService       *service.SessionService
+	securityEventService *service.SecurityEventService
+	lockoutService       *service.LockoutService
+	registrationService  *service.RegistrationService
+	statsService         *service.StatsService
 }
 
 func NewAdminHandler() *AdminHandler {
 	return &AdminHandler{
-		modelService:        service.NewModelService(),
-		userService:         service.NewUserService(),
-		announcementService: service.NewAnnouncementService(),
+		modelService:         service.NewModelService(),
+		userService:          service.NewUserService(),
+		announcementService:  service.NewAnnouncementService(),
+		moderationService:    service.NewModerationService(),
+		reportService:        service.NewReportService(),
+		sanctionService:      service.NewSanctionService(),
+		roleService:          service.NewRoleService(),
+		sessionService:       service.NewSessionService(),
+		securityEventService: service.NewSecurityEventService(),
+		lockoutService:       service.NewLockoutService(),
+		registrationService:  service.NewRegistrationService(),
+		statsService:         service.NewStatsService(),
 	}
 }
 
@@ -30,7 +47,7 @@ func (h *AdminHandler) ListPendingModels(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
 
-	models, total, err := h.modelService.ListPending(page, pageSize)
+	models, total, err := h.modelService.ListPending(c.Request.Context(), page, pageSize)
 	if err != nil {
 		response.InternalError(c, "failed to fetch pending models")
 		return
@@ -52,7 +69,7 @@ func (h *AdminHandler) ListAllModels(c *gin.Context) {
 		pageSize = 20
 	}
 
-	models, total, err := h.modelService.ListAll(page, pageSize, status, search)
+	models, total, err := h.modelService.ListAll(c.Request.Context(), page, pageSize, status, search)
 	if err != nil {
 		response.InternalError(c, "failed to fetch models")
 		return
@@ -68,11 +85,13 @@ func (h *AdminHandler) DeleteModel(c *gin.Context) {
 		return
 	}
 
-	if err := h.modelService.DeleteByAdmin(id); err != nil {
+	if err := h.modelService.DeleteByAdmin(c.Request.Context(), id); err != nil {
 		response.InternalError(c, "failed to delete model")
 		return
 	}
 
+	h.moderationService.Record(c.Request.Context(), middleware.GetUserID(c), "model.delete", "model", id, "")
+
 	response.SuccessWithMessage(c, "model deleted successfully", nil)
 }
 
@@ -80,7 +99,7 @@ func (h *AdminHandler) ListPendingUpdates(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
 
-	models, total, err := h.modelService.ListPendingUpdates(page, pageSize)
+	models, total, err := h.modelService.ListPendingUpdates(c.Request.Context(), page, pageSize)
 	if err != nil {
 		response.InternalError(c, "failed to fetch pending updates")
 		return
@@ -96,11 +115,13 @@ func (h *AdminHandler) ApproveModel(c *gin.Context) {
 		return
 	}
 
-	if err := h.modelService.Approve(id); err != nil {
+	if err := h.modelService.Approve(c.Request.Context(), id); err != nil {
 		response.InternalError(c, "failed to approve model")
 		return
 	}
 
+	h.moderationService.Record(c.Request.Context(), middleware.GetUserID(c), "model.approve", "model", id, "")
+
 	response.SuccessWithMessage(c, "model approved successfully", nil)
 }
 
@@ -120,11 +141,13 @@ func (h *AdminHandler) RejectModel(c *gin.Context) {
 		return
 	}
 
-	if err := h.modelService.Reject(id, req.Reason); err != nil {
+	if err := h.modelService.Reject(c.Request.Context(), id, req.Reason); err != nil {
 		response.InternalError(c, "failed to reject model")
 		return
 	}
 
+	h.moderationService.Record(c.Request.Context(), middleware.GetUserID(c), "model.reject", "model", id, req.Reason)
+
 	response.SuccessWithMessage(c, "model rejected", nil)
 }
 
@@ -132,7 +155,7 @@ func (h *AdminHandler) ListUsers(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
 
-	users, total, err := h.userService.List(page, pageSize)
+	users, total, err := h.userService.List(c.Request.Context(), page, pageSize)
 	if err != nil {
 		response.InternalError(c, "failed to fetch users")
 		return
@@ -158,14 +181,14 @@ func (h *AdminHandler) UpdateUserRole(c *gin.Context) {
 	}
 
 	currentRole := middleware.GetRole(c)
-	targetUser, err := h.userService.GetByID(id)
+	targetUser, err := h.userService.GetByID(c.Request.Context(), id)
 	if err != nil {
 		response.NotFound(c, "user not found")
 		return
 	}
 
-	if !isValidRole(req.Role) {
-		response.BadRequest(c, "invalid role, must be 'user' or 'admin'")
+	if _, err := h.roleService.GetByName(c.Request.Context(), req.Role); err != nil {
+		response.BadRequest(c, "invalid role")
 		return
 	}
 
@@ -174,17 +197,17 @@ func (h *AdminHandler) UpdateUserRole(c *gin.Context) {
 		return
 	}
 
-	if req.Role == "super_admin" {
+	if model.IsSuperAdmin(req.Role) {
 		response.BadRequest(c, "cannot assign super_admin role through this endpoint")
 		return
 	}
 
-	if req.Role == "admin" && !model.IsSuperAdmin(currentRole) {
-		response.Forbidden(c, "only super admin can assign admin role")
+	if !service.CanManageRole(c.Request.Context(), currentRole, targetUser.Role) || !service.CanManageRole(c.Request.Context(), currentRole, req.Role) {
+		response.Forbidden(c, "insufficient privileges to assign this role")
 		return
 	}
 
-	if err := h.userService.UpdateRole(id, req.Role); err != nil {
+	if err := h.userService.UpdateRole(c.Request.Context(), id, req.Role); err != nil {
 		response.BadRequest(c, err.Error())
 		return
 	}
@@ -192,15 +215,11 @@ func (h *AdminHandler) UpdateUserRole(c *gin.Context) {
 	response.SuccessWithMessage(c, "role updated successfully", nil)
 }
 
-func isValidRole(role string) bool {
-	return role == "user" || role == "admin"
-}
-
 func (h *AdminHandler) ListPendingProfiles(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
 
-	users, total, err := h.userService.ListPendingProfiles(page, pageSize)
+	users, total, err := h.userService.ListPendingProfiles(c.Request.Context(), page, pageSize)
 	if err != nil {
 		response.InternalError(c, "failed to fetch pending profiles")
 		return
@@ -216,7 +235,7 @@ func (h *AdminHandler) ApproveProfile(c *gin.Context) {
 		return
 	}
 
-	if err := h.userService.ApproveProfile(id); err != nil {
+	if err := h.userService.ApproveProfile(c.Request.Context(), id); err != nil {
 		response.InternalError(c, "failed to approve profile")
 		return
 	}
@@ -231,7 +250,7 @@ func (h *AdminHandler) RejectProfile(c *gin.Context) {
 		return
 	}
 
-	if err := h.userService.RejectProfile(id); err != nil {
+	if err := h.userService.RejectProfile(c.Request.Context(), id); err != nil {
 		response.InternalError(c, "failed to reject profile")
 		return
 	}
@@ -246,7 +265,7 @@ func (h *AdminHandler) CreateAnnouncement(c *gin.Context) {
 		return
 	}
 
-	announcement, err := h.announcementService.Create(&req)
+	announcement, err := h.announcementService.Create(c.Request.Context(), &req)
 	if err != nil {
 		response.InternalError(c, "failed to create announcement")
 		return
@@ -268,7 +287,7 @@ func (h *AdminHandler) UpdateAnnouncement(c *gin.Context) {
 		return
 	}
 
-	announcement, err := h.announcementService.Update(id, &req)
+	announcement, err := h.announcementService.Update(c.Request.Context(), id, &req)
 	if err != nil {
 		response.InternalError(c, "failed to update announcement")
 		return
@@ -284,7 +303,7 @@ func (h *AdminHandler) DeleteAnnouncement(c *gin.Context) {
 		return
 	}
 
-	if err := h.announcementService.Delete(id); err != nil {
+	if err := h.announcementService.Delete(c.Request.Context(), id); err != nil {
 		response.InternalError(c, "failed to delete announcement")
 		return
 	}
@@ -293,21 +312,36 @@ func (h *AdminHandler) DeleteAnnouncement(c *gin.Context) {
 }
 
 func (h *AdminHandler) GetStats(c *gin.Context) {
-	totalUsers, _ := h.userService.Count()
-	totalModels, _ := h.modelService.Count()
-	pendingModels, _ := h.modelService.CountByStatus("pending")
-	totalDownloads, _ := h.modelService.SumDownloads()
+	totalUsers, _ := h.userService.Count(c.Request.Context())
+	totalModels, _ := h.modelService.Count(c.Request.Context())
+	pendingModels, _ := h.modelService.CountByStatus(c.Request.Context(), "pending")
+	totalDownloads, _ := h.modelService.SumDownloads(c.Request.Context())
+	openReports, _ := h.reportService.CountOpen(c.Request.Context())
 
 	response.Success(c, gin.H{
 		"total_users":     totalUsers,
 		"total_models":    totalModels,
 		"pending_models":  pendingModels,
 		"total_downloads": totalDownloads,
+		"open_reports":    openReports,
 	})
 }
 
+// GetStatsHistory 返回最近 days 天的每日快照，由 stats.rollup 任务汇总
+func (h *AdminHandler) GetStatsHistory(c *gin.Context) {
+	days, _ := strconv.Atoi(c.DefaultQuery("days", "30"))
+
+	stats, err := h.statsService.History(c.Request.Context(), days)
+	if err != nil {
+		response.InternalError(c, "failed to fetch stats history")
+		return
+	}
+
+	response.Success(c, stats)
+}
+
 func (h *AdminHandler) GetSuperAdmin(c *gin.Context) {
-	user, err := h.userService.GetSuperAdmin()
+	user, err := h.userService.GetSuperAdmin(c.Request.Context())
 	if err != nil {
 		response.NotFound(c, "super admin not found")
 		return
@@ -323,7 +357,23 @@ func (h *AdminHandler) SetAdmin(c *gin.Context) {
 		return
 	}
 
-	if err := h.userService.SetRole(id, "admin"); err != nil {
+	user, err := h.userService.GetByID(c.Request.Context(), id)
+	if err != nil {
+		response.NotFound(c, "user not found")
+		return
+	}
+
+	if model.IsSuperAdmin(user.Role) {
+		response.BadRequest(c, "cannot modify super admin role")
+		return
+	}
+
+	if !service.CanManageRole(c.Request.Context(), middleware.GetRole(c), model.RoleAdmin) {
+		response.Forbidden(c, "insufficient privileges to grant admin role")
+		return
+	}
+
+	if err := h.userService.SetRole(c.Request.Context(), id, model.RoleAdmin); err != nil {
 		response.BadRequest(c, err.Error())
 		return
 	}
@@ -338,7 +388,7 @@ func (h *AdminHandler) RemoveAdmin(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userService.GetByID(id)
+	user, err := h.userService.GetByID(c.Request.Context(), id)
 	if err != nil {
 		response.NotFound(c, "user not found")
 		return
@@ -349,7 +399,12 @@ func (h *AdminHandler) RemoveAdmin(c *gin.Context) {
 		return
 	}
 
-	if err := h.userService.SetRole(id, "user"); err != nil {
+	if !service.CanManageRole(c.Request.Context(), middleware.GetRole(c), user.Role) {
+		response.Forbidden(c, "insufficient privileges to remove this role")
+		return
+	}
+
+	if err := h.userService.SetRole(c.Request.Context(), id, model.RoleUser); err != nil {
 		response.BadRequest(c, err.Error())
 		return
 	}
@@ -365,7 +420,7 @@ func (h *AdminHandler) BanUser(c *gin.Context) {
 	}
 
 	currentRole := middleware.GetRole(c)
-	targetUser, err := h.userService.GetByID(id)
+	targetUser, err := h.userService.GetByID(c.Request.Context(), id)
 	if err != nil {
 		response.NotFound(c, "user not found")
 		return
@@ -376,13 +431,14 @@ func (h *AdminHandler) BanUser(c *gin.Context) {
 		return
 	}
 
-	if model.IsAdmin(targetUser.Role) && !model.IsSuperAdmin(currentRole) {
-		response.Forbidden(c, "only super admin can ban admin users")
+	if !service.CanManageRole(c.Request.Context(), currentRole, targetUser.Role) {
+		response.Forbidden(c, "insufficient privileges to ban this user")
 		return
 	}
 
 	var req struct {
-		Reason string `json:"reason" binding:"required"`
+		Reason        string `json:"reason" binding:"required"`
+		DurationHours *int   `json:"duration_hours" binding:"omitempty,min=1"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -390,17 +446,20 @@ func (h *AdminHandler) BanUser(c *gin.Context) {
 		return
 	}
 
-	now := time.Now()
-	targetUser.IsBanned = true
-	targetUser.BannedAt = &now
-	targetUser.BannedReason = &req.Reason
-
-	if err := h.userService.Update(targetUser); err != nil {
-		response.InternalError(c, "failed to ban user")
+	adminID := middleware.GetUserID(c)
+	sanction, err := h.sanctionService.Issue(c.Request.Context(), id, adminID, currentRole, &service.IssueSanctionRequest{
+		Type:          model.SanctionBan,
+		Reason:        req.Reason,
+		DurationHours: req.DurationHours,
+	})
+	if err != nil {
+		response.BadRequest(c, err.Error())
 		return
 	}
 
-	response.SuccessWithMessage(c, "user banned successfully", nil)
+	h.moderationService.Record(c.Request.Context(), adminID, "user.ban", "user", id, req.Reason)
+
+	response.SuccessWithMessage(c, "user banned successfully", sanction)
 }
 
 func (h *AdminHandler) UnbanUser(c *gin.Context) {
@@ -410,20 +469,505 @@ func (h *AdminHandler) UnbanUser(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userService.GetByID(id)
+	adminID := middleware.GetUserID(c)
+	if err := h.sanctionService.Unban(c.Request.Context(), id, adminID, middleware.GetRole(c)); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	h.moderationService.Record(c.Request.Context(), adminID, "user.unban", "user", id, "")
+
+	response.SuccessWithMessage(c, "user unbanned successfully", nil)
+}
+
+// UnlockUser 解除连续登录失败导致的临时锁定
+func (h *AdminHandler) UnlockUser(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid user id")
+		return
+	}
+
+	targetUser, err := h.userService.GetByID(c.Request.Context(), id)
 	if err != nil {
 		response.NotFound(c, "user not found")
 		return
 	}
 
-	user.IsBanned = false
-	user.BannedAt = nil
-	user.BannedReason = nil
+	if !service.CanManageRole(c.Request.Context(), middleware.GetRole(c), targetUser.Role) {
+		response.Forbidden(c, "insufficient privileges to manage this user")
+		return
+	}
 
-	if err := h.userService.Update(user); err != nil {
-		response.InternalError(c, "failed to unban user")
+	if err := h.lockoutService.Unlock(c.Request.Context(), id); err != nil {
+		response.InternalError(c, "failed to unlock user")
 		return
 	}
 
-	response.SuccessWithMessage(c, "user unbanned successfully", nil)
+	h.moderationService.Record(c.Request.Context(), middleware.GetUserID(c), "user.unlock", "user", id, "")
+
+	response.SuccessWithMessage(c, "user unlocked successfully", nil)
+}
+
+func (h *AdminHandler) IssueSanction(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid user id")
+		return
+	}
+
+	var req service.IssueSanctionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	adminID := middleware.GetUserID(c)
+	sanction, err := h.sanctionService.Issue(c.Request.Context(), id, adminID, middleware.GetRole(c), &req)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	h.moderationService.Record(c.Request.Context(), adminID, "user.sanction."+req.Type, "user", id, req.Reason)
+
+	response.Success(c, sanction)
+}
+
+func (h *AdminHandler) ListUserSanctions(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid user id")
+		return
+	}
+
+	sanctions, err := h.sanctionService.ListByUser(c.Request.Context(), id)
+	if err != nil {
+		response.InternalError(c, "failed to fetch sanctions")
+		return
+	}
+
+	response.Success(c, sanctions)
+}
+
+func (h *AdminHandler) RevokeSanction(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid sanction id")
+		return
+	}
+
+	adminID := middleware.GetUserID(c)
+	if err := h.sanctionService.Revoke(c.Request.Context(), id, adminID, middleware.GetRole(c)); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	h.moderationService.Record(c.Request.Context(), adminID, "sanction.revoke", "sanction", id, "")
+
+	response.SuccessWithMessage(c, "sanction revoked", nil)
+}
+
+func (h *AdminHandler) ListUserSessions(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid user id")
+		return
+	}
+
+	sessions, err := h.sessionService.List(c.Request.Context(), id, uuid.Nil)
+	if err != nil {
+		response.InternalError(c, "failed to fetch sessions")
+		return
+	}
+
+	response.Success(c, sessions)
+}
+
+func (h *AdminHandler) RevokeUserSession(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid user id")
+		return
+	}
+
+	sessionID, err := uuid.Parse(c.Param("sessionId"))
+	if err != nil {
+		response.BadRequest(c, "invalid session id")
+		return
+	}
+
+	targetUser, err := h.userService.GetByID(c.Request.Context(), id)
+	if err != nil {
+		response.NotFound(c, "user not found")
+		return
+	}
+
+	if !service.CanManageRole(c.Request.Context(), middleware.GetRole(c), targetUser.Role) {
+		response.Forbidden(c, "insufficient privileges to manage this user")
+		return
+	}
+
+	if err := h.sessionService.Revoke(c.Request.Context(), id, sessionID); err != nil {
+		response.NotFound(c, err.Error())
+		return
+	}
+
+	h.moderationService.Record(c.Request.Context(), middleware.GetUserID(c), "session.revoke", "session", sessionID, "")
+
+	response.SuccessWithMessage(c, "session revoked", nil)
+}
+
+func (h *AdminHandler) RevokeAllUserSessions(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid user id")
+		return
+	}
+
+	targetUser, err := h.userService.GetByID(c.Request.Context(), id)
+	if err != nil {
+		response.NotFound(c, "user not found")
+		return
+	}
+
+	if !service.CanManageRole(c.Request.Context(), middleware.GetRole(c), targetUser.Role) {
+		response.Forbidden(c, "insufficient privileges to manage this user")
+		return
+	}
+
+	if err := service.RevokeUserTokens(c.Request.Context(), id); err != nil {
+		response.InternalError(c, "failed to revoke sessions")
+		return
+	}
+
+	h.moderationService.Record(c.Request.Context(), middleware.GetUserID(c), "user.logout_all", "user", id, "")
+
+	response.SuccessWithMessage(c, "all sessions revoked", nil)
+}
+
+func (h *AdminHandler) BulkModelAction(c *gin.Context) {
+	var req service.BulkModelActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	if req.Action == "delete" && !middleware.HasPermission(c, model.PermModelsManage) {
+		response.Forbidden(c, "permission required: "+model.PermModelsManage)
+		return
+	}
+
+	action, err := h.moderationService.BulkModelAction(c.Request.Context(), middleware.GetUserID(c), &req)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	response.Success(c, action)
+}
+
+func (h *AdminHandler) BulkBanUsers(c *gin.Context) {
+	var req service.BulkBanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	action, err := h.moderationService.BulkBan(c.Request.Context(), middleware.GetUserID(c), middleware.GetRole(c), &req)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	response.Success(c, action)
+}
+
+func (h *AdminHandler) ListActions(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	action := c.Query("action")
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	actions, total, err := h.moderationService.ListActions(c.Request.Context(), page, pageSize, action)
+	if err != nil {
+		response.InternalError(c, "failed to fetch admin actions")
+		return
+	}
+
+	response.Paginated(c, actions, total, page, pageSize)
+}
+
+func (h *AdminHandler) GetAction(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid action id")
+		return
+	}
+
+	action, err := h.moderationService.GetAction(c.Request.Context(), id)
+	if err != nil {
+		response.NotFound(c, "action not found")
+		return
+	}
+
+	response.Success(c, action)
+}
+
+func (h *AdminHandler) ListReports(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	status := c.DefaultQuery("status", "open")
+	targetType := c.Query("target_type")
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	reports, total, err := h.reportService.List(c.Request.Context(), page, pageSize, status, targetType)
+	if err != nil {
+		response.InternalError(c, "failed to fetch reports")
+		return
+	}
+
+	response.Paginated(c, reports, total, page, pageSize)
+}
+
+func (h *AdminHandler) GetReport(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid report id")
+		return
+	}
+
+	report, err := h.reportService.GetByID(c.Request.Context(), id)
+	if err != nil {
+		response.NotFound(c, "report not found")
+		return
+	}
+
+	response.Success(c, report)
+}
+
+func (h *AdminHandler) ResolveReport(c *gin.Context) {
+	h.closeReport(c, "resolve")
+}
+
+func (h *AdminHandler) DismissReport(c *gin.Context) {
+	h.closeReport(c, "dismiss")
+}
+
+func (h *AdminHandler) closeReport(c *gin.Context, action string) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid report id")
+		return
+	}
+
+	var req service.ReviewReportRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, err.Error())
+			return
+		}
+	}
+
+	adminID := middleware.GetUserID(c)
+
+	var report *model.Report
+	if action == "resolve" {
+		report, err = h.reportService.Resolve(c.Request.Context(), id, adminID, req.Note)
+	} else {
+		report, err = h.reportService.Dismiss(c.Request.Context(), id, adminID, req.Note)
+	}
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	h.moderationService.Record(c.Request.Context(), adminID, "report."+action, "report", id, req.Note)
+	response.Success(c, report)
+}
+
+func (h *AdminHandler) ListRoles(c *gin.Context) {
+	roles, err := h.roleService.List(c.Request.Context())
+	if err != nil {
+		response.InternalError(c, "failed to fetch roles")
+		return
+	}
+
+	response.Success(c, roles)
+}
+
+func (h *AdminHandler) ListPermissions(c *gin.Context) {
+	response.Success(c, model.Permissions)
+}
+
+func (h *AdminHandler) CreateRole(c *gin.Context) {
+	var req service.CreateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	role, err := h.roleService.Create(c.Request.Context(), middleware.GetRole(c), &req)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	response.Success(c, role)
+}
+
+func (h *AdminHandler) UpdateRole(c *gin.Context) {
+	var req service.UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	role, err := h.roleService.Update(c.Request.Context(), middleware.GetRole(c), c.Param("name"), &req)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	response.Success(c, role)
+}
+
+func (h *AdminHandler) DeleteRole(c *gin.Context) {
+	if err := h.roleService.Delete(c.Request.Context(), middleware.GetRole(c), c.Param("name")); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "role deleted successfully", nil)
+}
+
+func (h *AdminHandler) ListSecurityEvents(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	eventType := c.Query("type")
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	var userID *uuid.UUID
+	if raw := c.Query("user_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			response.BadRequest(c, "invalid user id")
+			return
+		}
+		userID = &id
+	}
+
+	events, total, err := h.securityEventService.List(c.Request.Context(), page, pageSize, userID, eventType)
+	if err != nil {
+		response.InternalError(c, "failed to fetch security events")
+		return
+	}
+
+	response.Paginated(c, events, total, page, pageSize)
+}
+
+func (h *AdminHandler) GetRegistration(c *gin.Context) {
+	response.Success(c, h.registrationService.Settings(c.Request.Context()))
+}
+
+// UpdateRegistration 切换注册模式，立即在本实例生效，其他实例 30 秒内生效
+func (h *AdminHandler) UpdateRegistration(c *gin.Context) {
+	var req service.UpdateRegistrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	adminID := middleware.GetUserID(c)
+	settings, err := h.registrationService.UpdateSettings(c.Request.Context(), adminID, &req)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	h.moderationService.Record(c.Request.Context(), adminID, "registration.update", "setting", uuid.Nil, settings.Mode)
+
+	response.SuccessWithMessage(c, "registration settings updated", settings)
+}
+
+func (h *AdminHandler) ListInvites(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	var createdBy *uuid.UUID
+	if raw := c.Query("created_by"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			response.BadRequest(c, "invalid user id")
+			return
+		}
+		createdBy = &id
+	}
+
+	invites, total, err := h.registrationService.ListAllInvites(c.Request.Context(), page, pageSize, createdBy)
+	if err != nil {
+		response.InternalError(c, "failed to fetch invite codes")
+		return
+	}
+
+	response.Paginated(c, invites, total, page, pageSize)
+}
+
+func (h *AdminHandler) RevokeInvite(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid invite id")
+		return
+	}
+
+	if err := h.registrationService.RevokeInvite(c.Request.Context(), id, nil); err != nil {
+		response.NotFound(c, err.Error())
+		return
+	}
+
+	h.moderationService.Record(c.Request.Context(), middleware.GetUserID(c), "invite.revoke", "invite", id, "")
+
+	response.SuccessWithMessage(c, "invite code revoked", nil)
+}
+
+// ListUserInvitees 查看某用户邀请注册的账号，便于追查批量注册
+func (h *AdminHandler) ListUserInvitees(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid user id")
+		return
+	}
+
+	users, err := h.registrationService.ListInvitees(c.Request.Context(), id)
+	if err != nil {
+		response.InternalError(c, "failed to fetch invited users")
+		return
+	}
+
+	response.Success(c, users)
 }
@@ -1,30 +1,77 @@
 package handler
 
 import (
+	"errors"
+	"net/http"
+
 	"github.com/gin-gonic/gin"
 	"github.com/ysmmc/backend/internal/middleware"
 	"github.com/ysmmc/backend/internal/service"
+	"github.com/ysmmc/backend/pkg/auth"
 	"github.com/ysmmc/backend/pkg/response"
 )
 
 type AuthHandler struct {
-	authService *service.AuthService
+	authService         *service.AuthService
+	oidcService         *service.OIDCService
+	challengeService    *service.ChallengeService
+	registrationService *service.RegistrationService
 }
 
 func NewAuthHandler() *AuthHandler {
 	return &AuthHandler{
-		authService: service.NewAuthService(),
+		authService:         service.NewAuthService(),
+		oidcService:         service.NewOIDCService(),
+		challengeService:    service.NewChallengeService(),
+		registrationService: service.NewRegistrationService(),
+	}
+}
+
+// powProof 注册与找回密码请求附带的工作量证明
+type powProof struct {
+	PowToken    string `json:"pow_token"`
+	PowSolution string `json:"pow_solution"`
+}
+
+// Challenge 签发工作量证明挑战，purpose 为 register 或 forgot_password
+func (h *AuthHandler) Challenge(c *gin.Context) {
+	challenge, err := h.challengeService.Issue(c.Query("purpose"), c.ClientIP())
+	if errors.Is(err, service.ErrInvalidChallengePurpose) {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if err != nil {
+		response.InternalError(c, "failed to issue challenge")
+		return
 	}
+
+	c.Header("Cache-Control", "no-store")
+	response.Success(c, challenge)
 }
 
 func (h *AuthHandler) Register(c *gin.Context) {
-	var req service.RegisterRequest
+	var req struct {
+		service.RegisterRequest
+		powProof
+	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.BadRequest(c, err.Error())
 		return
 	}
 
-	user, err := h.authService.Register(&req)
+	if err := h.challengeService.Verify(c.Request.Context(), service.ChallengeRegister, req.PowToken, req.PowSolution, c.ClientIP()); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	if req.Language == "" {
+		req.Language = c.GetHeader("Accept-Language")
+	}
+	user, err := h.authService.Register(c.Request.Context(), &req.RegisterRequest)
+	if isRegistrationDenied(err) {
+		response.Forbidden(c, err.Error())
+		return
+	}
 	if err != nil {
 		response.BadRequest(c, err.Error())
 		return
@@ -37,6 +84,18 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	})
 }
 
+// Registration 返回当前注册模式，前端据此决定是否显示邀请码输入框
+func (h *AuthHandler) Registration(c *gin.Context) {
+	response.Success(c, h.registrationService.Settings(c.Request.Context()))
+}
+
+// isRegistrationDenied 注册模式拒绝的请求返回 403，与参数错误区分
+func isRegistrationDenied(err error) bool {
+	return errors.Is(err, service.ErrRegistrationClosed) ||
+		errors.Is(err, service.ErrInviteRequired) ||
+		errors.Is(err, service.ErrEmailDomainNotAllowed)
+}
+
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req service.LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -44,7 +103,31 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	result, err := h.authService.Login(&req)
+	result, err := h.authService.Login(c.Request.Context(), &req, clientInfo(c))
+	if errors.Is(err, service.ErrAccountLocked) {
+		response.TooManyRequests(c, err.Error())
+		return
+	}
+	if err != nil {
+		response.Unauthorized(c, err.Error())
+		return
+	}
+
+	response.Success(c, result)
+}
+
+func (h *AuthHandler) LoginMFA(c *gin.Context) {
+	var req service.LoginMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.authService.LoginMFA(c.Request.Context(), &req, clientInfo(c))
+	if errors.Is(err, service.ErrAccountLocked) {
+		response.TooManyRequests(c, err.Error())
+		return
+	}
 	if err != nil {
 		response.Unauthorized(c, err.Error())
 		return
@@ -63,7 +146,7 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	tokens, err := h.authService.RefreshToken(req.RefreshToken)
+	tokens, err := h.authService.RefreshToken(c.Request.Context(), req.RefreshToken, clientInfo(c))
 	if err != nil {
 		response.Unauthorized(c, err.Error())
 		return
@@ -75,6 +158,7 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 func (h *AuthHandler) ForgotPassword(c *gin.Context) {
 	var req struct {
 		Email string `json:"email" binding:"required,email"`
+		powProof
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -82,7 +166,12 @@ func (h *AuthHandler) ForgotPassword(c *gin.Context) {
 		return
 	}
 
-	if err := h.authService.ForgotPassword(req.Email); err != nil {
+	if err := h.challengeService.Verify(c.Request.Context(), service.ChallengeForgotPassword, req.PowToken, req.PowSolution, c.ClientIP()); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	if err := h.authService.ForgotPassword(c.Request.Context(), req.Email); err != nil {
 		response.InternalError(c, "failed to process request")
 		return
 	}
@@ -101,7 +190,7 @@ func (h *AuthHandler) ResetPassword(c *gin.Context) {
 		return
 	}
 
-	if err := h.authService.ResetPassword(req.Token, req.Password); err != nil {
+	if err := h.authService.ResetPassword(c.Request.Context(), req.Token, req.Password); err != nil {
 		response.BadRequest(c, err.Error())
 		return
 	}
@@ -113,7 +202,7 @@ func (h *AuthHandler) Me(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 
 	userService := service.NewUserService()
-	user, err := userService.GetByID(userID)
+	user, err := userService.GetByID(c.Request.Context(), userID)
 	if err != nil {
 		response.NotFound(c, "user not found")
 		return
@@ -129,7 +218,7 @@ func (h *AuthHandler) VerifyEmail(c *gin.Context) {
 		return
 	}
 
-	if err := h.authService.VerifyEmail(token); err != nil {
+	if err := h.authService.VerifyEmail(c.Request.Context(), token); err != nil {
 		response.BadRequest(c, err.Error())
 		return
 	}
@@ -137,6 +226,15 @@ func (h *AuthHandler) VerifyEmail(c *gin.Context) {
 	response.SuccessWithMessage(c, "email verified successfully", nil)
 }
 
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+	if err := h.authService.ResendVerification(c.Request.Context(), middleware.GetUserID(c)); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "verification email sent", nil)
+}
+
 func (h *AuthHandler) ChangeEmail(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 
@@ -146,7 +244,7 @@ func (h *AuthHandler) ChangeEmail(c *gin.Context) {
 		return
 	}
 
-	if err := h.authService.ChangeEmail(userID, req.NewEmail); err != nil {
+	if err := h.authService.ChangeEmail(c.Request.Context(), userID, req.NewEmail); err != nil {
 		response.BadRequest(c, err.Error())
 		return
 	}
@@ -161,7 +259,7 @@ func (h *AuthHandler) VerifyEmailChange(c *gin.Context) {
 		return
 	}
 
-	if err := h.authService.VerifyEmailChange(token); err != nil {
+	if err := h.authService.VerifyEmailChange(c.Request.Context(), token); err != nil {
 		response.BadRequest(c, err.Error())
 		return
 	}
@@ -179,10 +277,67 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 		return
 	}
 
-	if err := h.authService.Logout(req.RefreshToken); err != nil {
+	if err := h.authService.Logout(c.Request.Context(), req.RefreshToken); err != nil {
 		response.SuccessWithMessage(c, "logged out successfully", nil)
 		return
 	}
 
 	response.SuccessWithMessage(c, "logged out successfully", nil)
 }
+
+func (h *AuthHandler) ListOIDCProviders(c *gin.Context) {
+	response.Success(c, h.oidcService.Providers())
+}
+
+// OIDCAuthorize 返回第三方登录的授权地址，前端保存 state 后跳转
+func (h *AuthHandler) OIDCAuthorize(c *gin.Context) {
+	result, err := h.oidcService.BeginLogin(c.Request.Context(), c.Param("provider"))
+	if err != nil {
+		if errors.Is(err, service.ErrOIDCProviderNotFound) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		response.Error(c, 502, 502, err.Error())
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// OIDCCallback 前端回调页提交提供方返回的 code 与 state，完成登录
+func (h *AuthHandler) OIDCCallback(c *gin.Context) {
+	var req service.OIDCCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.oidcService.CompleteLogin(c.Request.Context(), &req, clientInfo(c))
+	if err != nil {
+		if errors.Is(err, service.ErrOIDCEmailInUse) {
+			response.Error(c, 409, 409, err.Error())
+			return
+		}
+		if isRegistrationDenied(err) {
+			response.Forbidden(c, err.Error())
+			return
+		}
+		response.Unauthorized(c, err.Error())
+		return
+	}
+
+	response.Success(c, result)
+}
+
+func clientInfo(c *gin.Context) service.ClientInfo {
+	return service.ClientInfo{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+}
+
+// JWKS 公开当前的验证公钥（RFC 7517），供其他服务校验本站签发的 token
+func (h *AuthHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, auth.PublicJWKS())
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/ysmmc/backend/internal/middleware"
+	"github.com/ysmmc/backend/internal/service"
+	"github.com/ysmmc/backend/pkg/email"
+	"github.com/ysmmc/backend/pkg/response"
+)
+
+// EmailHandler 邮件投递记录的管理接口
+type EmailHandler struct {
+	outboxService     *service.EmailOutboxService
+	moderationService *service.ModerationService
+}
+
+func NewEmailHandler() *EmailHandler {
+	return &EmailHandler{
+		outboxService:     service.NewEmailOutboxService(),
+		moderationService: service.NewModerationService(),
+	}
+}
+
+func (h *EmailHandler) List(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	emails, total, err := h.outboxService.List(c.Request.Context(), page, pageSize, c.Query("status"), c.Query("category"), c.Query("to"))
+	if err != nil {
+		response.InternalError(c, "failed to fetch emails")
+		return
+	}
+
+	response.Paginated(c, emails, total, page, pageSize)
+}
+
+func (h *EmailHandler) Stats(c *gin.Context) {
+	stats, err := h.outboxService.Stats(c.Request.Context())
+	if err != nil {
+		response.InternalError(c, "failed to fetch email stats")
+		return
+	}
+
+	response.Success(c, stats)
+}
+
+func (h *EmailHandler) Get(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid email id")
+		return
+	}
+
+	outbox, err := h.outboxService.Get(c.Request.Context(), id)
+	if err != nil {
+		response.NotFound(c, "email not found")
+		return
+	}
+
+	response.Success(c, outbox)
+}
+
+// Resend 重新投递发送失败的邮件
+func (h *EmailHandler) Resend(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid email id")
+		return
+	}
+
+	if err := h.outboxService.Resend(c.Request.Context(), id); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	h.moderationService.Record(c.Request.Context(), middleware.GetUserID(c), "email.resend", "email", id, "")
+	response.SuccessWithMessage(c, "email queued for delivery", nil)
+}
+
+func (h *EmailHandler) ListTemplates(c *gin.Context) {
+	response.Success(c, h.outboxService.Templates())
+}
+
+// PreviewTemplate 使用示例数据渲染模板；format=html 或 format=text 时直接返回对应正文，便于在浏览器中查看
+func (h *EmailHandler) PreviewTemplate(c *gin.Context) {
+	msg, err := h.outboxService.PreviewTemplate(c.Param("name"), c.Query("locale"))
+	if errors.Is(err, email.ErrUnknownTemplate) {
+		response.NotFound(c, "email template not found")
+		return
+	}
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	switch c.Query("format") {
+	case "html":
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(msg.Body))
+	case "text":
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(msg.Text))
+	default:
+		response.Success(c, gin.H{"subject": msg.Subject, "html": msg.Body, "text": msg.Text})
+	}
+}
@@ -2,9 +2,9 @@ package handler
 
 import (
 	"errors"
-	"fmt"
 	"io"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -31,7 +31,7 @@ func (h *FileHandler) GetFile(c *gin.Context) {
 		return
 	}
 
-	data, mimeType, err := h.fileService.GetFileData(id)
+	data, mimeType, err := h.fileService.GetFileData(c.Request.Context(), id)
 	if err != nil {
 		response.NotFound(c, "file not found")
 		return
@@ -41,6 +41,7 @@ func (h *FileHandler) GetFile(c *gin.Context) {
 }
 
 func (h *FileHandler) UploadFile(c *gin.Context) {
+	start := time.Now()
 	file, header, err := c.Request.FormFile("file")
 	if err != nil {
 		response.BadRequest(c, "no file uploaded")
@@ -89,11 +90,12 @@ func (h *FileHandler) UploadFile(c *gin.Context) {
 		userID = &uid
 	}
 
-	savedFile, err := h.fileService.SaveFile(header.Filename, mimeType, data, category, userID)
+	savedFile, err := h.fileService.SaveFile(c.Request.Context(), header.Filename, mimeType, data, category, userID)
 	if err != nil {
 		response.InternalError(c, "failed to save file")
 		return
 	}
+	observeUpload("file", savedFile.Size, start)
 
 	response.Success(c, gin.H{
 		"id":       savedFile.ID,
@@ -112,7 +114,7 @@ func (h *FileHandler) DeleteFile(c *gin.Context) {
 		return
 	}
 
-	if err := h.fileService.DeleteFile(id); err != nil {
+	if err := h.fileService.DeleteFile(c.Request.Context(), id); err != nil {
 		response.NotFound(c, "file not found")
 		return
 	}
@@ -140,8 +142,6 @@ func validateImageMagicNumber(data []byte, ext string) error {
 		return errors.New("file too small")
 	}
 
-	fmt.Printf("validateImageMagicNumber: ext=%s, len=%d, data=%v\n", ext, len(data), data[:8])
-
 	jpegMagic := []byte{0xFF, 0xD8, 0xFF}
 	pngMagic := []byte{0x89, 0x50, 0x4E, 0x47, 0x0D, 0x0A, 0x1A, 0x0A}
 	gifMagic1 := []byte{0x47, 0x49, 0x46, 0x38, 0x37, 0x61}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/ysmmc/backend/internal/middleware"
+	"github.com/ysmmc/backend/internal/service"
+	"github.com/ysmmc/backend/pkg/response"
+)
+
+// JobHandler 后台任务队列的管理接口
+type JobHandler struct {
+	jobService        *service.JobService
+	moderationService *service.ModerationService
+}
+
+func NewJobHandler() *JobHandler {
+	return &JobHandler{
+		jobService:        service.NewJobService(),
+		moderationService: service.NewModerationService(),
+	}
+}
+
+func (h *JobHandler) List(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	jobs, total, err := h.jobService.List(c.Request.Context(), page, pageSize, c.Query("status"), c.Query("kind"))
+	if err != nil {
+		response.InternalError(c, "failed to fetch jobs")
+		return
+	}
+
+	response.Paginated(c, jobs, total, page, pageSize)
+}
+
+func (h *JobHandler) Stats(c *gin.Context) {
+	stats, err := h.jobService.Stats(c.Request.Context())
+	if err != nil {
+		response.InternalError(c, "failed to fetch job stats")
+		return
+	}
+
+	response.Success(c, stats)
+}
+
+func (h *JobHandler) Get(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid job id")
+		return
+	}
+
+	job, err := h.jobService.Get(c.Request.Context(), id)
+	if err != nil {
+		response.NotFound(c, "job not found")
+		return
+	}
+
+	response.Success(c, job)
+}
+
+// Retry 重新执行进入死信的任务
+func (h *JobHandler) Retry(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid job id")
+		return
+	}
+
+	if err := h.jobService.Retry(c.Request.Context(), id); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	h.moderationService.Record(c.Request.Context(), middleware.GetUserID(c), "job.retry", "job", id, "")
+	response.SuccessWithMessage(c, "job queued for retry", nil)
+}
@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/ysmmc/backend/pkg/metrics"
+)
+
+var (
+	uploadSizeBytes = metrics.NewHistogramVec("ysmmc_upload_size_bytes",
+		"Size of accepted uploads by kind (model, image, file).",
+		[]float64{64 << 10, 256 << 10, 1 << 20, 4 << 20, 16 << 20, 64 << 20, 256 << 20, 1 << 30}, "kind")
+	uploadDuration = metrics.NewHistogramVec("ysmmc_upload_duration_seconds",
+		"Time from the start of an accepted upload request until the file is stored, by kind.",
+		[]float64{.1, .25, .5, 1, 2.5, 5, 10, 30, 60, 120, 300}, "kind")
+)
+
+// observeUpload 记录成功保存的上传文件大小与耗时
+func observeUpload(kind string, size int64, start time.Time) {
+	uploadSizeBytes.Observe(float64(size), kind)
+	uploadDuration.Observe(time.Since(start).Seconds(), kind)
+}
@@ -1,7 +1,6 @@
 package handler
 
 import (
-	"log"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -11,6 +10,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/ysmmc/backend/internal/config"
 	"github.com/ysmmc/backend/internal/middleware"
+	"github.com/ysmmc/backend/internal/model"
 	"github.com/ysmmc/backend/internal/service"
 	"github.com/ysmmc/backend/pkg/response"
 )
@@ -39,7 +39,7 @@ func (h *ModelHandler) List(c *gin.Context) {
 		pageSize = 12
 	}
 
-	models, total, err := h.modelService.ListPublic(page, pageSize, search)
+	models, total, err := h.modelService.ListPublic(c.Request.Context(), page, pageSize, search)
 	if err != nil {
 		response.InternalError(c, "failed to fetch models")
 		return
@@ -55,7 +55,7 @@ func (h *ModelHandler) GetByID(c *gin.Context) {
 		return
 	}
 
-	model, err := h.modelService.GetByID(id)
+	model, err := h.modelService.GetByID(c.Request.Context(), id)
 	if err != nil {
 		response.NotFound(c, "model not found")
 		return
@@ -67,10 +67,10 @@ func (h *ModelHandler) GetByID(c *gin.Context) {
 
 	userID, exists := c.Get("user_id")
 	if exists {
-		result["is_favorited"] = h.favoriteService.IsFavorited(userID.(uuid.UUID), id)
+		result["is_favorited"] = h.favoriteService.IsFavorited(c.Request.Context(), userID.(uuid.UUID), id)
 	}
 
-	favoriteCount, _ := h.favoriteService.CountByModel(id)
+	favoriteCount, _ := h.favoriteService.CountByModel(c.Request.Context(), id)
 	result["favorite_count"] = favoriteCount
 
 	response.Success(c, result)
@@ -85,7 +85,7 @@ func (h *ModelHandler) Create(c *gin.Context) {
 		return
 	}
 
-	model, err := h.modelService.Create(userID, &req)
+	model, err := h.modelService.Create(c.Request.Context(), userID, &req)
 	if err != nil {
 		response.InternalError(c, "failed to create model")
 		return
@@ -102,7 +102,6 @@ func (h *ModelHandler) Update(c *gin.Context) {
 	}
 
 	userID := middleware.GetUserID(c)
-	role := middleware.GetRole(c)
 
 	var req service.UpdateModelRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -110,8 +109,8 @@ func (h *ModelHandler) Update(c *gin.Context) {
 		return
 	}
 
-	isAdmin := role == "admin" || role == "super_admin"
-	model, err := h.modelService.Update(id, userID, &req, isAdmin)
+	isAdmin := middleware.HasPermission(c, model.PermModelsManage)
+	model, err := h.modelService.Update(c.Request.Context(), id, userID, &req, isAdmin)
 	if err != nil {
 		response.BadRequest(c, err.Error())
 		return
@@ -128,10 +127,9 @@ func (h *ModelHandler) Delete(c *gin.Context) {
 	}
 
 	userID := middleware.GetUserID(c)
-	role := middleware.GetRole(c)
 
-	isAdmin := role == "admin" || role == "super_admin"
-	if err := h.modelService.Delete(id, userID, isAdmin); err != nil {
+	isAdmin := middleware.HasPermission(c, model.PermModelsManage)
+	if err := h.modelService.Delete(c.Request.Context(), id, userID, isAdmin); err != nil {
 		response.BadRequest(c, err.Error())
 		return
 	}
@@ -146,7 +144,7 @@ func (h *ModelHandler) Download(c *gin.Context) {
 		return
 	}
 
-	model, err := h.modelService.GetByID(id)
+	model, err := h.modelService.GetByID(c.Request.Context(), id)
 	if err != nil {
 		response.NotFound(c, "model not found")
 		return
@@ -157,7 +155,7 @@ func (h *ModelHandler) Download(c *gin.Context) {
 		return
 	}
 
-	h.modelService.IncrementDownloads(id)
+	h.modelService.IncrementDownloads(c.Request.Context(), id)
 
 	response.Success(c, gin.H{
 		"download_url": "/api/models/" + id.String() + "/file",
@@ -174,7 +172,7 @@ func (h *ModelHandler) AddFavorite(c *gin.Context) {
 
 	userID := middleware.GetUserID(c)
 
-	if err := h.favoriteService.Add(userID, id); err != nil {
+	if err := h.favoriteService.Add(c.Request.Context(), userID, id); err != nil {
 		response.InternalError(c, "failed to add favorite")
 		return
 	}
@@ -191,7 +189,7 @@ func (h *ModelHandler) RemoveFavorite(c *gin.Context) {
 
 	userID := middleware.GetUserID(c)
 
-	if err := h.favoriteService.Remove(userID, id); err != nil {
+	if err := h.favoriteService.Remove(c.Request.Context(), userID, id); err != nil {
 		response.InternalError(c, "failed to remove favorite")
 		return
 	}
@@ -209,7 +207,7 @@ func (h *ModelHandler) CheckFavorite(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 
 	response.Success(c, gin.H{
-		"is_favorited": h.favoriteService.IsFavorited(userID, id),
+		"is_favorited": h.favoriteService.IsFavorited(c.Request.Context(), userID, id),
 	})
 }
 
@@ -220,7 +218,7 @@ func (h *ModelHandler) ServeModelFile(c *gin.Context) {
 		return
 	}
 
-	model, err := h.modelService.GetByID(id)
+	model, err := h.modelService.GetByID(c.Request.Context(), id)
 	if err != nil {
 		response.NotFound(c, "model not found")
 		return
@@ -238,8 +236,6 @@ func (h *ModelHandler) ServeModelFile(c *gin.Context) {
 
 	fullPath := filepath.Join(config.AppConfig.UploadPath, filePath)
 
-	log.Printf("ServeModelFile: modelID=%s, modelFilePath=%s, fullPath=%s", id, model.FilePath, fullPath)
-
 	if _, err := os.Stat(fullPath); os.IsNotExist(err) {
 		response.NotFound(c, "file not found")
 		return
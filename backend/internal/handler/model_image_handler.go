@@ -4,6 +4,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/ysmmc/backend/internal/middleware"
+	"github.com/ysmmc/backend/internal/model"
 	"github.com/ysmmc/backend/internal/service"
 	"github.com/ysmmc/backend/pkg/response"
 )
@@ -26,8 +27,7 @@ func (h *ModelImageHandler) AddImage(c *gin.Context) {
 	}
 
 	userID := middleware.GetUserID(c)
-	role := middleware.GetRole(c)
-	isAdmin := role == "admin" || role == "super_admin"
+	isAdmin := middleware.HasPermission(c, model.PermModelsManage)
 
 	var req service.AddModelImageRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -35,7 +35,7 @@ func (h *ModelImageHandler) AddImage(c *gin.Context) {
 		return
 	}
 
-	image, err := h.imageService.AddImage(modelID, userID, &req, isAdmin)
+	image, err := h.imageService.AddImage(c.Request.Context(), modelID, userID, &req, isAdmin)
 	if err != nil {
 		response.BadRequest(c, err.Error())
 		return
@@ -51,7 +51,7 @@ func (h *ModelImageHandler) ListImages(c *gin.Context) {
 		return
 	}
 
-	images, err := h.imageService.ListImages(modelID)
+	images, err := h.imageService.ListImages(c.Request.Context(), modelID)
 	if err != nil {
 		response.InternalError(c, "failed to fetch images")
 		return
@@ -74,10 +74,9 @@ func (h *ModelImageHandler) DeleteImage(c *gin.Context) {
 	}
 
 	userID := middleware.GetUserID(c)
-	role := middleware.GetRole(c)
-	isAdmin := role == "admin" || role == "super_admin"
+	isAdmin := middleware.HasPermission(c, model.PermModelsManage)
 
-	if err := h.imageService.DeleteImage(modelID, fileID, userID, isAdmin); err != nil {
+	if err := h.imageService.DeleteImage(c.Request.Context(), modelID, fileID, userID, isAdmin); err != nil {
 		response.BadRequest(c, err.Error())
 		return
 	}
@@ -93,8 +92,7 @@ func (h *ModelImageHandler) UpdateOrder(c *gin.Context) {
 	}
 
 	userID := middleware.GetUserID(c)
-	role := middleware.GetRole(c)
-	isAdmin := role == "admin" || role == "super_admin"
+	isAdmin := middleware.HasPermission(c, model.PermModelsManage)
 
 	var req service.UpdateImageOrderRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -102,7 +100,7 @@ func (h *ModelImageHandler) UpdateOrder(c *gin.Context) {
 		return
 	}
 
-	if err := h.imageService.UpdateOrder(modelID, userID, &req, isAdmin); err != nil {
+	if err := h.imageService.UpdateOrder(c.Request.Context(), modelID, userID, &req, isAdmin); err != nil {
 		response.BadRequest(c, err.Error())
 		return
 	}
@@ -8,6 +8,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/ysmmc/backend/internal/config"
 	"github.com/ysmmc/backend/internal/middleware"
+	"github.com/ysmmc/backend/internal/model"
 	"github.com/ysmmc/backend/internal/service"
 	"github.com/ysmmc/backend/pkg/response"
 )
@@ -39,7 +40,7 @@ func (h *ModelVersionHandler) CreateVersion(c *gin.Context) {
 		return
 	}
 
-	version, err := h.versionService.CreateVersion(modelID, userID, &req)
+	version, err := h.versionService.CreateVersion(c.Request.Context(), modelID, userID, &req)
 	if err != nil {
 		response.BadRequest(c, err.Error())
 		return
@@ -55,7 +56,7 @@ func (h *ModelVersionHandler) ListVersions(c *gin.Context) {
 		return
 	}
 
-	versions, err := h.versionService.ListVersions(modelID)
+	versions, err := h.versionService.ListVersions(c.Request.Context(), modelID)
 	if err != nil {
 		response.InternalError(c, "failed to fetch versions")
 		return
@@ -71,7 +72,7 @@ func (h *ModelVersionHandler) GetVersion(c *gin.Context) {
 		return
 	}
 
-	version, err := h.versionService.GetVersion(versionID)
+	version, err := h.versionService.GetVersion(c.Request.Context(), versionID)
 	if err != nil {
 		response.NotFound(c, "version not found")
 		return
@@ -94,10 +95,9 @@ func (h *ModelVersionHandler) SetCurrentVersion(c *gin.Context) {
 	}
 
 	userID := middleware.GetUserID(c)
-	role := middleware.GetRole(c)
-	isAdmin := role == "admin" || role == "super_admin"
+	isAdmin := middleware.HasPermission(c, model.PermModelsManage)
 
-	if err := h.versionService.SetCurrentVersion(modelID, versionID, userID, isAdmin); err != nil {
+	if err := h.versionService.SetCurrentVersion(c.Request.Context(), modelID, versionID, userID, isAdmin); err != nil {
 		response.BadRequest(c, err.Error())
 		return
 	}
@@ -113,8 +113,7 @@ func (h *ModelVersionHandler) UpdateVersion(c *gin.Context) {
 	}
 
 	userID := middleware.GetUserID(c)
-	role := middleware.GetRole(c)
-	isAdmin := role == "admin" || role == "super_admin"
+	isAdmin := middleware.HasPermission(c, model.PermModelsManage)
 
 	var req service.UpdateVersionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -122,7 +121,7 @@ func (h *ModelVersionHandler) UpdateVersion(c *gin.Context) {
 		return
 	}
 
-	version, err := h.versionService.UpdateVersion(versionID, userID, &req, isAdmin)
+	version, err := h.versionService.UpdateVersion(c.Request.Context(), versionID, userID, &req, isAdmin)
 	if err != nil {
 		response.BadRequest(c, err.Error())
 		return
@@ -145,10 +144,9 @@ func (h *ModelVersionHandler) DeleteVersion(c *gin.Context) {
 	}
 
 	userID := middleware.GetUserID(c)
-	role := middleware.GetRole(c)
-	isAdmin := role == "admin" || role == "super_admin"
+	isAdmin := middleware.HasPermission(c, model.PermModelsManage)
 
-	if err := h.versionService.DeleteVersion(modelID, versionID, userID, isAdmin); err != nil {
+	if err := h.versionService.DeleteVersion(c.Request.Context(), modelID, versionID, userID, isAdmin); err != nil {
 		response.BadRequest(c, err.Error())
 		return
 	}
@@ -163,7 +161,7 @@ func (h *ModelVersionHandler) DownloadVersion(c *gin.Context) {
 		return
 	}
 
-	version, err := h.versionService.GetVersion(versionID)
+	version, err := h.versionService.GetVersion(c.Request.Context(), versionID)
 	if err != nil {
 		response.NotFound(c, "version not found")
 		return
@@ -179,7 +177,7 @@ func (h *ModelVersionHandler) DownloadVersion(c *gin.Context) {
 		return
 	}
 
-	h.versionService.IncrementDownloads(versionID)
+	h.versionService.IncrementDownloads(c.Request.Context(), versionID)
 
 	response.Success(c, gin.H{
 		"download_url": "/api/models/" + version.ModelID.String() + "/versions/" + versionID.String() + "/file",
@@ -194,7 +192,7 @@ func (h *ModelVersionHandler) ServeVersionFile(c *gin.Context) {
 		return
 	}
 
-	version, err := h.versionService.GetVersion(versionID)
+	version, err := h.versionService.GetVersion(c.Request.Context(), versionID)
 	if err != nil {
 		response.NotFound(c, "version not found")
 		return
@@ -26,7 +26,7 @@ func (h *FavoriteHandler) List(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "12"))
 
-	favorites, total, err := h.favoriteService.ListByUserID(userID, page, pageSize)
+	favorites, total, err := h.favoriteService.ListByUserID(c.Request.Context(), userID, page, pageSize)
 	if err != nil {
 		response.InternalError(c, "failed to fetch favorites")
 		return
@@ -46,7 +46,7 @@ func NewAnnouncementHandler() *AnnouncementHandler {
 }
 
 func (h *AnnouncementHandler) List(c *gin.Context) {
-	announcements, err := h.announcementService.ListActive()
+	announcements, err := h.announcementService.ListActive(c.Request.Context())
 	if err != nil {
 		response.InternalError(c, "failed to fetch announcements")
 		return
@@ -59,7 +59,7 @@ func (h *AnnouncementHandler) ListAll(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
 
-	announcements, total, err := h.announcementService.List(page, pageSize)
+	announcements, total, err := h.announcementService.List(c.Request.Context(), page, pageSize)
 	if err != nil {
 		response.InternalError(c, "failed to fetch announcements")
 		return
@@ -75,7 +75,7 @@ func (h *AnnouncementHandler) GetByID(c *gin.Context) {
 		return
 	}
 
-	announcement, err := h.announcementService.GetByID(id)
+	announcement, err := h.announcementService.GetByID(c.Request.Context(), id)
 	if err != nil {
 		response.NotFound(c, "announcement not found")
 		return
@@ -83,3 +83,113 @@ func (h *AnnouncementHandler) GetByID(c *gin.Context) {
 
 	response.Success(c, announcement)
 }
+
+type NotificationHandler struct {
+	notificationService *service.NotificationService
+}
+
+func NewNotificationHandler() *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: service.NewNotificationService(),
+	}
+}
+
+func (h *NotificationHandler) List(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	unreadOnly := c.Query("unread") == "true"
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	notifications, total, err := h.notificationService.List(c.Request.Context(), middleware.GetUserID(c), page, pageSize, unreadOnly)
+	if err != nil {
+		response.InternalError(c, "failed to fetch notifications")
+		return
+	}
+
+	response.Paginated(c, notifications, total, page, pageSize)
+}
+
+func (h *NotificationHandler) UnreadCount(c *gin.Context) {
+	count, err := h.notificationService.CountUnread(c.Request.Context(), middleware.GetUserID(c))
+	if err != nil {
+		response.InternalError(c, "failed to count notifications")
+		return
+	}
+
+	response.Success(c, gin.H{"count": count})
+}
+
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid notification id")
+		return
+	}
+
+	if err := h.notificationService.MarkRead(c.Request.Context(), id, middleware.GetUserID(c)); err != nil {
+		response.NotFound(c, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "notification marked as read", nil)
+}
+
+func (h *NotificationHandler) MarkAllRead(c *gin.Context) {
+	if err := h.notificationService.MarkAllRead(c.Request.Context(), middleware.GetUserID(c)); err != nil {
+		response.InternalError(c, "failed to update notifications")
+		return
+	}
+
+	response.SuccessWithMessage(c, "all notifications marked as read", nil)
+}
+
+func (h *NotificationHandler) GetSettings(c *gin.Context) {
+	settings, err := h.notificationService.GetSettings(c.Request.Context(), middleware.GetUserID(c))
+	if err != nil {
+		response.InternalError(c, "failed to fetch notification settings")
+		return
+	}
+
+	response.Success(c, settings)
+}
+
+func (h *NotificationHandler) UpdateSettings(c *gin.Context) {
+	var req service.UpdateNotificationSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	settings, err := h.notificationService.UpdateSettings(c.Request.Context(), middleware.GetUserID(c), &req)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	response.Success(c, settings)
+}
+
+// Unsubscribe 邮件退订链接，凭签名 token 操作，无需登录
+func (h *NotificationHandler) Unsubscribe(c *gin.Context) {
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	topic, err := h.notificationService.Unsubscribe(c.Request.Context(), req.Token)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "unsubscribed", gin.H{"topic": topic})
+}
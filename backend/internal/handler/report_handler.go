@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ysmmc/backend/internal/middleware"
+	"github.com/ysmmc/backend/internal/service"
+	"github.com/ysmmc/backend/pkg/response"
+)
+
+type ReportHandler struct {
+	reportService *service.ReportService
+}
+
+func NewReportHandler() *ReportHandler {
+	return &ReportHandler{
+		reportService: service.NewReportService(),
+	}
+}
+
+func (h *ReportHandler) Create(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	var req service.CreateReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	report, err := h.reportService.Create(c.Request.Context(), userID, &req)
+	if err != nil {
+		if errors.Is(err, service.ErrAlreadyReported) {
+			response.Error(c, 409, 409, err.Error())
+			return
+		}
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "report submitted", gin.H{
+		"id":     report.ID,
+		"status": report.Status,
+	})
+}
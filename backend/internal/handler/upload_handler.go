@@ -3,8 +3,9 @@ package handler
 import (
 	"bytes"
 	"io"
-	"log"
+	"log/slog"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -26,6 +27,7 @@ func NewUploadHandler() *UploadHandler {
 }
 
 func (h *UploadHandler) UploadModel(c *gin.Context) {
+	start := time.Now()
 	file, header, err := c.Request.FormFile("file")
 	if err != nil {
 		response.BadRequest(c, "no file uploaded")
@@ -74,6 +76,7 @@ func (h *UploadHandler) UploadModel(c *gin.Context) {
 		response.InternalError(c, "failed to save file")
 		return
 	}
+	observeUpload("model", header.Size, start)
 
 	response.Success(c, gin.H{
 		"file_path":  "/uploads/" + savedPath,
@@ -84,9 +87,9 @@ func (h *UploadHandler) UploadModel(c *gin.Context) {
 }
 
 func (h *UploadHandler) UploadImage(c *gin.Context) {
+	start := time.Now()
 	file, header, err := c.Request.FormFile("file")
 	if err != nil {
-		log.Printf("UploadImage: no file uploaded: %v", err)
 		response.BadRequest(c, "no file uploaded")
 		return
 	}
@@ -94,7 +97,6 @@ func (h *UploadHandler) UploadImage(c *gin.Context) {
 
 	maxImageSize := int64(10 * 1024 * 1024)
 	if header.Size > maxImageSize {
-		log.Printf("UploadImage: image too large: %d", header.Size)
 		response.BadRequest(c, "image too large, maximum size is 10MB")
 		return
 	}
@@ -103,27 +105,23 @@ func (h *UploadHandler) UploadImage(c *gin.Context) {
 	tee := io.TeeReader(file, buf)
 
 	headerBuf := make([]byte, 16)
-	n, readErr := tee.Read(headerBuf)
-	log.Printf("UploadImage: read %d bytes for magic check, err=%v, bytes=%v", n, readErr, headerBuf[:n])
+	n, _ := tee.Read(headerBuf)
 
 	if n < 8 {
-		log.Printf("UploadImage: file too small, only %d bytes", n)
 		response.BadRequest(c, "file too small")
 		return
 	}
 
 	if err := validateImageMagicNumber(headerBuf[:n], ""); err != nil {
-		log.Printf("UploadImage: invalid image content: %v", err)
 		response.BadRequest(c, "invalid image content: file is not a valid image")
 		return
 	}
 
 	mimeType := detectMimeType(headerBuf[:n])
-	log.Printf("UploadImage: filename=%s, detected mimeType=%s", header.Filename, mimeType)
 
 	restData, err := io.ReadAll(file)
 	if err != nil {
-		log.Printf("UploadImage: failed to read rest of file: %v", err)
+		slog.ErrorContext(c.Request.Context(), "Failed to read uploaded image", "error", err)
 		response.InternalError(c, "failed to read file")
 		return
 	}
@@ -139,14 +137,14 @@ func (h *UploadHandler) UploadImage(c *gin.Context) {
 
 	category := c.DefaultPostForm("category", "model_image")
 
-	savedFile, err := h.fileService.SaveFile(header.Filename, mimeType, data, category, userID)
+	savedFile, err := h.fileService.SaveFile(c.Request.Context(), header.Filename, mimeType, data, category, userID)
 	if err != nil {
-		log.Printf("UploadImage: failed to save file: %v", err)
+		slog.ErrorContext(c.Request.Context(), "Failed to save uploaded image", "error", err)
 		response.InternalError(c, "failed to save file")
 		return
 	}
+	observeUpload("image", savedFile.Size, start)
 
-	log.Printf("UploadImage: file saved successfully, id=%s", savedFile.ID)
 	response.Success(c, gin.H{
 		"id":        savedFile.ID,
 		"file_id":   savedFile.ID,
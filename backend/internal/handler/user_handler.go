@@ -1,27 +1,47 @@
 package handler
 
 import (
+	"errors"
+	"strconv"
+
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/ysmmc/backend/internal/middleware"
+	"github.com/ysmmc/backend/internal/model"
 	"github.com/ysmmc/backend/internal/service"
 	"github.com/ysmmc/backend/pkg/response"
 )
 
 type UserHandler struct {
-	userService *service.UserService
+	userService         *service.UserService
+	authService         *service.AuthService
+	sanctionService     *service.SanctionService
+	mfaService          *service.MFAService
+	apiTokenService     *service.APITokenService
+	sessionService      *service.SessionService
+	oidcService         *service.OIDCService
+	registrationService *service.RegistrationService
+	accountService      *service.AccountService
 }
 
 func NewUserHandler() *UserHandler {
 	return &UserHandler{
-		userService: service.NewUserService(),
+		userService:         service.NewUserService(),
+		authService:         service.NewAuthService(),
+		sanctionService:     service.NewSanctionService(),
+		mfaService:          service.NewMFAService(),
+		apiTokenService:     service.NewAPITokenService(),
+		sessionService:      service.NewSessionService(),
+		oidcService:         service.NewOIDCService(),
+		registrationService: service.NewRegistrationService(),
+		accountService:      service.NewAccountService(),
 	}
 }
 
 func (h *UserHandler) GetMe(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 
-	user, err := h.userService.GetByID(userID)
+	user, err := h.userService.GetByID(c.Request.Context(), userID)
 	if err != nil {
 		response.NotFound(c, "user not found")
 		return
@@ -32,7 +52,6 @@ func (h *UserHandler) GetMe(c *gin.Context) {
 
 func (h *UserHandler) UpdateMe(c *gin.Context) {
 	userID := middleware.GetUserID(c)
-	role := middleware.GetRole(c)
 
 	var req service.UpdateProfileRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -40,8 +59,24 @@ func (h *UserHandler) UpdateMe(c *gin.Context) {
 		return
 	}
 
-	isAdmin := role == "admin" || role == "super_admin"
-	user, err := h.userService.UpdateProfile(userID, &req, isAdmin)
+	isAdmin := middleware.HasPermission(c, model.PermProfilesReview)
+	user, err := h.userService.UpdateProfile(c.Request.Context(), userID, &req, isAdmin)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	response.Success(c, user)
+}
+
+func (h *UserHandler) UpdateLanguage(c *gin.Context) {
+	var req service.UpdateLanguageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	user, err := h.userService.UpdateLanguage(c.Request.Context(), middleware.GetUserID(c), req.Language)
 	if err != nil {
 		response.BadRequest(c, err.Error())
 		return
@@ -59,12 +94,264 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 		return
 	}
 
-	if err := h.userService.ChangePassword(userID, &req); err != nil {
+	if err := h.userService.ChangePassword(c.Request.Context(), userID, &req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	user, err := h.userService.GetByID(c.Request.Context(), userID)
+	if err != nil {
+		response.NotFound(c, "user not found")
+		return
+	}
+
+	tokens, err := h.authService.IssueTokens(c.Request.Context(), user, clientInfo(c))
+	if err != nil {
+		response.InternalError(c, "failed to issue new tokens")
+		return
+	}
+
+	response.SuccessWithMessage(c, "password changed successfully", tokens)
+}
+
+func (h *UserHandler) GetTwoFactor(c *gin.Context) {
+	status, err := h.mfaService.Status(c.Request.Context(), middleware.GetUserID(c))
+	if err != nil {
+		response.NotFound(c, err.Error())
+		return
+	}
+
+	response.Success(c, status)
+}
+
+func (h *UserHandler) SetupTwoFactor(c *gin.Context) {
+	var req service.MFASetupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	setup, err := h.mfaService.Setup(c.Request.Context(), middleware.GetUserID(c), &req)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	response.Success(c, setup)
+}
+
+func (h *UserHandler) EnableTwoFactor(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	var req service.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	codes, err := h.mfaService.Enable(c.Request.Context(), userID, &req)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	user, err := h.userService.GetByID(c.Request.Context(), userID)
+	if err != nil {
+		response.NotFound(c, "user not found")
+		return
+	}
+
+	tokens, err := h.authService.IssueTokens(c.Request.Context(), user, clientInfo(c))
+	if err != nil {
+		response.InternalError(c, "failed to issue new tokens")
+		return
+	}
+
+	response.SuccessWithMessage(c, "two-factor authentication enabled", gin.H{
+		"recovery_codes": codes,
+		"tokens":         tokens,
+	})
+}
+
+func (h *UserHandler) DisableTwoFactor(c *gin.Context) {
+	var req service.MFADisableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	if err := h.mfaService.Disable(c.Request.Context(), middleware.GetUserID(c), &req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "two-factor authentication disabled", nil)
+}
+
+func (h *UserHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req service.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	codes, err := h.mfaService.RegenerateRecoveryCodes(c.Request.Context(), middleware.GetUserID(c), &req)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	response.Success(c, gin.H{"recovery_codes": codes})
+}
+
+func (h *UserHandler) ListSessions(c *gin.Context) {
+	sessions, err := h.sessionService.List(c.Request.Context(), middleware.GetUserID(c), middleware.GetSessionID(c))
+	if err != nil {
+		response.InternalError(c, "failed to fetch sessions")
+		return
+	}
+
+	response.Success(c, sessions)
+}
+
+func (h *UserHandler) RevokeSession(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid session id")
+		return
+	}
+
+	if err := h.sessionService.Revoke(c.Request.Context(), middleware.GetUserID(c), id); err != nil {
+		response.NotFound(c, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "session revoked", nil)
+}
+
+func (h *UserHandler) RevokeOtherSessions(c *gin.Context) {
+	count, err := h.sessionService.RevokeOthers(c.Request.Context(), middleware.GetUserID(c), middleware.GetSessionID(c))
+	if err != nil {
+		response.InternalError(c, "failed to revoke sessions")
+		return
+	}
+
+	response.SuccessWithMessage(c, "other sessions revoked", gin.H{"revoked": count})
+}
+
+func (h *UserHandler) ListAPITokens(c *gin.Context) {
+	tokens, err := h.apiTokenService.List(c.Request.Context(), middleware.GetUserID(c))
+	if err != nil {
+		response.InternalError(c, "failed to fetch api tokens")
+		return
+	}
+
+	response.Success(c, gin.H{
+		"tokens":           tokens,
+		"available_scopes": model.TokenScopes,
+	})
+}
+
+func (h *UserHandler) CreateAPIToken(c *gin.Context) {
+	var req service.CreateAPITokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.apiTokenService.Create(c.Request.Context(), middleware.GetUserID(c), &req)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "api token created, copy it now as it will not be shown again", result)
+}
+
+func (h *UserHandler) RevokeAPIToken(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid token id")
+		return
+	}
+
+	if err := h.apiTokenService.Revoke(c.Request.Context(), id, middleware.GetUserID(c)); err != nil {
+		response.NotFound(c, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "api token revoked", nil)
+}
+
+func (h *UserHandler) ListIdentities(c *gin.Context) {
+	identities, err := h.oidcService.ListIdentities(c.Request.Context(), middleware.GetUserID(c))
+	if err != nil {
+		response.InternalError(c, "failed to fetch identities")
+		return
+	}
+
+	response.Success(c, gin.H{
+		"identities": identities,
+		"providers":  h.oidcService.Providers(),
+	})
+}
+
+// LinkIdentity 发起关联第三方身份，返回授权地址
+func (h *UserHandler) LinkIdentity(c *gin.Context) {
+	result, err := h.oidcService.BeginLink(c.Request.Context(), middleware.GetUserID(c), c.Param("provider"))
+	if err != nil {
+		if errors.Is(err, service.ErrOIDCProviderNotFound) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		response.Error(c, 502, 502, err.Error())
+		return
+	}
+
+	response.Success(c, result)
+}
+
+func (h *UserHandler) CompleteLinkIdentity(c *gin.Context) {
+	var req service.OIDCCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	identity, err := h.oidcService.CompleteLink(c.Request.Context(), middleware.GetUserID(c), &req, clientInfo(c))
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "identity linked", identity)
+}
+
+func (h *UserHandler) UnlinkIdentity(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid identity id")
+		return
+	}
+
+	if err := h.oidcService.Unlink(c.Request.Context(), middleware.GetUserID(c), id, clientInfo(c)); err != nil {
 		response.BadRequest(c, err.Error())
 		return
 	}
 
-	response.SuccessWithMessage(c, "password changed successfully", nil)
+	response.SuccessWithMessage(c, "identity unlinked", nil)
+}
+
+func (h *UserHandler) GetMySanctions(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	sanctions, err := h.sanctionService.ListActive(c.Request.Context(), userID)
+	if err != nil {
+		response.InternalError(c, "failed to fetch sanctions")
+		return
+	}
+
+	response.Success(c, sanctions)
 }
 
 func (h *UserHandler) GetByID(c *gin.Context) {
@@ -74,7 +361,7 @@ func (h *UserHandler) GetByID(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userService.GetPublicProfile(id)
+	user, err := h.userService.GetPublicProfile(c.Request.Context(), id)
 	if err != nil {
 		response.NotFound(c, "user not found")
 		return
@@ -83,6 +370,26 @@ func (h *UserHandler) GetByID(c *gin.Context) {
 	response.Success(c, user)
 }
 
+// GetMyModels 列出当前用户发布的全部模型，包括待审核与被拒绝的模型
+func (h *UserHandler) GetMyModels(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	models, total, err := service.NewModelService().ListByUserID(c.Request.Context(), middleware.GetUserID(c), page, pageSize)
+	if err != nil {
+		response.InternalError(c, "failed to fetch models")
+		return
+	}
+
+	response.Paginated(c, models, total, page, pageSize)
+}
+
 func (h *UserHandler) GetUserModels(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
@@ -94,7 +401,7 @@ func (h *UserHandler) GetUserModels(c *gin.Context) {
 	pageSize := 10
 
 	modelService := service.NewModelService()
-	models, total, err := modelService.ListByUserID(id, page, pageSize)
+	models, total, err := modelService.ListByUserID(c.Request.Context(), id, page, pageSize)
 	if err != nil {
 		response.InternalError(c, "failed to fetch models")
 		return
@@ -107,7 +414,7 @@ func (h *UserHandler) List(c *gin.Context) {
 	page := 1
 	pageSize := 20
 
-	users, total, err := h.userService.List(page, pageSize)
+	users, total, err := h.userService.List(c.Request.Context(), page, pageSize)
 	if err != nil {
 		response.InternalError(c, "failed to fetch users")
 		return
@@ -132,7 +439,7 @@ func (h *UserHandler) UpdateRole(c *gin.Context) {
 		return
 	}
 
-	if err := h.userService.UpdateRole(id, req.Role); err != nil {
+	if err := h.userService.UpdateRole(c.Request.Context(), id, req.Role); err != nil {
 		response.BadRequest(c, err.Error())
 		return
 	}
@@ -147,10 +454,144 @@ func (h *UserHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	if err := h.userService.Delete(id); err != nil {
+	if err := h.userService.Delete(c.Request.Context(), id); err != nil {
 		response.InternalError(c, "failed to delete user")
 		return
 	}
 
 	response.SuccessWithMessage(c, "user deleted successfully", nil)
 }
+
+func (h *UserHandler) ListInvites(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	invites, err := h.registrationService.ListInvites(c.Request.Context(), userID)
+	if err != nil {
+		response.InternalError(c, "failed to fetch invite codes")
+		return
+	}
+
+	result := gin.H{"invites": invites, "can_create": true}
+	if !middleware.HasPermission(c, model.PermRegistrationManage) {
+		user, err := h.userService.GetByID(c.Request.Context(), userID)
+		if err != nil {
+			response.NotFound(c, "user not found")
+			return
+		}
+		if err := h.registrationService.CanInvite(c.Request.Context(), user); err != nil {
+			result["can_create"] = false
+			result["reason"] = err.Error()
+		}
+	}
+
+	response.Success(c, result)
+}
+
+func (h *UserHandler) CreateInvite(c *gin.Context) {
+	var req service.CreateInviteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	user, err := h.userService.GetByID(c.Request.Context(), middleware.GetUserID(c))
+	if err != nil {
+		response.NotFound(c, "user not found")
+		return
+	}
+
+	invite, err := h.registrationService.CreateInvite(c.Request.Context(), user, middleware.HasPermission(c, model.PermRegistrationManage), &req)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "invite code created", invite)
+}
+
+func (h *UserHandler) RevokeInvite(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid invite id")
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	if err := h.registrationService.RevokeInvite(c.Request.Context(), id, &userID); err != nil {
+		response.NotFound(c, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "invite code revoked", nil)
+}
+
+// ListInvitees 通过当前用户的邀请码注册的账号
+func (h *UserHandler) ListInvitees(c *gin.Context) {
+	users, err := h.registrationService.ListInvitees(c.Request.Context(), middleware.GetUserID(c))
+	if err != nil {
+		response.InternalError(c, "failed to fetch invited users")
+		return
+	}
+
+	response.Success(c, users)
+}
+
+// RequestExport 申请导出个人数据，压缩包在后台生成
+func (h *UserHandler) RequestExport(c *gin.Context) {
+	export, err := h.accountService.RequestExport(c.Request.Context(), middleware.GetUserID(c))
+	if err != nil {
+		if errors.Is(err, service.ErrExportInProgress) || errors.Is(err, service.ErrExportTooFrequent) {
+			response.TooManyRequests(c, err.Error())
+			return
+		}
+		response.InternalError(c, "failed to start data export")
+		return
+	}
+
+	response.SuccessWithMessage(c, "data export started", export)
+}
+
+func (h *UserHandler) GetExport(c *gin.Context) {
+	export, err := h.accountService.GetExport(c.Request.Context(), middleware.GetUserID(c))
+	if err != nil {
+		response.InternalError(c, "failed to fetch data export")
+		return
+	}
+
+	response.Success(c, export)
+}
+
+func (h *UserHandler) DownloadExport(c *gin.Context) {
+	path, err := h.accountService.ExportFile(c.Request.Context(), middleware.GetUserID(c))
+	if err != nil {
+		response.NotFound(c, err.Error())
+		return
+	}
+
+	c.FileAttachment(path, "ysmmc-data-export.zip")
+}
+
+// ScheduleDeletion 申请注销账号，需重新输入密码（启用两步验证时还需验证码）
+func (h *UserHandler) ScheduleDeletion(c *gin.Context) {
+	var req service.DeletionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	at, err := h.accountService.ScheduleDeletion(c.Request.Context(), middleware.GetUserID(c), &req, clientInfo(c))
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "account deletion scheduled", gin.H{"deletion_scheduled_at": at})
+}
+
+func (h *UserHandler) CancelDeletion(c *gin.Context) {
+	if err := h.accountService.CancelDeletion(c.Request.Context(), middleware.GetUserID(c), clientInfo(c)); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "account deletion cancelled", nil)
+}
@@ -5,12 +5,16 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
-	"github.com/ysmmc/backend/internal/model"
+	"github.com/ysmmc/backend/internal/service"
 	"github.com/ysmmc/backend/pkg/auth"
 	"github.com/ysmmc/backend/pkg/response"
 )
 
-func Auth() gin.HandlerFunc {
+// Auth 校验 JWT 或个人访问令牌。
+// 个人访问令牌只能访问声明了 scopes 的路由，且须具备其中任一 scope；未声明 scopes 的路由仅接受 JWT
+func Auth(scopes ...string) gin.HandlerFunc {
+	apiTokenService := service.NewAPITokenService()
+
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -26,6 +30,11 @@ func Auth() gin.HandlerFunc {
 			return
 		}
 
+		if auth.IsAPIToken(parts[1]) {
+			authenticateAPIToken(c, apiTokenService, parts[1], scopes)
+			return
+		}
+
 		claims, err := auth.ParseToken(parts[1])
 		if err != nil {
 			response.Unauthorized(c, "invalid or expired token")
@@ -33,30 +42,68 @@ func Auth() gin.HandlerFunc {
 			return
 		}
 
+		if !service.IsTokenVersionCurrent(c.Request.Context(), claims.UserID, claims.TokenVersion) || !service.IsSessionActive(c.Request.Context(), claims.SessionID) {
+			response.Unauthorized(c, "token has been revoked")
+			c.Abort()
+			return
+		}
+
 		c.Set("user_id", claims.UserID)
 		c.Set("email", claims.Email)
 		c.Set("role", claims.Role)
+		c.Set("mfa", claims.MFA)
+		c.Set("session_id", claims.SessionID)
 		c.Next()
 	}
 }
 
-func AdminOnly() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		role, exists := c.Get("role")
-		if !exists || !model.IsAdmin(role.(string)) {
-			response.Forbidden(c, "admin access required")
-			c.Abort()
-			return
+func authenticateAPIToken(c *gin.Context, apiTokenService *service.APITokenService, raw string, scopes []string) {
+	if len(scopes) == 0 {
+		response.Forbidden(c, "personal access tokens cannot access this endpoint")
+		c.Abort()
+		return
+	}
+
+	token, err := apiTokenService.Authenticate(c.Request.Context(), raw, c.ClientIP())
+	if err != nil {
+		response.Unauthorized(c, err.Error())
+		c.Abort()
+		return
+	}
+
+	allowed := false
+	for _, scope := range scopes {
+		if token.HasScope(scope) {
+			allowed = true
+			break
 		}
-		c.Next()
 	}
+	if !allowed {
+		response.Forbidden(c, "token scope required: "+strings.Join(scopes, " or "))
+		c.Abort()
+		return
+	}
+
+	c.Set("user_id", token.User.ID)
+	c.Set("email", token.User.Email)
+	c.Set("role", token.User.Role)
+	c.Set("mfa", false)
+	c.Set("api_token_id", token.ID)
+	c.Next()
 }
 
-func SuperAdminOnly() gin.HandlerFunc {
+// RequirePermission 要求当前用户的角色拥有指定权限；
+// 若角色要求两步验证，还要求本次登录经过了二次验证
+func RequirePermission(perm string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		role, exists := c.Get("role")
-		if !exists || !model.IsSuperAdmin(role.(string)) {
-			response.Forbidden(c, "super admin access required")
+		if !exists || !service.HasPermission(c.Request.Context(), role.(string), perm) {
+			response.Forbidden(c, "permission required: "+perm)
+			c.Abort()
+			return
+		}
+		if !mfaSatisfied(c, role.(string)) {
+			response.Forbidden(c, "two-factor authentication is required for your role")
 			c.Abort()
 			return
 		}
@@ -64,11 +111,39 @@ func SuperAdminOnly() gin.HandlerFunc {
 	}
 }
 
+// HasPermission 判断当前请求是否具备角色权限；个人访问令牌只携带 scope，不继承角色权限
+func HasPermission(c *gin.Context, perm string) bool {
+	if _, viaToken := c.Get("api_token_id"); viaToken {
+		return false
+	}
+	role, exists := c.Get("role")
+	if !exists {
+		return false
+	}
+	return service.HasPermission(c.Request.Context(), role.(string), perm) && mfaSatisfied(c, role.(string))
+}
+
+func mfaSatisfied(c *gin.Context, role string) bool {
+	if !service.RoleRequiresMFA(c.Request.Context(), role) {
+		return true
+	}
+	mfa, _ := c.Get("mfa")
+	verified, _ := mfa.(bool)
+	return verified
+}
+
 func GetUserID(c *gin.Context) uuid.UUID {
 	userID, _ := c.Get("user_id")
 	return userID.(uuid.UUID)
 }
 
+// GetSessionID 返回当前 access token 所属的会话 ID，个人访问令牌与旧 token 返回 uuid.Nil
+func GetSessionID(c *gin.Context) uuid.UUID {
+	sessionID, _ := c.Get("session_id")
+	id, _ := sessionID.(uuid.UUID)
+	return id
+}
+
 func GetRole(c *gin.Context) string {
 	role, _ := c.Get("role")
 	return role.(string)
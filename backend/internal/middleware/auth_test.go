@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/ysmmc/backend/internal/database"
+	"github.com/ysmmc/backend/internal/model"
+	"github.com/ysmmc/backend/internal/service"
+)
+
+func scopedRouter() *gin.Engine {
+	r := gin.New()
+	ok := func(c *gin.Context) { c.String(http.StatusOK, "ok") }
+	r.GET("/jwt-only", Auth(), ok)
+	r.GET("/models", Auth(model.ScopeModelsRead), ok)
+	r.GET("/uploads", Auth(model.ScopeModelsWrite, model.ScopeVersionsWrite), ok)
+	return r
+}
+
+func requestWithToken(r *gin.Engine, path, token string) int {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", path, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestAuth_APITokenRejectedOnUnscopedRoute(t *testing.T) {
+	r := scopedRouter()
+
+	if code := requestWithToken(r, "/jwt-only", "ysm_pat_anything"); code != http.StatusForbidden {
+		t.Errorf("expected 403 for a personal access token on a JWT-only route, got %d", code)
+	}
+}
+
+func TestAuth_APITokenScopes(t *testing.T) {
+	setupTestDB(t)
+	user := &model.User{Email: "pat-" + uuid.NewString()[:8] + "@example.com", Username: "pat" + uuid.NewString()[:8], PasswordHash: "x", Role: model.RoleUser}
+	if err := database.DB.Create(user).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	created, err := service.NewAPITokenService().Create(context.Background(), user.ID, &service.CreateAPITokenRequest{
+		Name:   "build script",
+		Scopes: []string{model.ScopeVersionsWrite},
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	r := scopedRouter()
+
+	if code := requestWithToken(r, "/models", created.Token); code != http.StatusForbidden {
+		t.Errorf("expected 403 for a token without models:read, got %d", code)
+	}
+	if code := requestWithToken(r, "/uploads", created.Token); code != http.StatusOK {
+		t.Errorf("expected a token with one of the route scopes to pass, got %d", code)
+	}
+	if code := requestWithToken(r, "/uploads", created.Token+"x"); code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for an unknown token, got %d", code)
+	}
+}
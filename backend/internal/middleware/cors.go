@@ -1,9 +1,8 @@
 package middleware
 
 import (
-	"log"
+	"log/slog"
 	"strings"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/ysmmc/backend/internal/config"
@@ -38,11 +37,12 @@ func CORS() gin.HandlerFunc {
 		if allowedOrigin != "" {
 			c.Writer.Header().Set("Access-Control-Allow-Origin", allowedOrigin)
 			c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-			c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+			c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Request-ID")
+			c.Writer.Header().Set("Access-Control-Expose-Headers", RequestIDHeader)
 			c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE, PATCH")
 			c.Writer.Header().Set("Access-Control-Max-Age", "86400")
 		} else {
-			log.Printf("[CORS] Blocked origin: %s (allowed: %v)", origin, allowedOrigins)
+			slog.WarnContext(c.Request.Context(), "CORS blocked origin", "origin", origin)
 		}
 
 		if c.Request.Method == "OPTIONS" {
@@ -69,18 +69,3 @@ func isAllowedOrigin(origin string, allowedOrigins []string) bool {
 	}
 	return false
 }
-
-func Logger() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		t := time.Now()
-		c.Next()
-		latency := time.Since(t)
-		gin.DefaultWriter.Write([]byte(
-			"[GIN] " + t.Format("2006/01/02 - 15:04:05") +
-				" | " + c.ClientIP() +
-				" | " + c.Request.Method +
-				" | " + c.Request.URL.Path +
-				" | " + latency.String() + "\n",
-		))
-	}
-}
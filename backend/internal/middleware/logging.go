@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"io"
+	"log/slog"
+	"regexp"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/ysmmc/backend/pkg/logging"
+	"github.com/ysmmc/backend/pkg/response"
+)
+
+// RequestIDHeader 请求 ID 所在的请求头与响应头
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDPattern 接受上游代理生成的请求 ID 的格式，其余值（含换行等）重新生成，避免伪造日志
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestID 沿用请求头中的 X-Request-ID 或生成新的 ID，写入响应头与请求的 context，
+// 之后以该 context 记录的日志（含 SQL 日志）都带有请求 ID
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !requestIDPattern.MatchString(id) {
+			id = uuid.NewString()
+		}
+		c.Header(RequestIDHeader, id)
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+// Logger 请求结束后输出访问日志；只记录路径，不记录可能包含令牌的查询参数
+func Logger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			slog.Float64("duration_ms", logging.Milliseconds(time.Since(start))),
+			slog.Int("bytes", c.Writer.Size()),
+			slog.String("ip", c.ClientIP()),
+		}
+		if userID, ok := c.Get("user_id"); ok {
+			attrs = append(attrs, slog.Any("user_id", userID))
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("errors", c.Errors.String()))
+		}
+
+		level := slog.LevelInfo
+		if status >= 500 {
+			level = slog.LevelError
+		}
+		slog.LogAttrs(c.Request.Context(), level, "HTTP request", attrs...)
+	}
+}
+
+// Recovery 把 handler 中的 panic 记录为带调用栈的错误日志并返回 500
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, err any) {
+		slog.ErrorContext(c.Request.Context(), "Panic while handling request", "panic", err, "stack", string(debug.Stack()))
+		response.InternalError(c, "internal server error")
+		c.Abort()
+	})
+}
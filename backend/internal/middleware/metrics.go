@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ysmmc/backend/internal/config"
+	"github.com/ysmmc/backend/pkg/metrics"
+	"github.com/ysmmc/backend/pkg/response"
+)
+
+var (
+	httpRequestDuration = metrics.NewHistogramVec("ysmmc_http_request_duration_seconds",
+		"HTTP request latency by route template and status code.", metrics.DefaultBuckets, "method", "route", "status")
+	httpRequestsInFlight = metrics.NewGaugeVec("ysmmc_http_requests_in_flight",
+		"HTTP requests currently being handled.")
+	rateLimitRejections = metrics.NewCounterVec("ysmmc_rate_limit_rejections_total",
+		"Requests rejected by the rate limiter, by route template.", "route")
+)
+
+// Metrics 记录请求耗时；标签使用路由模板而不是实际路径，未匹配路由的请求记为 unmatched
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		httpRequestsInFlight.Add(1)
+		defer httpRequestsInFlight.Add(-1)
+
+		c.Next()
+
+		httpRequestDuration.Observe(time.Since(start).Seconds(),
+			c.Request.Method, routeLabel(c), strconv.Itoa(c.Writer.Status()))
+	}
+}
+
+func routeLabel(c *gin.Context) string {
+	if route := c.FullPath(); route != "" {
+		return route
+	}
+	return "unmatched"
+}
+
+// MetricsAccess 限制 /metrics 的访问：携带 METRICS_TOKEN 的 Bearer 令牌，或来源 IP 在 METRICS_ALLOWED_IPS 中。
+// 来源 IP 使用连接的对端地址而不是 X-Forwarded-For，避免伪造请求头绕过限制；
+// 两者都未配置时返回 404
+func MetricsAccess() gin.HandlerFunc {
+	cfg := config.AppConfig
+	token := cfg.MetricsToken
+	networks, _ := config.ParseNetworks(cfg.MetricsAllowedIPs)
+
+	return func(c *gin.Context) {
+		if token == "" && len(networks) == 0 {
+			response.NotFound(c, "not found")
+			c.Abort()
+			return
+		}
+
+		if token != "" {
+			provided, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+			if ok && subtle.ConstantTimeCompare([]byte(provided), []byte(token)) == 1 {
+				c.Next()
+				return
+			}
+		}
+		if ip := net.ParseIP(c.RemoteIP()); ip != nil {
+			for _, network := range networks {
+				if network.Contains(ip) {
+					c.Next()
+					return
+				}
+			}
+		}
+
+		response.Forbidden(c, "access to metrics denied")
+		c.Abort()
+	}
+}
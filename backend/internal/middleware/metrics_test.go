@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ysmmc/backend/internal/config"
+)
+
+func metricsRouter(cfg *config.Config) *gin.Engine {
+	config.AppConfig = cfg
+	r := gin.New()
+	r.GET("/metrics", MetricsAccess(), func(c *gin.Context) {
+		c.String(http.StatusOK, "metrics")
+	})
+	return r
+}
+
+func requestMetrics(r *gin.Engine, remoteAddr, authorization string) int {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/metrics", nil)
+	req.RemoteAddr = remoteAddr
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestMetricsAccess_DisabledWithoutConfig(t *testing.T) {
+	r := metricsRouter(&config.Config{})
+
+	if code := requestMetrics(r, "127.0.0.1:1234", ""); code != http.StatusNotFound {
+		t.Errorf("expected 404 when metrics access is not configured, got %d", code)
+	}
+}
+
+func TestMetricsAccess_Token(t *testing.T) {
+	r := metricsRouter(&config.Config{MetricsToken: "scrape-secret"})
+
+	if code := requestMetrics(r, "203.0.113.5:1234", "Bearer scrape-secret"); code != http.StatusOK {
+		t.Errorf("expected valid token to be accepted, got %d", code)
+	}
+	if code := requestMetrics(r, "203.0.113.5:1234", "Bearer wrong"); code != http.StatusForbidden {
+		t.Errorf("expected wrong token to be rejected, got %d", code)
+	}
+	if code := requestMetrics(r, "203.0.113.5:1234", ""); code != http.StatusForbidden {
+		t.Errorf("expected missing token to be rejected, got %d", code)
+	}
+}
+
+func TestMetricsAccess_AllowedIPs(t *testing.T) {
+	r := metricsRouter(&config.Config{MetricsAllowedIPs: []string{"10.0.0.0/8"}})
+
+	if code := requestMetrics(r, "10.1.2.3:1234", ""); code != http.StatusOK {
+		t.Errorf("expected address in allowed network to be accepted, got %d", code)
+	}
+	if code := requestMetrics(r, "192.168.1.1:1234", ""); code != http.StatusForbidden {
+		t.Errorf("expected address outside allowed networks to be rejected, got %d", code)
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/metrics", nil)
+	req.RemoteAddr = "192.168.1.1:1234"
+	req.Header.Set("X-Forwarded-For", "10.1.2.3")
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected X-Forwarded-For to be ignored, got %d", w.Code)
+	}
+}
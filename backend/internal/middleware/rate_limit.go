@@ -1,10 +1,12 @@
 package middleware
 
 import (
+	"context"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/ysmmc/backend/pkg/response"
 )
 
@@ -28,20 +30,15 @@ var limiter = &RateLimiter{
 	visitors: make(map[string]*visitor),
 }
 
-func init() {
-	go cleanupVisitors()
-}
+// CleanupVisitors 清除一小时内没有请求的访问记录，由服务以 lifecycle.Every 定期执行
+func CleanupVisitors(ctx context.Context) {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
 
-func cleanupVisitors() {
-	for {
-		time.Sleep(time.Minute)
-		limiter.mu.Lock()
-		for ip, v := range limiter.visitors {
-			if time.Since(v.lastSeen) > time.Hour {
-				delete(limiter.visitors, ip)
-			}
+	for ip, v := range limiter.visitors {
+		if time.Since(v.lastSeen) > time.Hour {
+			delete(limiter.visitors, ip)
 		}
-		limiter.mu.Unlock()
 	}
 }
 
@@ -84,6 +81,7 @@ func RateLimit(config RateLimitConfig) gin.HandlerFunc {
 		v.lastSeen = now
 
 		if v.count > config.Requests {
+			rateLimitRejections.Inc(routeLabel(c))
 			response.TooManyRequests(c, "too many requests, please try again later")
 			c.Abort()
 			return
@@ -97,6 +95,14 @@ func IPKeyFunc(c *gin.Context) string {
 	return c.ClientIP()
 }
 
+func UserKeyFunc(c *gin.Context) string {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		return ""
+	}
+	return "user:" + userID.(uuid.UUID).String()
+}
+
 func LoginRateLimit() gin.HandlerFunc {
 	return RateLimit(RateLimitConfig{
 		Requests: 5,
@@ -121,6 +127,23 @@ func ForgotPasswordRateLimit() gin.HandlerFunc {
 	})
 }
 
+// ResendVerificationRateLimit 每个用户每小时最多重发 3 封验证邮件
+func ResendVerificationRateLimit() gin.HandlerFunc {
+	return RateLimit(RateLimitConfig{
+		Requests: 3,
+		Window:   time.Hour,
+		KeyFunc:  func(c *gin.Context) string { return "resend-verification:" + UserKeyFunc(c) },
+	})
+}
+
+func ReportRateLimit() gin.HandlerFunc {
+	return RateLimit(RateLimitConfig{
+		Requests: 20,
+		Window:   time.Hour,
+		KeyFunc:  UserKeyFunc,
+	})
+}
+
 func GlobalRateLimit() gin.HandlerFunc {
 	return RateLimit(RateLimitConfig{
 		Requests: 100,
@@ -1,12 +1,14 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 func init() {
@@ -104,6 +106,23 @@ func TestIPKeyFunc(t *testing.T) {
 	}
 }
 
+func TestUserKeyFunc(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/test", nil)
+
+	if key := UserKeyFunc(c); key != "" {
+		t.Errorf("anonymous request should produce empty key, got %q", key)
+	}
+
+	userID := uuid.New()
+	c.Set("user_id", userID)
+
+	if key := UserKeyFunc(c); key != "user:"+userID.String() {
+		t.Errorf("unexpected user key: %q", key)
+	}
+}
+
 func TestLoginRateLimit(t *testing.T) {
 	r := gin.New()
 	r.Use(LoginRateLimit())
@@ -187,3 +206,22 @@ func TestForgotPasswordRateLimit(t *testing.T) {
 		t.Errorf("fourth forgot-password request should be rate limited, got status %d", w.Code)
 	}
 }
+
+func TestCleanupVisitors(t *testing.T) {
+	stale := getVisitor("cleanup-stale")
+	limiter.mu.Lock()
+	stale.lastSeen = time.Now().Add(-2 * time.Hour)
+	limiter.mu.Unlock()
+	getVisitor("cleanup-fresh")
+
+	CleanupVisitors(context.Background())
+
+	limiter.mu.RLock()
+	defer limiter.mu.RUnlock()
+	if _, ok := limiter.visitors["cleanup-stale"]; ok {
+		t.Error("stale visitor should be removed")
+	}
+	if _, ok := limiter.visitors["cleanup-fresh"]; !ok {
+		t.Error("recent visitor should be kept")
+	}
+}
@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/ysmmc/backend/internal/service"
+	"github.com/ysmmc/backend/pkg/response"
+)
+
+func RequireNoSanction(sanctionType string) gin.HandlerFunc {
+	sanctionService := service.NewSanctionService()
+
+	return func(c *gin.Context) {
+		if err := sanctionService.CheckRestriction(c.Request.Context(), GetUserID(c), sanctionType); err != nil {
+			response.Forbidden(c, err.Error())
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/ysmmc/backend/internal/config"
+	"github.com/ysmmc/backend/internal/database"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var (
+	testDBOnce sync.Once
+	testDB     *gorm.DB
+	testDBErr  error
+)
+
+// setupTestDB 连接 TEST_DATABASE_URL 指定的 Postgres 并执行迁移，未设置时跳过测试。
+// 每个测试在一个事务中运行，结束时回滚
+func setupTestDB(t *testing.T) {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	testDBOnce.Do(func() {
+		if config.AppConfig == nil {
+			config.LoadConfig()
+		}
+		testDB, testDBErr = gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+		if testDBErr != nil {
+			return
+		}
+		database.DB = testDB
+		testDBErr = database.Migrate()
+	})
+	if testDBErr != nil {
+		t.Fatalf("failed to prepare test database: %v", testDBErr)
+	}
+
+	tx := testDB.Begin()
+	if tx.Error != nil {
+		t.Fatalf("failed to begin test transaction: %v", tx.Error)
+	}
+	database.DB = tx
+	t.Cleanup(func() {
+		tx.Rollback()
+		database.DB = testDB
+	})
+}
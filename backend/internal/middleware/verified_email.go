@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/ysmmc/backend/internal/service"
+	"github.com/ysmmc/backend/pkg/response"
+)
+
+// RequireVerifiedEmail 要求已验证邮箱才能执行 action 类操作，需放在 Auth 之后
+func RequireVerifiedEmail(action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := service.CheckEmailVerified(c.Request.Context(), GetUserID(c), action); err != nil {
+			response.Forbidden(c, err.Error())
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,74 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type AdminAction struct {
+	ID         uuid.UUID          `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	AdminID    uuid.UUID          `json:"admin_id" gorm:"type:uuid;not null;index"`
+	Action     string             `json:"action" gorm:"size:50;not null;index"`
+	TargetType string             `json:"target_type" gorm:"size:20;not null"`
+	Reason     *string            `json:"reason" gorm:"type:text"`
+	Total      int                `json:"total" gorm:"default:0"`
+	Succeeded  int                `json:"succeeded" gorm:"default:0"`
+	Failed     int                `json:"failed" gorm:"default:0"`
+	Results    AdminActionResults `json:"results" gorm:"type:jsonb"`
+	CreatedAt  time.Time          `json:"created_at" gorm:"index"`
+
+	Admin *User `json:"admin,omitempty" gorm:"foreignKey:AdminID"`
+}
+
+type AdminActionResult struct {
+	ID      uuid.UUID `json:"id"`
+	Success bool      `json:"success"`
+	Error   string    `json:"error,omitempty"`
+}
+
+type AdminActionResults []AdminActionResult
+
+func (r *AdminActionResults) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(bytes, r)
+}
+
+func (r AdminActionResults) Value() (driver.Value, error) {
+	if r == nil {
+		return "[]", nil
+	}
+	return json.Marshal(r)
+}
+
+func (a *AdminAction) AddResult(id uuid.UUID, err error) {
+	result := AdminActionResult{ID: id, Success: err == nil}
+	if err != nil {
+		result.Error = err.Error()
+		a.Failed++
+	} else {
+		a.Succeeded++
+	}
+	a.Total++
+	a.Results = append(a.Results, result)
+}
+
+func (a *AdminAction) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+func (AdminAction) TableName() string {
+	return "admin_actions"
+}
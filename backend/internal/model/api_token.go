@@ -0,0 +1,83 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+const (
+	ScopeProfileRead    = "profile:read"
+	ScopeModelsRead     = "models:read"
+	ScopeModelsWrite    = "models:write"
+	ScopeVersionsWrite  = "versions:write"
+	ScopeFavoritesRead  = "favorites:read"
+	ScopeFavoritesWrite = "favorites:write"
+)
+
+// TokenScopes 列出个人访问令牌可申请的权限范围及其说明
+var TokenScopes = []struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}{
+	{ScopeProfileRead, "读取当前账号信息"},
+	{ScopeModelsRead, "读取自己发布的模型，包括待审核与被拒绝的模型"},
+	{ScopeModelsWrite, "创建、编辑、删除模型及其图片"},
+	{ScopeVersionsWrite, "发布、编辑、删除模型版本并上传文件"},
+	{ScopeFavoritesRead, "读取收藏列表"},
+	{ScopeFavoritesWrite, "添加或取消收藏"},
+}
+
+// APIToken 用户创建的个人访问令牌，仅保存哈希
+type APIToken struct {
+	ID         uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID     uuid.UUID      `json:"user_id" gorm:"type:uuid;not null;index"`
+	Name       string         `json:"name" gorm:"size:100;not null"`
+	TokenHash  string         `json:"-" gorm:"size:64;not null;uniqueIndex"`
+	Hint       string         `json:"hint" gorm:"size:20"`
+	Scopes     pq.StringArray `json:"scopes" gorm:"type:text[]"`
+	ExpiresAt  *time.Time     `json:"expires_at"`
+	LastUsedAt *time.Time     `json:"last_used_at"`
+	LastUsedIP *string        `json:"last_used_ip" gorm:"size:64"`
+	RevokedAt  *time.Time     `json:"revoked_at"`
+	CreatedAt  time.Time      `json:"created_at"`
+	User       *User          `json:"-" gorm:"foreignKey:UserID"`
+}
+
+func (t *APIToken) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+func (APIToken) TableName() string {
+	return "api_tokens"
+}
+
+func (t *APIToken) IsActive(now time.Time) bool {
+	if t.RevokedAt != nil {
+		return false
+	}
+	return t.ExpiresAt == nil || t.ExpiresAt.After(now)
+}
+
+func (t *APIToken) HasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func IsValidTokenScope(scope string) bool {
+	for _, s := range TokenScopes {
+		if s.Name == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// ConsumedChallenge 记录已使用的工作量证明挑战，ID 为挑战 token 的 jti。
+// 保存在数据库中，多实例部署或重启后同一挑战也不能重放；过期后由清理任务删除
+type ConsumedChallenge struct {
+	ID        string    `json:"id" gorm:"primary_key;size:64"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null;index"`
+}
+
+func (ConsumedChallenge) TableName() string {
+	return "consumed_challenges"
+}
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	DataExportPending = "pending"
+	DataExportReady   = "ready"
+	DataExportFailed  = "failed"
+)
+
+// DataExport 用户个人数据导出任务，生成的压缩包保存在 UPLOAD_PATH/exports 下，过期后清理
+type DataExport struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID      uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	Status      string     `json:"status" gorm:"size:20;not null;default:pending"`
+	FilePath    string     `json:"-" gorm:"size:500"`
+	Size        int64      `json:"size" gorm:"default:0"`
+	Error       *string    `json:"error" gorm:"type:text"`
+	ExpiresAt   *time.Time `json:"expires_at" gorm:"index"`
+	CompletedAt *time.Time `json:"completed_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+func (e *DataExport) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+func (DataExport) TableName() string {
+	return "data_exports"
+}
+
+// IsDownloadable 导出已完成且未过期
+func (e *DataExport) IsDownloadable(now time.Time) bool {
+	return e.Status == DataExportReady && e.ExpiresAt != nil && e.ExpiresAt.After(now)
+}
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	EmailQueued   = "queued"
+	EmailRetrying = "retrying"
+	EmailSent     = "sent"
+	// EmailFailed 被服务器永久拒收或重试次数用尽
+	EmailFailed = "failed"
+)
+
+// 邮件类别
+const (
+	EmailCategoryWelcome       = "welcome"
+	EmailCategoryVerifyEmail   = "verify_email"
+	EmailCategoryResetPassword = "reset_password"
+	EmailCategoryEmailChange   = "email_change"
+	EmailCategorySecurityAlert = "security_alert"
+	EmailCategoryNotification  = "notification"
+	EmailCategoryDigest        = "digest"
+)
+
+// EmailOutbox 待发送与已发送的邮件；正文含一次性链接，不通过接口返回
+type EmailOutbox struct {
+	ID            uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID        *uuid.UUID `json:"user_id" gorm:"type:uuid;index"`
+	Category      string     `json:"category" gorm:"size:50;not null"`
+	ToAddress     string     `json:"to_address" gorm:"size:255;not null"`
+	Subject       string     `json:"subject" gorm:"size:255;not null"`
+	Body          string     `json:"-" gorm:"type:text;not null"`
+	TextBody      string     `json:"-" gorm:"type:text;not null;default:''"`
+	Status        string     `json:"status" gorm:"size:20;not null;default:queued;index"`
+	Attempts      int        `json:"attempts" gorm:"not null;default:0"`
+	LastError     *string    `json:"last_error" gorm:"type:text"`
+	LastAttemptAt *time.Time `json:"last_attempt_at"`
+	SentAt        *time.Time `json:"sent_at"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"index"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+func (e *EmailOutbox) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+func (EmailOutbox) TableName() string {
+	return "email_outbox"
+}
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// InviteCode 邀请码，invite_only 模式下注册必须提供；其他模式下提供则记录邀请关系
+type InviteCode struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Code      string     `json:"code" gorm:"size:32;not null;uniqueIndex"`
+	CreatedBy uuid.UUID  `json:"created_by" gorm:"type:uuid;not null;index"`
+	Note      string     `json:"note" gorm:"size:255"`
+	MaxUses   int        `json:"max_uses" gorm:"not null;default:1"`
+	Uses      int        `json:"uses" gorm:"not null;default:0"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	Creator   *User      `json:"creator,omitempty" gorm:"foreignKey:CreatedBy"`
+}
+
+func (i *InviteCode) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
+
+func (InviteCode) TableName() string {
+	return "invite_codes"
+}
+
+func (i *InviteCode) IsUsable(now time.Time) bool {
+	if i.RevokedAt != nil || i.Uses >= i.MaxUses {
+		return false
+	}
+	return i.ExpiresAt == nil || i.ExpiresAt.After(now)
+}
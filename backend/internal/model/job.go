@@ -0,0 +1,81 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	JobPending   = "pending"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	// JobDead 重试次数用尽或遇到不可重试的错误，需要管理员处理
+	JobDead = "dead"
+)
+
+// Job 持久化在数据库中的后台任务，由 JobWorker 以 FOR UPDATE SKIP LOCKED 领取执行
+type Job struct {
+	ID          uuid.UUID       `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Kind        string          `json:"kind" gorm:"size:64;not null;index"`
+	Payload     json.RawMessage `json:"payload" gorm:"type:jsonb"`
+	Status      string          `json:"status" gorm:"size:20;not null;default:pending"`
+	Attempts    int             `json:"attempts" gorm:"not null;default:0"`
+	MaxAttempts int             `json:"max_attempts" gorm:"not null;default:5"`
+	RunAt       time.Time       `json:"run_at" gorm:"not null"`
+	LockedBy    *string         `json:"locked_by" gorm:"size:100"`
+	LockedAt    *time.Time      `json:"locked_at"`
+	LastError   *string         `json:"last_error" gorm:"type:text"`
+	// ScheduleName 由定时计划产生的任务记录计划名
+	ScheduleName *string    `json:"schedule_name" gorm:"size:64"`
+	CompletedAt  *time.Time `json:"completed_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+func (j *Job) BeforeCreate(tx *gorm.DB) error {
+	if j.ID == uuid.Nil {
+		j.ID = uuid.New()
+	}
+	return nil
+}
+
+func (Job) TableName() string {
+	return "jobs"
+}
+
+// JobSchedule cron 表达式描述的周期任务，到期时向队列写入一个 Kind 任务
+type JobSchedule struct {
+	Name      string     `json:"name" gorm:"primary_key;size:64"`
+	Spec      string     `json:"spec" gorm:"size:100;not null"`
+	Kind      string     `json:"kind" gorm:"size:64;not null"`
+	NextRunAt time.Time  `json:"next_run_at" gorm:"not null"`
+	LastRunAt *time.Time `json:"last_run_at"`
+	LastJobID *uuid.UUID `json:"last_job_id" gorm:"type:uuid"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+func (JobSchedule) TableName() string {
+	return "job_schedules"
+}
+
+// DailyStat 每日站点数据快照，由 stats.rollup 任务写入
+type DailyStat struct {
+	Date           time.Time `json:"date" gorm:"type:date;primary_key"`
+	TotalUsers     int64     `json:"total_users"`
+	NewUsers       int64     `json:"new_users"`
+	TotalModels    int64     `json:"total_models"`
+	NewModels      int64     `json:"new_models"`
+	ApprovedModels int64     `json:"approved_models"`
+	TotalDownloads int64     `json:"total_downloads"`
+	NewReports     int64     `json:"new_reports"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+func (DailyStat) TableName() string {
+	return "daily_stats"
+}
@@ -0,0 +1,38 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	NotificationSecurity = "security"
+	// NotificationModeration 模型审核结果、被自动检查驳回等审核相关通知
+	NotificationModeration = "moderation"
+	// NotificationFavoriteUpdate 收藏的模型发布了新版本
+	NotificationFavoriteUpdate = "favorite_update"
+)
+
+type Notification struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	Type      string     `json:"type" gorm:"size:30;not null"`
+	Title     string     `json:"title" gorm:"size:255;not null"`
+	Body      string     `json:"body" gorm:"type:text"`
+	Link      *string    `json:"link" gorm:"type:text"`
+	ReadAt    *time.Time `json:"read_at"`
+	CreatedAt time.Time  `json:"created_at" gorm:"index"`
+}
+
+func (n *Notification) BeforeCreate(tx *gorm.DB) error {
+	if n.ID == uuid.Nil {
+		n.ID = uuid.New()
+	}
+	return nil
+}
+
+func (Notification) TableName() string {
+	return "notifications"
+}
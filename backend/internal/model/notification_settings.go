@@ -0,0 +1,79 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// 通知渠道
+const (
+	// ChannelInApp 仅站内通知
+	ChannelInApp = "in_app"
+	// ChannelEmail 站内通知并发送邮件；开启摘要时邮件合并到摘要中
+	ChannelEmail = "email"
+	ChannelOff   = "off"
+)
+
+// 摘要邮件频率
+const (
+	DigestOff    = "off"
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+// NotificationDefaults 可由用户设置的通知类型及其默认渠道。安全通知不在其中，
+// 固定发送站内通知，邮件由安全事件单独发送
+var NotificationDefaults = map[string]string{
+	NotificationModeration:     ChannelEmail,
+	NotificationFavoriteUpdate: ChannelInApp,
+}
+
+// NotificationPreferences 通知类型到渠道的映射，只保存用户修改过的类型
+type NotificationPreferences map[string]string
+
+func (p *NotificationPreferences) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(bytes, p)
+}
+
+func (p NotificationPreferences) Value() (driver.Value, error) {
+	if p == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(p)
+}
+
+// NotificationSettings 用户的通知偏好，没有记录时使用默认值
+type NotificationSettings struct {
+	UserID      uuid.UUID               `json:"-" gorm:"type:uuid;primary_key"`
+	Preferences NotificationPreferences `json:"preferences" gorm:"type:jsonb;not null;default:'{}'"`
+	Digest      string                  `json:"digest" gorm:"size:10;not null;default:off"`
+	// LastDigestAt 上一次生成摘要的时间，下一封摘要只包含之后的通知
+	LastDigestAt *time.Time `json:"last_digest_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+func (NotificationSettings) TableName() string {
+	return "notification_settings"
+}
+
+// Channel 返回某类通知的渠道；不可设置的类型固定为站内通知
+func (s *NotificationSettings) Channel(notificationType string) string {
+	def, ok := NotificationDefaults[notificationType]
+	if !ok {
+		return ChannelInApp
+	}
+	if channel, ok := s.Preferences[notificationType]; ok {
+		return channel
+	}
+	return def
+}
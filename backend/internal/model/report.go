@@ -0,0 +1,77 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Report struct {
+	ID             uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TargetType     string     `json:"target_type" gorm:"size:20;not null;index:idx_report_target"`
+	TargetID       uuid.UUID  `json:"target_id" gorm:"type:uuid;not null;index:idx_report_target"`
+	Category       string     `json:"category" gorm:"size:20;not null"`
+	Status         string     `json:"status" gorm:"size:20;default:open;index"`
+	ReportCount    int        `json:"report_count" gorm:"default:0"`
+	AutoHidden     bool       `json:"auto_hidden" gorm:"default:false"`
+	PreviousStatus *string    `json:"-" gorm:"size:20"`
+	Resolution     *string    `json:"resolution" gorm:"type:text"`
+	ResolvedBy     *uuid.UUID `json:"resolved_by" gorm:"type:uuid"`
+	ResolvedAt     *time.Time `json:"resolved_at"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"index"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	Entries []ReportEntry `json:"entries,omitempty" gorm:"foreignKey:ReportID"`
+}
+
+type ReportEntry struct {
+	ID          uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ReportID    uuid.UUID `json:"report_id" gorm:"type:uuid;not null;uniqueIndex:idx_report_reporter"`
+	ReporterID  uuid.UUID `json:"reporter_id" gorm:"type:uuid;not null;uniqueIndex:idx_report_reporter;index"`
+	Category    string    `json:"category" gorm:"size:20;not null"`
+	Description *string   `json:"description" gorm:"type:text"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	Reporter *User `json:"reporter,omitempty" gorm:"foreignKey:ReporterID"`
+}
+
+func (r *Report) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+func (Report) TableName() string {
+	return "reports"
+}
+
+func (e *ReportEntry) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+func (ReportEntry) TableName() string {
+	return "report_entries"
+}
+
+func IsValidReportTarget(targetType string) bool {
+	switch targetType {
+	case "model", "version", "user", "image":
+		return true
+	default:
+		return false
+	}
+}
+
+func IsValidReportCategory(category string) bool {
+	switch category {
+	case "stolen", "nsfw", "malicious", "spam", "harassment", "other":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,122 @@
+package model
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	RoleUser       = "user"
+	RoleAdmin      = "admin"
+	RoleSuperAdmin = "super_admin"
+)
+
+const (
+	PermAll                 = "*"
+	PermModelsApprove       = "models.approve"
+	PermModelsManage        = "models.manage"
+	PermUsersView           = "users.view"
+	PermUsersBan            = "users.ban"
+	PermUsersRoles          = "users.roles"
+	PermProfilesReview      = "profiles.review"
+	PermReportsTriage       = "reports.triage"
+	PermAnnouncementsManage = "announcements.manage"
+	PermStatsView           = "stats.view"
+	PermAuditView           = "audit.view"
+	PermRolesManage         = "roles.manage"
+	PermRegistrationManage  = "registration.manage"
+	PermJobsManage          = "jobs.manage"
+)
+
+// Permissions 列出所有可分配的权限及其说明
+var Permissions = []struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}{
+	{PermModelsApprove, "审核、通过或拒绝模型"},
+	{PermModelsManage, "编辑或删除任意模型及其版本、图片"},
+	{PermUsersView, "查看用户列表与处罚记录"},
+	{PermUsersBan, "封禁用户、施加或撤销处罚"},
+	{PermUsersRoles, "修改用户角色"},
+	{PermProfilesReview, "审核用户资料修改"},
+	{PermReportsTriage, "处理举报"},
+	{PermAnnouncementsManage, "发布与管理公告"},
+	{PermStatsView, "查看后台统计"},
+	{PermAuditView, "查看管理操作记录"},
+	{PermRolesManage, "编辑角色定义"},
+	{PermRegistrationManage, "切换注册模式、管理全部邀请码"},
+	{PermJobsManage, "查看后台任务队列、重试失败的任务"},
+}
+
+type Role struct {
+	Name        string         `json:"name" gorm:"primary_key;size:20"`
+	Description string         `json:"description" gorm:"size:255"`
+	Permissions pq.StringArray `json:"permissions" gorm:"type:text[]"`
+	IsSystem    bool           `json:"is_system" gorm:"default:false"`
+	RequireMFA  bool           `json:"require_mfa" gorm:"column:require_mfa;default:false"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+func (Role) TableName() string {
+	return "roles"
+}
+
+func (r *Role) HasPermission(perm string) bool {
+	for _, p := range r.Permissions {
+		if p == PermAll || p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+func IsValidPermission(perm string) bool {
+	if perm == PermAll {
+		return true
+	}
+	for _, p := range Permissions {
+		if p.Name == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultRoles 返回首次迁移时写入的内置角色
+func DefaultRoles() []Role {
+	return []Role{
+		{
+			Name:        RoleUser,
+			Description: "普通用户",
+			Permissions: pq.StringArray{},
+			IsSystem:    true,
+		},
+		{
+			Name:        "reviewer",
+			Description: "审核员：审核模型、资料与举报",
+			Permissions: pq.StringArray{PermModelsApprove, PermProfilesReview, PermReportsTriage, PermStatsView},
+		},
+		{
+			Name:        "announcer",
+			Description: "公告员：发布与管理公告",
+			Permissions: pq.StringArray{PermAnnouncementsManage},
+		},
+		{
+			Name:        RoleAdmin,
+			Description: "管理员",
+			Permissions: pq.StringArray{
+				PermModelsApprove, PermModelsManage, PermUsersView, PermUsersBan, PermUsersRoles,
+				PermProfilesReview, PermReportsTriage, PermAnnouncementsManage, PermStatsView, PermAuditView,
+			},
+			IsSystem: true,
+		},
+		{
+			Name:        RoleSuperAdmin,
+			Description: "超级管理员",
+			Permissions: pq.StringArray{PermAll},
+			IsSystem:    true,
+		},
+	}
+}
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	SanctionBan       = "ban"
+	SanctionNoUpload  = "no_upload"
+	SanctionNoComment = "no_comment"
+)
+
+type UserSanction struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	Type      string     `json:"type" gorm:"size:20;not null;index"`
+	Reason    string     `json:"reason" gorm:"type:text;not null"`
+	IssuedBy  *uuid.UUID `json:"issued_by" gorm:"type:uuid"`
+	ExpiresAt *time.Time `json:"expires_at" gorm:"index"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	RevokedBy *uuid.UUID `json:"revoked_by" gorm:"type:uuid"`
+	CreatedAt time.Time  `json:"created_at" gorm:"index"`
+}
+
+func (s *UserSanction) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+func (UserSanction) TableName() string {
+	return "user_sanctions"
+}
+
+func (s *UserSanction) IsActive(now time.Time) bool {
+	if s.RevokedAt != nil {
+		return false
+	}
+	return s.ExpiresAt == nil || s.ExpiresAt.After(now)
+}
+
+func IsValidSanctionType(sanctionType string) bool {
+	switch sanctionType {
+	case SanctionBan, SanctionNoUpload, SanctionNoComment:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,45 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	SecurityEventRefreshTokenReuse = "refresh_token_reuse"
+	SecurityEventIdentityLinked    = "identity_linked"
+	SecurityEventIdentityUnlinked  = "identity_unlinked"
+	// SecurityEventAccountLocked 连续登录失败导致账号被临时锁定
+	SecurityEventAccountLocked = "account_locked"
+	// SecurityEventLoginAfterFailures 在若干次失败之后登录成功
+	SecurityEventLoginAfterFailures = "login_after_failures"
+	// 用户自助注销：申请、撤销与到期后的实际删除
+	SecurityEventDeletionScheduled = "account_deletion_scheduled"
+	SecurityEventDeletionCancelled = "account_deletion_cancelled"
+	SecurityEventAccountDeleted    = "account_deleted"
+)
+
+// SecurityEvent 记录与账号安全相关的异常事件，供管理员排查
+type SecurityEvent struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    *uuid.UUID `json:"user_id" gorm:"type:uuid;index"`
+	Type      string     `json:"type" gorm:"size:50;not null;index"`
+	IP        string     `json:"ip" gorm:"size:64"`
+	UserAgent string     `json:"user_agent" gorm:"type:text"`
+	Details   string     `json:"details" gorm:"type:text"`
+	CreatedAt time.Time  `json:"created_at" gorm:"index"`
+	User      *User      `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+func (e *SecurityEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+func (SecurityEvent) TableName() string {
+	return "security_events"
+}
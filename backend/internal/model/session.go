@@ -8,11 +8,15 @@ import (
 )
 
 type Session struct {
-	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;not null;index"`
-	TokenHash string    `json:"-" gorm:"not null;size:255"`
-	ExpiresAt time.Time `json:"expires_at"`
-	CreatedAt time.Time `json:"created_at"`
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID      uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	TokenHash   string     `json:"-" gorm:"not null;size:255"`
+	DeviceLabel string     `json:"device_label" gorm:"size:100"`
+	UserAgent   string     `json:"user_agent" gorm:"type:text"`
+	IP          string     `json:"ip" gorm:"size:64"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	CreatedAt   time.Time  `json:"created_at"`
 }
 
 func (s *Session) BeforeCreate(tx *gorm.DB) error {
@@ -25,3 +29,17 @@ func (s *Session) BeforeCreate(tx *gorm.DB) error {
 func (Session) TableName() string {
 	return "sessions"
 }
+
+// ConsumedRefreshToken 记录已被轮换掉的 refresh token。
+// 每个 Session 即一个 token 家族，旧 token 再次出现说明可能已泄露，需要注销整个家族
+type ConsumedRefreshToken struct {
+	TokenHash  string    `json:"-" gorm:"primary_key;size:64"`
+	SessionID  uuid.UUID `json:"session_id" gorm:"type:uuid;not null;index"`
+	UserID     uuid.UUID `json:"user_id" gorm:"type:uuid;not null"`
+	ConsumedAt time.Time `json:"consumed_at"`
+	ExpiresAt  time.Time `json:"expires_at" gorm:"index"`
+}
+
+func (ConsumedRefreshToken) TableName() string {
+	return "consumed_refresh_tokens"
+}
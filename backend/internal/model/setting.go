@@ -0,0 +1,47 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Setting 可在运行时由管理员修改的站点设置，值为 JSON 文本
+type Setting struct {
+	Key       string     `json:"key" gorm:"primary_key;size:100"`
+	Value     string     `json:"value" gorm:"type:text;not null"`
+	UpdatedBy *uuid.UUID `json:"updated_by" gorm:"type:uuid"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+func (Setting) TableName() string {
+	return "settings"
+}
+
+const (
+	SettingRegistration = "registration"
+	// SettingDeletedUser 接收已注销用户匿名化内容的占位账号 ID
+	SettingDeletedUser = "deleted_user"
+)
+
+// 注册模式
+const (
+	RegistrationOpen            = "open"
+	RegistrationInviteOnly      = "invite_only"
+	RegistrationClosed          = "closed"
+	RegistrationDomainAllowlist = "domain_allowlist"
+)
+
+// RegistrationSettings 注册模式；AllowedDomains 仅在 domain_allowlist 模式下生效
+type RegistrationSettings struct {
+	Mode           string   `json:"mode"`
+	AllowedDomains []string `json:"allowed_domains"`
+}
+
+func IsValidRegistrationMode(mode string) bool {
+	switch mode {
+	case RegistrationOpen, RegistrationInviteOnly, RegistrationClosed, RegistrationDomainAllowlist:
+		return true
+	}
+	return false
+}
@@ -22,7 +22,10 @@ type User struct {
 	ProfileStatus     string          `json:"profile_status" gorm:"size:20;default:approved"`
 	PendingChanges    *PendingChanges `json:"pending_changes" gorm:"type:jsonb"`
 	EmailVerified     bool            `json:"email_verified" gorm:"default:false"`
+	// Language 邮件使用的语言
+	Language          string          `json:"language" gorm:"size:10;not null;default:'zh-CN'"`
 	VerificationToken *string         `json:"-" gorm:"column:verification_token;size:255"`
+	VerificationExpires *time.Time    `json:"-" gorm:"column:verification_token_expires"`
 	ResetToken        *string         `json:"-" gorm:"size:255"`
 	ResetExpires      *time.Time      `json:"-" gorm:"column:reset_token_expires"`
 	NewEmail          *string         `json:"-" gorm:"size:255"`
@@ -32,6 +35,18 @@ type User struct {
 	IsBanned          bool            `json:"is_banned" gorm:"default:false"`
 	BannedAt          *time.Time      `json:"banned_at"`
 	BannedReason      *string         `json:"banned_reason" gorm:"type:text"`
+	BanExpiresAt      *time.Time      `json:"ban_expires_at"`
+	TokenVersion      int             `json:"-" gorm:"not null;default:0"`
+	TOTPSecret        *string         `json:"-" gorm:"column:totp_secret;size:64"`
+	TOTPEnabled       bool            `json:"totp_enabled" gorm:"column:totp_enabled;default:false"`
+	TOTPLastStep      int64           `json:"-" gorm:"column:totp_last_step;not null;default:0"`
+	FailedLoginCount  int             `json:"-" gorm:"not null;default:0"`
+	LastFailedLoginAt *time.Time      `json:"-"`
+	LockedUntil       *time.Time      `json:"locked_until"`
+	InvitedBy         *uuid.UUID      `json:"invited_by" gorm:"type:uuid;index"`
+	InviteCodeID      *uuid.UUID      `json:"-" gorm:"type:uuid"`
+	DeletionScheduledAt *time.Time    `json:"deletion_scheduled_at" gorm:"index"`
+	DeletionModelAction string        `json:"deletion_model_action,omitempty" gorm:"size:20"`
 	CreatedAt         time.Time       `json:"created_at"`
 	UpdatedAt         time.Time       `json:"updated_at"`
 }
@@ -72,10 +87,34 @@ func (User) TableName() string {
 	return "users"
 }
 
-func IsSuperAdmin(role string) bool {
-	return role == "super_admin"
+func (u *User) IsBanActive(now time.Time) bool {
+	if !u.IsBanned {
+		return false
+	}
+	return u.BanExpiresAt == nil || u.BanExpiresAt.After(now)
+}
+
+// IsLocked 判断账号是否因连续登录失败处于临时锁定中
+func (u *User) IsLocked(now time.Time) bool {
+	return u.LockedUntil != nil && u.LockedUntil.After(now)
 }
 
-func IsAdmin(role string) bool {
-	return role == "admin" || role == "super_admin"
+// 需要已验证邮箱的操作类别，由 REQUIRE_VERIFIED_EMAIL 配置
+const (
+	VerifiedActionUpload = "upload"
+	VerifiedActionReport = "report"
+)
+
+// 注销账号时对其发布的模型的处理方式
+const (
+	DeletionModelsAnonymize = "anonymize"
+	DeletionModelsDelete    = "delete"
+)
+
+func IsValidDeletionModelAction(action string) bool {
+	return action == DeletionModelsAnonymize || action == DeletionModelsDelete
+}
+
+func IsSuperAdmin(role string) bool {
+	return role == RoleSuperAdmin
 }
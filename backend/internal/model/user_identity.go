@@ -0,0 +1,46 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UserIdentity 用户关联的第三方登录身份，以 (provider, subject) 唯一确定
+type UserIdentity struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID      uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	Provider    string     `json:"provider" gorm:"size:50;not null;uniqueIndex:idx_user_identities_provider_subject"`
+	Subject     string     `json:"-" gorm:"size:255;not null;uniqueIndex:idx_user_identities_provider_subject"`
+	Email       string     `json:"email" gorm:"size:255"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+func (i *UserIdentity) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
+
+func (UserIdentity) TableName() string {
+	return "user_identities"
+}
+
+// OIDCState 进行中的第三方登录请求，保存 PKCE verifier 与 nonce，回调时一次性消费。
+// UserID 非空表示已登录用户发起的关联请求
+type OIDCState struct {
+	StateHash    string     `gorm:"primary_key;size:64"`
+	Provider     string     `gorm:"size:50;not null"`
+	CodeVerifier string     `gorm:"size:128;not null"`
+	Nonce        string     `gorm:"size:128;not null"`
+	UserID       *uuid.UUID `gorm:"type:uuid"`
+	ExpiresAt    time.Time  `gorm:"not null;index"`
+	CreatedAt    time.Time
+}
+
+func (OIDCState) TableName() string {
+	return "oidc_states"
+}
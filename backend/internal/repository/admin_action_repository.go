@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/ysmmc/backend/internal/database"
+	"github.com/ysmmc/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+type AdminActionRepository struct {
+	DB *gorm.DB
+}
+
+func NewAdminActionRepository() *AdminActionRepository {
+	return &AdminActionRepository{DB: database.DB}
+}
+
+func (r *AdminActionRepository) Create(ctx context.Context, action *model.AdminAction) error {
+	return r.DB.WithContext(ctx).Create(action).Error
+}
+
+func (r *AdminActionRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.AdminAction, error) {
+	var action model.AdminAction
+	err := r.DB.WithContext(ctx).Preload("Admin").First(&action, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &action, nil
+}
+
+func (r *AdminActionRepository) List(ctx context.Context, page, pageSize int, action string) ([]model.AdminAction, int64, error) {
+	var actions []model.AdminAction
+	var total int64
+
+	query := r.DB.WithContext(ctx).Model(&model.AdminAction{})
+	if action != "" {
+		query = query.Where("action = ?", action)
+	}
+	query.Count(&total)
+
+	offset := (page - 1) * pageSize
+	err := query.Preload("Admin").Omit("results").Offset(offset).Limit(pageSize).Order("created_at DESC").Find(&actions).Error
+	return actions, total, err
+}
@@ -1,51 +1,55 @@
 package repository
 
 import (
+	"context"
 	"github.com/google/uuid"
 	"github.com/ysmmc/backend/internal/database"
 	"github.com/ysmmc/backend/internal/model"
+	"gorm.io/gorm"
 )
 
-type AnnouncementRepository struct{}
+type AnnouncementRepository struct {
+	DB *gorm.DB
+}
 
 func NewAnnouncementRepository() *AnnouncementRepository {
-	return &AnnouncementRepository{}
+	return &AnnouncementRepository{DB: database.DB}
 }
 
-func (r *AnnouncementRepository) Create(announcement *model.Announcement) error {
-	return database.DB.Create(announcement).Error
+func (r *AnnouncementRepository) Create(ctx context.Context, announcement *model.Announcement) error {
+	return r.DB.WithContext(ctx).Create(announcement).Error
 }
 
-func (r *AnnouncementRepository) FindByID(id uuid.UUID) (*model.Announcement, error) {
+func (r *AnnouncementRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.Announcement, error) {
 	var announcement model.Announcement
-	err := database.DB.First(&announcement, "id = ?", id).Error
+	err := r.DB.WithContext(ctx).First(&announcement, "id = ?", id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &announcement, nil
 }
 
-func (r *AnnouncementRepository) Update(announcement *model.Announcement) error {
-	return database.DB.Save(announcement).Error
+func (r *AnnouncementRepository) Update(ctx context.Context, announcement *model.Announcement) error {
+	return r.DB.WithContext(ctx).Save(announcement).Error
 }
 
-func (r *AnnouncementRepository) Delete(id uuid.UUID) error {
-	return database.DB.Delete(&model.Announcement{}, "id = ?", id).Error
+func (r *AnnouncementRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.DB.WithContext(ctx).Delete(&model.Announcement{}, "id = ?", id).Error
 }
 
-func (r *AnnouncementRepository) ListActive() ([]model.Announcement, error) {
+func (r *AnnouncementRepository) ListActive(ctx context.Context) ([]model.Announcement, error) {
 	var announcements []model.Announcement
-	err := database.DB.Where("is_active = ?", true).Order("created_at DESC").Find(&announcements).Error
+	err := r.DB.WithContext(ctx).Where("is_active = ?", true).Order("created_at DESC").Find(&announcements).Error
 	return announcements, err
 }
 
-func (r *AnnouncementRepository) List(page, pageSize int) ([]model.Announcement, int64, error) {
+func (r *AnnouncementRepository) List(ctx context.Context, page, pageSize int) ([]model.Announcement, int64, error) {
 	var announcements []model.Announcement
 	var total int64
 
-	database.DB.Model(&model.Announcement{}).Count(&total)
+	r.DB.WithContext(ctx).Model(&model.Announcement{}).Count(&total)
 
 	offset := (page - 1) * pageSize
-	err := database.DB.Offset(offset).Limit(pageSize).Order("created_at DESC").Find(&announcements).Error
+	err := r.DB.WithContext(ctx).Offset(offset).Limit(pageSize).Order("created_at DESC").Find(&announcements).Error
 	return announcements, total, err
 }
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ysmmc/backend/internal/database"
+	"github.com/ysmmc/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+type APITokenRepository struct {
+	DB *gorm.DB
+}
+
+func NewAPITokenRepository() *APITokenRepository {
+	return &APITokenRepository{DB: database.DB}
+}
+
+func (r *APITokenRepository) Create(ctx context.Context, token *model.APIToken) error {
+	return r.DB.WithContext(ctx).Create(token).Error
+}
+
+func (r *APITokenRepository) FindByHash(ctx context.Context, tokenHash string) (*model.APIToken, error) {
+	var token model.APIToken
+	err := r.DB.WithContext(ctx).Preload("User").Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *APITokenRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]model.APIToken, error) {
+	var tokens []model.APIToken
+	err := r.DB.WithContext(ctx).Where("user_id = ? AND revoked_at IS NULL", userID).Order("created_at DESC").Find(&tokens).Error
+	return tokens, err
+}
+
+func (r *APITokenRepository) CountActiveByUserID(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.DB.WithContext(ctx).Model(&model.APIToken{}).
+		Where("user_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", userID, time.Now()).
+		Count(&count).Error
+	return count, err
+}
+
+// Revoke 吊销属于该用户的令牌，返回是否有记录被更新
+func (r *APITokenRepository) Revoke(ctx context.Context, id, userID uuid.UUID) (bool, error) {
+	result := r.DB.WithContext(ctx).Model(&model.APIToken{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *APITokenRepository) TouchLastUsed(ctx context.Context, id uuid.UUID, ip string, usedAt time.Time) error {
+	return r.DB.WithContext(ctx).Model(&model.APIToken{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+		"last_used_at": usedAt,
+		"last_used_ip": ip,
+	}).Error
+}
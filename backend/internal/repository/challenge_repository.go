@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/ysmmc/backend/internal/database"
+	"github.com/ysmmc/backend/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ChallengeRepository struct {
+	DB *gorm.DB
+}
+
+func NewChallengeRepository() *ChallengeRepository {
+	return &ChallengeRepository{DB: database.DB}
+}
+
+// Consume 记录挑战已使用，依赖主键保证并发提交时只有一次成功；已使用过返回 false
+func (r *ChallengeRepository) Consume(ctx context.Context, id string, expiresAt time.Time) (bool, error) {
+	result := r.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.ConsumedChallenge{ID: id, ExpiresAt: expiresAt})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *ChallengeRepository) DeleteExpired(ctx context.Context) error {
+	return r.DB.WithContext(ctx).Where("expires_at < ?", time.Now()).Delete(&model.ConsumedChallenge{}).Error
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/ysmmc/backend/internal/database"
+	"github.com/ysmmc/backend/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DailyStatRepository struct {
+	DB *gorm.DB
+}
+
+func NewDailyStatRepository() *DailyStatRepository {
+	return &DailyStatRepository{DB: database.DB}
+}
+
+// Upsert 写入某天的快照，重复执行时覆盖已有数据
+func (r *DailyStatRepository) Upsert(ctx context.Context, stat *model.DailyStat) error {
+	return r.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "date"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"total_users", "new_users", "total_models", "new_models",
+			"approved_models", "total_downloads", "new_reports", "updated_at",
+		}),
+	}).Create(stat).Error
+}
+
+// Collect 统计截至 end 的总量与 [start, end) 内的新增量；下载量为统计时的累计值
+func (r *DailyStatRepository) Collect(ctx context.Context, start, end time.Time) (*model.DailyStat, error) {
+	stat := &model.DailyStat{Date: start}
+	counts := []struct {
+		dst   *int64
+		query *gorm.DB
+	}{
+		{&stat.TotalUsers, r.DB.WithContext(ctx).Model(&model.User{}).Where("created_at < ?", end)},
+		{&stat.NewUsers, r.DB.WithContext(ctx).Model(&model.User{}).Where("created_at >= ? AND created_at < ?", start, end)},
+		{&stat.TotalModels, r.DB.WithContext(ctx).Model(&model.Model{}).Where("created_at < ?", end)},
+		{&stat.NewModels, r.DB.WithContext(ctx).Model(&model.Model{}).Where("created_at >= ? AND created_at < ?", start, end)},
+		{&stat.ApprovedModels, r.DB.WithContext(ctx).Model(&model.Model{}).Where("status = ? AND created_at < ?", "approved", end)},
+		{&stat.NewReports, r.DB.WithContext(ctx).Model(&model.Report{}).Where("created_at >= ? AND created_at < ?", start, end)},
+	}
+	for _, c := range counts {
+		if err := c.query.Count(c.dst).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	err := r.DB.WithContext(ctx).Model(&model.Model{}).Select("COALESCE(SUM(downloads), 0)").Scan(&stat.TotalDownloads).Error
+	if err != nil {
+		return nil, err
+	}
+	return stat, nil
+}
+
+func (r *DailyStatRepository) ListSince(ctx context.Context, since time.Time) ([]model.DailyStat, error) {
+	var stats []model.DailyStat
+	err := r.DB.WithContext(ctx).Where("date >= ?", since).Order("date").Find(&stats).Error
+	return stats, err
+}
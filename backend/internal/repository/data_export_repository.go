@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ysmmc/backend/internal/database"
+	"github.com/ysmmc/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+type DataExportRepository struct {
+	DB *gorm.DB
+}
+
+func NewDataExportRepository() *DataExportRepository {
+	return &DataExportRepository{DB: database.DB}
+}
+
+func (r *DataExportRepository) Create(ctx context.Context, export *model.DataExport) error {
+	return r.DB.WithContext(ctx).Create(export).Error
+}
+
+func (r *DataExportRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.DataExport, error) {
+	var export model.DataExport
+	if err := r.DB.WithContext(ctx).First(&export, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+func (r *DataExportRepository) FindLatestByUserID(ctx context.Context, userID uuid.UUID) (*model.DataExport, error) {
+	var export model.DataExport
+	err := r.DB.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC").First(&export).Error
+	if err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+func (r *DataExportRepository) MarkReady(ctx context.Context, id uuid.UUID, filePath string, size int64, completedAt, expiresAt time.Time) error {
+	return r.DB.WithContext(ctx).Model(&model.DataExport{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":       model.DataExportReady,
+		"file_path":    filePath,
+		"size":         size,
+		"completed_at": completedAt,
+		"expires_at":   expiresAt,
+	}).Error
+}
+
+func (r *DataExportRepository) MarkFailed(ctx context.Context, id uuid.UUID, reason string) error {
+	return r.DB.WithContext(ctx).Model(&model.DataExport{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":       model.DataExportFailed,
+		"error":        reason,
+		"completed_at": time.Now(),
+	}).Error
+}
+
+// FailStale 将创建时间早于 before 仍未完成的导出标记为失败（通常是生成过程中服务重启）
+func (r *DataExportRepository) FailStale(ctx context.Context, before time.Time) (int64, error) {
+	result := r.DB.WithContext(ctx).Model(&model.DataExport{}).
+		Where("status = ? AND created_at < ?", model.DataExportPending, before).
+		Updates(map[string]interface{}{
+			"status":       model.DataExportFailed,
+			"error":        "export was interrupted",
+			"completed_at": time.Now(),
+		})
+	return result.RowsAffected, result.Error
+}
+
+func (r *DataExportRepository) ListExpired(ctx context.Context, now time.Time) ([]model.DataExport, error) {
+	var exports []model.DataExport
+	err := r.DB.WithContext(ctx).Where("expires_at < ?", now).Find(&exports).Error
+	return exports, err
+}
+
+func (r *DataExportRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]model.DataExport, error) {
+	var exports []model.DataExport
+	err := r.DB.WithContext(ctx).Where("user_id = ?", userID).Find(&exports).Error
+	return exports, err
+}
+
+func (r *DataExportRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.DB.WithContext(ctx).Delete(&model.DataExport{}, "id = ?", id).Error
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ysmmc/backend/internal/database"
+	"github.com/ysmmc/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+type EmailOutboxRepository struct {
+	DB *gorm.DB
+}
+
+func NewEmailOutboxRepository() *EmailOutboxRepository {
+	return &EmailOutboxRepository{DB: database.DB}
+}
+
+func (r *EmailOutboxRepository) Create(ctx context.Context, message *model.EmailOutbox) error {
+	return r.DB.WithContext(ctx).Create(message).Error
+}
+
+func (r *EmailOutboxRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.EmailOutbox, error) {
+	var message model.EmailOutbox
+	if err := r.DB.WithContext(ctx).Where("id = ?", id).First(&message).Error; err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// RecordAttempt 记录一次投递的结果；reason 为空表示发送成功
+func (r *EmailOutboxRepository) RecordAttempt(ctx context.Context, id uuid.UUID, status, reason string, at time.Time) error {
+	updates := map[string]interface{}{
+		"status":          status,
+		"attempts":        gorm.Expr("attempts + 1"),
+		"last_attempt_at": at,
+	}
+	if status == model.EmailSent {
+		updates["sent_at"] = at
+	}
+	if reason != "" {
+		updates["last_error"] = reason
+	}
+	return r.DB.WithContext(ctx).Model(&model.EmailOutbox{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// Requeue 将发送失败的邮件改回待发送；只有 failed 状态的邮件可以重发
+func (r *EmailOutboxRepository) Requeue(ctx context.Context, id uuid.UUID) (bool, error) {
+	result := r.DB.WithContext(ctx).Model(&model.EmailOutbox{}).
+		Where("id = ? AND status = ?", id, model.EmailFailed).
+		Update("status", model.EmailQueued)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *EmailOutboxRepository) List(ctx context.Context, page, pageSize int, status, category, to string) ([]model.EmailOutbox, int64, error) {
+	var messages []model.EmailOutbox
+	var total int64
+
+	query := r.DB.WithContext(ctx).Model(&model.EmailOutbox{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if category != "" {
+		query = query.Where("category = ?", category)
+	}
+	if to != "" {
+		query = query.Where("LOWER(to_address) = LOWER(?)", to)
+	}
+	query.Count(&total)
+
+	offset := (page - 1) * pageSize
+	err := query.Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&messages).Error
+	return messages, total, err
+}
+
+// CountByStatus 各投递状态的邮件数
+func (r *EmailOutboxRepository) CountByStatus(ctx context.Context, since time.Time) (map[string]int64, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	err := r.DB.WithContext(ctx).Model(&model.EmailOutbox{}).Select("status, COUNT(*) AS count").
+		Where("created_at >= ?", since).Group("status").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := map[string]int64{}
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+// DeleteBefore 删除创建时间早于 before 且已结束投递的邮件
+func (r *EmailOutboxRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.DB.WithContext(ctx).Where("created_at < ? AND status IN ?", before, []string{model.EmailSent, model.EmailFailed}).
+		Delete(&model.EmailOutbox{})
+	return result.RowsAffected, result.Error
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"github.com/google/uuid"
 	"github.com/ysmmc/backend/internal/database"
 	"github.com/ysmmc/backend/internal/model"
@@ -12,34 +13,34 @@ func NewFavoriteRepository() *FavoriteRepository {
 	return &FavoriteRepository{}
 }
 
-func (r *FavoriteRepository) Create(favorite *model.Favorite) error {
-	return database.DB.Create(favorite).Error
+func (r *FavoriteRepository) Create(ctx context.Context, favorite *model.Favorite) error {
+	return database.DB.WithContext(ctx).Create(favorite).Error
 }
 
-func (r *FavoriteRepository) Delete(userID, modelID uuid.UUID) error {
-	return database.DB.Where("user_id = ? AND model_id = ?", userID, modelID).Delete(&model.Favorite{}).Error
+func (r *FavoriteRepository) Delete(ctx context.Context, userID, modelID uuid.UUID) error {
+	return database.DB.WithContext(ctx).Where("user_id = ? AND model_id = ?", userID, modelID).Delete(&model.Favorite{}).Error
 }
 
-func (r *FavoriteRepository) FindByUserAndModel(userID, modelID uuid.UUID) (*model.Favorite, error) {
+func (r *FavoriteRepository) FindByUserAndModel(ctx context.Context, userID, modelID uuid.UUID) (*model.Favorite, error) {
 	var favorite model.Favorite
-	err := database.DB.Where("user_id = ? AND model_id = ?", userID, modelID).First(&favorite).Error
+	err := database.DB.WithContext(ctx).Where("user_id = ? AND model_id = ?", userID, modelID).First(&favorite).Error
 	if err != nil {
 		return nil, err
 	}
 	return &favorite, nil
 }
 
-func (r *FavoriteRepository) Exists(userID, modelID uuid.UUID) bool {
+func (r *FavoriteRepository) Exists(ctx context.Context, userID, modelID uuid.UUID) bool {
 	var count int64
-	database.DB.Model(&model.Favorite{}).Where("user_id = ? AND model_id = ?", userID, modelID).Count(&count)
+	database.DB.WithContext(ctx).Model(&model.Favorite{}).Where("user_id = ? AND model_id = ?", userID, modelID).Count(&count)
 	return count > 0
 }
 
-func (r *FavoriteRepository) ListByUserID(userID uuid.UUID, page, pageSize int) ([]model.Favorite, int64, error) {
+func (r *FavoriteRepository) ListByUserID(ctx context.Context, userID uuid.UUID, page, pageSize int) ([]model.Favorite, int64, error) {
 	var favorites []model.Favorite
 	var total int64
 
-	query := database.DB.Model(&model.Favorite{}).Where("user_id = ?", userID)
+	query := database.DB.WithContext(ctx).Model(&model.Favorite{}).Where("user_id = ?", userID)
 	query.Count(&total)
 
 	offset := (page - 1) * pageSize
@@ -47,12 +48,19 @@ func (r *FavoriteRepository) ListByUserID(userID uuid.UUID, page, pageSize int)
 	return favorites, total, err
 }
 
-func (r *FavoriteRepository) CountByModel(modelID uuid.UUID) (int64, error) {
+func (r *FavoriteRepository) CountByModel(ctx context.Context, modelID uuid.UUID) (int64, error) {
 	var count int64
-	err := database.DB.Model(&model.Favorite{}).Where("model_id = ?", modelID).Count(&count).Error
+	err := database.DB.WithContext(ctx).Model(&model.Favorite{}).Where("model_id = ?", modelID).Count(&count).Error
 	return count, err
 }
 
-func (r *FavoriteRepository) DeleteByModel(modelID uuid.UUID) error {
-	return database.DB.Where("model_id = ?", modelID).Delete(&model.Favorite{}).Error
+func (r *FavoriteRepository) DeleteByModel(ctx context.Context, modelID uuid.UUID) error {
+	return database.DB.WithContext(ctx).Where("model_id = ?", modelID).Delete(&model.Favorite{}).Error
+}
+
+// ListUserIDsByModel 收藏了该模型的用户
+func (r *FavoriteRepository) ListUserIDsByModel(ctx context.Context, modelID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := database.DB.WithContext(ctx).Model(&model.Favorite{}).Where("model_id = ?", modelID).Pluck("user_id", &ids).Error
+	return ids, err
 }
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ysmmc/backend/internal/database"
+	"github.com/ysmmc/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+type FileRepository struct {
+	DB *gorm.DB
+}
+
+func NewFileRepository() *FileRepository {
+	return &FileRepository{DB: database.DB}
+}
+
+func (r *FileRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.File, error) {
+	var file model.File
+	err := r.DB.WithContext(ctx).First(&file, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+// ListMetaByUserID 列出用户上传的文件，不加载文件内容
+func (r *FileRepository) ListMetaByUserID(ctx context.Context, userID uuid.UUID) ([]model.File, error) {
+	var files []model.File
+	err := r.DB.WithContext(ctx).Omit("data").Where("user_id = ?", userID).Order("created_at ASC").Find(&files).Error
+	return files, err
+}
+
+// unreferencedFileCondition 文件未被模型、版本、模型图片、头像或待审核修改引用
+const unreferencedFileCondition = `NOT EXISTS (SELECT 1 FROM models m WHERE m.image_id = files.id OR m.pending_changes->>'image_id' = files.id::text)
+	AND NOT EXISTS (SELECT 1 FROM model_versions v WHERE v.image_id = files.id)
+	AND NOT EXISTS (SELECT 1 FROM model_images i WHERE i.file_id = files.id)
+	AND NOT EXISTS (SELECT 1 FROM users u WHERE u.avatar_id = files.id OR u.pending_changes->>'avatar_id' = files.id::text)`
+
+// ListOrphans 列出 before 之前上传且未被引用的文件，不加载文件内容
+func (r *FileRepository) ListOrphans(ctx context.Context, before time.Time) ([]model.File, error) {
+	var files []model.File
+	err := r.DB.WithContext(ctx).Omit("data").Where("created_at < ?", before).Where(unreferencedFileCondition).
+		Order("created_at ASC").Find(&files).Error
+	return files, err
+}
+
+// DeleteOrphans 删除给定文件中仍未被引用的部分，返回删除数量；列出后重新被引用的文件会保留
+func (r *FileRepository) DeleteOrphans(ctx context.Context, ids []uuid.UUID) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	result := r.DB.WithContext(ctx).Where("id IN ?", ids).Where(unreferencedFileCondition).Delete(&model.File{})
+	return result.RowsAffected, result.Error
+}
+
+// ListReferencedPaths 返回模型、版本与头像记录中引用的全部上传路径（含待审核修改中的路径）
+func (r *FileRepository) ListReferencedPaths(ctx context.Context) ([]string, error) {
+	var paths []string
+	err := r.DB.WithContext(ctx).Raw(`SELECT file_path FROM models WHERE file_path <> ''
+		UNION SELECT image_url FROM models WHERE image_url IS NOT NULL
+		UNION SELECT pending_changes->>'file_path' FROM models WHERE pending_changes->>'file_path' IS NOT NULL
+		UNION SELECT pending_changes->>'image_url' FROM models WHERE pending_changes->>'image_url' IS NOT NULL
+		UNION SELECT file_path FROM model_versions WHERE file_path <> ''
+		UNION SELECT image_url FROM model_versions WHERE image_url IS NOT NULL
+		UNION SELECT avatar_url FROM users WHERE avatar_url IS NOT NULL
+		UNION SELECT pending_changes->>'avatar_url' FROM users WHERE pending_changes->>'avatar_url' IS NOT NULL`).
+		Scan(&paths).Error
+	return paths, err
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ysmmc/backend/internal/database"
+	"github.com/ysmmc/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+type InviteCodeRepository struct {
+	DB *gorm.DB
+}
+
+func NewInviteCodeRepository() *InviteCodeRepository {
+	return &InviteCodeRepository{DB: database.DB}
+}
+
+func (r *InviteCodeRepository) Create(ctx context.Context, invite *model.InviteCode) error {
+	return r.DB.WithContext(ctx).Create(invite).Error
+}
+
+func (r *InviteCodeRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.InviteCode, error) {
+	var invite model.InviteCode
+	err := r.DB.WithContext(ctx).First(&invite, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &invite, nil
+}
+
+func (r *InviteCodeRepository) ListByCreator(ctx context.Context, userID uuid.UUID) ([]model.InviteCode, error) {
+	var invites []model.InviteCode
+	err := r.DB.WithContext(ctx).Where("created_by = ?", userID).Order("created_at DESC").Find(&invites).Error
+	return invites, err
+}
+
+func (r *InviteCodeRepository) List(ctx context.Context, page, pageSize int, createdBy *uuid.UUID) ([]model.InviteCode, int64, error) {
+	var invites []model.InviteCode
+	var total int64
+
+	query := r.DB.WithContext(ctx).Model(&model.InviteCode{})
+	if createdBy != nil {
+		query = query.Where("created_by = ?", *createdBy)
+	}
+	query.Count(&total)
+
+	offset := (page - 1) * pageSize
+	err := query.Preload("Creator").Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&invites).Error
+	return invites, total, err
+}
+
+// CountUsableByCreator 统计该用户仍可使用的邀请码数量
+func (r *InviteCodeRepository) CountUsableByCreator(ctx context.Context, userID uuid.UUID, now time.Time) (int64, error) {
+	var count int64
+	err := r.DB.WithContext(ctx).Model(&model.InviteCode{}).
+		Where("created_by = ? AND revoked_at IS NULL AND uses < max_uses AND (expires_at IS NULL OR expires_at > ?)", userID, now).
+		Count(&count).Error
+	return count, err
+}
+
+// Redeem 原子地占用一次邀请码，邀请码不存在、已过期、已吊销或次数用尽时返回 gorm.ErrRecordNotFound
+func (r *InviteCodeRepository) Redeem(ctx context.Context, code string, now time.Time) (*model.InviteCode, error) {
+	var invites []model.InviteCode
+	err := r.DB.WithContext(ctx).Raw(`UPDATE invite_codes SET uses = uses + 1
+		WHERE code = ? AND revoked_at IS NULL AND uses < max_uses AND (expires_at IS NULL OR expires_at > ?)
+		RETURNING *`, code, now).Scan(&invites).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(invites) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &invites[0], nil
+}
+
+func (r *InviteCodeRepository) Revoke(ctx context.Context, id uuid.UUID, now time.Time) (bool, error) {
+	result := r.DB.WithContext(ctx).Model(&model.InviteCode{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", now)
+	return result.RowsAffected > 0, result.Error
+}
+
+// ListInvitees 返回通过该用户邀请注册的账号
+func (r *InviteCodeRepository) ListInvitees(ctx context.Context, userID uuid.UUID) ([]model.User, error) {
+	var users []model.User
+	err := r.DB.WithContext(ctx).Where("invited_by = ?", userID).Order("created_at DESC").Find(&users).Error
+	return users, err
+}
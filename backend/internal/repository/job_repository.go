@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ysmmc/backend/internal/database"
+	"github.com/ysmmc/backend/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type JobRepository struct {
+	DB *gorm.DB
+}
+
+func NewJobRepository() *JobRepository {
+	return &JobRepository{DB: database.DB}
+}
+
+func (r *JobRepository) Create(ctx context.Context, job *model.Job) error {
+	return r.DB.WithContext(ctx).Create(job).Error
+}
+
+func (r *JobRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.Job, error) {
+	var job model.Job
+	if err := r.DB.WithContext(ctx).Where("id = ?", id).First(&job).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// ClaimNext 领取一个 kinds 类型中到期的任务并标记为 running；SKIP LOCKED 使多个 worker 互不阻塞。
+// 只领取本实例能处理的类型，滚动发布期间旧版本实例不会误领新增类型的任务。没有到期任务时返回 nil
+func (r *JobRepository) ClaimNext(ctx context.Context, workerID string, kinds []string, now time.Time) (*model.Job, error) {
+	var job model.Job
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND run_at <= ? AND kind IN ?", model.JobPending, now, kinds).
+			Order("run_at").Limit(1).Find(&job).Error
+		if err != nil || job.ID == uuid.Nil {
+			return err
+		}
+
+		job.Status = model.JobRunning
+		job.Attempts++
+		job.LockedBy = &workerID
+		job.LockedAt = &now
+		return tx.Model(&model.Job{}).Where("id = ?", job.ID).Updates(map[string]interface{}{
+			"status":    job.Status,
+			"attempts":  job.Attempts,
+			"locked_by": workerID,
+			"locked_at": now,
+		}).Error
+	})
+	if err != nil || job.ID == uuid.Nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *JobRepository) MarkSucceeded(ctx context.Context, id uuid.UUID, completedAt time.Time) error {
+	return r.DB.WithContext(ctx).Model(&model.Job{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":       model.JobSucceeded,
+		"locked_by":    nil,
+		"locked_at":    nil,
+		"completed_at": completedAt,
+	}).Error
+}
+
+// MarkRetry 记录失败原因并在 runAt 重新排队
+func (r *JobRepository) MarkRetry(ctx context.Context, id uuid.UUID, runAt time.Time, reason string) error {
+	return r.DB.WithContext(ctx).Model(&model.Job{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":     model.JobPending,
+		"run_at":     runAt,
+		"last_error": reason,
+		"locked_by":  nil,
+		"locked_at":  nil,
+	}).Error
+}
+
+func (r *JobRepository) MarkDead(ctx context.Context, id uuid.UUID, reason string, completedAt time.Time) error {
+	return r.DB.WithContext(ctx).Model(&model.Job{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":       model.JobDead,
+		"last_error":   reason,
+		"locked_by":    nil,
+		"locked_at":    nil,
+		"completed_at": completedAt,
+	}).Error
+}
+
+// RequeueStale 将领取时间早于 before 仍在运行的任务放回队列（通常是执行中的实例崩溃），
+// 已领取的次数照常计入 attempts
+func (r *JobRepository) RequeueStale(ctx context.Context, before time.Time) (int64, error) {
+	result := r.DB.WithContext(ctx).Model(&model.Job{}).
+		Where("status = ? AND locked_at < ?", model.JobRunning, before).
+		Updates(map[string]interface{}{
+			"status":     model.JobPending,
+			"last_error": "worker stopped while running the job",
+			"locked_by":  nil,
+			"locked_at":  nil,
+		})
+	return result.RowsAffected, result.Error
+}
+
+// Retry 将死信任务重新排队并清零尝试次数；只有 dead 状态的任务可以重试
+func (r *JobRepository) Retry(ctx context.Context, id uuid.UUID, runAt time.Time) (bool, error) {
+	result := r.DB.WithContext(ctx).Model(&model.Job{}).
+		Where("id = ? AND status = ?", id, model.JobDead).
+		Updates(map[string]interface{}{
+			"status":       model.JobPending,
+			"attempts":     0,
+			"run_at":       runAt,
+			"completed_at": nil,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *JobRepository) List(ctx context.Context, page, pageSize int, status, kind string) ([]model.Job, int64, error) {
+	var jobs []model.Job
+	var total int64
+
+	query := r.DB.WithContext(ctx).Model(&model.Job{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	query.Count(&total)
+
+	offset := (page - 1) * pageSize
+	err := query.Order("updated_at DESC").Offset(offset).Limit(pageSize).Find(&jobs).Error
+	return jobs, total, err
+}
+
+// JobCount 按类型与状态统计的任务数
+type JobCount struct {
+	Kind   string `json:"kind"`
+	Status string `json:"status"`
+	Count  int64  `json:"count"`
+}
+
+func (r *JobRepository) CountByKindAndStatus(ctx context.Context) ([]JobCount, error) {
+	var counts []JobCount
+	err := r.DB.WithContext(ctx).Model(&model.Job{}).
+		Select("kind, status, COUNT(*) AS count").
+		Group("kind, status").Order("kind, status").
+		Scan(&counts).Error
+	return counts, err
+}
+
+// OldestPendingRunAt 最早到期且仍在等待的任务时间，用于判断队列是否积压
+func (r *JobRepository) OldestPendingRunAt(ctx context.Context, now time.Time) (*time.Time, error) {
+	var job model.Job
+	err := r.DB.WithContext(ctx).Select("run_at").Where("status = ? AND run_at <= ?", model.JobPending, now).
+		Order("run_at").Limit(1).Find(&job).Error
+	if err != nil || job.RunAt.IsZero() {
+		return nil, err
+	}
+	return &job.RunAt, nil
+}
+
+// DeleteFinished 删除完成时间早于 before、处于 status 状态的任务
+func (r *JobRepository) DeleteFinished(ctx context.Context, status string, before time.Time) (int64, error) {
+	result := r.DB.WithContext(ctx).Where("status = ? AND completed_at < ?", status, before).Delete(&model.Job{})
+	return result.RowsAffected, result.Error
+}
+
+// EnsureSchedule 注册周期任务：不存在时创建；表达式变化时更新并重新计算下次执行时间
+func (r *JobRepository) EnsureSchedule(ctx context.Context, schedule *model.JobSchedule) error {
+	var existing model.JobSchedule
+	err := r.DB.WithContext(ctx).Where("name = ?", schedule.Name).First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return r.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(schedule).Error
+	}
+	if err != nil {
+		return err
+	}
+	if existing.Spec == schedule.Spec && existing.Kind == schedule.Kind {
+		return nil
+	}
+	return r.DB.WithContext(ctx).Model(&model.JobSchedule{}).Where("name = ?", schedule.Name).Updates(map[string]interface{}{
+		"spec":        schedule.Spec,
+		"kind":        schedule.Kind,
+		"next_run_at": schedule.NextRunAt,
+	}).Error
+}
+
+// ListDueSchedulesForUpdate 锁定到期的周期任务，需在事务中调用；其他实例会跳过已被锁定的行
+func (r *JobRepository) ListDueSchedulesForUpdate(ctx context.Context, now time.Time) ([]model.JobSchedule, error) {
+	var schedules []model.JobSchedule
+	err := r.DB.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("next_run_at <= ?", now).Find(&schedules).Error
+	return schedules, err
+}
+
+func (r *JobRepository) AdvanceSchedule(ctx context.Context, name string, lastRunAt, nextRunAt time.Time, jobID uuid.UUID) error {
+	return r.DB.WithContext(ctx).Model(&model.JobSchedule{}).Where("name = ?", name).Updates(map[string]interface{}{
+		"last_run_at": lastRunAt,
+		"next_run_at": nextRunAt,
+		"last_job_id": jobID,
+	}).Error
+}
+
+func (r *JobRepository) ListSchedules(ctx context.Context) ([]model.JobSchedule, error) {
+	var schedules []model.JobSchedule
+	err := r.DB.WithContext(ctx).Order("name").Find(&schedules).Error
+	return schedules, err
+}
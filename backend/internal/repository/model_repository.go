@@ -1,50 +1,93 @@
 package repository
 
 import (
+	"context"
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/ysmmc/backend/internal/database"
 	"github.com/ysmmc/backend/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-type ModelRepository struct{}
+type ModelRepository struct {
+	DB *gorm.DB
+}
 
 func NewModelRepository() *ModelRepository {
-	return &ModelRepository{}
+	return &ModelRepository{DB: database.DB}
+}
+
+type ModelFilter struct {
+	Status        string
+	UserID        *uuid.UUID
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+}
+
+func (r *ModelRepository) Create(ctx context.Context, m *model.Model) error {
+	return r.DB.WithContext(ctx).Create(m).Error
 }
 
-func (r *ModelRepository) Create(m *model.Model) error {
-	return database.DB.Create(m).Error
+func (r *ModelRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.Model, error) {
+	var m model.Model
+	err := r.DB.WithContext(ctx).Preload("User").First(&m, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
 }
 
-func (r *ModelRepository) FindByID(id uuid.UUID) (*model.Model, error) {
+// FindByIDForUpdate 读取并锁定模型，需在事务中调用
+func (r *ModelRepository) FindByIDForUpdate(ctx context.Context, id uuid.UUID) (*model.Model, error) {
 	var m model.Model
-	err := database.DB.Preload("User").First(&m, "id = ?", id).Error
+	err := r.DB.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&m, "id = ?", id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &m, nil
 }
 
-func (r *ModelRepository) Update(m *model.Model) error {
-	return database.DB.Model(m).Omit("User").Save(m).Error
+func (r *ModelRepository) Update(ctx context.Context, m *model.Model) error {
+	return r.DB.WithContext(ctx).Model(m).Omit("User").Save(m).Error
 }
 
-func (r *ModelRepository) Delete(id uuid.UUID) error {
-	return database.DB.Delete(&model.Model{}, "id = ?", id).Error
+func (r *ModelRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.DB.WithContext(ctx).Delete(&model.Model{}, "id = ?", id).Error
 }
 
-func (r *ModelRepository) ClearReferences(id uuid.UUID) error {
-	return database.DB.Model(&model.Model{}).Where("id = ?", id).Updates(map[string]interface{}{
+func (r *ModelRepository) ClearReferences(ctx context.Context, id uuid.UUID) error {
+	return r.DB.WithContext(ctx).Model(&model.Model{}).Where("id = ?", id).Updates(map[string]interface{}{
 		"current_version_id": nil,
 		"image_id":           nil,
 	}).Error
 }
 
-func (r *ModelRepository) ListPublic(page, pageSize int, search string) ([]model.Model, int64, error) {
+// ReassignOwner 将 fromUserID 发布的全部模型转移给 toUserID
+func (r *ModelRepository) ReassignOwner(ctx context.Context, fromUserID, toUserID uuid.UUID) error {
+	return r.DB.WithContext(ctx).Model(&model.Model{}).Where("user_id = ?", fromUserID).UpdateColumn("user_id", toUserID).Error
+}
+
+func (r *ModelRepository) FindIDsByUserID(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.DB.WithContext(ctx).Model(&model.Model{}).Where("user_id = ?", userID).Pluck("id", &ids).Error
+	return ids, err
+}
+
+// ReindexSearch 重建标题搜索索引并刷新统计信息；REINDEX CONCURRENTLY 不阻塞读写，不能在事务中执行
+func (r *ModelRepository) ReindexSearch(ctx context.Context) error {
+	if err := r.DB.WithContext(ctx).Exec("REINDEX INDEX CONCURRENTLY idx_models_title_trgm").Error; err != nil {
+		return err
+	}
+	return r.DB.WithContext(ctx).Exec("ANALYZE models").Error
+}
+
+func (r *ModelRepository) ListPublic(ctx context.Context, page, pageSize int, search string) ([]model.Model, int64, error) {
 	var models []model.Model
 	var total int64
 
-	query := database.DB.Model(&model.Model{}).Where("status = ? AND is_public = ?", "approved", true)
+	query := r.DB.WithContext(ctx).Model(&model.Model{}).Where("status = ? AND is_public = ?", "approved", true)
 
 	if search != "" {
 		query = query.Where("title ILIKE ?", "%"+search+"%")
@@ -57,11 +100,11 @@ func (r *ModelRepository) ListPublic(page, pageSize int, search string) ([]model
 	return models, total, err
 }
 
-func (r *ModelRepository) ListByUserID(userID uuid.UUID, page, pageSize int) ([]model.Model, int64, error) {
+func (r *ModelRepository) ListByUserID(ctx context.Context, userID uuid.UUID, page, pageSize int) ([]model.Model, int64, error) {
 	var models []model.Model
 	var total int64
 
-	query := database.DB.Model(&model.Model{}).Where("user_id = ?", userID)
+	query := r.DB.WithContext(ctx).Model(&model.Model{}).Where("user_id = ?", userID)
 	query.Count(&total)
 
 	offset := (page - 1) * pageSize
@@ -69,11 +112,11 @@ func (r *ModelRepository) ListByUserID(userID uuid.UUID, page, pageSize int) ([]
 	return models, total, err
 }
 
-func (r *ModelRepository) ListPending(page, pageSize int) ([]model.Model, int64, error) {
+func (r *ModelRepository) ListPending(ctx context.Context, page, pageSize int) ([]model.Model, int64, error) {
 	var models []model.Model
 	var total int64
 
-	query := database.DB.Model(&model.Model{}).Where("status = ?", "pending")
+	query := r.DB.WithContext(ctx).Model(&model.Model{}).Where("status = ?", "pending")
 	query.Count(&total)
 
 	offset := (page - 1) * pageSize
@@ -81,11 +124,11 @@ func (r *ModelRepository) ListPending(page, pageSize int) ([]model.Model, int64,
 	return models, total, err
 }
 
-func (r *ModelRepository) ListPendingUpdates(page, pageSize int) ([]model.Model, int64, error) {
+func (r *ModelRepository) ListPendingUpdates(ctx context.Context, page, pageSize int) ([]model.Model, int64, error) {
 	var models []model.Model
 	var total int64
 
-	query := database.DB.Model(&model.Model{}).Where("update_status = ?", "pending_review")
+	query := r.DB.WithContext(ctx).Model(&model.Model{}).Where("update_status = ?", "pending_review")
 	query.Count(&total)
 
 	offset := (page - 1) * pageSize
@@ -93,33 +136,33 @@ func (r *ModelRepository) ListPendingUpdates(page, pageSize int) ([]model.Model,
 	return models, total, err
 }
 
-func (r *ModelRepository) IncrementDownloads(id uuid.UUID) error {
-	return database.DB.Model(&model.Model{}).Where("id = ?", id).UpdateColumn("downloads", database.DB.Raw("downloads + 1")).Error
+func (r *ModelRepository) IncrementDownloads(ctx context.Context, id uuid.UUID) error {
+	return r.DB.WithContext(ctx).Model(&model.Model{}).Where("id = ?", id).UpdateColumn("downloads", r.DB.WithContext(ctx).Raw("downloads + 1")).Error
 }
 
-func (r *ModelRepository) Count() (int64, error) {
+func (r *ModelRepository) Count(ctx context.Context) (int64, error) {
 	var count int64
-	err := database.DB.Model(&model.Model{}).Count(&count).Error
+	err := r.DB.WithContext(ctx).Model(&model.Model{}).Count(&count).Error
 	return count, err
 }
 
-func (r *ModelRepository) CountByStatus(status string) (int64, error) {
+func (r *ModelRepository) CountByStatus(ctx context.Context, status string) (int64, error) {
 	var count int64
-	err := database.DB.Model(&model.Model{}).Where("status = ?", status).Count(&count).Error
+	err := r.DB.WithContext(ctx).Model(&model.Model{}).Where("status = ?", status).Count(&count).Error
 	return count, err
 }
 
-func (r *ModelRepository) SumDownloads() (int64, error) {
+func (r *ModelRepository) SumDownloads(ctx context.Context) (int64, error) {
 	var total int64
-	err := database.DB.Model(&model.Model{}).Select("COALESCE(SUM(downloads), 0)").Scan(&total).Error
+	err := r.DB.WithContext(ctx).Model(&model.Model{}).Select("COALESCE(SUM(downloads), 0)").Scan(&total).Error
 	return total, err
 }
 
-func (r *ModelRepository) ListAll(page, pageSize int, status, search string) ([]model.Model, int64, error) {
+func (r *ModelRepository) ListAll(ctx context.Context, page, pageSize int, status, search string) ([]model.Model, int64, error) {
 	var models []model.Model
 	var total int64
 
-	query := database.DB.Model(&model.Model{})
+	query := r.DB.WithContext(ctx).Model(&model.Model{})
 
 	if status != "" && status != "all" {
 		query = query.Where("status = ?", status)
@@ -135,3 +178,63 @@ func (r *ModelRepository) ListAll(page, pageSize int, status, search string) ([]
 	err := query.Preload("User").Offset(offset).Limit(pageSize).Order("created_at DESC").Find(&models).Error
 	return models, total, err
 }
+
+func (r *ModelRepository) FindIDsByFilter(ctx context.Context, filter ModelFilter, limit int) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+
+	query := r.DB.WithContext(ctx).Model(&model.Model{})
+
+	if filter.Status != "" && filter.Status != "all" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.CreatedAfter != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		query = query.Where("created_at < ?", *filter.CreatedBefore)
+	}
+
+	err := query.Order("created_at ASC").Limit(limit).Pluck("id", &ids).Error
+	return ids, err
+}
+
+// FindFileIDs 返回模型封面、各版本封面与图集引用的文件 ID
+func (r *ModelRepository) FindFileIDs(ctx context.Context, id uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.DB.WithContext(ctx).Raw(`
+		SELECT image_id FROM models WHERE id = ? AND image_id IS NOT NULL
+		UNION SELECT image_id FROM model_versions WHERE model_id = ? AND image_id IS NOT NULL
+		UNION SELECT file_id FROM model_images WHERE model_id = ?`,
+		id, id, id).Scan(&ids).Error
+	return ids, err
+}
+
+// DeleteWithDependents 删除模型及其收藏、图集、版本与引用的图片文件，调用方负责删除磁盘上的模型文件
+func (r *ModelRepository) DeleteWithDependents(ctx context.Context, id uuid.UUID) error {
+	fileIDs, err := r.FindFileIDs(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := r.ClearReferences(ctx, id); err != nil {
+		return err
+	}
+	if err := r.DB.WithContext(ctx).Where("model_id = ?", id).Delete(&model.Favorite{}).Error; err != nil {
+		return err
+	}
+	if err := r.DB.WithContext(ctx).Where("model_id = ?", id).Delete(&model.ModelImage{}).Error; err != nil {
+		return err
+	}
+	if err := r.DB.WithContext(ctx).Where("model_id = ?", id).Delete(&model.ModelVersion{}).Error; err != nil {
+		return err
+	}
+	if err := r.Delete(ctx, id); err != nil {
+		return err
+	}
+	if len(fileIDs) == 0 {
+		return nil
+	}
+	return r.DB.WithContext(ctx).Where("id IN ?", fileIDs).Delete(&model.File{}).Error
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"github.com/google/uuid"
 	"github.com/ysmmc/backend/internal/database"
 	"github.com/ysmmc/backend/internal/model"
@@ -12,62 +13,62 @@ func NewModelVersionRepository() *ModelVersionRepository {
 	return &ModelVersionRepository{}
 }
 
-func (r *ModelVersionRepository) Create(version *model.ModelVersion) error {
-	return database.DB.Create(version).Error
+func (r *ModelVersionRepository) Create(ctx context.Context, version *model.ModelVersion) error {
+	return database.DB.WithContext(ctx).Create(version).Error
 }
 
-func (r *ModelVersionRepository) FindByID(id uuid.UUID) (*model.ModelVersion, error) {
+func (r *ModelVersionRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.ModelVersion, error) {
 	var version model.ModelVersion
-	err := database.DB.First(&version, "id = ?", id).Error
+	err := database.DB.WithContext(ctx).First(&version, "id = ?", id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &version, nil
 }
 
-func (r *ModelVersionRepository) FindByIDWithModel(id uuid.UUID) (*model.ModelVersion, error) {
+func (r *ModelVersionRepository) FindByIDWithModel(ctx context.Context, id uuid.UUID) (*model.ModelVersion, error) {
 	var version model.ModelVersion
-	err := database.DB.Preload("Model").Preload("Model.User").First(&version, "id = ?", id).Error
+	err := database.DB.WithContext(ctx).Preload("Model").Preload("Model.User").First(&version, "id = ?", id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &version, nil
 }
 
-func (r *ModelVersionRepository) FindByModelID(modelID uuid.UUID) ([]model.ModelVersion, error) {
+func (r *ModelVersionRepository) FindByModelID(ctx context.Context, modelID uuid.UUID) ([]model.ModelVersion, error) {
 	var versions []model.ModelVersion
-	err := database.DB.Where("model_id = ?", modelID).Order("created_at DESC").Find(&versions).Error
+	err := database.DB.WithContext(ctx).Where("model_id = ?", modelID).Order("created_at DESC").Find(&versions).Error
 	return versions, err
 }
 
-func (r *ModelVersionRepository) FindCurrentVersion(modelID uuid.UUID) (*model.ModelVersion, error) {
+func (r *ModelVersionRepository) FindCurrentVersion(ctx context.Context, modelID uuid.UUID) (*model.ModelVersion, error) {
 	var version model.ModelVersion
-	err := database.DB.Where("model_id = ? AND is_current = ?", modelID, true).First(&version).Error
+	err := database.DB.WithContext(ctx).Where("model_id = ? AND is_current = ?", modelID, true).First(&version).Error
 	if err != nil {
 		return nil, err
 	}
 	return &version, nil
 }
 
-func (r *ModelVersionRepository) FindByModelIDAndVersion(modelID uuid.UUID, versionNumber string) (*model.ModelVersion, error) {
+func (r *ModelVersionRepository) FindByModelIDAndVersion(ctx context.Context, modelID uuid.UUID, versionNumber string) (*model.ModelVersion, error) {
 	var version model.ModelVersion
-	err := database.DB.Where("model_id = ? AND version_number = ?", modelID, versionNumber).First(&version).Error
+	err := database.DB.WithContext(ctx).Where("model_id = ? AND version_number = ?", modelID, versionNumber).First(&version).Error
 	if err != nil {
 		return nil, err
 	}
 	return &version, nil
 }
 
-func (r *ModelVersionRepository) Update(version *model.ModelVersion) error {
-	return database.DB.Save(version).Error
+func (r *ModelVersionRepository) Update(ctx context.Context, version *model.ModelVersion) error {
+	return database.DB.WithContext(ctx).Save(version).Error
 }
 
-func (r *ModelVersionRepository) Delete(id uuid.UUID) error {
-	return database.DB.Delete(&model.ModelVersion{}, "id = ?", id).Error
+func (r *ModelVersionRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return database.DB.WithContext(ctx).Delete(&model.ModelVersion{}, "id = ?", id).Error
 }
 
-func (r *ModelVersionRepository) SetCurrentVersion(modelID, versionID uuid.UUID) error {
-	tx := database.DB.Begin()
+func (r *ModelVersionRepository) SetCurrentVersion(ctx context.Context, modelID, versionID uuid.UUID) error {
+	tx := database.DB.WithContext(ctx).Begin()
 	defer func() {
 		if r := recover(); r != nil {
 			tx.Rollback()
@@ -92,26 +93,26 @@ func (r *ModelVersionRepository) SetCurrentVersion(modelID, versionID uuid.UUID)
 	return tx.Commit().Error
 }
 
-func (r *ModelVersionRepository) IncrementDownloads(versionID uuid.UUID) error {
-	return database.DB.Model(&model.ModelVersion{}).Where("id = ?", versionID).UpdateColumn("downloads", database.DB.Raw("downloads + 1")).Error
+func (r *ModelVersionRepository) IncrementDownloads(ctx context.Context, versionID uuid.UUID) error {
+	return database.DB.WithContext(ctx).Model(&model.ModelVersion{}).Where("id = ?", versionID).UpdateColumn("downloads", database.DB.WithContext(ctx).Raw("downloads + 1")).Error
 }
 
-func (r *ModelVersionRepository) CountByModelID(modelID uuid.UUID) (int64, error) {
+func (r *ModelVersionRepository) CountByModelID(ctx context.Context, modelID uuid.UUID) (int64, error) {
 	var count int64
-	err := database.DB.Model(&model.ModelVersion{}).Where("model_id = ?", modelID).Count(&count).Error
+	err := database.DB.WithContext(ctx).Model(&model.ModelVersion{}).Where("model_id = ?", modelID).Count(&count).Error
 	return count, err
 }
 
-func (r *ModelVersionRepository) DeleteByModelID(modelID uuid.UUID) error {
-	return database.DB.Where("model_id = ?", modelID).Delete(&model.ModelVersion{}).Error
+func (r *ModelVersionRepository) DeleteByModelID(ctx context.Context, modelID uuid.UUID) error {
+	return database.DB.WithContext(ctx).Where("model_id = ?", modelID).Delete(&model.ModelVersion{}).Error
 }
 
-func (r *ModelVersionRepository) ClearImageReferences(modelID uuid.UUID) error {
-	return database.DB.Model(&model.ModelVersion{}).Where("model_id = ?", modelID).Update("image_id", nil).Error
+func (r *ModelVersionRepository) ClearImageReferences(ctx context.Context, modelID uuid.UUID) error {
+	return database.DB.WithContext(ctx).Model(&model.ModelVersion{}).Where("model_id = ?", modelID).Update("image_id", nil).Error
 }
 
-func (r *ModelVersionRepository) ExistsByVersion(modelID uuid.UUID, versionNumber string) (bool, error) {
+func (r *ModelVersionRepository) ExistsByVersion(ctx context.Context, modelID uuid.UUID, versionNumber string) (bool, error) {
 	var count int64
-	err := database.DB.Model(&model.ModelVersion{}).Where("model_id = ? AND version_number = ?", modelID, versionNumber).Count(&count).Error
+	err := database.DB.WithContext(ctx).Model(&model.ModelVersion{}).Where("model_id = ? AND version_number = ?", modelID, versionNumber).Count(&count).Error
 	return count > 0, err
 }
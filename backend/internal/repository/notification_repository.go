@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ysmmc/backend/internal/database"
+	"github.com/ysmmc/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+type NotificationRepository struct {
+	DB *gorm.DB
+}
+
+func NewNotificationRepository() *NotificationRepository {
+	return &NotificationRepository{DB: database.DB}
+}
+
+func (r *NotificationRepository) Create(ctx context.Context, notification *model.Notification) error {
+	return r.DB.WithContext(ctx).Create(notification).Error
+}
+
+func (r *NotificationRepository) ListByUserID(ctx context.Context, userID uuid.UUID, page, pageSize int, unreadOnly bool) ([]model.Notification, int64, error) {
+	var notifications []model.Notification
+	var total int64
+
+	query := r.DB.WithContext(ctx).Model(&model.Notification{}).Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	query.Count(&total)
+
+	offset := (page - 1) * pageSize
+	err := query.Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&notifications).Error
+	return notifications, total, err
+}
+
+func (r *NotificationRepository) CountUnread(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.DB.WithContext(ctx).Model(&model.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+func (r *NotificationRepository) MarkRead(ctx context.Context, id, userID uuid.UUID) (bool, error) {
+	result := r.DB.WithContext(ctx).Model(&model.Notification{}).
+		Where("id = ? AND user_id = ? AND read_at IS NULL", id, userID).
+		Update("read_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *NotificationRepository) MarkAllRead(ctx context.Context, userID uuid.UUID) error {
+	return r.DB.WithContext(ctx).Model(&model.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", time.Now()).Error
+}
+
+// ListSince 用户在 since 之后收到的指定类型通知，按时间顺序，最多 limit 条
+func (r *NotificationRepository) ListSince(ctx context.Context, userID uuid.UUID, types []string, since time.Time, limit int) ([]model.Notification, error) {
+	var notifications []model.Notification
+	err := r.DB.WithContext(ctx).Where("user_id = ? AND type IN ? AND created_at > ?", userID, types, since).
+		Order("created_at ASC").Limit(limit).Find(&notifications).Error
+	return notifications, err
+}
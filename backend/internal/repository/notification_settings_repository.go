@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ysmmc/backend/internal/database"
+	"github.com/ysmmc/backend/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotificationSettingsRepository struct {
+	DB *gorm.DB
+}
+
+func NewNotificationSettingsRepository() *NotificationSettingsRepository {
+	return &NotificationSettingsRepository{DB: database.DB}
+}
+
+func (r *NotificationSettingsRepository) FindByUserID(ctx context.Context, userID uuid.UUID) (*model.NotificationSettings, error) {
+	var settings model.NotificationSettings
+	err := r.DB.WithContext(ctx).Where("user_id = ?", userID).First(&settings).Error
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// Save 写入用户的通知偏好，已有记录时覆盖偏好与摘要频率
+func (r *NotificationSettingsRepository) Save(ctx context.Context, settings *model.NotificationSettings) error {
+	return r.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"preferences", "digest", "updated_at"}),
+	}).Create(settings).Error
+}
+
+// ListByDigest 开启了指定频率摘要的用户
+func (r *NotificationSettingsRepository) ListByDigest(ctx context.Context, frequency string) ([]model.NotificationSettings, error) {
+	var settings []model.NotificationSettings
+	err := r.DB.WithContext(ctx).Where("digest = ?", frequency).Order("user_id").Find(&settings).Error
+	return settings, err
+}
+
+func (r *NotificationSettingsRepository) SetLastDigestAt(ctx context.Context, userID uuid.UUID, at time.Time) error {
+	return r.DB.WithContext(ctx).Model(&model.NotificationSettings{}).Where("user_id = ?", userID).Update("last_digest_at", at).Error
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/ysmmc/backend/internal/database"
+	"github.com/ysmmc/backend/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReportRepository struct {
+	DB *gorm.DB
+}
+
+func NewReportRepository() *ReportRepository {
+	return &ReportRepository{DB: database.DB}
+}
+
+func (r *ReportRepository) Create(ctx context.Context, report *model.Report) error {
+	return r.DB.WithContext(ctx).Create(report).Error
+}
+
+func (r *ReportRepository) Update(ctx context.Context, report *model.Report) error {
+	return r.DB.WithContext(ctx).Omit("Entries").Save(report).Error
+}
+
+func (r *ReportRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.Report, error) {
+	var report model.Report
+	err := r.DB.WithContext(ctx).Preload("Entries", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at ASC")
+	}).Preload("Entries.Reporter").First(&report, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// FindOrCreateOpenForUpdate 返回目标的未处理举报并加行锁，不存在时创建。
+// 依赖 idx_reports_open_target 唯一索引：并发的首次举报中只有一个插入成功，其余等待其提交后读取同一条记录
+func (r *ReportRepository) FindOrCreateOpenForUpdate(ctx context.Context, targetType string, targetID uuid.UUID, category string) (*model.Report, error) {
+	report := &model.Report{
+		TargetType: targetType,
+		TargetID:   targetID,
+		Category:   category,
+		Status:     "open",
+	}
+	err := r.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:     []clause.Column{{Name: "target_type"}, {Name: "target_id"}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "status = 'open'"}}},
+		DoNothing:   true,
+	}).Create(report).Error
+	if err != nil {
+		return nil, err
+	}
+	return r.FindOpenByTargetForUpdate(ctx, targetType, targetID)
+}
+
+func (r *ReportRepository) FindOpenByTargetForUpdate(ctx context.Context, targetType string, targetID uuid.UUID) (*model.Report, error) {
+	var report model.Report
+	err := r.DB.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("target_type = ? AND target_id = ? AND status = ?", targetType, targetID, "open").
+		First(&report).Error
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+func (r *ReportRepository) CreateEntry(ctx context.Context, entry *model.ReportEntry) error {
+	return r.DB.WithContext(ctx).Create(entry).Error
+}
+
+func (r *ReportRepository) EntryExists(ctx context.Context, reportID, reporterID uuid.UUID) bool {
+	var count int64
+	r.DB.WithContext(ctx).Model(&model.ReportEntry{}).Where("report_id = ? AND reporter_id = ?", reportID, reporterID).Count(&count)
+	return count > 0
+}
+
+func (r *ReportRepository) List(ctx context.Context, page, pageSize int, status, targetType string) ([]model.Report, int64, error) {
+	var reports []model.Report
+	var total int64
+
+	query := r.DB.WithContext(ctx).Model(&model.Report{})
+	if status != "" && status != "all" {
+		query = query.Where("status = ?", status)
+	}
+	if targetType != "" {
+		query = query.Where("target_type = ?", targetType)
+	}
+	query.Count(&total)
+
+	offset := (page - 1) * pageSize
+	err := query.Offset(offset).Limit(pageSize).Order("report_count DESC, created_at ASC").Find(&reports).Error
+	return reports, total, err
+}
+
+func (r *ReportRepository) CountByStatus(ctx context.Context, status string) (int64, error) {
+	var count int64
+	err := r.DB.WithContext(ctx).Model(&model.Report{}).Where("status = ?", status).Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"github.com/ysmmc/backend/internal/database"
+	"github.com/ysmmc/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+type RoleRepository struct {
+	DB *gorm.DB
+}
+
+func NewRoleRepository() *RoleRepository {
+	return &RoleRepository{DB: database.DB}
+}
+
+func (r *RoleRepository) Create(ctx context.Context, role *model.Role) error {
+	return r.DB.WithContext(ctx).Create(role).Error
+}
+
+func (r *RoleRepository) Update(ctx context.Context, role *model.Role) error {
+	return r.DB.WithContext(ctx).Save(role).Error
+}
+
+func (r *RoleRepository) Delete(ctx context.Context, name string) error {
+	return r.DB.WithContext(ctx).Delete(&model.Role{}, "name = ?", name).Error
+}
+
+func (r *RoleRepository) FindByName(ctx context.Context, name string) (*model.Role, error) {
+	var role model.Role
+	err := r.DB.WithContext(ctx).First(&role, "name = ?", name).Error
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *RoleRepository) List(ctx context.Context) ([]model.Role, error) {
+	var roles []model.Role
+	err := r.DB.WithContext(ctx).Order("created_at ASC, name ASC").Find(&roles).Error
+	return roles, err
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ysmmc/backend/internal/database"
+	"github.com/ysmmc/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+type SanctionRepository struct {
+	DB *gorm.DB
+}
+
+func NewSanctionRepository() *SanctionRepository {
+	return &SanctionRepository{DB: database.DB}
+}
+
+func (r *SanctionRepository) Create(ctx context.Context, sanction *model.UserSanction) error {
+	return r.DB.WithContext(ctx).Create(sanction).Error
+}
+
+func (r *SanctionRepository) Update(ctx context.Context, sanction *model.UserSanction) error {
+	return r.DB.WithContext(ctx).Save(sanction).Error
+}
+
+func (r *SanctionRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.UserSanction, error) {
+	var sanction model.UserSanction
+	err := r.DB.WithContext(ctx).First(&sanction, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &sanction, nil
+}
+
+func (r *SanctionRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]model.UserSanction, error) {
+	var sanctions []model.UserSanction
+	err := r.DB.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC").Find(&sanctions).Error
+	return sanctions, err
+}
+
+func (r *SanctionRepository) ListActive(ctx context.Context, userID uuid.UUID) ([]model.UserSanction, error) {
+	var sanctions []model.UserSanction
+	err := r.activeQuery(ctx, userID).Order("created_at DESC").Find(&sanctions).Error
+	return sanctions, err
+}
+
+func (r *SanctionRepository) FindActive(ctx context.Context, userID uuid.UUID, sanctionType string) (*model.UserSanction, error) {
+	var sanction model.UserSanction
+	err := r.activeQuery(ctx, userID).Where("type = ?", sanctionType).Order("expires_at DESC NULLS FIRST").First(&sanction).Error
+	if err != nil {
+		return nil, err
+	}
+	return &sanction, nil
+}
+
+func (r *SanctionRepository) RevokeActive(ctx context.Context, userID uuid.UUID, sanctionType string, revokedBy *uuid.UUID) error {
+	return r.activeQuery(ctx, userID).Where("type = ?", sanctionType).Updates(map[string]interface{}{
+		"revoked_at": time.Now(),
+		"revoked_by": revokedBy,
+	}).Error
+}
+
+func (r *SanctionRepository) activeQuery(ctx context.Context, userID uuid.UUID) *gorm.DB {
+	return r.DB.WithContext(ctx).Model(&model.UserSanction{}).
+		Where("user_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", userID, time.Now())
+}
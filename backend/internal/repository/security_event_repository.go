@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/ysmmc/backend/internal/database"
+	"github.com/ysmmc/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+type SecurityEventRepository struct {
+	DB *gorm.DB
+}
+
+func NewSecurityEventRepository() *SecurityEventRepository {
+	return &SecurityEventRepository{DB: database.DB}
+}
+
+func (r *SecurityEventRepository) Create(ctx context.Context, event *model.SecurityEvent) error {
+	return r.DB.WithContext(ctx).Create(event).Error
+}
+
+func (r *SecurityEventRepository) List(ctx context.Context, page, pageSize int, userID *uuid.UUID, eventType string) ([]model.SecurityEvent, int64, error) {
+	var events []model.SecurityEvent
+	var total int64
+
+	query := r.DB.WithContext(ctx).Model(&model.SecurityEvent{})
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	}
+	if eventType != "" {
+		query = query.Where("type = ?", eventType)
+	}
+	query.Count(&total)
+
+	offset := (page - 1) * pageSize
+	err := query.Preload("User").Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&events).Error
+	return events, total, err
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"time"
@@ -9,6 +10,7 @@ import (
 	"github.com/ysmmc/backend/internal/database"
 	"github.com/ysmmc/backend/internal/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type SessionRepository struct {
@@ -19,29 +21,103 @@ func NewSessionRepository() *SessionRepository {
 	return &SessionRepository{DB: database.DB}
 }
 
-func (r *SessionRepository) Create(session *model.Session) error {
-	return r.DB.Create(session).Error
+func (r *SessionRepository) Create(ctx context.Context, session *model.Session) error {
+	return r.DB.WithContext(ctx).Create(session).Error
 }
 
-func (r *SessionRepository) FindByTokenHash(tokenHash string) (*model.Session, error) {
+func (r *SessionRepository) FindByTokenHash(ctx context.Context, tokenHash string) (*model.Session, error) {
 	var session model.Session
-	err := r.DB.Where("token_hash = ?", tokenHash).First(&session).Error
+	err := r.DB.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&session).Error
 	if err != nil {
 		return nil, err
 	}
 	return &session, nil
 }
 
-func (r *SessionRepository) DeleteByTokenHash(tokenHash string) error {
-	return r.DB.Where("token_hash = ?", tokenHash).Delete(&model.Session{}).Error
+func (r *SessionRepository) FindByTokenHashForUpdate(ctx context.Context, tokenHash string) (*model.Session, error) {
+	var session model.Session
+	err := r.DB.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("token_hash = ?", tokenHash).First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *SessionRepository) Update(ctx context.Context, session *model.Session) error {
+	return r.DB.WithContext(ctx).Save(session).Error
+}
+
+func (r *SessionRepository) ListActiveByUserID(ctx context.Context, userID uuid.UUID) ([]model.Session, error) {
+	var sessions []model.Session
+	err := r.DB.WithContext(ctx).Where("user_id = ? AND expires_at > ?", userID, time.Now()).
+		Order("COALESCE(last_used_at, created_at) DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// IsActive 判断会话是否存在且未过期，只读
+func (r *SessionRepository) IsActive(ctx context.Context, id uuid.UUID, now time.Time) (bool, error) {
+	var count int64
+	err := r.DB.WithContext(ctx).Model(&model.Session{}).
+		Where("id = ? AND expires_at > ?", id, now).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// Touch 更新会话最近使用时间
+func (r *SessionRepository) Touch(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	return r.DB.WithContext(ctx).Model(&model.Session{}).
+		Where("id = ?", id).
+		UpdateColumn("last_used_at", usedAt).Error
+}
+
+func (r *SessionRepository) DeleteByIDAndUserID(ctx context.Context, id, userID uuid.UUID) (bool, error) {
+	result := r.DB.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).Delete(&model.Session{})
+	return result.RowsAffected > 0, result.Error
 }
 
-func (r *SessionRepository) DeleteByUserID(userID uuid.UUID) error {
-	return r.DB.Where("user_id = ?", userID).Delete(&model.Session{}).Error
+// DeleteOthers 删除用户除指定会话外的所有会话，返回被删除的会话 ID
+func (r *SessionRepository) DeleteOthers(ctx context.Context, userID, keepID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.DB.WithContext(ctx).Model(&model.Session{}).Where("user_id = ? AND id <> ?", userID, keepID).Pluck("id", &ids).Error
+	if err != nil || len(ids) == 0 {
+		return ids, err
+	}
+	return ids, r.DB.WithContext(ctx).Where("id IN ?", ids).Delete(&model.Session{}).Error
+}
+
+func (r *SessionRepository) DeleteByTokenHash(ctx context.Context, tokenHash string) error {
+	return r.DB.WithContext(ctx).Where("token_hash = ?", tokenHash).Delete(&model.Session{}).Error
+}
+
+func (r *SessionRepository) DeleteByUserID(ctx context.Context, userID uuid.UUID) error {
+	return r.DB.WithContext(ctx).Where("user_id = ?", userID).Delete(&model.Session{}).Error
+}
+
+func (r *SessionRepository) DeleteByID(ctx context.Context, id uuid.UUID) (bool, error) {
+	result := r.DB.WithContext(ctx).Where("id = ?", id).Delete(&model.Session{})
+	return result.RowsAffected > 0, result.Error
 }
 
-func (r *SessionRepository) DeleteExpired() error {
-	return r.DB.Where("expires_at < ?", time.Now()).Delete(&model.Session{}).Error
+func (r *SessionRepository) DeleteExpired(ctx context.Context) error {
+	now := time.Now()
+	if err := r.DB.WithContext(ctx).Where("expires_at < ?", now).Delete(&model.ConsumedRefreshToken{}).Error; err != nil {
+		return err
+	}
+	return r.DB.WithContext(ctx).Where("expires_at < ?", now).Delete(&model.Session{}).Error
+}
+
+func (r *SessionRepository) MarkConsumed(ctx context.Context, consumed *model.ConsumedRefreshToken) error {
+	return r.DB.WithContext(ctx).Create(consumed).Error
+}
+
+func (r *SessionRepository) FindConsumed(ctx context.Context, tokenHash string) (*model.ConsumedRefreshToken, error) {
+	var consumed model.ConsumedRefreshToken
+	err := r.DB.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&consumed).Error
+	if err != nil {
+		return nil, err
+	}
+	return &consumed, nil
 }
 
 func HashToken(token string) string {
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"github.com/ysmmc/backend/internal/database"
+	"github.com/ysmmc/backend/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SettingRepository struct {
+	DB *gorm.DB
+}
+
+func NewSettingRepository() *SettingRepository {
+	return &SettingRepository{DB: database.DB}
+}
+
+func (r *SettingRepository) Get(ctx context.Context, key string) (*model.Setting, error) {
+	var setting model.Setting
+	err := r.DB.WithContext(ctx).Where("key = ?", key).First(&setting).Error
+	if err != nil {
+		return nil, err
+	}
+	return &setting, nil
+}
+
+func (r *SettingRepository) Upsert(ctx context.Context, setting *model.Setting) error {
+	return r.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "updated_by", "updated_at"}),
+	}).Create(setting).Error
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ysmmc/backend/internal/database"
+	"github.com/ysmmc/backend/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UserIdentityRepository struct {
+	DB *gorm.DB
+}
+
+func NewUserIdentityRepository() *UserIdentityRepository {
+	return &UserIdentityRepository{DB: database.DB}
+}
+
+func (r *UserIdentityRepository) Create(ctx context.Context, identity *model.UserIdentity) error {
+	return r.DB.WithContext(ctx).Create(identity).Error
+}
+
+func (r *UserIdentityRepository) FindByProviderSubject(ctx context.Context, provider, subject string) (*model.UserIdentity, error) {
+	var identity model.UserIdentity
+	err := r.DB.WithContext(ctx).Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *UserIdentityRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]model.UserIdentity, error) {
+	var identities []model.UserIdentity
+	err := r.DB.WithContext(ctx).Where("user_id = ?", userID).Order("created_at ASC").Find(&identities).Error
+	return identities, err
+}
+
+func (r *UserIdentityRepository) CountByUserID(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.DB.WithContext(ctx).Model(&model.UserIdentity{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+func (r *UserIdentityRepository) TouchLogin(ctx context.Context, id uuid.UUID, email string, at time.Time) error {
+	return r.DB.WithContext(ctx).Model(&model.UserIdentity{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"email": email, "last_login_at": at}).Error
+}
+
+func (r *UserIdentityRepository) DeleteByIDAndUserID(ctx context.Context, id, userID uuid.UUID) (bool, error) {
+	result := r.DB.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).Delete(&model.UserIdentity{})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *UserIdentityRepository) CreateState(ctx context.Context, state *model.OIDCState) error {
+	return r.DB.WithContext(ctx).Create(state).Error
+}
+
+// ConsumeState 删除并返回未过期的登录请求，保证每个 state 只能使用一次
+func (r *UserIdentityRepository) ConsumeState(ctx context.Context, stateHash string) (*model.OIDCState, error) {
+	var states []model.OIDCState
+	err := r.DB.WithContext(ctx).Clauses(clause.Returning{}).
+		Where("state_hash = ? AND expires_at > ?", stateHash, time.Now()).
+		Delete(&states).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(states) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &states[0], nil
+}
+
+func (r *UserIdentityRepository) DeleteExpiredStates(ctx context.Context) error {
+	return r.DB.WithContext(ctx).Where("expires_at < ?", time.Now()).Delete(&model.OIDCState{}).Error
+}
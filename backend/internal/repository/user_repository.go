@@ -1,6 +1,9 @@
 package repository
 
 import (
+	"context"
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/ysmmc/backend/internal/database"
 	"github.com/ysmmc/backend/internal/model"
@@ -15,88 +18,202 @@ func NewUserRepository() *UserRepository {
 	return &UserRepository{DB: database.DB}
 }
 
-func (r *UserRepository) Create(user *model.User) error {
-	return r.DB.Create(user).Error
+func (r *UserRepository) Create(ctx context.Context, user *model.User) error {
+	return r.DB.WithContext(ctx).Create(user).Error
 }
 
-func (r *UserRepository) FindByID(id uuid.UUID) (*model.User, error) {
+func (r *UserRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
 	var user model.User
-	err := r.DB.First(&user, "id = ?", id).Error
+	err := r.DB.WithContext(ctx).First(&user, "id = ?", id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *UserRepository) FindByEmail(email string) (*model.User, error) {
+func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*model.User, error) {
 	var user model.User
-	err := r.DB.Where("email = ?", email).First(&user).Error
+	err := r.DB.WithContext(ctx).Where("email = ?", email).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *UserRepository) FindByUsername(username string) (*model.User, error) {
+func (r *UserRepository) FindByUsername(ctx context.Context, username string) (*model.User, error) {
 	var user model.User
-	err := r.DB.Where("username = ?", username).First(&user).Error
+	err := r.DB.WithContext(ctx).Where("username = ?", username).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *UserRepository) Update(user *model.User) error {
-	return r.DB.Save(user).Error
+func (r *UserRepository) Update(ctx context.Context, user *model.User) error {
+	return r.DB.WithContext(ctx).Omit("token_version", "totp_last_step", "failed_login_count", "last_failed_login_at", "locked_until",
+		"deletion_scheduled_at", "deletion_model_action").Save(user).Error
+}
+
+func (r *UserRepository) GetTokenVersion(ctx context.Context, id uuid.UUID) (int, error) {
+	var version int
+	err := r.DB.WithContext(ctx).Model(&model.User{}).Where("id = ?", id).Select("token_version").Row().Scan(&version)
+	return version, err
+}
+
+func (r *UserRepository) IncrementTokenVersion(ctx context.Context, id uuid.UUID) error {
+	return r.DB.WithContext(ctx).Model(&model.User{}).Where("id = ?", id).UpdateColumn("token_version", gorm.Expr("token_version + 1")).Error
+}
+
+// RecordFailedLogin 原子地累加连续失败次数并返回新值；距上次失败超过 window 时重新计数
+func (r *UserRepository) RecordFailedLogin(ctx context.Context, id uuid.UUID, now time.Time, window time.Duration) (int, error) {
+	var count int
+	err := r.DB.WithContext(ctx).Raw(`UPDATE users SET
+		failed_login_count = CASE WHEN last_failed_login_at IS NULL OR last_failed_login_at < ? THEN 1 ELSE failed_login_count + 1 END,
+		last_failed_login_at = ?
+		WHERE id = ? RETURNING failed_login_count`, now.Add(-window), now, id).Scan(&count).Error
+	return count, err
+}
+
+func (r *UserRepository) SetLockedUntil(ctx context.Context, id uuid.UUID, until time.Time) error {
+	return r.DB.WithContext(ctx).Model(&model.User{}).Where("id = ?", id).UpdateColumn("locked_until", until).Error
+}
+
+// ResetFailedLogins 清除失败计数与锁定状态
+func (r *UserRepository) ResetFailedLogins(ctx context.Context, id uuid.UUID) error {
+	return r.DB.WithContext(ctx).Model(&model.User{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+		"failed_login_count":   0,
+		"last_failed_login_at": nil,
+		"locked_until":         nil,
+	}).Error
+}
+
+// ScheduleDeletion 标记账号在 at 时刻注销；已处于注销等待期时返回 false
+func (r *UserRepository) ScheduleDeletion(ctx context.Context, id uuid.UUID, at time.Time, modelAction string) (bool, error) {
+	result := r.DB.WithContext(ctx).Model(&model.User{}).Where("id = ? AND deletion_scheduled_at IS NULL", id).UpdateColumns(map[string]interface{}{
+		"deletion_scheduled_at": at,
+		"deletion_model_action": modelAction,
+	})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *UserRepository) CancelDeletion(ctx context.Context, id uuid.UUID) (bool, error) {
+	result := r.DB.WithContext(ctx).Model(&model.User{}).Where("id = ? AND deletion_scheduled_at IS NOT NULL", id).UpdateColumns(map[string]interface{}{
+		"deletion_scheduled_at": nil,
+		"deletion_model_action": "",
+	})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *UserRepository) ListDueForDeletion(ctx context.Context, now time.Time, limit int) ([]model.User, error) {
+	var users []model.User
+	err := r.DB.WithContext(ctx).Where("deletion_scheduled_at <= ?", now).Order("deletion_scheduled_at ASC").Limit(limit).Find(&users).Error
+	return users, err
+}
+
+// DeleteWithDependents 删除账号及其个人数据。调用前需先处理该用户发布的模型；
+// 审计记录与仍被引用的图片转交给 heirID（注销用户占位账号），安全事件保留但解除关联
+func (r *UserRepository) DeleteWithDependents(ctx context.Context, id, heirID uuid.UUID) error {
+	personal := []interface{}{
+		&model.Favorite{}, &model.Session{}, &model.ConsumedRefreshToken{}, &model.APIToken{},
+		&model.RecoveryCode{}, &model.Notification{}, &model.UserIdentity{}, &model.OIDCState{},
+		&model.UserSanction{}, &model.DataExport{}, &model.NotificationSettings{}, &model.EmailOutbox{},
+	}
+	for _, m := range personal {
+		if err := r.DB.WithContext(ctx).Where("user_id = ?", id).Delete(m).Error; err != nil {
+			return err
+		}
+	}
+	if err := r.DB.WithContext(ctx).Where("reporter_id = ?", id).Delete(&model.ReportEntry{}).Error; err != nil {
+		return err
+	}
+
+	if err := r.DB.WithContext(ctx).Exec(`UPDATE users SET invited_by = NULL, invite_code_id = NULL
+		WHERE invited_by = ? OR invite_code_id IN (SELECT id FROM invite_codes WHERE created_by = ?)`, id, id).Error; err != nil {
+		return err
+	}
+	if err := r.DB.WithContext(ctx).Where("created_by = ?", id).Delete(&model.InviteCode{}).Error; err != nil {
+		return err
+	}
+
+	updates := []struct {
+		table, column string
+		value         interface{}
+	}{
+		{"user_sanctions", "issued_by", nil},
+		{"user_sanctions", "revoked_by", nil},
+		{"reports", "resolved_by", nil},
+		{"settings", "updated_by", nil},
+		{"security_events", "user_id", nil},
+		{"admin_actions", "admin_id", heirID},
+	}
+	for _, u := range updates {
+		if err := r.DB.WithContext(ctx).Table(u.table).Where(u.column+" = ?", id).UpdateColumn(u.column, u.value).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := r.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	// 仍被模型或其他账号引用的图片转交占位账号，其余一并删除
+	if err := r.DB.WithContext(ctx).Exec(`UPDATE files SET user_id = ? WHERE user_id = ? AND (
+		id IN (SELECT image_id FROM models WHERE image_id IS NOT NULL) OR
+		id IN (SELECT image_id FROM model_versions WHERE image_id IS NOT NULL) OR
+		id IN (SELECT file_id FROM model_images) OR
+		id IN (SELECT avatar_id FROM users WHERE avatar_id IS NOT NULL))`, heirID, id).Error; err != nil {
+		return err
+	}
+	return r.DB.WithContext(ctx).Where("user_id = ?", id).Delete(&model.File{}).Error
 }
 
-func (r *UserRepository) Delete(id uuid.UUID) error {
-	return r.DB.Delete(&model.User{}, "id = ?", id).Error
+func (r *UserRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.DB.WithContext(ctx).Delete(&model.User{}, "id = ?", id).Error
 }
 
-func (r *UserRepository) List(page, pageSize int) ([]model.User, int64, error) {
+func (r *UserRepository) List(ctx context.Context, page, pageSize int) ([]model.User, int64, error) {
 	var users []model.User
 	var total int64
 
-	r.DB.Model(&model.User{}).Count(&total)
+	r.DB.WithContext(ctx).Model(&model.User{}).Count(&total)
 
 	offset := (page - 1) * pageSize
-	err := r.DB.Offset(offset).Limit(pageSize).Order("created_at DESC").Find(&users).Error
+	err := r.DB.WithContext(ctx).Offset(offset).Limit(pageSize).Order("created_at DESC").Find(&users).Error
 	return users, total, err
 }
 
-func (r *UserRepository) FindByResetToken(token string) (*model.User, error) {
+func (r *UserRepository) FindByResetToken(ctx context.Context, token string) (*model.User, error) {
 	var user model.User
-	err := r.DB.Where("reset_token = ?", token).First(&user).Error
+	err := r.DB.WithContext(ctx).Where("reset_token = ?", token).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *UserRepository) FindByVerificationToken(token string) (*model.User, error) {
+func (r *UserRepository) FindByVerificationToken(ctx context.Context, token string) (*model.User, error) {
 	var user model.User
-	err := r.DB.Where("verification_token = ?", token).First(&user).Error
+	err := r.DB.WithContext(ctx).Where("verification_token = ?", token).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *UserRepository) FindByEmailChangeToken(token string) (*model.User, error) {
+func (r *UserRepository) FindByEmailChangeToken(ctx context.Context, token string) (*model.User, error) {
 	var user model.User
-	err := r.DB.Where("email_change_token = ?", token).First(&user).Error
+	err := r.DB.WithContext(ctx).Where("email_change_token = ?", token).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *UserRepository) ListPendingProfiles(page, pageSize int) ([]model.User, int64, error) {
+func (r *UserRepository) ListPendingProfiles(ctx context.Context, page, pageSize int) ([]model.User, int64, error) {
 	var users []model.User
 	var total int64
 
-	query := r.DB.Model(&model.User{}).Where("profile_status = ?", "pending_review")
+	query := r.DB.WithContext(ctx).Model(&model.User{}).Where("profile_status = ?", "pending_review")
 	query.Count(&total)
 
 	offset := (page - 1) * pageSize
@@ -104,11 +221,11 @@ func (r *UserRepository) ListPendingProfiles(page, pageSize int) ([]model.User,
 	return users, total, err
 }
 
-func (r *UserRepository) ListByRole(role string, page, pageSize int) ([]model.User, int64, error) {
+func (r *UserRepository) ListByRole(ctx context.Context, role string, page, pageSize int) ([]model.User, int64, error) {
 	var users []model.User
 	var total int64
 
-	query := r.DB.Model(&model.User{}).Where("role = ?", role)
+	query := r.DB.WithContext(ctx).Model(&model.User{}).Where("role = ?", role)
 	query.Count(&total)
 
 	offset := (page - 1) * pageSize
@@ -116,30 +233,60 @@ func (r *UserRepository) ListByRole(role string, page, pageSize int) ([]model.Us
 	return users, total, err
 }
 
-func (r *UserRepository) ExistsByEmail(email string) bool {
+func (r *UserRepository) ExistsByEmail(ctx context.Context, email string) bool {
 	var count int64
-	r.DB.Model(&model.User{}).Where("email = ?", email).Count(&count)
+	r.DB.WithContext(ctx).Model(&model.User{}).Where("email = ?", email).Count(&count)
 	return count > 0
 }
 
-func (r *UserRepository) ExistsByUsername(username string) bool {
+// ExistsByEmailIgnoreCase 忽略大小写判断邮箱是否已被使用，用于第三方登录的邮箱冲突检查
+func (r *UserRepository) ExistsByEmailIgnoreCase(ctx context.Context, email string) bool {
 	var count int64
-	r.DB.Model(&model.User{}).Where("username = ?", username).Count(&count)
+	r.DB.WithContext(ctx).Model(&model.User{}).Where("LOWER(email) = LOWER(?)", email).Count(&count)
 	return count > 0
 }
 
-func (r *UserRepository) Count() (int64, error) {
+func (r *UserRepository) ExistsByUsername(ctx context.Context, username string) bool {
 	var count int64
-	err := r.DB.Model(&model.User{}).Count(&count).Error
+	r.DB.WithContext(ctx).Model(&model.User{}).Where("username = ?", username).Count(&count)
+	return count > 0
+}
+
+func (r *UserRepository) Count(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.DB.WithContext(ctx).Model(&model.User{}).Count(&count).Error
 	return count, err
 }
 
-func (r *UserRepository) CountByRole(role string) (int64, error) {
+func (r *UserRepository) CountByRole(ctx context.Context, role string) (int64, error) {
 	var count int64
-	err := r.DB.Model(&model.User{}).Where("role = ?", role).Count(&count).Error
+	err := r.DB.WithContext(ctx).Model(&model.User{}).Where("role = ?", role).Count(&count).Error
 	return count, err
 }
 
-func (r *UserRepository) Transaction(fn func(*gorm.DB) error) error {
-	return r.DB.Transaction(fn)
+func (r *UserRepository) Transaction(ctx context.Context, fn func(*gorm.DB) error) error {
+	return r.DB.WithContext(ctx).Transaction(fn)
+}
+
+func (r *UserRepository) FindIDsByCreatedRange(ctx context.Context, after, before *time.Time, limit int) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+
+	query := r.DB.WithContext(ctx).Model(&model.User{})
+	if after != nil {
+		query = query.Where("created_at >= ?", *after)
+	}
+	if before != nil {
+		query = query.Where("created_at < ?", *before)
+	}
+
+	err := query.Order("created_at ASC").Limit(limit).Pluck("id", &ids).Error
+	return ids, err
+}
+
+// AdvanceTOTPStep 记录最近一次使用的 TOTP 时间窗口，同一窗口的验证码不能重复使用
+func (r *UserRepository) AdvanceTOTPStep(ctx context.Context, id uuid.UUID, step int64) (bool, error) {
+	result := r.DB.WithContext(ctx).Model(&model.User{}).
+		Where("id = ? AND totp_last_step < ?", id, step).
+		UpdateColumn("totp_last_step", step)
+	return result.RowsAffected > 0, result.Error
 }
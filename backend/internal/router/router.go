@@ -4,11 +4,16 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/ysmmc/backend/internal/handler"
 	"github.com/ysmmc/backend/internal/middleware"
+	"github.com/ysmmc/backend/internal/model"
+	"github.com/ysmmc/backend/pkg/metrics"
 )
 
 func Setup(r *gin.Engine) {
-	r.Use(middleware.CORS())
+	r.Use(middleware.RequestID())
+	r.Use(middleware.Metrics())
 	r.Use(middleware.Logger())
+	r.Use(middleware.Recovery())
+	r.Use(middleware.CORS())
 
 	authHandler := handler.NewAuthHandler()
 	userHandler := handler.NewUserHandler()
@@ -20,60 +25,99 @@ func Setup(r *gin.Engine) {
 	announcementHandler := handler.NewAnnouncementHandler()
 	uploadHandler := handler.NewUploadHandler()
 	fileHandler := handler.NewFileHandler()
+	reportHandler := handler.NewReportHandler()
+	notificationHandler := handler.NewNotificationHandler()
+	jobHandler := handler.NewJobHandler()
+	emailHandler := handler.NewEmailHandler()
 
 	api := r.Group("/api")
 	{
 		auth := api.Group("/auth")
 		{
+			auth.GET("/challenge", authHandler.Challenge)
+			auth.GET("/registration", authHandler.Registration)
 			auth.POST("/register", middleware.RegisterRateLimit(), authHandler.Register)
 			auth.POST("/login", middleware.LoginRateLimit(), authHandler.Login)
+			auth.POST("/login/mfa", middleware.LoginRateLimit(), authHandler.LoginMFA)
 			auth.POST("/logout", middleware.Auth(), authHandler.Logout)
 			auth.POST("/refresh", authHandler.RefreshToken)
 			auth.POST("/forgot-password", middleware.ForgotPasswordRateLimit(), authHandler.ForgotPassword)
 			auth.POST("/reset-password", authHandler.ResetPassword)
 			auth.GET("/verify", authHandler.VerifyEmail)
+			auth.POST("/resend-verification", middleware.Auth(), middleware.ResendVerificationRateLimit(), authHandler.ResendVerification)
 			auth.POST("/change-email", middleware.Auth(), authHandler.ChangeEmail)
 			auth.GET("/verify-email-change", authHandler.VerifyEmailChange)
-			auth.GET("/me", middleware.Auth(), authHandler.Me)
+			auth.GET("/me", middleware.Auth(model.ScopeProfileRead), authHandler.Me)
+			auth.GET("/oidc/providers", authHandler.ListOIDCProviders)
+			auth.POST("/oidc/:provider/authorize", middleware.LoginRateLimit(), authHandler.OIDCAuthorize)
+			auth.POST("/oidc/callback", middleware.LoginRateLimit(), authHandler.OIDCCallback)
 		}
 
 		users := api.Group("/users")
 		{
-			users.GET("/me", middleware.Auth(), userHandler.GetMe)
+			users.GET("/me", middleware.Auth(model.ScopeProfileRead), userHandler.GetMe)
 			users.PUT("/me", middleware.Auth(), userHandler.UpdateMe)
 			users.PUT("/me/password", middleware.Auth(), userHandler.ChangePassword)
+			users.PUT("/me/language", middleware.Auth(), userHandler.UpdateLanguage)
+			users.GET("/me/models", middleware.Auth(model.ScopeModelsRead), userHandler.GetMyModels)
+			users.GET("/me/sanctions", middleware.Auth(), userHandler.GetMySanctions)
+			users.GET("/me/sessions", middleware.Auth(), userHandler.ListSessions)
+			users.DELETE("/me/sessions", middleware.Auth(), userHandler.RevokeOtherSessions)
+			users.DELETE("/me/sessions/:id", middleware.Auth(), userHandler.RevokeSession)
+			users.GET("/me/tokens", middleware.Auth(), userHandler.ListAPITokens)
+			users.POST("/me/tokens", middleware.Auth(), userHandler.CreateAPIToken)
+			users.DELETE("/me/tokens/:id", middleware.Auth(), userHandler.RevokeAPIToken)
+			users.GET("/me/identities", middleware.Auth(), userHandler.ListIdentities)
+			users.POST("/me/identities/callback", middleware.Auth(), userHandler.CompleteLinkIdentity)
+			users.POST("/me/identities/:provider", middleware.Auth(), userHandler.LinkIdentity)
+			users.DELETE("/me/identities/:id", middleware.Auth(), userHandler.UnlinkIdentity)
+			users.GET("/me/invites", middleware.Auth(), userHandler.ListInvites)
+			users.POST("/me/invites", middleware.Auth(), userHandler.CreateInvite)
+			users.DELETE("/me/invites/:id", middleware.Auth(), userHandler.RevokeInvite)
+			users.GET("/me/invitees", middleware.Auth(), userHandler.ListInvitees)
+			users.POST("/me/export", middleware.Auth(), userHandler.RequestExport)
+			users.GET("/me/export", middleware.Auth(), userHandler.GetExport)
+			users.GET("/me/export/download", middleware.Auth(), userHandler.DownloadExport)
+			users.POST("/me/deletion", middleware.Auth(), middleware.LoginRateLimit(), userHandler.ScheduleDeletion)
+			users.DELETE("/me/deletion", middleware.Auth(), userHandler.CancelDeletion)
+			users.GET("/me/2fa", middleware.Auth(), userHandler.GetTwoFactor)
+			users.POST("/me/2fa/setup", middleware.Auth(), userHandler.SetupTwoFactor)
+			users.POST("/me/2fa/enable", middleware.Auth(), middleware.LoginRateLimit(), userHandler.EnableTwoFactor)
+			users.POST("/me/2fa/disable", middleware.Auth(), middleware.LoginRateLimit(), userHandler.DisableTwoFactor)
+			users.POST("/me/2fa/recovery-codes", middleware.Auth(), middleware.LoginRateLimit(), userHandler.RegenerateRecoveryCodes)
 			users.GET("/:id", userHandler.GetByID)
 			users.GET("/:id/models", userHandler.GetUserModels)
 		}
 
 		models := api.Group("/models")
 		{
+			verifiedUpload := middleware.RequireVerifiedEmail(model.VerifiedActionUpload)
 			models.GET("", modelHandler.List)
 			models.GET("/:id", modelHandler.GetByID)
 			models.GET("/:id/file", modelHandler.ServeModelFile)
-			models.POST("", middleware.Auth(), modelHandler.Create)
-			models.PUT("/:id", middleware.Auth(), modelHandler.Update)
-			models.DELETE("/:id", middleware.Auth(), modelHandler.Delete)
+			models.POST("", middleware.Auth(model.ScopeModelsWrite), verifiedUpload, middleware.RequireNoSanction(model.SanctionNoUpload), modelHandler.Create)
+			models.PUT("/:id", middleware.Auth(model.ScopeModelsWrite), verifiedUpload, modelHandler.Update)
+			models.DELETE("/:id", middleware.Auth(model.ScopeModelsWrite), modelHandler.Delete)
 			models.POST("/:id/download", modelHandler.Download)
-			models.POST("/:id/favorite", middleware.Auth(), modelHandler.AddFavorite)
-			models.DELETE("/:id/favorite", middleware.Auth(), modelHandler.RemoveFavorite)
-			models.GET("/:id/favorite", middleware.Auth(), modelHandler.CheckFavorite)
+			models.POST("/:id/favorite", middleware.Auth(model.ScopeFavoritesWrite), modelHandler.AddFavorite)
+			models.DELETE("/:id/favorite", middleware.Auth(model.ScopeFavoritesWrite), modelHandler.RemoveFavorite)
+			models.GET("/:id/favorite", middleware.Auth(model.ScopeFavoritesRead), modelHandler.CheckFavorite)
 			models.GET("/:id/versions", modelVersionHandler.ListVersions)
-			models.POST("/:id/versions", middleware.Auth(), modelVersionHandler.CreateVersion)
+			models.POST("/:id/versions", middleware.Auth(model.ScopeVersionsWrite), verifiedUpload, middleware.RequireNoSanction(model.SanctionNoUpload), modelVersionHandler.CreateVersion)
 			models.GET("/:id/versions/:versionId", modelVersionHandler.GetVersion)
 			models.GET("/:id/versions/:versionId/file", modelVersionHandler.ServeVersionFile)
-			models.PUT("/:id/versions/:versionId", middleware.Auth(), modelVersionHandler.UpdateVersion)
-			models.PUT("/:id/versions/:versionId/current", middleware.Auth(), modelVersionHandler.SetCurrentVersion)
-			models.DELETE("/:id/versions/:versionId", middleware.Auth(), modelVersionHandler.DeleteVersion)
+			models.PUT("/:id/versions/:versionId", middleware.Auth(model.ScopeVersionsWrite), verifiedUpload, modelVersionHandler.UpdateVersion)
+			models.PUT("/:id/versions/:versionId/current", middleware.Auth(model.ScopeVersionsWrite), modelVersionHandler.SetCurrentVersion)
+			models.DELETE("/:id/versions/:versionId", middleware.Auth(model.ScopeVersionsWrite), modelVersionHandler.DeleteVersion)
 			models.POST("/:id/versions/:versionId/download", modelVersionHandler.DownloadVersion)
 			models.GET("/:id/images", modelImageHandler.ListImages)
-			models.POST("/:id/images", middleware.Auth(), modelImageHandler.AddImage)
-			models.DELETE("/:id/images/:fileId", middleware.Auth(), modelImageHandler.DeleteImage)
-			models.PUT("/:id/images/order", middleware.Auth(), modelImageHandler.UpdateOrder)
+			models.POST("/:id/images", middleware.Auth(model.ScopeModelsWrite), verifiedUpload, middleware.RequireNoSanction(model.SanctionNoUpload), modelImageHandler.AddImage)
+			models.DELETE("/:id/images/:fileId", middleware.Auth(model.ScopeModelsWrite), modelImageHandler.DeleteImage)
+			models.PUT("/:id/images/order", middleware.Auth(model.ScopeModelsWrite), modelImageHandler.UpdateOrder)
 		}
 
 		favorites := api.Group("/favorites")
-		favorites.Use(middleware.Auth())
+		favorites.Use(middleware.Auth(model.ScopeFavoritesRead))
 		{
 			favorites.GET("", favoriteHandler.List)
 		}
@@ -88,41 +132,114 @@ func Setup(r *gin.Engine) {
 		files := api.Group("/files")
 		{
 			files.GET("/:id", fileHandler.GetFile)
-			files.POST("", middleware.Auth(), fileHandler.UploadFile)
-			files.DELETE("/:id", middleware.Auth(), fileHandler.DeleteFile)
+			files.POST("", middleware.Auth(model.ScopeModelsWrite, model.ScopeVersionsWrite), middleware.RequireVerifiedEmail(model.VerifiedActionUpload), middleware.RequireNoSanction(model.SanctionNoUpload), fileHandler.UploadFile)
+			files.DELETE("/:id", middleware.Auth(model.ScopeModelsWrite, model.ScopeVersionsWrite), fileHandler.DeleteFile)
 		}
 
+		// 退订链接来自邮件，不要求登录
+		api.POST("/notifications/unsubscribe", notificationHandler.Unsubscribe)
+
+		notifications := api.Group("/notifications")
+		notifications.Use(middleware.Auth())
+		{
+			notifications.GET("", notificationHandler.List)
+			notifications.GET("/unread-count", notificationHandler.UnreadCount)
+			notifications.GET("/settings", notificationHandler.GetSettings)
+			notifications.PUT("/settings", notificationHandler.UpdateSettings)
+			notifications.PUT("/read-all", notificationHandler.MarkAllRead)
+			notifications.PUT("/:id/read", notificationHandler.MarkRead)
+		}
+
+		api.POST("/reports", middleware.Auth(), middleware.RequireVerifiedEmail(model.VerifiedActionReport), middleware.ReportRateLimit(), reportHandler.Create)
+
 		upload := api.Group("/upload")
-		upload.Use(middleware.Auth())
 		{
-			upload.POST("/model", uploadHandler.UploadModel)
-			upload.POST("/image", uploadHandler.UploadImage)
+			noUploadBan := middleware.RequireNoSanction(model.SanctionNoUpload)
+			verifiedUpload := middleware.RequireVerifiedEmail(model.VerifiedActionUpload)
+			upload.POST("/model", middleware.Auth(model.ScopeVersionsWrite), verifiedUpload, noUploadBan, uploadHandler.UploadModel)
+			upload.POST("/image", middleware.Auth(model.ScopeModelsWrite, model.ScopeVersionsWrite), verifiedUpload, noUploadBan, uploadHandler.UploadImage)
 		}
 
 		admin := api.Group("/admin")
 		admin.Use(middleware.Auth())
-		admin.Use(middleware.AdminOnly())
 		{
-			admin.GET("/stats", adminHandler.GetStats)
-			admin.GET("/super-admin", adminHandler.GetSuperAdmin)
-			admin.GET("/models", adminHandler.ListAllModels)
-			admin.GET("/models/pending", adminHandler.ListPendingModels)
-			admin.GET("/models/pending-updates", adminHandler.ListPendingUpdates)
-			admin.PUT("/models/:id/approve", adminHandler.ApproveModel)
-			admin.PUT("/models/:id/reject", adminHandler.RejectModel)
-			admin.DELETE("/models/:id", adminHandler.DeleteModel)
-			admin.GET("/users", adminHandler.ListUsers)
-			admin.PUT("/users/:id/role", adminHandler.UpdateUserRole)
-			admin.PUT("/users/:id/admin", middleware.SuperAdminOnly(), adminHandler.SetAdmin)
-			admin.DELETE("/users/:id/admin", middleware.SuperAdminOnly(), adminHandler.RemoveAdmin)
-			admin.PUT("/users/:id/ban", adminHandler.BanUser)
-			admin.PUT("/users/:id/unban", adminHandler.UnbanUser)
-			admin.GET("/profiles/pending", adminHandler.ListPendingProfiles)
-			admin.PUT("/profiles/:id/approve", adminHandler.ApproveProfile)
-			admin.PUT("/profiles/:id/reject", adminHandler.RejectProfile)
-			admin.POST("/announcements", adminHandler.CreateAnnouncement)
-			admin.PUT("/announcements/:id", adminHandler.UpdateAnnouncement)
-			admin.DELETE("/announcements/:id", adminHandler.DeleteAnnouncement)
+			admin.GET("/stats", middleware.RequirePermission(model.PermStatsView), adminHandler.GetStats)
+			admin.GET("/stats/history", middleware.RequirePermission(model.PermStatsView), adminHandler.GetStatsHistory)
+			admin.GET("/super-admin", middleware.RequirePermission(model.PermUsersView), adminHandler.GetSuperAdmin)
+
+			reviewModels := middleware.RequirePermission(model.PermModelsApprove)
+			admin.GET("/models", reviewModels, adminHandler.ListAllModels)
+			admin.GET("/models/pending", reviewModels, adminHandler.ListPendingModels)
+			admin.GET("/models/pending-updates", reviewModels, adminHandler.ListPendingUpdates)
+			admin.PUT("/models/:id/approve", reviewModels, adminHandler.ApproveModel)
+			admin.PUT("/models/:id/reject", reviewModels, adminHandler.RejectModel)
+			admin.POST("/models/bulk", reviewModels, adminHandler.BulkModelAction)
+			admin.DELETE("/models/:id", middleware.RequirePermission(model.PermModelsManage), adminHandler.DeleteModel)
+
+			viewUsers := middleware.RequirePermission(model.PermUsersView)
+			assignRoles := middleware.RequirePermission(model.PermUsersRoles)
+			banUsers := middleware.RequirePermission(model.PermUsersBan)
+			admin.GET("/users", viewUsers, adminHandler.ListUsers)
+			admin.PUT("/users/:id/role", assignRoles, adminHandler.UpdateUserRole)
+			admin.PUT("/users/:id/admin", assignRoles, adminHandler.SetAdmin)
+			admin.DELETE("/users/:id/admin", assignRoles, adminHandler.RemoveAdmin)
+			admin.PUT("/users/:id/ban", banUsers, adminHandler.BanUser)
+			admin.PUT("/users/:id/unban", banUsers, adminHandler.UnbanUser)
+			admin.PUT("/users/:id/unlock", banUsers, adminHandler.UnlockUser)
+			admin.POST("/users/bulk-ban", banUsers, adminHandler.BulkBanUsers)
+			admin.GET("/users/:id/sanctions", viewUsers, adminHandler.ListUserSanctions)
+			admin.POST("/users/:id/sanctions", banUsers, adminHandler.IssueSanction)
+			admin.DELETE("/sanctions/:id", banUsers, adminHandler.RevokeSanction)
+			admin.GET("/users/:id/sessions", viewUsers, adminHandler.ListUserSessions)
+			admin.DELETE("/users/:id/sessions", banUsers, adminHandler.RevokeAllUserSessions)
+			admin.DELETE("/users/:id/sessions/:sessionId", banUsers, adminHandler.RevokeUserSession)
+			admin.GET("/users/:id/invitees", viewUsers, adminHandler.ListUserInvitees)
+
+			viewAudit := middleware.RequirePermission(model.PermAuditView)
+			admin.GET("/actions", viewAudit, adminHandler.ListActions)
+			admin.GET("/actions/:id", viewAudit, adminHandler.GetAction)
+			admin.GET("/security-events", viewAudit, adminHandler.ListSecurityEvents)
+
+			triageReports := middleware.RequirePermission(model.PermReportsTriage)
+			admin.GET("/reports", triageReports, adminHandler.ListReports)
+			admin.GET("/reports/:id", triageReports, adminHandler.GetReport)
+			admin.PUT("/reports/:id/resolve", triageReports, adminHandler.ResolveReport)
+			admin.PUT("/reports/:id/dismiss", triageReports, adminHandler.DismissReport)
+
+			reviewProfiles := middleware.RequirePermission(model.PermProfilesReview)
+			admin.GET("/profiles/pending", reviewProfiles, adminHandler.ListPendingProfiles)
+			admin.PUT("/profiles/:id/approve", reviewProfiles, adminHandler.ApproveProfile)
+			admin.PUT("/profiles/:id/reject", reviewProfiles, adminHandler.RejectProfile)
+
+			manageAnnouncements := middleware.RequirePermission(model.PermAnnouncementsManage)
+			admin.POST("/announcements", manageAnnouncements, adminHandler.CreateAnnouncement)
+			admin.PUT("/announcements/:id", manageAnnouncements, adminHandler.UpdateAnnouncement)
+			admin.DELETE("/announcements/:id", manageAnnouncements, adminHandler.DeleteAnnouncement)
+
+			manageRoles := middleware.RequirePermission(model.PermRolesManage)
+			admin.GET("/permissions", manageRoles, adminHandler.ListPermissions)
+			admin.GET("/roles", manageRoles, adminHandler.ListRoles)
+			admin.POST("/roles", manageRoles, adminHandler.CreateRole)
+			admin.PUT("/roles/:name", manageRoles, adminHandler.UpdateRole)
+			admin.DELETE("/roles/:name", manageRoles, adminHandler.DeleteRole)
+
+			manageRegistration := middleware.RequirePermission(model.PermRegistrationManage)
+			admin.GET("/registration", manageRegistration, adminHandler.GetRegistration)
+			admin.PUT("/registration", manageRegistration, adminHandler.UpdateRegistration)
+			admin.GET("/invites", manageRegistration, adminHandler.ListInvites)
+			admin.DELETE("/invites/:id", manageRegistration, adminHandler.RevokeInvite)
+
+			manageJobs := middleware.RequirePermission(model.PermJobsManage)
+			admin.GET("/jobs", manageJobs, jobHandler.List)
+			admin.GET("/jobs/stats", manageJobs, jobHandler.Stats)
+			admin.GET("/jobs/:id", manageJobs, jobHandler.Get)
+			admin.POST("/jobs/:id/retry", manageJobs, jobHandler.Retry)
+			admin.GET("/emails", manageJobs, emailHandler.List)
+			admin.GET("/emails/stats", manageJobs, emailHandler.Stats)
+			admin.GET("/emails/:id", manageJobs, emailHandler.Get)
+			admin.POST("/emails/:id/resend", manageJobs, emailHandler.Resend)
+			admin.GET("/email-templates", manageJobs, emailHandler.ListTemplates)
+			admin.GET("/email-templates/:name/preview", manageJobs, emailHandler.PreviewTemplate)
 		}
 	}
 
@@ -132,7 +249,10 @@ func Setup(r *gin.Engine) {
 		uploads.GET("/models/*filename", uploadHandler.ServeModelFile)
 	}
 
+	r.GET("/.well-known/jwks.json", authHandler.JWKS)
+
 	r.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{"status": "ok"})
 	})
+	r.GET("/metrics", middleware.MetricsAccess(), gin.WrapH(metrics.Handler()))
 }
@@ -0,0 +1,542 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ysmmc/backend/internal/config"
+	"github.com/ysmmc/backend/internal/database"
+	"github.com/ysmmc/backend/internal/model"
+	"github.com/ysmmc/backend/internal/repository"
+	"github.com/ysmmc/backend/pkg/auth"
+	"gorm.io/gorm"
+)
+
+const (
+	// exportCooldown 两次数据导出之间的最小间隔
+	exportCooldown = 24 * time.Hour
+	// exportStaleAfter 超过该时长仍未完成的导出视为被中断（例如生成过程中服务重启）
+	exportStaleAfter = time.Hour
+	exportPageSize   = 500
+	// deletionBatchSize 每轮最多清除的到期账号数
+	deletionBatchSize = 50
+)
+
+var (
+	ErrExportInProgress  = errors.New("a data export is already being prepared")
+	ErrExportTooFrequent = errors.New("data exports can be requested once every 24 hours")
+)
+
+type AccountService struct {
+	userRepo             *repository.UserRepository
+	modelRepo            *repository.ModelRepository
+	versionRepo          *repository.ModelVersionRepository
+	favoriteRepo         *repository.FavoriteRepository
+	sessionRepo          *repository.SessionRepository
+	fileRepo             *repository.FileRepository
+	exportRepo           *repository.DataExportRepository
+	settingRepo          *repository.SettingRepository
+	mfaService           *MFAService
+	securityEventService *SecurityEventService
+}
+
+func NewAccountService() *AccountService {
+	return &AccountService{
+		userRepo:             repository.NewUserRepository(),
+		modelRepo:            repository.NewModelRepository(),
+		versionRepo:          repository.NewModelVersionRepository(),
+		favoriteRepo:         repository.NewFavoriteRepository(),
+		sessionRepo:          repository.NewSessionRepository(),
+		fileRepo:             repository.NewFileRepository(),
+		exportRepo:           repository.NewDataExportRepository(),
+		settingRepo:          repository.NewSettingRepository(),
+		mfaService:           NewMFAService(),
+		securityEventService: NewSecurityEventService(),
+	}
+}
+
+type DeletionRequest struct {
+	Password string `json:"password" binding:"required"`
+	// Code 启用两步验证时必填
+	Code string `json:"code"`
+	// ModelAction 对已发布模型的处理：anonymize（默认，转为匿名保留）或 delete
+	ModelAction string `json:"model_action"`
+}
+
+// ScheduleDeletion 重新验证密码后申请注销，宽限期结束前可随时撤销
+func (s *AccountService) ScheduleDeletion(ctx context.Context, userID uuid.UUID, req *DeletionRequest, client ClientInfo) (*time.Time, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	if user.DeletionScheduledAt != nil {
+		return nil, errors.New("account deletion is already scheduled")
+	}
+	if user.Role != model.RoleUser {
+		return nil, errors.New("staff accounts must be demoted before they can be deleted")
+	}
+	if user.PasswordHash == "" {
+		return nil, errors.New("set a password before deleting your account")
+	}
+	if !auth.CheckPassword(req.Password, user.PasswordHash) {
+		return nil, errors.New("incorrect password")
+	}
+	if user.TOTPEnabled {
+		if err := s.mfaService.VerifySecondFactor(ctx, user, req.Code); err != nil {
+			return nil, err
+		}
+	}
+
+	action, err := normalizeDeletionModelAction(req.ModelAction)
+	if err != nil {
+		return nil, err
+	}
+
+	at := time.Now().AddDate(0, 0, config.AppConfig.AccountDeletionGraceDays)
+	scheduled, err := s.userRepo.ScheduleDeletion(ctx, userID, at, action)
+	if err != nil {
+		return nil, err
+	}
+	if !scheduled {
+		return nil, errors.New("account deletion is already scheduled")
+	}
+
+	s.securityEventService.Record(ctx, &userID, model.SecurityEventDeletionScheduled, client,
+		fmt.Sprintf("models=%s scheduled_at=%s", action, at.Format(time.RFC3339)))
+	s.securityEventService.AlertUser(ctx, userID, "账号注销申请已提交",
+		fmt.Sprintf("您的账号将于 %s 被永久删除。在此之前登录并撤销注销申请即可保留账号。", at.Format("2006-01-02 15:04")), client)
+	return &at, nil
+}
+
+func (s *AccountService) CancelDeletion(ctx context.Context, userID uuid.UUID, client ClientInfo) error {
+	cancelled, err := s.userRepo.CancelDeletion(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !cancelled {
+		return errors.New("account deletion is not scheduled")
+	}
+
+	s.securityEventService.Record(ctx, &userID, model.SecurityEventDeletionCancelled, client, "")
+	s.securityEventService.AlertUser(ctx, userID, "账号注销已撤销", "您的账号注销申请已撤销，账号将继续保留。", client)
+	return nil
+}
+
+// normalizeDeletionModelAction 未指定时默认匿名保留模型
+func normalizeDeletionModelAction(action string) (string, error) {
+	action = strings.ToLower(strings.TrimSpace(action))
+	if action == "" {
+		return model.DeletionModelsAnonymize, nil
+	}
+	if !model.IsValidDeletionModelAction(action) {
+		return "", errors.New("model_action must be anonymize or delete")
+	}
+	return action, nil
+}
+
+// RequestExport 创建导出记录，并与生成压缩包的任务在同一事务中写入任务队列
+func (s *AccountService) RequestExport(ctx context.Context, userID uuid.UUID) (*model.DataExport, error) {
+	latest, err := s.exportRepo.FindLatestByUserID(ctx, userID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if latest != nil {
+		if latest.Status == model.DataExportPending {
+			if time.Since(latest.CreatedAt) < exportStaleAfter {
+				return nil, ErrExportInProgress
+			}
+			// 长时间未完成的导出视为已中断，不必等清理任务即可重新申请
+			if err := s.exportRepo.MarkFailed(ctx, latest.ID, "export was interrupted"); err != nil {
+				return nil, err
+			}
+		}
+		if latest.Status == model.DataExportReady && time.Since(latest.CreatedAt) < exportCooldown {
+			return nil, ErrExportTooFrequent
+		}
+	}
+
+	export := &model.DataExport{UserID: userID, Status: model.DataExportPending}
+	err = database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := (&repository.DataExportRepository{DB: tx}).Create(ctx, export); err != nil {
+			return err
+		}
+		_, err := enqueueJob(ctx, &repository.JobRepository{DB: tx}, JobBuildExport, BuildExportPayload{ExportID: export.ID}, time.Now(), jobMaxAttempts)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// handleBuildExport 执行 account.build_export 任务；导出已完成、失败或被删除时直接跳过
+func (s *AccountService) handleBuildExport(ctx context.Context, job *model.Job) error {
+	var p BuildExportPayload
+	if err := decodeJobPayload(job, &p); err != nil {
+		return err
+	}
+
+	export, err := s.exportRepo.FindByID(ctx, p.ExportID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if export.Status != model.DataExportPending {
+		return nil
+	}
+
+	s.buildExport(ctx, export)
+	return nil
+}
+
+// GetExport 返回最近一次导出，没有时返回 nil
+func (s *AccountService) GetExport(ctx context.Context, userID uuid.UUID) (*model.DataExport, error) {
+	export, err := s.exportRepo.FindLatestByUserID(ctx, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return export, err
+}
+
+// ExportFile 返回可供下载的导出文件路径
+func (s *AccountService) ExportFile(ctx context.Context, userID uuid.UUID) (string, error) {
+	export, err := s.exportRepo.FindLatestByUserID(ctx, userID)
+	if err != nil || !export.IsDownloadable(time.Now()) {
+		return "", errors.New("no data export is available for download")
+	}
+	return export.FilePath, nil
+}
+
+func (s *AccountService) buildExport(ctx context.Context, export *model.DataExport) {
+	path, size, err := s.writeExport(ctx, export)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to build data export", "export_id", export.ID, "user_id", export.UserID, "error", err)
+		if err := s.exportRepo.MarkFailed(ctx, export.ID, "failed to build export"); err != nil {
+			slog.ErrorContext(ctx, "Failed to mark data export as failed", "export_id", export.ID, "error", err)
+		}
+		return
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(time.Duration(config.AppConfig.DataExportExpireHours) * time.Hour)
+	if err := s.exportRepo.MarkReady(ctx, export.ID, path, size, now, expiresAt); err != nil {
+		slog.ErrorContext(ctx, "Failed to mark data export as ready", "export_id", export.ID, "error", err)
+		os.Remove(path)
+	}
+}
+
+// exportFavorite 收藏只导出模型的基本信息，不包含其他用户的资料
+type exportFavorite struct {
+	ModelID    uuid.UUID `json:"model_id"`
+	ModelTitle string    `json:"model_title"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (s *AccountService) writeExport(ctx context.Context, export *model.DataExport) (string, int64, error) {
+	dir := exportDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", 0, err
+	}
+
+	path := filepath.Join(dir, export.ID.String()+".zip")
+	tmpPath := path + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return "", 0, err
+	}
+	defer os.Remove(tmpPath)
+
+	zw := zip.NewWriter(f)
+	if err := s.writeExportEntries(ctx, zw, export.UserID); err != nil {
+		f.Close()
+		return "", 0, err
+	}
+	if err := zw.Close(); err != nil {
+		f.Close()
+		return "", 0, err
+	}
+	if err := f.Close(); err != nil {
+		return "", 0, err
+	}
+
+	info, err := os.Stat(tmpPath)
+	if err != nil {
+		return "", 0, err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return "", 0, err
+	}
+	return path, info.Size(), nil
+}
+
+func (s *AccountService) writeExportEntries(ctx context.Context, zw *zip.Writer, userID uuid.UUID) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := writeJSONEntry(zw, "profile.json", user); err != nil {
+		return err
+	}
+
+	models := []model.Model{}
+	versions := []model.ModelVersion{}
+	for page := 1; ; page++ {
+		batch, _, err := s.modelRepo.ListByUserID(ctx, userID, page, exportPageSize)
+		if err != nil {
+			return err
+		}
+		for _, m := range batch {
+			mv, err := s.versionRepo.FindByModelID(ctx, m.ID)
+			if err != nil {
+				return err
+			}
+			versions = append(versions, mv...)
+		}
+		models = append(models, batch...)
+		if len(batch) < exportPageSize {
+			break
+		}
+	}
+	if err := writeJSONEntry(zw, "models.json", models); err != nil {
+		return err
+	}
+	if err := writeJSONEntry(zw, "versions.json", versions); err != nil {
+		return err
+	}
+
+	favorites := []exportFavorite{}
+	for page := 1; ; page++ {
+		batch, _, err := s.favoriteRepo.ListByUserID(ctx, userID, page, exportPageSize)
+		if err != nil {
+			return err
+		}
+		for _, fav := range batch {
+			entry := exportFavorite{ModelID: fav.ModelID, CreatedAt: fav.CreatedAt}
+			if fav.Model != nil {
+				entry.ModelTitle = fav.Model.Title
+			}
+			favorites = append(favorites, entry)
+		}
+		if len(batch) < exportPageSize {
+			break
+		}
+	}
+	if err := writeJSONEntry(zw, "favorites.json", favorites); err != nil {
+		return err
+	}
+
+	sessions, err := s.sessionRepo.ListActiveByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := writeJSONEntry(zw, "sessions.json", sessions); err != nil {
+		return err
+	}
+
+	files, err := s.fileRepo.ListMetaByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := writeJSONEntry(zw, "files.json", files); err != nil {
+		return err
+	}
+	// 文件内容逐个加载，避免一次性读入全部图片
+	for _, meta := range files {
+		file, err := s.fileRepo.FindByID(ctx, meta.ID)
+		if err != nil {
+			return err
+		}
+		w, err := zw.Create("files/" + exportFileName(file))
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(file.Data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeJSONEntry(zw *zip.Writer, name string, v interface{}) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// exportFileName 压缩包内的文件名使用文件 ID，避免用户提供的原始文件名造成路径穿越或重名
+func exportFileName(f *model.File) string {
+	switch f.MimeType {
+	case "image/jpeg":
+		return f.ID.String() + ".jpg"
+	case "image/png":
+		return f.ID.String() + ".png"
+	case "image/gif":
+		return f.ID.String() + ".gif"
+	case "image/webp":
+		return f.ID.String() + ".webp"
+	}
+	return f.ID.String() + ".bin"
+}
+
+func exportDir() string {
+	return filepath.Join(config.AppConfig.UploadPath, "exports")
+}
+
+// CleanupExports 删除过期的导出文件，并将中断的导出标记为失败
+func (s *AccountService) CleanupExports(ctx context.Context) {
+	if _, err := s.exportRepo.FailStale(ctx, time.Now().Add(-exportStaleAfter)); err != nil {
+		slog.ErrorContext(ctx, "Failed to mark stale data exports", "error", err)
+	}
+
+	expired, err := s.exportRepo.ListExpired(ctx, time.Now())
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to list expired data exports", "error", err)
+		return
+	}
+	for _, export := range expired {
+		removeExportFile(export.FilePath)
+		if err := s.exportRepo.Delete(ctx, export.ID); err != nil {
+			slog.ErrorContext(ctx, "Failed to delete data export", "export_id", export.ID, "error", err)
+		}
+	}
+}
+
+func removeExportFile(path string) {
+	if path == "" {
+		return
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		slog.Warn("Failed to remove data export file", "path", path, "error", err)
+	}
+}
+
+// PurgeDueAccounts 永久删除宽限期已结束的账号，返回删除数量
+func (s *AccountService) PurgeDueAccounts(ctx context.Context) int {
+	users, err := s.userRepo.ListDueForDeletion(ctx, time.Now(), deletionBatchSize)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to list accounts due for deletion", "error", err)
+		return 0
+	}
+
+	purged := 0
+	for i := range users {
+		if err := s.purge(ctx, &users[i]); err != nil {
+			slog.ErrorContext(ctx, "Failed to delete account", "user_id", users[i].ID, "error", err)
+			continue
+		}
+		purged++
+	}
+	return purged
+}
+
+func (s *AccountService) purge(ctx context.Context, user *model.User) error {
+	heirID, err := s.deletedUserID(ctx)
+	if err != nil {
+		return fmt.Errorf("deleted user placeholder: %w", err)
+	}
+
+	exports, err := s.exportRepo.ListByUserID(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	removedFiles := []string{}
+	err = database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		modelRepo := &repository.ModelRepository{DB: tx}
+		if user.DeletionModelAction == model.DeletionModelsDelete {
+			ids, err := modelRepo.FindIDsByUserID(ctx, user.ID)
+			if err != nil {
+				return err
+			}
+			for _, id := range ids {
+				files, err := applyModelAction(ctx, modelRepo, id, "delete", "")
+				if err != nil {
+					return err
+				}
+				removedFiles = append(removedFiles, files...)
+			}
+		} else if err := modelRepo.ReassignOwner(ctx, user.ID, heirID); err != nil {
+			return err
+		}
+
+		return (&repository.UserRepository{DB: tx}).DeleteWithDependents(ctx, user.ID, heirID)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, path := range removedFiles {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			slog.WarnContext(ctx, "Account deletion: failed to remove file", "path", path, "error", err)
+		}
+	}
+	for _, export := range exports {
+		removeExportFile(export.FilePath)
+	}
+	if user.AvatarURL != nil && strings.HasPrefix(*user.AvatarURL, "/uploads/") {
+		avatarPath := filepath.Join(config.AppConfig.UploadPath, strings.TrimPrefix(*user.AvatarURL, "/uploads/"))
+		if err := os.Remove(avatarPath); err != nil && !os.IsNotExist(err) {
+			slog.WarnContext(ctx, "Account deletion: failed to remove avatar", "path", avatarPath, "error", err)
+		}
+	}
+
+	s.securityEventService.Record(ctx, nil, model.SecurityEventAccountDeleted, ClientInfo{},
+		fmt.Sprintf("user=%s models=%s", user.ID, user.DeletionModelAction))
+	return nil
+}
+
+// deletedUserID 返回接收匿名化内容的占位账号，不存在时创建。
+// 该账号没有密码且处于封禁状态，无法登录
+func (s *AccountService) deletedUserID(ctx context.Context) (uuid.UUID, error) {
+	if setting, err := s.settingRepo.Get(ctx, model.SettingDeletedUser); err == nil {
+		var id uuid.UUID
+		if err := json.Unmarshal([]byte(setting.Value), &id); err == nil {
+			if _, err := s.userRepo.FindByID(ctx, id); err == nil {
+				return id, nil
+			}
+		}
+	}
+
+	id := uuid.New()
+	username := "deleted-user"
+	if s.userRepo.ExistsByUsername(ctx, username) {
+		username += "-" + id.String()[:8]
+	}
+	reason := "placeholder account for content of deleted users"
+	now := time.Now()
+	placeholder := &model.User{
+		ID:            id,
+		Email:         "deleted-" + id.String() + "@users.invalid",
+		Username:      username,
+		Role:          model.RoleUser,
+		ProfileStatus: "approved",
+		IsBanned:      true,
+		BannedAt:      &now,
+		BannedReason:  &reason,
+	}
+	if err := s.userRepo.Create(ctx, placeholder); err != nil {
+		return uuid.Nil, err
+	}
+
+	value, _ := json.Marshal(id)
+	if err := s.settingRepo.Upsert(ctx, &model.Setting{Key: model.SettingDeletedUser, Value: string(value)}); err != nil {
+		return uuid.Nil, err
+	}
+	return id, nil
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ysmmc/backend/internal/database"
+	"github.com/ysmmc/backend/internal/model"
+)
+
+func TestNormalizeDeletionModelAction(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"", model.DeletionModelsAnonymize, false},
+		{"anonymize", model.DeletionModelsAnonymize, false},
+		{" Delete ", model.DeletionModelsDelete, false},
+		{"transfer", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := normalizeDeletionModelAction(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("normalizeDeletionModelAction(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("normalizeDeletionModelAction(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestExportFileName_IgnoresOriginalName(t *testing.T) {
+	id := uuid.New()
+	f := &model.File{ID: id, Name: "../../etc/passwd.png", MimeType: "image/png"}
+	if got := exportFileName(f); got != id.String()+".png" {
+		t.Errorf("unexpected export file name %q", got)
+	}
+
+	f.MimeType = "application/x-unknown"
+	if got := exportFileName(f); got != id.String()+".bin" {
+		t.Errorf("unexpected export file name for unknown type %q", got)
+	}
+}
+
+func TestWriteJSONEntry(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	favorites := []exportFavorite{{ModelID: uuid.New(), ModelTitle: "Castle"}}
+	if err := writeJSONEntry(zw, "favorites.json", favorites); err != nil {
+		t.Fatalf("writeJSONEntry failed: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("failed to close archive: %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("failed to read archive: %v", err)
+	}
+	if len(zr.File) != 1 || zr.File[0].Name != "favorites.json" {
+		t.Fatalf("unexpected archive entries: %v", zr.File)
+	}
+
+	rc, err := zr.File[0].Open()
+	if err != nil {
+		t.Fatalf("failed to open entry: %v", err)
+	}
+	defer rc.Close()
+
+	var decoded []exportFavorite
+	if err := json.NewDecoder(rc).Decode(&decoded); err != nil {
+		t.Fatalf("failed to decode entry: %v", err)
+	}
+	if len(decoded) != 1 || decoded[0].ModelTitle != "Castle" {
+		t.Errorf("unexpected decoded favorites: %+v", decoded)
+	}
+}
+
+func TestRequestExport_QueuesJobAndReplacesStaleExport(t *testing.T) {
+	setupTestDB(t)
+	ctx := context.Background()
+	user := createTestUser(t, model.RoleUser)
+	s := NewAccountService()
+
+	first, err := s.RequestExport(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("RequestExport() error = %v", err)
+	}
+	if _, err := s.RequestExport(ctx, user.ID); !errors.Is(err, ErrExportInProgress) {
+		t.Fatalf("expected ErrExportInProgress while the export is pending, got %v", err)
+	}
+
+	var jobs int64
+	database.DB.Model(&model.Job{}).Where("kind = ? AND payload->>'export_id' = ?", JobBuildExport, first.ID.String()).Count(&jobs)
+	if jobs != 1 {
+		t.Errorf("expected one queued build job for the export, got %d", jobs)
+	}
+
+	database.DB.Model(first).UpdateColumn("created_at", time.Now().Add(-exportStaleAfter-time.Minute))
+	second, err := s.RequestExport(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("expected a stale pending export not to block a new request, got %v", err)
+	}
+	if second.ID == first.ID {
+		t.Error("expected a new export to be created")
+	}
+	stale, _ := s.exportRepo.FindByID(ctx, first.ID)
+	if stale == nil || stale.Status != model.DataExportFailed {
+		t.Errorf("expected the stale export to be marked failed, got %+v", stale)
+	}
+}
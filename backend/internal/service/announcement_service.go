@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"github.com/google/uuid"
 	"github.com/ysmmc/backend/internal/model"
 	"github.com/ysmmc/backend/internal/repository"
@@ -27,26 +28,26 @@ type UpdateAnnouncementRequest struct {
 	IsActive *bool   `json:"is_active"`
 }
 
-func (s *AnnouncementService) Create(req *CreateAnnouncementRequest) (*model.Announcement, error) {
+func (s *AnnouncementService) Create(ctx context.Context, req *CreateAnnouncementRequest) (*model.Announcement, error) {
 	announcement := &model.Announcement{
 		Title:    req.Title,
 		Content:  req.Content,
 		IsActive: true,
 	}
 
-	if err := s.announcementRepo.Create(announcement); err != nil {
+	if err := s.announcementRepo.Create(ctx, announcement); err != nil {
 		return nil, err
 	}
 
 	return announcement, nil
 }
 
-func (s *AnnouncementService) GetByID(id uuid.UUID) (*model.Announcement, error) {
-	return s.announcementRepo.FindByID(id)
+func (s *AnnouncementService) GetByID(ctx context.Context, id uuid.UUID) (*model.Announcement, error) {
+	return s.announcementRepo.FindByID(ctx, id)
 }
 
-func (s *AnnouncementService) Update(id uuid.UUID, req *UpdateAnnouncementRequest) (*model.Announcement, error) {
-	announcement, err := s.announcementRepo.FindByID(id)
+func (s *AnnouncementService) Update(ctx context.Context, id uuid.UUID, req *UpdateAnnouncementRequest) (*model.Announcement, error) {
+	announcement, err := s.announcementRepo.FindByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -61,21 +62,21 @@ func (s *AnnouncementService) Update(id uuid.UUID, req *UpdateAnnouncementReques
 		announcement.IsActive = *req.IsActive
 	}
 
-	if err := s.announcementRepo.Update(announcement); err != nil {
+	if err := s.announcementRepo.Update(ctx, announcement); err != nil {
 		return nil, err
 	}
 
 	return announcement, nil
 }
 
-func (s *AnnouncementService) Delete(id uuid.UUID) error {
-	return s.announcementRepo.Delete(id)
+func (s *AnnouncementService) Delete(ctx context.Context, id uuid.UUID) error {
+	return s.announcementRepo.Delete(ctx, id)
 }
 
-func (s *AnnouncementService) ListActive() ([]model.Announcement, error) {
-	return s.announcementRepo.ListActive()
+func (s *AnnouncementService) ListActive(ctx context.Context) ([]model.Announcement, error) {
+	return s.announcementRepo.ListActive(ctx)
 }
 
-func (s *AnnouncementService) List(page, pageSize int) ([]model.Announcement, int64, error) {
-	return s.announcementRepo.List(page, pageSize)
+func (s *AnnouncementService) List(ctx context.Context, page, pageSize int) ([]model.Announcement, int64, error) {
+	return s.announcementRepo.List(ctx, page, pageSize)
 }
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/ysmmc/backend/internal/model"
+	"github.com/ysmmc/backend/internal/repository"
+	"github.com/ysmmc/backend/pkg/auth"
+)
+
+const (
+	maxAPITokensPerUser = 20
+	// apiTokenTouchInterval 限制 last_used_at 的写入频率，避免每个请求都更新数据库
+	apiTokenTouchInterval = time.Minute
+)
+
+var ErrInvalidAPIToken = errors.New("invalid or expired api token")
+
+type APITokenService struct {
+	tokenRepo *repository.APITokenRepository
+}
+
+func NewAPITokenService() *APITokenService {
+	return &APITokenService{
+		tokenRepo: repository.NewAPITokenRepository(),
+	}
+}
+
+type CreateAPITokenRequest struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Scopes        []string `json:"scopes" binding:"required,min=1"`
+	ExpiresInDays *int     `json:"expires_in_days" binding:"omitempty,min=1,max=365"`
+}
+
+type CreateAPITokenResponse struct {
+	Token    string          `json:"token"`
+	APIToken *model.APIToken `json:"api_token"`
+}
+
+func (s *APITokenService) Create(ctx context.Context, userID uuid.UUID, req *CreateAPITokenRequest) (*CreateAPITokenResponse, error) {
+	scopes, err := normalizeScopes(req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := s.tokenRepo.CountActiveByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxAPITokensPerUser {
+		return nil, errors.New("too many active api tokens")
+	}
+
+	raw, err := auth.GenerateAPIToken()
+	if err != nil {
+		return nil, err
+	}
+
+	token := &model.APIToken{
+		UserID:    userID,
+		Name:      req.Name,
+		TokenHash: repository.HashToken(raw),
+		Hint:      raw[len(raw)-4:],
+		Scopes:    scopes,
+	}
+	if req.ExpiresInDays != nil {
+		expiresAt := time.Now().Add(time.Duration(*req.ExpiresInDays) * 24 * time.Hour)
+		token.ExpiresAt = &expiresAt
+	}
+
+	if err := s.tokenRepo.Create(ctx, token); err != nil {
+		return nil, err
+	}
+
+	return &CreateAPITokenResponse{Token: raw, APIToken: token}, nil
+}
+
+func (s *APITokenService) List(ctx context.Context, userID uuid.UUID) ([]model.APIToken, error) {
+	return s.tokenRepo.ListByUserID(ctx, userID)
+}
+
+func (s *APITokenService) Revoke(ctx context.Context, id, userID uuid.UUID) error {
+	revoked, err := s.tokenRepo.Revoke(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return errors.New("api token not found")
+	}
+	return nil
+}
+
+// Authenticate 校验个人访问令牌，返回令牌及其所属用户（用户角色以数据库为准）
+func (s *APITokenService) Authenticate(ctx context.Context, raw, ip string) (*model.APIToken, error) {
+	token, err := s.tokenRepo.FindByHash(ctx, repository.HashToken(raw))
+	if err != nil || token.User == nil {
+		return nil, ErrInvalidAPIToken
+	}
+
+	now := time.Now()
+	if !token.IsActive(now) {
+		return nil, ErrInvalidAPIToken
+	}
+
+	if token.User.IsBanActive(now) {
+		return nil, errors.New("account is banned")
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= apiTokenTouchInterval {
+		s.tokenRepo.TouchLastUsed(ctx, token.ID, ip, now)
+	}
+
+	return token, nil
+}
+
+func normalizeScopes(scopes []string) (pq.StringArray, error) {
+	seen := make(map[string]bool, len(scopes))
+	result := pq.StringArray{}
+	for _, scope := range scopes {
+		if !model.IsValidTokenScope(scope) {
+			return nil, errors.New("invalid scope: " + scope)
+		}
+		if seen[scope] {
+			continue
+		}
+		seen[scope] = true
+		result = append(result, scope)
+	}
+	return result, nil
+}
@@ -0,0 +1,21 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/ysmmc/backend/internal/model"
+)
+
+func TestNormalizeScopes(t *testing.T) {
+	scopes, err := normalizeScopes([]string{model.ScopeVersionsWrite, model.ScopeModelsWrite, model.ScopeVersionsWrite})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(scopes) != 2 || scopes[0] != model.ScopeVersionsWrite || scopes[1] != model.ScopeModelsWrite {
+		t.Errorf("expected deduplicated scopes in order, got %v", scopes)
+	}
+
+	if _, err := normalizeScopes([]string{"admin:all"}); err == nil {
+		t.Error("expected error for unknown scope")
+	}
+}
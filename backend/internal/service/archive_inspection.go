@@ -0,0 +1,174 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/ysmmc/backend/internal/config"
+	"github.com/ysmmc/backend/internal/database"
+	"github.com/ysmmc/backend/internal/model"
+	"github.com/ysmmc/backend/internal/repository"
+	"gorm.io/gorm"
+)
+
+// 压缩包检查的上限，超过即视为压缩炸弹或异常文件
+const (
+	archiveMaxEntries          = 10000
+	archiveMaxUncompressedSize = 1 << 30
+	// archiveMaxRatio 单个条目的最大压缩比，仅对解压后超过 archiveRatioMinSize 的条目检查
+	archiveMaxRatio     = 100
+	archiveRatioMinSize = 1 << 20
+)
+
+// InspectArchivePayload model.inspect_archive 任务参数
+type InspectArchivePayload struct {
+	ModelID  uuid.UUID `json:"model_id"`
+	FilePath string    `json:"file_path"`
+	// PendingChange 检查的是待审核修改中的文件，而不是新模型本身
+	PendingChange bool `json:"pending_change"`
+}
+
+// inspectArchive 检查 zip 文件的条目数、解压后大小、压缩比与路径，并完整解压一遍校验 CRC。
+// 文件不合格时返回原因，读取失败等无法判断的情况返回 error
+func inspectArchive(ctx context.Context, filePath string) (string, error) {
+	r, err := zip.OpenReader(filePath)
+	if err != nil {
+		if errors.Is(err, zip.ErrFormat) || errors.Is(err, zip.ErrAlgorithm) {
+			return "not a valid zip archive", nil
+		}
+		return "", err
+	}
+	defer r.Close()
+
+	if len(r.File) > archiveMaxEntries {
+		return fmt.Sprintf("archive contains %d entries, the limit is %d", len(r.File), archiveMaxEntries), nil
+	}
+
+	var total uint64
+	for _, f := range r.File {
+		if !isSafeArchivePath(f.Name) {
+			return fmt.Sprintf("archive entry %q points outside the archive", f.Name), nil
+		}
+		total += f.UncompressedSize64
+		if total > archiveMaxUncompressedSize {
+			return fmt.Sprintf("archive expands to more than %d bytes", archiveMaxUncompressedSize), nil
+		}
+		if f.UncompressedSize64 > archiveRatioMinSize && f.UncompressedSize64 > uint64(archiveMaxRatio)*f.CompressedSize64 {
+			return fmt.Sprintf("archive entry %q has a suspicious compression ratio", f.Name), nil
+		}
+	}
+
+	for _, f := range r.File {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if problem, err := readArchiveEntry(f); problem != "" || err != nil {
+			return problem, err
+		}
+	}
+	return "", nil
+}
+
+// readArchiveEntry 解压单个条目；archive/zip 会在实际大小与声明不符或 CRC 错误时报错
+func readArchiveEntry(f *zip.File) (string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Sprintf("archive entry %q cannot be read: %v", f.Name, err), nil
+	}
+	defer rc.Close()
+
+	if _, err := io.Copy(io.Discard, rc); err != nil {
+		return fmt.Sprintf("archive entry %q is corrupted: %v", f.Name, err), nil
+	}
+	return "", nil
+}
+
+// isSafeArchivePath 拒绝绝对路径、盘符与 .. 等会在解压时写到目标目录之外的条目名
+func isSafeArchivePath(name string) bool {
+	name = strings.ReplaceAll(name, "\\", "/")
+	if name == "" || strings.HasPrefix(name, "/") || filepath.VolumeName(name) != "" || strings.Contains(name, ":") {
+		return false
+	}
+	for _, part := range strings.Split(path.Clean(name), "/") {
+		if part == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// handleInspectArchive 检查待审核模型上传的压缩包，不合格时自动驳回并通知作者。
+// .ysm 文件由客户端校验，这里只处理 zip
+func handleInspectArchive(ctx context.Context, job *model.Job) error {
+	var p InspectArchivePayload
+	if err := decodeJobPayload(job, &p); err != nil {
+		return err
+	}
+	if !strings.EqualFold(filepath.Ext(p.FilePath), ".zip") {
+		return nil
+	}
+
+	problem, err := inspectArchive(ctx, NewStorageService().ResolvePath(p.FilePath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Permanent(err)
+		}
+		return err
+	}
+	if problem == "" {
+		return nil
+	}
+	return rejectUnsafeArchive(ctx, p, problem)
+}
+
+// rejectUnsafeArchive 驳回仍在等待审核且文件未变化的模型或修改；管理员已处理或作者已换文件时不做任何事
+func rejectUnsafeArchive(ctx context.Context, p InspectArchivePayload, problem string) error {
+	var rejected *model.Model
+	err := database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		repo := &repository.ModelRepository{DB: tx}
+		m, err := repo.FindByIDForUpdate(ctx, p.ModelID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if p.PendingChange {
+			if m.UpdateStatus != "pending_review" || m.PendingChanges == nil || m.PendingChanges.FilePath == nil || *m.PendingChanges.FilePath != p.FilePath {
+				return nil
+			}
+		} else if m.Status != "pending" || m.FilePath != p.FilePath {
+			return nil
+		}
+
+		applyRejection(m, "automatic check failed: "+problem)
+		rejected = m
+		return repo.Update(ctx, m)
+	})
+	if err != nil || rejected == nil {
+		return err
+	}
+	modelReviews.Inc("rejected", "automatic")
+
+	slog.InfoContext(ctx, "Rejected model after archive inspection", "model_id", rejected.ID, "problem", problem)
+	link := fmt.Sprintf("%s/model/%s", config.AppConfig.FrontendURL, rejected.ID)
+	title := "模型文件未通过自动检查"
+	body := fmt.Sprintf("您的模型「%s」上传的文件未通过安全检查，已被自动驳回：%s", rejected.Title, problem)
+	if err := NewNotificationService().Notify(ctx, rejected.UserID, model.NotificationModeration, title, body, &link); err != nil {
+		slog.ErrorContext(ctx, "Failed to notify user about rejected archive", "model_id", rejected.ID, "error", err)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type zipEntry struct {
+	name string
+	data []byte
+}
+
+func writeZip(t *testing.T, entries []zipEntry) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "model.zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	for _, e := range entries {
+		w, err := zw.Create(e.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(e.data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestInspectArchive(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []zipEntry
+		problem string
+	}{
+		{"valid", []zipEntry{{"model.json", []byte(`{"name":"steve"}`)}, {"textures/skin.png", []byte("png")}}, ""},
+		{"parent traversal", []zipEntry{{"../../evil.sh", []byte("x")}}, "outside the archive"},
+		{"absolute path", []zipEntry{{"/etc/cron.d/evil", []byte("x")}}, "outside the archive"},
+		{"windows traversal", []zipEntry{{"models\\..\\..\\evil.dll", []byte("x")}}, "outside the archive"},
+		{"compression bomb", []zipEntry{{"zeros.bin", bytes.Repeat([]byte{0}, 4<<20)}}, "compression ratio"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problem, err := inspectArchive(context.Background(), writeZip(t, tt.entries))
+			if err != nil {
+				t.Fatalf("inspectArchive() error = %v", err)
+			}
+			if tt.problem == "" && problem != "" {
+				t.Errorf("expected archive to pass, got %q", problem)
+			}
+			if tt.problem != "" && !strings.Contains(problem, tt.problem) {
+				t.Errorf("expected problem containing %q, got %q", tt.problem, problem)
+			}
+		})
+	}
+}
+
+func TestInspectArchive_NotAZip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fake.zip")
+	if err := os.WriteFile(path, []byte("PK\x03\x04 but not really"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	problem, err := inspectArchive(context.Background(), path)
+	if err != nil {
+		t.Fatalf("inspectArchive() error = %v", err)
+	}
+	if problem == "" {
+		t.Error("expected a corrupt archive to be rejected")
+	}
+}
+
+func TestInspectArchive_MissingFile(t *testing.T) {
+	_, err := inspectArchive(context.Background(), filepath.Join(t.TempDir(), "missing.zip"))
+	if !os.IsNotExist(err) {
+		t.Errorf("expected not-exist error, got %v", err)
+	}
+}
@@ -1,37 +1,61 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/ysmmc/backend/internal/config"
+	"github.com/ysmmc/backend/internal/database"
 	"github.com/ysmmc/backend/internal/model"
 	"github.com/ysmmc/backend/internal/repository"
 	"github.com/ysmmc/backend/pkg/auth"
 	"github.com/ysmmc/backend/pkg/email"
 	"github.com/ysmmc/backend/pkg/utils"
+	"gorm.io/gorm"
 )
 
 type AuthService struct {
-	userRepo     *repository.UserRepository
-	sessionRepo  *repository.SessionRepository
-	emailService *email.EmailService
+	userRepo             *repository.UserRepository
+	sessionRepo          *repository.SessionRepository
+	emailService         *email.EmailService
+	sanctionService      *SanctionService
+	mfaService           *MFAService
+	securityEventService *SecurityEventService
+	lockoutService       *LockoutService
+	registrationService  *RegistrationService
 }
 
 func NewAuthService() *AuthService {
 	return &AuthService{
-		userRepo:     repository.NewUserRepository(),
-		sessionRepo:  repository.NewSessionRepository(),
-		emailService: email.NewEmailService(),
+		userRepo:             repository.NewUserRepository(),
+		sessionRepo:          repository.NewSessionRepository(),
+		emailService:         email.NewEmailService(),
+		sanctionService:      NewSanctionService(),
+		mfaService:           NewMFAService(),
+		securityEventService: NewSecurityEventService(),
+		lockoutService:       NewLockoutService(),
+		registrationService:  NewRegistrationService(),
 	}
 }
 
+// refreshReuseGracePeriod 旧 refresh token 在轮换后的短时间内重复出现不视为泄露
+const refreshReuseGracePeriod = 10 * time.Second
+
+// emailChangeExpiry 修改邮箱验证链接的有效期
+const emailChangeExpiry = time.Hour
+
 type RegisterRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required,min=6"`
 	Username string `json:"username" binding:"required,min=2,max=50"`
+	// InviteCode invite_only 模式下必填，其他模式下填写则记录邀请关系
+	InviteCode string `json:"invite_code"`
+	// Language 邮件语言，未填写时由 handler 根据 Accept-Language 确定
+	Language string `json:"language"`
 }
 
 type LoginRequest struct {
@@ -40,18 +64,26 @@ type LoginRequest struct {
 }
 
 type LoginResponse struct {
-	AccessToken        string      `json:"access_token"`
-	RefreshToken       string      `json:"refresh_token"`
-	ExpiresIn          int64       `json:"expires_in"`
-	User               *model.User `json:"user"`
-	MustChangePassword bool        `json:"must_change_password"`
+	AccessToken           string      `json:"access_token,omitempty"`
+	RefreshToken          string      `json:"refresh_token,omitempty"`
+	ExpiresIn             int64       `json:"expires_in,omitempty"`
+	User                  *model.User `json:"user,omitempty"`
+	MustChangePassword    bool        `json:"must_change_password"`
+	MFARequired           bool        `json:"mfa_required,omitempty"`
+	MFAToken              string      `json:"mfa_token,omitempty"`
+	MFAEnrollmentRequired bool        `json:"mfa_enrollment_required,omitempty"`
+}
+
+type LoginMFARequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
 }
 
 type ChangeEmailRequest struct {
 	NewEmail string `json:"new_email" binding:"required,email"`
 }
 
-func (s *AuthService) Register(req *RegisterRequest) (*model.User, error) {
+func (s *AuthService) Register(ctx context.Context, req *RegisterRequest) (*model.User, error) {
 	req.Email = utils.SanitizeString(req.Email)
 	req.Username = utils.SanitizeString(req.Username)
 
@@ -67,11 +99,21 @@ func (s *AuthService) Register(req *RegisterRequest) (*model.User, error) {
 		return nil, errors.New("password must be at least 6 characters")
 	}
 
-	if s.userRepo.ExistsByEmail(req.Email) {
+	var count int64
+	s.userRepo.DB.Model(&model.User{}).Count(&count)
+	// 第一个账号（超级管理员）不受注册模式限制
+	bootstrap := count == 0
+	if !bootstrap {
+		if err := s.registrationService.CheckPolicy(ctx, req.Email, NormalizeInviteCode(req.InviteCode) != ""); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.userRepo.ExistsByEmail(ctx, req.Email) {
 		return nil, errors.New("email already registered")
 	}
 
-	if s.userRepo.ExistsByUsername(req.Username) {
+	if s.userRepo.ExistsByUsername(ctx, req.Username) {
 		return nil, errors.New("username already taken")
 	}
 
@@ -81,12 +123,10 @@ func (s *AuthService) Register(req *RegisterRequest) (*model.User, error) {
 	}
 
 	var userRole string
-	var count int64
-	s.userRepo.DB.Model(&model.User{}).Count(&count)
-	if count == 0 {
-		userRole = "super_admin"
+	if bootstrap {
+		userRole = model.RoleSuperAdmin
 	} else {
-		userRole = "user"
+		userRole = model.RoleUser
 	}
 
 	user := &model.User{
@@ -96,111 +136,341 @@ func (s *AuthService) Register(req *RegisterRequest) (*model.User, error) {
 		Role:          userRole,
 		ProfileStatus: "approved",
 		EmailVerified: false,
+		Language:      email.NormalizeLocale(req.Language),
 	}
 
-	if err := s.userRepo.Create(user); err != nil {
+	// 欢迎邮件与账号在同一事务中写入，不会出现账号已创建却没有验证邮件的情况
+	var verifyLink string
+	if s.emailService.IsConfigured() {
+		verifyLink = issueVerificationToken(user)
+	}
+
+	err = database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if !bootstrap {
+			invite, err := s.registrationService.admit(ctx, tx, req.Email, req.InviteCode)
+			if err != nil {
+				return err
+			}
+			if invite != nil {
+				user.InvitedBy = &invite.CreatedBy
+				user.InviteCodeID = &invite.ID
+			}
+		}
+		if err := (&repository.UserRepository{DB: tx}).Create(ctx, user); err != nil {
+			return err
+		}
+		if verifyLink == "" {
+			return nil
+		}
+		msg, err := s.emailService.WelcomeMessage(user.Language, user.Email, user.Username, verifyLink, config.AppConfig.EmailVerificationExpireHours)
+		if err != nil {
+			return err
+		}
+		return queueEmail(ctx, tx, &user.ID, model.EmailCategoryWelcome, msg)
+	})
+	if err != nil {
 		return nil, err
 	}
+	userRegistrations.Inc("password")
 
-	if s.emailService.IsConfigured() {
-		verifyToken := uuid.New().String()
-		user.VerificationToken = &verifyToken
-		s.userRepo.Update(user)
+	return user, nil
+}
+
+// issueVerificationToken 为用户设置新的邮箱验证 token（旧链接随之失效），返回验证链接；调用方负责保存用户
+func issueVerificationToken(user *model.User) string {
+	verifyToken := uuid.New().String()
+	expires := time.Now().Add(time.Duration(config.AppConfig.EmailVerificationExpireHours) * time.Hour)
+	user.VerificationToken = &verifyToken
+	user.VerificationExpires = &expires
+	return fmt.Sprintf("%s/verify-email?token=%s", config.AppConfig.FrontendURL, verifyToken)
+}
 
-		verifyLink := fmt.Sprintf("%s/verify-email?token=%s", config.AppConfig.FrontendURL, verifyToken)
-		go s.emailService.SendWelcome(user.Email, user.Username, verifyLink)
+// saveWithEmail 在同一事务中保存用户并写入邮件
+func saveWithEmail(ctx context.Context, user *model.User, category string, msg email.Message) error {
+	return database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := (&repository.UserRepository{DB: tx}).Update(ctx, user); err != nil {
+			return err
+		}
+		return queueEmail(ctx, tx, &user.ID, category, msg)
+	})
+}
+
+// ResendVerification 重新发送验证邮件
+func (s *AuthService) ResendVerification(ctx context.Context, userID uuid.UUID) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	if user.EmailVerified {
+		return errors.New("email is already verified")
+	}
+	if !s.emailService.IsConfigured() {
+		return errors.New("email service is not configured")
 	}
 
-	return user, nil
+	verifyLink := issueVerificationToken(user)
+	msg, err := s.emailService.VerifyEmailMessage(user.Language, user.Email, user.Username, verifyLink, config.AppConfig.EmailVerificationExpireHours)
+	if err != nil {
+		return err
+	}
+	return saveWithEmail(ctx, user, model.EmailCategoryVerifyEmail, msg)
 }
 
-func (s *AuthService) Login(req *LoginRequest) (*LoginResponse, error) {
-	user, err := s.userRepo.FindByEmail(req.Email)
+func (s *AuthService) Login(ctx context.Context, req *LoginRequest, client ClientInfo) (*LoginResponse, error) {
+	user, err := s.userRepo.FindByEmail(ctx, req.Email)
 	if err != nil {
+		user = nil
+	}
+
+	// 无论邮箱是否存在都执行一次密码比较，保持响应耗时一致
+	passwordOK := s.lockoutService.CheckPassword(user, req.Password)
+	if user == nil {
 		return nil, errors.New("invalid email or password")
 	}
 
-	if user.IsBanned {
-		return nil, errors.New("your account has been banned")
+	if err := s.lockoutService.Check(ctx, user, client); err != nil {
+		return nil, err
 	}
 
-	if !auth.CheckPassword(req.Password, user.PasswordHash) {
+	if !passwordOK {
+		s.lockoutService.RecordFailure(ctx, user, client)
 		return nil, errors.New("invalid email or password")
 	}
 
-	tokens, err := auth.GenerateToken(user.ID, user.Email, user.Role)
+	if !user.TOTPEnabled {
+		s.lockoutService.RecordSuccess(ctx, user, client)
+	}
+
+	return s.startLogin(ctx, user, client)
+}
+
+// startLogin 第一因素（密码或第三方身份）验证通过后的公共流程：
+// 检查封禁，启用了两步验证时返回挑战 token，否则直接签发 token
+func (s *AuthService) startLogin(ctx context.Context, user *model.User, client ClientInfo) (*LoginResponse, error) {
+	if err := s.sanctionService.CheckBan(ctx, user); err != nil {
+		return nil, err
+	}
+
+	if user.TOTPEnabled {
+		mfaToken, err := auth.GenerateMFAToken(user.ID, user.TokenVersion)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResponse{
+			MustChangePassword: user.MustChangePassword,
+			MFARequired:        true,
+			MFAToken:           mfaToken,
+		}, nil
+	}
+
+	return s.completeLogin(ctx, user, client)
+}
+
+// LoginMFA 两步登录的第二步：校验挑战 token 与验证码（或恢复码）后签发正式 token
+func (s *AuthService) LoginMFA(ctx context.Context, req *LoginMFARequest, client ClientInfo) (*LoginResponse, error) {
+	claims, err := auth.ParseMFAToken(req.MFAToken)
+	if err != nil {
+		return nil, errors.New("invalid or expired mfa token")
+	}
+
+	user, err := s.userRepo.FindByID(ctx, claims.UserID)
 	if err != nil {
+		return nil, errors.New("invalid or expired mfa token")
+	}
+
+	if user.TokenVersion != claims.TokenVersion || !user.TOTPEnabled {
+		return nil, errors.New("invalid or expired mfa token")
+	}
+
+	if err := s.sanctionService.CheckBan(ctx, user); err != nil {
 		return nil, err
 	}
 
-	expiresAt := time.Now().Add(time.Duration(config.AppConfig.JWTRefreshExpireDays) * 24 * time.Hour)
-	session := &model.Session{
-		UserID:    user.ID,
-		TokenHash: repository.HashToken(tokens.RefreshToken),
-		ExpiresAt: expiresAt,
+	if err := s.lockoutService.Check(ctx, user, client); err != nil {
+		return nil, err
+	}
+
+	if err := s.mfaService.VerifySecondFactor(ctx, user, req.Code); err != nil {
+		if errors.Is(err, ErrInvalidMFACode) {
+			s.lockoutService.RecordFailure(ctx, user, client)
+		}
+		return nil, err
+	}
+
+	s.lockoutService.RecordSuccess(ctx, user, client)
+	return s.completeLogin(ctx, user, client)
+}
+
+func (s *AuthService) completeLogin(ctx context.Context, user *model.User, client ClientInfo) (*LoginResponse, error) {
+	tokens, err := s.IssueTokens(ctx, user, client)
+	if err != nil {
+		return nil, err
 	}
-	s.sessionRepo.Create(session)
 
 	return &LoginResponse{
-		AccessToken:        tokens.AccessToken,
-		RefreshToken:       tokens.RefreshToken,
-		ExpiresIn:          tokens.ExpiresIn,
-		User:               user,
-		MustChangePassword: user.MustChangePassword,
+		AccessToken:           tokens.AccessToken,
+		RefreshToken:          tokens.RefreshToken,
+		ExpiresIn:             tokens.ExpiresIn,
+		User:                  user,
+		MustChangePassword:    user.MustChangePassword,
+		MFAEnrollmentRequired: !user.TOTPEnabled && RoleRequiresMFA(ctx, user.Role),
 	}, nil
 }
 
-func (s *AuthService) RefreshToken(refreshToken string) (*auth.TokenPair, error) {
+// RefreshToken 轮换会话的 refresh token，会话 ID 保持不变以便用户管理设备。
+// 每个会话即一个 token 家族：被轮换掉的 token 若再次出现，视为泄露并注销整个家族
+func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string, client ClientInfo) (*auth.TokenPair, error) {
 	tokenHash := repository.HashToken(refreshToken)
-	session, err := s.sessionRepo.FindByTokenHash(tokenHash)
+
+	var tokens *auth.TokenPair
+	var reused *model.ConsumedRefreshToken
+	var expired bool
+	err := database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		sessionRepo := &repository.SessionRepository{DB: tx}
+
+		session, err := sessionRepo.FindByTokenHashForUpdate(ctx, tokenHash)
+		if err != nil {
+			if consumed, err := sessionRepo.FindConsumed(ctx, tokenHash); err == nil {
+				reused = consumed
+			}
+			return errors.New("invalid refresh token")
+		}
+
+		now := time.Now()
+		if session.ExpiresAt.Before(now) {
+			expired = true
+			return errors.New("refresh token has expired")
+		}
+
+		user, err := s.userRepo.FindByID(ctx, session.UserID)
+		if err != nil {
+			return errors.New("user not found")
+		}
+
+		if err := s.sanctionService.CheckBan(ctx, user); err != nil {
+			return err
+		}
+
+		tokens, err = s.generateSessionTokens(user, session.ID)
+		if err != nil {
+			return err
+		}
+
+		session.TokenHash = repository.HashToken(tokens.RefreshToken)
+		session.ExpiresAt = refreshExpiry(now)
+		session.LastUsedAt = &now
+		client.apply(session)
+		if err := sessionRepo.Update(ctx, session); err != nil {
+			return err
+		}
+
+		return sessionRepo.MarkConsumed(ctx, &model.ConsumedRefreshToken{
+			TokenHash:  tokenHash,
+			SessionID:  session.ID,
+			UserID:     session.UserID,
+			ConsumedAt: now,
+			ExpiresAt:  session.ExpiresAt,
+		})
+	})
+
+	if reused != nil {
+		return nil, s.handleRefreshTokenReuse(ctx, reused, client)
+	}
+	if expired {
+		s.sessionRepo.DeleteByTokenHash(ctx, tokenHash)
+	}
 	if err != nil {
-		return nil, errors.New("invalid refresh token")
+		return nil, err
 	}
+	return tokens, nil
+}
 
-	if session.ExpiresAt.Before(time.Now()) {
-		s.sessionRepo.DeleteByTokenHash(tokenHash)
-		return nil, errors.New("refresh token has expired")
+// handleRefreshTokenReuse 处理已轮换 token 的重放。并发刷新（如多个标签页同时刷新）
+// 会在极短时间内重复提交同一 token，宽限期内仅拒绝请求，不注销会话
+func (s *AuthService) handleRefreshTokenReuse(ctx context.Context, consumed *model.ConsumedRefreshToken, client ClientInfo) error {
+	if time.Since(consumed.ConsumedAt) < refreshReuseGracePeriod {
+		return errors.New("refresh token has already been used")
 	}
 
-	user, err := s.userRepo.FindByID(session.UserID)
+	revoked, err := s.sessionRepo.DeleteByID(ctx, consumed.SessionID)
 	if err != nil {
-		return nil, errors.New("user not found")
+		slog.ErrorContext(ctx, "Failed to revoke session after refresh token reuse", "session_id", consumed.SessionID, "error", err)
 	}
+	activeSessions.invalidate(consumed.SessionID)
+
+	userID := consumed.UserID
+	s.securityEventService.Record(ctx, &userID, model.SecurityEventRefreshTokenReuse, client,
+		fmt.Sprintf("session=%s consumed_at=%s family_revoked=%t", consumed.SessionID, consumed.ConsumedAt.Format(time.RFC3339), revoked))
 
-	if user.IsBanned {
-		return nil, errors.New("your account has been banned")
+	if revoked {
+		s.securityEventService.AlertUser(ctx, userID, "检测到异常登录",
+			"一个已失效的登录凭证被再次使用，可能已被他人窃取。为保护您的账号，我们已注销对应的登录设备。", client)
 	}
 
-	s.sessionRepo.DeleteByTokenHash(tokenHash)
+	return errors.New("refresh token reuse detected, session has been revoked")
+}
 
-	tokens, err := auth.GenerateToken(user.ID, user.Email, user.Role)
+// IssueTokens 签发一组新的 token，并为 refresh token 创建会话。
+// 启用两步验证的用户只能经由 LoginMFA 获得会话，因此其 token 均标记为已通过二次验证
+func (s *AuthService) IssueTokens(ctx context.Context, user *model.User, client ClientInfo) (*auth.TokenPair, error) {
+	sessionID := uuid.New()
+	tokens, err := s.generateSessionTokens(user, sessionID)
 	if err != nil {
 		return nil, err
 	}
 
-	expiresAt := time.Now().Add(time.Duration(config.AppConfig.JWTRefreshExpireDays) * 24 * time.Hour)
-	newSession := &model.Session{
-		UserID:    user.ID,
-		TokenHash: repository.HashToken(tokens.RefreshToken),
-		ExpiresAt: expiresAt,
+	now := time.Now()
+	session := &model.Session{
+		ID:         sessionID,
+		UserID:     user.ID,
+		TokenHash:  repository.HashToken(tokens.RefreshToken),
+		ExpiresAt:  refreshExpiry(now),
+		LastUsedAt: &now,
+	}
+	client.apply(session)
+	if err := s.sessionRepo.Create(ctx, session); err != nil {
+		return nil, err
 	}
-	s.sessionRepo.Create(newSession)
 
 	return tokens, nil
 }
 
-func (s *AuthService) Logout(refreshToken string) error {
+func (s *AuthService) generateSessionTokens(user *model.User, sessionID uuid.UUID) (*auth.TokenPair, error) {
+	return auth.GenerateToken(auth.TokenSubject{
+		UserID:       user.ID,
+		Email:        user.Email,
+		Role:         user.Role,
+		TokenVersion: user.TokenVersion,
+		MFA:          user.TOTPEnabled,
+		SessionID:    sessionID,
+	})
+}
+
+func refreshExpiry(from time.Time) time.Time {
+	return from.Add(time.Duration(config.AppConfig.JWTRefreshExpireDays) * 24 * time.Hour)
+}
+
+func (s *AuthService) Logout(ctx context.Context, refreshToken string) error {
 	tokenHash := repository.HashToken(refreshToken)
-	return s.sessionRepo.DeleteByTokenHash(tokenHash)
+	session, err := s.sessionRepo.FindByTokenHash(ctx, tokenHash)
+	if err != nil {
+		return nil
+	}
+
+	if err := s.sessionRepo.DeleteByTokenHash(ctx, tokenHash); err != nil {
+		return err
+	}
+	activeSessions.invalidate(session.ID)
+	return nil
 }
 
-func (s *AuthService) ForgotPassword(emailAddr string) error {
-	user, err := s.userRepo.FindByEmail(emailAddr)
+func (s *AuthService) ForgotPassword(ctx context.Context, emailAddr string) error {
+	user, err := s.userRepo.FindByEmail(ctx, emailAddr)
 	if err != nil {
 		return nil
 	}
 
-	if user.IsBanned {
+	if user.IsBanActive(time.Now()) {
 		return nil
 	}
 
@@ -210,20 +480,20 @@ func (s *AuthService) ForgotPassword(emailAddr string) error {
 	expires := time.Now().Add(1 * time.Hour)
 	user.ResetExpires = &expires
 
-	if err := s.userRepo.Update(user); err != nil {
-		return err
+	if !s.emailService.IsConfigured() {
+		return s.userRepo.Update(ctx, user)
 	}
 
-	if s.emailService.IsConfigured() {
-		resetLink := fmt.Sprintf("%s/update-password?token=%s", config.AppConfig.FrontendURL, resetToken)
-		go s.emailService.SendResetPassword(user.Email, resetLink)
+	resetLink := fmt.Sprintf("%s/update-password?token=%s", config.AppConfig.FrontendURL, resetToken)
+	msg, err := s.emailService.ResetPasswordMessage(user.Language, user.Email, resetLink)
+	if err != nil {
+		return err
 	}
-
-	return nil
+	return saveWithEmail(ctx, user, model.EmailCategoryResetPassword, msg)
 }
 
-func (s *AuthService) ResetPassword(token, newPassword string) error {
-	user, err := s.userRepo.FindByResetToken(token)
+func (s *AuthService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	user, err := s.userRepo.FindByResetToken(ctx, token)
 	if err != nil {
 		return errors.New("invalid reset token")
 	}
@@ -241,52 +511,65 @@ func (s *AuthService) ResetPassword(token, newPassword string) error {
 	user.ResetToken = nil
 	user.ResetExpires = nil
 
-	return s.userRepo.Update(user)
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+
+	return RevokeUserTokens(ctx, user.ID)
 }
 
-func (s *AuthService) VerifyEmail(token string) error {
-	user, err := s.userRepo.FindByVerificationToken(token)
+func (s *AuthService) VerifyEmail(ctx context.Context, token string) error {
+	user, err := s.userRepo.FindByVerificationToken(ctx, token)
 	if err != nil {
 		return errors.New("invalid verification token")
 	}
 
+	if user.VerificationExpires == nil || user.VerificationExpires.Before(time.Now()) {
+		return errors.New("verification token has expired, please request a new one")
+	}
+
 	user.EmailVerified = true
 	user.VerificationToken = nil
+	user.VerificationExpires = nil
+
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
 
-	return s.userRepo.Update(user)
+	emailVerified.invalidate(user.ID)
+	return nil
 }
 
-func (s *AuthService) ChangeEmail(userID uuid.UUID, newEmail string) error {
-	user, err := s.userRepo.FindByID(userID)
+func (s *AuthService) ChangeEmail(ctx context.Context, userID uuid.UUID, newEmail string) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
 	if err != nil {
 		return errors.New("user not found")
 	}
 
-	if s.userRepo.ExistsByEmail(newEmail) {
+	if s.userRepo.ExistsByEmail(ctx, newEmail) {
 		return errors.New("email already in use")
 	}
 
 	changeToken := uuid.New().String()
-	expires := time.Now().Add(1 * time.Hour)
+	expires := time.Now().Add(emailChangeExpiry)
 	user.NewEmail = &newEmail
 	user.EmailChangeToken = &changeToken
 	user.EmailChangeExpires = &expires
 
-	if err := s.userRepo.Update(user); err != nil {
-		return err
+	if !s.emailService.IsConfigured() {
+		return s.userRepo.Update(ctx, user)
 	}
 
-	if s.emailService.IsConfigured() {
-		verifyLink := fmt.Sprintf("%s/verify-email-change?token=%s", config.AppConfig.FrontendURL, changeToken)
-		go s.emailService.Send(newEmail, "验证新邮箱 - YSM模型站",
-			fmt.Sprintf(`<html><body><h2>验证新邮箱</h2><p>您正在修改邮箱地址，请点击以下链接验证新邮箱：</p><p><a href="%s">%s</a></p><p>此链接将在1小时后失效。</p></body></html>`, verifyLink, verifyLink))
+	verifyLink := fmt.Sprintf("%s/verify-email-change?token=%s", config.AppConfig.FrontendURL, changeToken)
+	msg, err := s.emailService.EmailChangeMessage(user.Language, newEmail, user.Username, verifyLink, int(emailChangeExpiry/time.Hour))
+	if err != nil {
+		return err
 	}
-
-	return nil
+	return saveWithEmail(ctx, user, model.EmailCategoryEmailChange, msg)
 }
 
-func (s *AuthService) VerifyEmailChange(token string) error {
-	user, err := s.userRepo.FindByEmailChangeToken(token)
+func (s *AuthService) VerifyEmailChange(ctx context.Context, token string) error {
+	user, err := s.userRepo.FindByEmailChangeToken(ctx, token)
 	if err != nil {
 		return errors.New("invalid email change token")
 	}
@@ -299,7 +582,7 @@ func (s *AuthService) VerifyEmailChange(token string) error {
 		user.NewEmail = nil
 		user.EmailChangeToken = nil
 		user.EmailChangeExpires = nil
-		s.userRepo.Update(user)
+		s.userRepo.Update(ctx, user)
 		return errors.New("email change token has expired")
 	}
 
@@ -309,5 +592,10 @@ func (s *AuthService) VerifyEmailChange(token string) error {
 	user.EmailChangeExpires = nil
 	user.EmailVerified = true
 
-	return s.userRepo.Update(user)
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+
+	emailVerified.invalidate(user.ID)
+	return nil
 }
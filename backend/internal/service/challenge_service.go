@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ysmmc/backend/internal/config"
+	"github.com/ysmmc/backend/internal/repository"
+	"github.com/ysmmc/backend/pkg/auth"
+	"github.com/ysmmc/backend/pkg/pow"
+)
+
+// 需要工作量证明的操作
+const (
+	ChallengeRegister       = "register"
+	ChallengeForgotPassword = "forgot_password"
+)
+
+const (
+	challengeTTL = 5 * time.Minute
+	// challengeWindow 统计请求量的时间窗口，窗口内超过阈值时提高难度
+	challengeWindow          = 10 * time.Minute
+	challengeIPThreshold     = 5
+	challengeGlobalThreshold = 100
+)
+
+var (
+	ErrInvalidChallengePurpose = errors.New("invalid challenge purpose")
+	ErrChallengeFailed         = errors.New("proof-of-work verification failed, please request a new challenge")
+)
+
+type Challenge struct {
+	Token      string    `json:"token"`
+	Algorithm  string    `json:"algorithm"`
+	Difficulty int       `json:"difficulty"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+type challengeCounter struct {
+	start time.Time
+	count int
+}
+
+// challengeTracker 记录各 IP 与全站的请求量，仅保存在本进程内存中，只影响难度；
+// 已使用的挑战记录在数据库中
+type challengeTracker struct {
+	mu        sync.Mutex
+	counters  map[string]*challengeCounter
+	lastSweep time.Time
+}
+
+var challenges = &challengeTracker{
+	counters: make(map[string]*challengeCounter),
+}
+
+// hit 计数并返回窗口内的累计次数
+func (t *challengeTracker) hit(key string, now time.Time) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.sweep(now)
+	c, ok := t.counters[key]
+	if !ok || now.Sub(c.start) > challengeWindow {
+		c = &challengeCounter{start: now}
+		t.counters[key] = c
+	}
+	c.count++
+	return c.count
+}
+
+func (t *challengeTracker) sweep(now time.Time) {
+	if now.Sub(t.lastSweep) < time.Minute {
+		return
+	}
+	t.lastSweep = now
+	for key, c := range t.counters {
+		if now.Sub(c.start) > challengeWindow {
+			delete(t.counters, key)
+		}
+	}
+}
+
+// challengeDifficulty 在基础难度上按请求量加难：超过阈值后请求量每翻一倍增加 1 位
+func challengeDifficulty(base, max, ipCount, globalCount int) int {
+	if base <= 0 {
+		return 0
+	}
+	difficulty := base + extraBits(ipCount, challengeIPThreshold) + extraBits(globalCount, challengeGlobalThreshold)
+	if difficulty > max {
+		return max
+	}
+	return difficulty
+}
+
+func extraBits(count, threshold int) int {
+	n := 0
+	for ; count > threshold; count /= 2 {
+		n++
+	}
+	return n
+}
+
+type ChallengeService struct {
+	challengeRepo *repository.ChallengeRepository
+}
+
+func NewChallengeService() *ChallengeService {
+	return &ChallengeService{
+		challengeRepo: repository.NewChallengeRepository(),
+	}
+}
+
+func validChallengePurpose(purpose string) bool {
+	return purpose == ChallengeRegister || purpose == ChallengeForgotPassword
+}
+
+func (s *ChallengeService) enabled() bool {
+	return config.AppConfig.PowDifficulty > 0
+}
+
+// Issue 签发挑战：客户端需找到 solution 使 SHA-256(token + ":" + solution) 前 difficulty 位为 0
+func (s *ChallengeService) Issue(purpose, ip string) (*Challenge, error) {
+	if !validChallengePurpose(purpose) {
+		return nil, ErrInvalidChallengePurpose
+	}
+
+	now := time.Now()
+	ipCount := challenges.hit(purpose+":ip:"+ip, now)
+	globalCount := challenges.hit(purpose+":global", now)
+
+	cfg := config.AppConfig
+	maxDifficulty := cfg.PowMaxDifficulty
+	if maxDifficulty > pow.MaxDifficulty {
+		maxDifficulty = pow.MaxDifficulty
+	}
+	difficulty := challengeDifficulty(cfg.PowDifficulty, maxDifficulty, ipCount, globalCount)
+
+	token, claims, err := auth.GenerateChallengeToken(purpose, difficulty, challengeTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Challenge{
+		Token:      token,
+		Algorithm:  "sha256",
+		Difficulty: difficulty,
+		ExpiresAt:  claims.ExpiresAt.Time,
+	}, nil
+}
+
+// Verify 校验挑战的签名、用途、解答与一次性；未启用工作量证明时直接通过
+func (s *ChallengeService) Verify(ctx context.Context, purpose, token, solution, ip string) error {
+	if !s.enabled() {
+		return nil
+	}
+
+	now := time.Now()
+	claims, err := auth.ParseChallengeToken(token)
+	if err != nil || claims.Purpose != purpose || !pow.Verify(token, solution, claims.Difficulty) {
+		// 失败的提交同样计入该 IP 的请求量
+		challenges.hit(purpose+":ip:"+ip, now)
+		return ErrChallengeFailed
+	}
+
+	consumed, err := s.challengeRepo.Consume(ctx, claims.ID, claims.ExpiresAt.Time)
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return ErrChallengeFailed
+	}
+	return nil
+}
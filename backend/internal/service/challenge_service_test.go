@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/ysmmc/backend/internal/config"
+	"github.com/ysmmc/backend/pkg/pow"
+)
+
+func TestChallengeDifficulty(t *testing.T) {
+	tests := []struct {
+		name        string
+		ipCount     int
+		globalCount int
+		want        int
+	}{
+		{"normal traffic", 1, 10, 18},
+		{"ip at threshold", challengeIPThreshold, 10, 18},
+		{"ip just over threshold", challengeIPThreshold + 1, 10, 19},
+		{"ip four times threshold", challengeIPThreshold * 4, 10, 20},
+		{"global flood", 1, challengeGlobalThreshold * 2, 19},
+		{"capped", 10000, 100000, 24},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := challengeDifficulty(18, 24, tt.ipCount, tt.globalCount); got != tt.want {
+				t.Errorf("expected difficulty %d, got %d", tt.want, got)
+			}
+		})
+	}
+
+	if got := challengeDifficulty(0, 24, 1000, 1000); got != 0 {
+		t.Errorf("disabled proof-of-work should stay at difficulty 0, got %d", got)
+	}
+}
+
+func TestChallengeService_VerifyOnce(t *testing.T) {
+	setupTestDB(t)
+	setTestSigningKey(t)
+	difficulty, maxDifficulty := config.AppConfig.PowDifficulty, config.AppConfig.PowMaxDifficulty
+	config.AppConfig.PowDifficulty, config.AppConfig.PowMaxDifficulty = 4, 4
+	defer func() {
+		config.AppConfig.PowDifficulty, config.AppConfig.PowMaxDifficulty = difficulty, maxDifficulty
+	}()
+
+	ctx := context.Background()
+	s := NewChallengeService()
+	challenge, err := s.Issue(ChallengeRegister, "203.0.113.7")
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+	solution := ""
+	for i := 0; solution == ""; i++ {
+		if candidate := strconv.Itoa(i); pow.Verify(challenge.Token, candidate, challenge.Difficulty) {
+			solution = candidate
+		}
+	}
+
+	if err := s.Verify(ctx, ChallengeForgotPassword, challenge.Token, solution, "203.0.113.7"); err == nil {
+		t.Error("expected a challenge to be rejected for another purpose")
+	}
+	if err := s.Verify(ctx, ChallengeRegister, challenge.Token, solution, "203.0.113.7"); err != nil {
+		t.Fatalf("first use should succeed, got %v", err)
+	}
+	// 新建的服务实例模拟另一个副本，已使用记录来自数据库
+	if err := NewChallengeService().Verify(ctx, ChallengeRegister, challenge.Token, solution, "203.0.113.8"); err == nil {
+		t.Error("replayed challenge should be rejected")
+	}
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ysmmc/backend/internal/database"
+	"github.com/ysmmc/backend/internal/model"
+	"github.com/ysmmc/backend/internal/repository"
+	"github.com/ysmmc/backend/pkg/email"
+	"gorm.io/gorm"
+)
+
+const (
+	// emailMaxAttempts 邮件的最多发送次数；按任务队列的退避策略，SMTP 服务器约 3 小时内恢复都能送达
+	emailMaxAttempts = 10
+	// emailOutboxRetention 投递记录（含正文）保留时间
+	emailOutboxRetention = 30 * 24 * time.Hour
+	// emailStatsWindow 投递概况统计的时间范围
+	emailStatsWindow = 24 * time.Hour
+)
+
+// DeliverEmailPayload email.deliver 任务参数
+type DeliverEmailPayload struct {
+	OutboxID uuid.UUID `json:"outbox_id"`
+}
+
+// queueEmail 在 tx 中写入邮件及其投递任务，随触发它的数据变更一同提交或回滚
+func queueEmail(ctx context.Context, tx *gorm.DB, userID *uuid.UUID, category string, msg email.Message) error {
+	outbox := &model.EmailOutbox{
+		UserID:    userID,
+		Category:  category,
+		ToAddress: msg.To,
+		Subject:   msg.Subject,
+		Body:      msg.Body,
+		TextBody:  msg.Text,
+		Status:    model.EmailQueued,
+	}
+	if err := (&repository.EmailOutboxRepository{DB: tx}).Create(ctx, outbox); err != nil {
+		return err
+	}
+	_, err := enqueueJob(ctx, &repository.JobRepository{DB: tx}, JobDeliverEmail, DeliverEmailPayload{OutboxID: outbox.ID}, time.Now(), emailMaxAttempts)
+	return err
+}
+
+// classifyDelivery 根据发送结果确定邮件的投递状态，并返回交给任务队列的错误：
+// 永久性错误不再重试，临时错误在最后一次尝试失败后才标记为 failed
+func classifyDelivery(err error, lastAttempt bool) (string, error) {
+	switch {
+	case err == nil:
+		return model.EmailSent, nil
+	case email.IsPermanent(err):
+		return model.EmailFailed, Permanent(err)
+	case lastAttempt:
+		return model.EmailFailed, err
+	default:
+		return model.EmailRetrying, err
+	}
+}
+
+type EmailOutboxService struct {
+	outboxRepo   *repository.EmailOutboxRepository
+	emailService *email.EmailService
+}
+
+func NewEmailOutboxService() *EmailOutboxService {
+	return &EmailOutboxService{
+		outboxRepo:   repository.NewEmailOutboxRepository(),
+		emailService: email.NewEmailService(),
+	}
+}
+
+// Deliver 处理 email.deliver 任务
+func (s *EmailOutboxService) Deliver(ctx context.Context, job *model.Job) error {
+	var p DeliverEmailPayload
+	if err := decodeJobPayload(job, &p); err != nil {
+		return err
+	}
+
+	outbox, err := s.outboxRepo.FindByID(ctx, p.OutboxID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if outbox.Status == model.EmailSent {
+		return nil
+	}
+
+	sendErr := s.emailService.SendMessage(email.Message{To: outbox.ToAddress, Subject: outbox.Subject, Body: outbox.Body, Text: outbox.TextBody})
+	status, jobErr := classifyDelivery(sendErr, job.Attempts >= job.MaxAttempts)
+	emailDeliveries.Inc(outbox.Category, status)
+
+	reason := ""
+	if sendErr != nil {
+		reason = sendErr.Error()
+	}
+	if err := s.outboxRepo.RecordAttempt(ctx, outbox.ID, status, reason, time.Now()); err != nil {
+		slog.ErrorContext(ctx, "Failed to record email delivery", "outbox_id", outbox.ID, "error", err)
+	}
+	return jobErr
+}
+
+// Queue 在事务之外写入一封邮件，用于不伴随数据变更的通知
+func (s *EmailOutboxService) Queue(ctx context.Context, userID *uuid.UUID, category string, msg email.Message) error {
+	return database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return queueEmail(ctx, tx, userID, category, msg)
+	})
+}
+
+func (s *EmailOutboxService) Get(ctx context.Context, id uuid.UUID) (*model.EmailOutbox, error) {
+	return s.outboxRepo.FindByID(ctx, id)
+}
+
+func (s *EmailOutboxService) List(ctx context.Context, page, pageSize int, status, category, to string) ([]model.EmailOutbox, int64, error) {
+	return s.outboxRepo.List(ctx, page, pageSize, status, category, to)
+}
+
+// Stats 最近 24 小时各投递状态的邮件数
+func (s *EmailOutboxService) Stats(ctx context.Context) (map[string]int64, error) {
+	return s.outboxRepo.CountByStatus(ctx, time.Now().Add(-emailStatsWindow))
+}
+
+// Resend 重新投递发送失败的邮件
+func (s *EmailOutboxService) Resend(ctx context.Context, id uuid.UUID) error {
+	return database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		requeued, err := (&repository.EmailOutboxRepository{DB: tx}).Requeue(ctx, id)
+		if err != nil {
+			return err
+		}
+		if !requeued {
+			return errors.New("only failed emails can be resent")
+		}
+		_, err = enqueueJob(ctx, &repository.JobRepository{DB: tx}, JobDeliverEmail, DeliverEmailPayload{OutboxID: id}, time.Now(), emailMaxAttempts)
+		return err
+	})
+}
+
+// EmailTemplateInfo 可预览的邮件模板
+type EmailTemplateInfo struct {
+	Names   []string `json:"names"`
+	Locales []string `json:"locales"`
+}
+
+func (s *EmailOutboxService) Templates() EmailTemplateInfo {
+	return EmailTemplateInfo{Names: email.TemplateNames(), Locales: email.Locales}
+}
+
+// PreviewTemplate 使用示例数据渲染模板，locale 为空时使用默认语言
+func (s *EmailOutboxService) PreviewTemplate(name, locale string) (email.Message, error) {
+	return s.emailService.Preview(locale, name)
+}
+
+// Prune 删除早于保留期限且已结束投递的邮件
+func (s *EmailOutboxService) Prune(ctx context.Context, now time.Time) (int64, error) {
+	return s.outboxRepo.DeleteBefore(ctx, now.Add(-emailOutboxRetention))
+}
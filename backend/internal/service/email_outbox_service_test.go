@@ -0,0 +1,39 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ysmmc/backend/internal/model"
+	"github.com/ysmmc/backend/pkg/email"
+	"github.com/ysmmc/backend/pkg/email/emailtest"
+)
+
+func TestClassifyDelivery(t *testing.T) {
+	srv := emailtest.NewServer(t)
+	svc := email.New(email.Config{Host: srv.Host(), Port: srv.Port(), User: "mailer", Password: "secret", From: "mailer@ysmmc.local"})
+	send := func() error {
+		return svc.SendMessage(email.Message{To: "steve@example.com", Subject: "Hello", Body: "body"})
+	}
+
+	status, err := classifyDelivery(send(), false)
+	if status != model.EmailSent || err != nil {
+		t.Errorf("delivered email: got (%s, %v)", status, err)
+	}
+
+	srv.RejectRecipients("451 4.7.1 try again later")
+	status, err = classifyDelivery(send(), false)
+	if status != model.EmailRetrying || err == nil || errors.As(err, new(*PermanentError)) {
+		t.Errorf("temporary rejection should be retried: got (%s, %v)", status, err)
+	}
+	status, _ = classifyDelivery(send(), true)
+	if status != model.EmailFailed {
+		t.Errorf("last attempt should fail the email, got %s", status)
+	}
+
+	srv.RejectRecipients("550 5.1.1 user unknown")
+	status, err = classifyDelivery(send(), false)
+	if status != model.EmailFailed || !errors.As(err, new(*PermanentError)) {
+		t.Errorf("permanent rejection must not be retried: got (%s, %v)", status, err)
+	}
+}
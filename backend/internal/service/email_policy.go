@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/ysmmc/backend/internal/config"
+	"github.com/ysmmc/backend/internal/repository"
+	"github.com/ysmmc/backend/pkg/email"
+)
+
+var ErrEmailNotVerified = errors.New("please verify your email address before performing this action")
+
+var emailVerified = newLoaderCache(authCacheTTL, func(ctx context.Context, userID uuid.UUID) (bool, error) {
+	user, err := repository.NewUserRepository().FindByID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return user.EmailVerified, nil
+})
+
+// EmailVerificationRequired 判断该类操作是否要求已验证邮箱；未配置 SMTP 时用户无法完成验证，不做限制
+func EmailVerificationRequired(action string) bool {
+	if !email.NewEmailService().IsConfigured() {
+		return false
+	}
+	for _, a := range config.AppConfig.RequireVerifiedEmail {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckEmailVerified 按 REQUIRE_VERIFIED_EMAIL 策略校验用户邮箱是否已验证
+func CheckEmailVerified(ctx context.Context, userID uuid.UUID, action string) error {
+	if !EmailVerificationRequired(action) {
+		return nil
+	}
+	verified, err := emailVerified.get(ctx, userID)
+	if err != nil || !verified {
+		return ErrEmailNotVerified
+	}
+	return nil
+}
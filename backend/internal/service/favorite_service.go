@@ -1,9 +1,16 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
 	"github.com/google/uuid"
+	"github.com/ysmmc/backend/internal/config"
 	"github.com/ysmmc/backend/internal/model"
 	"github.com/ysmmc/backend/internal/repository"
+	"gorm.io/gorm"
 )
 
 type FavoriteService struct {
@@ -18,8 +25,8 @@ func NewFavoriteService() *FavoriteService {
 	}
 }
 
-func (s *FavoriteService) Add(userID, modelID uuid.UUID) error {
-	if s.favoriteRepo.Exists(userID, modelID) {
+func (s *FavoriteService) Add(ctx context.Context, userID, modelID uuid.UUID) error {
+	if s.favoriteRepo.Exists(ctx, userID, modelID) {
 		return nil
 	}
 
@@ -28,21 +35,67 @@ func (s *FavoriteService) Add(userID, modelID uuid.UUID) error {
 		ModelID: modelID,
 	}
 
-	return s.favoriteRepo.Create(favorite)
+	return s.favoriteRepo.Create(ctx, favorite)
+}
+
+func (s *FavoriteService) Remove(ctx context.Context, userID, modelID uuid.UUID) error {
+	return s.favoriteRepo.Delete(ctx, userID, modelID)
+}
+
+func (s *FavoriteService) IsFavorited(ctx context.Context, userID, modelID uuid.UUID) bool {
+	return s.favoriteRepo.Exists(ctx, userID, modelID)
 }
 
-func (s *FavoriteService) Remove(userID, modelID uuid.UUID) error {
-	return s.favoriteRepo.Delete(userID, modelID)
+func (s *FavoriteService) ListByUserID(ctx context.Context, userID uuid.UUID, page, pageSize int) ([]model.Favorite, int64, error) {
+	return s.favoriteRepo.ListByUserID(ctx, userID, page, pageSize)
 }
 
-func (s *FavoriteService) IsFavorited(userID, modelID uuid.UUID) bool {
-	return s.favoriteRepo.Exists(userID, modelID)
+func (s *FavoriteService) CountByModel(ctx context.Context, modelID uuid.UUID) (int64, error) {
+	return s.favoriteRepo.CountByModel(ctx, modelID)
 }
 
-func (s *FavoriteService) ListByUserID(userID uuid.UUID, page, pageSize int) ([]model.Favorite, int64, error) {
-	return s.favoriteRepo.ListByUserID(userID, page, pageSize)
+// NotifyFavoritesPayload notifications.favorite_update 任务参数
+type NotifyFavoritesPayload struct {
+	ModelID   uuid.UUID `json:"model_id"`
+	VersionID uuid.UUID `json:"version_id"`
 }
 
-func (s *FavoriteService) CountByModel(modelID uuid.UUID) (int64, error) {
-	return s.favoriteRepo.CountByModel(modelID)
+// handleNotifyFavorites 通知收藏了该模型的用户有新版本；模型未公开时不通知
+func handleNotifyFavorites(ctx context.Context, job *model.Job) error {
+	var p NotifyFavoritesPayload
+	if err := decodeJobPayload(job, &p); err != nil {
+		return err
+	}
+
+	version, err := repository.NewModelVersionRepository().FindByIDWithModel(ctx, p.VersionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	m := version.Model
+	if m == nil || m.Status != "approved" || !m.IsPublic {
+		return nil
+	}
+
+	userIDs, err := repository.NewFavoriteRepository().ListUserIDsByModel(ctx, m.ID)
+	if err != nil {
+		return err
+	}
+
+	notificationService := NewNotificationService()
+	link := fmt.Sprintf("%s/model/%s", config.AppConfig.FrontendURL, m.ID)
+	title := "收藏的模型有新版本"
+	body := fmt.Sprintf("您收藏的模型「%s」发布了新版本 %s", m.Title, version.VersionNumber)
+	// 部分用户失败时不重试整个任务，避免其他用户收到重复通知
+	for _, userID := range userIDs {
+		if userID == m.UserID {
+			continue
+		}
+		if err := notificationService.Notify(ctx, userID, model.NotificationFavoriteUpdate, title, body, &link); err != nil {
+			slog.ErrorContext(ctx, "Failed to notify user about new version", "user_id", userID, "model_id", m.ID, "error", err)
+		}
+	}
+	return nil
 }
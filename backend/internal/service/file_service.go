@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"io"
 
@@ -16,7 +17,7 @@ func NewFileService() *FileService {
 	return &FileService{}
 }
 
-func (s *FileService) SaveFile(name string, mimeType string, data []byte, category string, userID *uuid.UUID) (*model.File, error) {
+func (s *FileService) SaveFile(ctx context.Context, name string, mimeType string, data []byte, category string, userID *uuid.UUID) (*model.File, error) {
 	if !model.IsValidImageMimeType(mimeType) {
 		return nil, errors.New("invalid file type, only images are allowed")
 	}
@@ -35,24 +36,24 @@ func (s *FileService) SaveFile(name string, mimeType string, data []byte, catego
 		UserID:   userID,
 	}
 
-	if err := database.DB.Create(file).Error; err != nil {
+	if err := database.DB.WithContext(ctx).Create(file).Error; err != nil {
 		return nil, err
 	}
 
 	return file, nil
 }
 
-func (s *FileService) SaveFileFromReader(name string, mimeType string, reader io.Reader, category string, userID *uuid.UUID) (*model.File, error) {
+func (s *FileService) SaveFileFromReader(ctx context.Context, name string, mimeType string, reader io.Reader, category string, userID *uuid.UUID) (*model.File, error) {
 	data, err := io.ReadAll(reader)
 	if err != nil {
 		return nil, err
 	}
-	return s.SaveFile(name, mimeType, data, category, userID)
+	return s.SaveFile(ctx, name, mimeType, data, category, userID)
 }
 
-func (s *FileService) GetFile(id uuid.UUID) (*model.File, error) {
+func (s *FileService) GetFile(ctx context.Context, id uuid.UUID) (*model.File, error) {
 	var file model.File
-	if err := database.DB.First(&file, "id = ?", id).Error; err != nil {
+	if err := database.DB.WithContext(ctx).First(&file, "id = ?", id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("file not found")
 		}
@@ -61,16 +62,16 @@ func (s *FileService) GetFile(id uuid.UUID) (*model.File, error) {
 	return &file, nil
 }
 
-func (s *FileService) GetFileData(id uuid.UUID) ([]byte, string, error) {
-	file, err := s.GetFile(id)
+func (s *FileService) GetFileData(ctx context.Context, id uuid.UUID) ([]byte, string, error) {
+	file, err := s.GetFile(ctx, id)
 	if err != nil {
 		return nil, "", err
 	}
 	return file.Data, file.MimeType, nil
 }
 
-func (s *FileService) DeleteFile(id uuid.UUID) error {
-	result := database.DB.Delete(&model.File{}, "id = ?", id)
+func (s *FileService) DeleteFile(ctx context.Context, id uuid.UUID) error {
+	result := database.DB.WithContext(ctx).Delete(&model.File{}, "id = ?", id)
 	if result.Error != nil {
 		return result.Error
 	}
@@ -80,9 +81,9 @@ func (s *FileService) DeleteFile(id uuid.UUID) error {
 	return nil
 }
 
-func (s *FileService) GetFilesByUser(userID uuid.UUID, category string) ([]model.File, error) {
+func (s *FileService) GetFilesByUser(ctx context.Context, userID uuid.UUID, category string) ([]model.File, error) {
 	var files []model.File
-	query := database.DB.Where("user_id = ?", userID)
+	query := database.DB.WithContext(ctx).Where("user_id = ?", userID)
 	if category != "" {
 		query = query.Where("category = ?", category)
 	}
@@ -92,9 +93,9 @@ func (s *FileService) GetFilesByUser(userID uuid.UUID, category string) ([]model
 	return files, nil
 }
 
-func (s *FileService) GetFilesByIDs(ids []uuid.UUID) ([]model.File, error) {
+func (s *FileService) GetFilesByIDs(ctx context.Context, ids []uuid.UUID) ([]model.File, error) {
 	var files []model.File
-	if err := database.DB.Where("id IN ?", ids).Find(&files).Error; err != nil {
+	if err := database.DB.WithContext(ctx).Where("id IN ?", ids).Find(&files).Error; err != nil {
 		return nil, err
 	}
 	return files, nil
@@ -0,0 +1,353 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ysmmc/backend/internal/database"
+	"github.com/ysmmc/backend/internal/model"
+	"github.com/ysmmc/backend/internal/repository"
+	"github.com/ysmmc/backend/pkg/cron"
+	"github.com/ysmmc/backend/pkg/logging"
+	"gorm.io/gorm"
+)
+
+const (
+	// jobTimeout 单个任务的最长执行时间
+	jobTimeout = 10 * time.Minute
+	// jobStaleAfter 领取后超过该时间仍为 running 的任务视为执行实例已崩溃，重新排队
+	jobStaleAfter = 2 * jobTimeout
+	// jobMaintenanceInterval 检查到期周期任务与僵死任务的间隔
+	jobMaintenanceInterval = 30 * time.Second
+
+	// jobMaxAttempts 任务失败后最多执行的次数，之后进入死信
+	jobMaxAttempts    = 5
+	jobRetryBaseDelay = 30 * time.Second
+	jobRetryMaxDelay  = time.Hour
+)
+
+// JobHandlerFunc 执行一种类型的任务；返回 PermanentError 包装的错误时不再重试
+type JobHandlerFunc func(ctx context.Context, job *model.Job) error
+
+// PermanentError 重试也不会成功的错误（如参数无效），任务直接进入死信
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+func Permanent(err error) error {
+	return &PermanentError{Err: err}
+}
+
+// jobRetryDelay 第 attempt 次失败后的等待时间：30s 起按指数增长，最长 1 小时
+func jobRetryDelay(attempt int) time.Duration {
+	delay := jobRetryBaseDelay
+	for i := 1; i < attempt && delay < jobRetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > jobRetryMaxDelay {
+		delay = jobRetryMaxDelay
+	}
+	return delay
+}
+
+type JobService struct {
+	jobRepo *repository.JobRepository
+}
+
+func NewJobService() *JobService {
+	return &JobService{
+		jobRepo: repository.NewJobRepository(),
+	}
+}
+
+// Enqueue 写入一个立即可执行的任务，payload 序列化为 JSON
+func (s *JobService) Enqueue(ctx context.Context, kind string, payload interface{}) (*model.Job, error) {
+	return enqueueJob(ctx, s.jobRepo, kind, payload, time.Now(), jobMaxAttempts)
+}
+
+func enqueueJob(ctx context.Context, repo *repository.JobRepository, kind string, payload interface{}, runAt time.Time, maxAttempts int) (*model.Job, error) {
+	job := &model.Job{Kind: kind, Status: model.JobPending, MaxAttempts: maxAttempts, RunAt: runAt}
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s payload: %w", kind, err)
+		}
+		job.Payload = data
+	}
+	if err := repo.Create(ctx, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func (s *JobService) Get(ctx context.Context, id uuid.UUID) (*model.Job, error) {
+	return s.jobRepo.FindByID(ctx, id)
+}
+
+func (s *JobService) List(ctx context.Context, page, pageSize int, status, kind string) ([]model.Job, int64, error) {
+	return s.jobRepo.List(ctx, page, pageSize, status, kind)
+}
+
+// JobStats 队列概况：按类型与状态的计数、最早的积压任务与周期任务
+type JobStats struct {
+	Counts          []repository.JobCount `json:"counts"`
+	OldestPendingAt *time.Time            `json:"oldest_pending_at"`
+	Schedules       []model.JobSchedule   `json:"schedules"`
+}
+
+func (s *JobService) Stats(ctx context.Context) (*JobStats, error) {
+	counts, err := s.jobRepo.CountByKindAndStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	oldest, err := s.jobRepo.OldestPendingRunAt(ctx, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	schedules, err := s.jobRepo.ListSchedules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &JobStats{Counts: counts, OldestPendingAt: oldest, Schedules: schedules}, nil
+}
+
+// Retry 将死信任务重新排队
+func (s *JobService) Retry(ctx context.Context, id uuid.UUID) error {
+	retried, err := s.jobRepo.Retry(ctx, id, time.Now())
+	if err != nil {
+		return err
+	}
+	if !retried {
+		return errors.New("only dead jobs can be retried")
+	}
+	return nil
+}
+
+// Prune 删除早于 before 完成的成功任务与早于 deadBefore 的死信任务
+func (s *JobService) Prune(ctx context.Context, before, deadBefore time.Time) (int64, error) {
+	succeeded, err := s.jobRepo.DeleteFinished(ctx, model.JobSucceeded, before)
+	if err != nil {
+		return 0, err
+	}
+	dead, err := s.jobRepo.DeleteFinished(ctx, model.JobDead, deadBefore)
+	return succeeded + dead, err
+}
+
+type jobSchedule struct {
+	name, spec, kind string
+	schedule         *cron.Schedule
+}
+
+// JobWorker 从队列领取并执行任务，同时负责把到期的周期任务写入队列。
+// 多个实例可以同时运行，任务与周期计划都通过行锁保证只被处理一次
+type JobWorker struct {
+	jobRepo      *repository.JobRepository
+	id           string
+	concurrency  int
+	pollInterval time.Duration
+	handlers     map[string]JobHandlerFunc
+	schedules    []jobSchedule
+}
+
+func NewJobWorker(concurrency int, pollInterval time.Duration) *JobWorker {
+	host, _ := os.Hostname()
+	return &JobWorker{
+		jobRepo:      repository.NewJobRepository(),
+		id:           fmt.Sprintf("%s:%d", host, os.Getpid()),
+		concurrency:  concurrency,
+		pollInterval: pollInterval,
+		handlers:     map[string]JobHandlerFunc{},
+	}
+}
+
+// Handle 注册 kind 类型任务的处理函数
+func (w *JobWorker) Handle(kind string, fn JobHandlerFunc) {
+	w.handlers[kind] = fn
+}
+
+// Schedule 按 cron 表达式 spec 周期性地写入 kind 任务
+func (w *JobWorker) Schedule(name, spec, kind string) error {
+	schedule, err := cron.Parse(spec)
+	if err != nil {
+		return err
+	}
+	if _, ok := w.handlers[kind]; !ok {
+		return fmt.Errorf("schedule %s: no handler registered for %s", name, kind)
+	}
+	w.schedules = append(w.schedules, jobSchedule{name: name, spec: spec, kind: kind, schedule: schedule})
+	return nil
+}
+
+func (w *JobWorker) kinds() []string {
+	kinds := make([]string, 0, len(w.handlers))
+	for kind := range w.handlers {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// Run 启动 concurrency 个执行循环，直到 ctx 取消；正在执行的任务会在 jobTimeout 内执行完毕后返回
+func (w *JobWorker) Run(ctx context.Context) {
+	now := time.Now()
+	for _, s := range w.schedules {
+		err := w.jobRepo.EnsureSchedule(ctx, &model.JobSchedule{
+			Name: s.name, Spec: s.spec, Kind: s.kind, NextRunAt: s.schedule.Next(now),
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to register job schedule", "schedule", s.name, "error", err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		w.maintain(ctx)
+	}()
+	for i := 0; i < w.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.loop(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+func (w *JobWorker) loop(ctx context.Context) {
+	kinds := w.kinds()
+	for ctx.Err() == nil {
+		job, err := w.jobRepo.ClaimNext(ctx, w.id, kinds, time.Now())
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to claim job", "error", err)
+		}
+		if job != nil {
+			w.execute(ctx, job)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(w.pollInterval):
+		}
+	}
+}
+
+// execute 执行任务并记录结果。任务的 context 不随服务关闭取消，保证已领取的任务执行完毕；
+// 以它记录的日志都带有任务 ID 与类型
+func (w *JobWorker) execute(ctx context.Context, job *model.Job) {
+	ctx = logging.With(context.WithoutCancel(ctx), slog.String("job_id", job.ID.String()), slog.String("job_kind", job.Kind))
+	jobCtx, cancel := context.WithTimeout(ctx, jobTimeout)
+	defer cancel()
+
+	err := runJobHandler(jobCtx, w.handlers[job.Kind], job)
+	now := time.Now()
+
+	switch {
+	case err == nil:
+		err = w.jobRepo.MarkSucceeded(ctx, job.ID, now)
+	case errors.As(err, new(*PermanentError)) || job.Attempts >= job.MaxAttempts:
+		slog.ErrorContext(ctx, "Job failed permanently", "attempts", job.Attempts, "error", err)
+		err = w.jobRepo.MarkDead(ctx, job.ID, err.Error(), now)
+	default:
+		delay := jobRetryDelay(job.Attempts)
+		slog.WarnContext(ctx, "Job failed, will retry", "attempts", job.Attempts, "retry_in", delay.String(), "error", err)
+		err = w.jobRepo.MarkRetry(ctx, job.ID, now.Add(delay), err.Error())
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to record job result", "error", err)
+	}
+}
+
+// runJobHandler 调用处理函数并把 panic 转为错误
+func runJobHandler(ctx context.Context, fn JobHandlerFunc, job *model.Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.ErrorContext(ctx, "Job panicked", "panic", r, "stack", string(debug.Stack()))
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn(ctx, job)
+}
+
+// maintain 定期写入到期的周期任务并回收僵死任务
+func (w *JobWorker) maintain(ctx context.Context) {
+	ticker := time.NewTicker(jobMaintenanceInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.enqueueDueSchedules(ctx, time.Now()); err != nil {
+			slog.ErrorContext(ctx, "Failed to enqueue scheduled jobs", "error", err)
+		}
+		if n, err := w.jobRepo.RequeueStale(ctx, time.Now().Add(-jobStaleAfter)); err != nil {
+			slog.ErrorContext(ctx, "Failed to requeue stale jobs", "error", err)
+		} else if n > 0 {
+			slog.WarnContext(ctx, "Requeued jobs whose worker stopped responding", "count", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// enqueueDueSchedules 为每个到期的周期计划写入一个任务并推进下次执行时间。
+// 服务停机期间错过的多次执行只补一次
+func (w *JobWorker) enqueueDueSchedules(ctx context.Context, now time.Time) error {
+	specs := map[string]*cron.Schedule{}
+	for _, s := range w.schedules {
+		specs[s.name] = s.schedule
+	}
+
+	return database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		repo := &repository.JobRepository{DB: tx}
+		due, err := repo.ListDueSchedulesForUpdate(ctx, now)
+		if err != nil {
+			return err
+		}
+		for _, s := range due {
+			schedule, ok := specs[s.Name]
+			if !ok {
+				// 已从代码中移除或由其他版本注册的计划
+				continue
+			}
+			name := s.Name
+			job := &model.Job{Kind: s.Kind, Status: model.JobPending, MaxAttempts: jobMaxAttempts, RunAt: now, ScheduleName: &name}
+			if err := repo.Create(ctx, job); err != nil {
+				return err
+			}
+			if err := repo.AdvanceSchedule(ctx, s.Name, now, schedule.Next(now), job.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// decodeJobPayload 解析任务参数，格式错误时返回不可重试的错误
+func decodeJobPayload(job *model.Job, v interface{}) error {
+	if err := json.Unmarshal(job.Payload, v); err != nil {
+		return Permanent(fmt.Errorf("invalid %s payload: %w", job.Kind, err))
+	}
+	return nil
+}
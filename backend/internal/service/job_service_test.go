@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ysmmc/backend/internal/model"
+)
+
+func TestJobRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{5, 8 * time.Minute},
+		{8, time.Hour},
+		{50, time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := jobRetryDelay(tt.attempt); got != tt.want {
+			t.Errorf("jobRetryDelay(%d) = %s, want %s", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestPermanentError_SurvivesWrapping(t *testing.T) {
+	err := fmt.Errorf("send failed: %w", Permanent(errors.New("mailbox does not exist")))
+	if !errors.As(err, new(*PermanentError)) {
+		t.Error("wrapped permanent error should still be detected")
+	}
+	if errors.As(errors.New("timeout"), new(*PermanentError)) {
+		t.Error("plain error must not be treated as permanent")
+	}
+}
+
+func TestRunJobHandler_RecoversPanic(t *testing.T) {
+	job := &model.Job{Kind: "test.panic"}
+	err := runJobHandler(context.Background(), func(ctx context.Context, job *model.Job) error {
+		panic("boom")
+	}, job)
+	if err == nil {
+		t.Error("expected panic to be reported as an error")
+	}
+}
+
+func TestJobWorker_Schedule(t *testing.T) {
+	w := &JobWorker{handlers: map[string]JobHandlerFunc{}}
+	w.Handle("cleanup.test", func(ctx context.Context, job *model.Job) error { return nil })
+
+	if err := w.Schedule("bad-spec", "every hour", "cleanup.test"); err == nil {
+		t.Error("expected error for an invalid cron expression")
+	}
+	if err := w.Schedule("unknown", "@hourly", "cleanup.missing"); err == nil {
+		t.Error("expected error for a kind without handler")
+	}
+	if err := w.Schedule("hourly", "@hourly", "cleanup.test"); err != nil {
+		t.Fatalf("Schedule() error = %v", err)
+	}
+	if len(w.schedules) != 1 {
+		t.Errorf("expected 1 schedule, got %d", len(w.schedules))
+	}
+}
+
+func TestJobSchedules_AreValid(t *testing.T) {
+	w := &JobWorker{handlers: map[string]JobHandlerFunc{}}
+	for _, s := range jobSchedules {
+		w.Handle(s.kind, func(ctx context.Context, job *model.Job) error { return nil })
+	}
+	names := map[string]bool{}
+	for _, s := range jobSchedules {
+		if names[s.name] {
+			t.Errorf("duplicate schedule name %s", s.name)
+		}
+		names[s.name] = true
+		if err := w.Schedule(s.name, s.spec, s.kind); err != nil {
+			t.Errorf("schedule %s: %v", s.name, err)
+		}
+	}
+}
+
+func TestRollupDay(t *testing.T) {
+	now := time.Date(2026, 3, 6, 0, 10, 0, 0, time.UTC)
+	yesterday := "stats-rollup-yesterday"
+
+	tests := []struct {
+		name string
+		job  *model.Job
+		want time.Time
+	}{
+		{"default", &model.Job{}, now},
+		{"yesterday schedule", &model.Job{ScheduleName: &yesterday}, now.AddDate(0, 0, -1)},
+		{"explicit date", &model.Job{Payload: []byte(`{"date":"2026-02-01"}`)}, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := rollupDay(tt.job, now)
+		if err != nil {
+			t.Errorf("%s: rollupDay() error = %v", tt.name, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("%s: rollupDay() = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	_, err := rollupDay(&model.Job{Payload: []byte(`{"date":"March 1st"}`)}, now)
+	if !errors.As(err, new(*PermanentError)) {
+		t.Errorf("expected permanent error for an invalid date, got %v", err)
+	}
+}
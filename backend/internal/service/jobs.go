@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ysmmc/backend/internal/model"
+	"github.com/ysmmc/backend/internal/repository"
+)
+
+// 任务类型
+const (
+	JobDeliverEmail       = "email.deliver"
+	JobCleanTempFiles     = "cleanup.temp_files"
+	JobCleanSessions      = "cleanup.sessions"
+	JobAccountMaintenance = "account.maintenance"
+	JobPruneJobs          = "jobs.prune"
+	JobInspectArchive     = "model.inspect_archive"
+	JobRollupStats        = "stats.rollup"
+	JobNotifyFavorites    = "notifications.favorite_update"
+	JobSendDigests        = "notifications.digest"
+	JobBuildExport        = "account.build_export"
+)
+
+const (
+	// succeededJobRetention 成功任务保留时间，死信保留更久以便排查
+	succeededJobRetention = 7 * 24 * time.Hour
+	deadJobRetention      = 30 * 24 * time.Hour
+)
+
+// jobSchedules 周期任务：计划名、cron 表达式（服务器时区）与任务类型
+var jobSchedules = []struct {
+	name, spec, kind string
+}{
+	{"cleanup-temp-files", "17 * * * *", JobCleanTempFiles},
+	{"cleanup-sessions", "*/30 * * * *", JobCleanSessions},
+	{"account-maintenance", "5 * * * *", JobAccountMaintenance},
+	{"prune-jobs", "30 3 * * *", JobPruneJobs},
+	// 每天凌晨汇总前一天，每小时刷新当天的数据
+	{"stats-rollup", "55 * * * *", JobRollupStats},
+	{"stats-rollup-yesterday", "10 0 * * *", JobRollupStats},
+	{"digest-daily", "0 8 * * *", JobSendDigests},
+	{"digest-weekly", "0 8 * * 1", JobSendDigests},
+}
+
+// RollupStatsPayload stats.rollup 任务参数，Date 为空时汇总当前时间所在的一天，
+// 由 stats-rollup-yesterday 计划产生的任务汇总前一天
+type RollupStatsPayload struct {
+	Date string `json:"date"`
+}
+
+// BuildExportPayload account.build_export 任务参数
+type BuildExportPayload struct {
+	ExportID uuid.UUID `json:"export_id"`
+}
+
+// SendDigestsPayload notifications.digest 任务参数，由周期计划产生的任务按计划名确定频率
+type SendDigestsPayload struct {
+	Frequency string `json:"frequency"`
+}
+
+// RegisterJobs 注册所有任务类型的处理函数与周期计划
+func RegisterJobs(w *JobWorker) error {
+	outboxService := NewEmailOutboxService()
+	storageService := NewStorageService()
+	accountService := NewAccountService()
+	jobService := NewJobService()
+	statsService := NewStatsService()
+	notificationService := NewNotificationService()
+	sessionRepo := repository.NewSessionRepository()
+	identityRepo := repository.NewUserIdentityRepository()
+	challengeRepo := repository.NewChallengeRepository()
+
+	w.Handle(JobDeliverEmail, outboxService.Deliver)
+	w.Handle(JobCleanTempFiles, func(ctx context.Context, job *model.Job) error {
+		return storageService.CleanTempFiles()
+	})
+	w.Handle(JobCleanSessions, func(ctx context.Context, job *model.Job) error {
+		if err := sessionRepo.DeleteExpired(ctx); err != nil {
+			return err
+		}
+		if err := identityRepo.DeleteExpiredStates(ctx); err != nil {
+			return err
+		}
+		return challengeRepo.DeleteExpired(ctx)
+	})
+	w.Handle(JobAccountMaintenance, func(ctx context.Context, job *model.Job) error {
+		accountService.CleanupExports(ctx)
+		if purged := accountService.PurgeDueAccounts(ctx); purged > 0 {
+			slog.InfoContext(ctx, "Deleted accounts whose deletion grace period has ended", "count", purged)
+		}
+		return nil
+	})
+	w.Handle(JobPruneJobs, func(ctx context.Context, job *model.Job) error {
+		now := time.Now()
+		if _, err := jobService.Prune(ctx, now.Add(-succeededJobRetention), now.Add(-deadJobRetention)); err != nil {
+			return err
+		}
+		_, err := outboxService.Prune(ctx, now)
+		return err
+	})
+	w.Handle(JobInspectArchive, handleInspectArchive)
+	w.Handle(JobBuildExport, accountService.handleBuildExport)
+	w.Handle(JobRollupStats, func(ctx context.Context, job *model.Job) error {
+		day, err := rollupDay(job, time.Now())
+		if err != nil {
+			return err
+		}
+		_, err = statsService.Rollup(ctx, day)
+		return err
+	})
+	w.Handle(JobNotifyFavorites, handleNotifyFavorites)
+	w.Handle(JobSendDigests, func(ctx context.Context, job *model.Job) error {
+		frequency, err := digestFrequency(job)
+		if err != nil {
+			return err
+		}
+		sent, err := notificationService.SendDigests(ctx, frequency, time.Now())
+		if sent > 0 {
+			slog.InfoContext(ctx, "Queued digest emails", "count", sent, "frequency", frequency)
+		}
+		return err
+	})
+
+	for _, s := range jobSchedules {
+		if err := w.Schedule(s.name, s.spec, s.kind); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rollupDay 确定 stats.rollup 任务要汇总的日期
+func rollupDay(job *model.Job, now time.Time) (time.Time, error) {
+	if job.ScheduleName != nil && *job.ScheduleName == "stats-rollup-yesterday" {
+		return now.AddDate(0, 0, -1), nil
+	}
+	if len(job.Payload) == 0 {
+		return now, nil
+	}
+
+	var p RollupStatsPayload
+	if err := decodeJobPayload(job, &p); err != nil {
+		return time.Time{}, err
+	}
+	if p.Date == "" {
+		return now, nil
+	}
+	day, err := time.ParseInLocation("2006-01-02", p.Date, now.Location())
+	if err != nil {
+		return time.Time{}, Permanent(err)
+	}
+	return day, nil
+}
+
+// digestFrequency 确定 notifications.digest 任务的摘要频率
+func digestFrequency(job *model.Job) (string, error) {
+	if job.ScheduleName != nil {
+		switch *job.ScheduleName {
+		case "digest-daily":
+			return model.DigestDaily, nil
+		case "digest-weekly":
+			return model.DigestWeekly, nil
+		}
+	}
+
+	var p SendDigestsPayload
+	if err := decodeJobPayload(job, &p); err != nil {
+		return "", err
+	}
+	if p.Frequency != model.DigestDaily && p.Frequency != model.DigestWeekly {
+		return "", Permanent(fmt.Errorf("invalid digest frequency %q", p.Frequency))
+	}
+	return p.Frequency, nil
+}
+
+// EnqueueArchiveInspection 为待审核的模型文件排队检查，失败只记录日志，不影响上传流程
+func (s *JobService) EnqueueArchiveInspection(ctx context.Context, p InspectArchivePayload) {
+	if _, err := s.Enqueue(ctx, JobInspectArchive, p); err != nil {
+		slog.ErrorContext(ctx, "Failed to queue archive inspection", "model_id", p.ModelID, "error", err)
+	}
+}
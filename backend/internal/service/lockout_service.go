@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ysmmc/backend/internal/model"
+	"github.com/ysmmc/backend/internal/repository"
+	"github.com/ysmmc/backend/pkg/auth"
+)
+
+const (
+	// loginFreeAttempts 连续失败达到该次数后开始锁定
+	loginFreeAttempts = 5
+	// loginBackoffBase 首次锁定时长，此后每多失败一次翻倍
+	loginBackoffBase = 30 * time.Second
+	loginMaxLockout  = time.Hour
+	// loginFailureWindow 距上次失败超过该时间后重新计数
+	loginFailureWindow = 24 * time.Hour
+)
+
+var ErrAccountLocked = errors.New("too many failed login attempts, account is temporarily locked, please try again later")
+
+// dummyPasswordHash 账号不存在或未设置密码时也执行一次 bcrypt 比较，使响应时间不暴露邮箱是否注册
+var dummyPasswordHash = sync.OnceValue(func() string {
+	hash, err := auth.HashPassword("timing-equalization-placeholder")
+	if err != nil {
+		slog.Error("Failed to prepare dummy password hash", "error", err)
+	}
+	return hash
+})
+
+// lockoutDelay 返回连续失败 failures 次后的锁定时长
+func lockoutDelay(failures int) time.Duration {
+	if failures < loginFreeAttempts {
+		return 0
+	}
+	shift := failures - loginFreeAttempts
+	if shift >= 16 {
+		return loginMaxLockout
+	}
+	delay := loginBackoffBase << shift
+	if delay > loginMaxLockout {
+		return loginMaxLockout
+	}
+	return delay
+}
+
+type LockoutService struct {
+	userRepo             *repository.UserRepository
+	securityEventService *SecurityEventService
+}
+
+func NewLockoutService() *LockoutService {
+	return &LockoutService{
+		userRepo:             repository.NewUserRepository(),
+		securityEventService: NewSecurityEventService(),
+	}
+}
+
+// CheckPassword 以恒定耗时校验密码：user 为 nil 或未设置密码时与占位哈希比较，结果必为 false
+func (s *LockoutService) CheckPassword(user *model.User, password string) bool {
+	if user == nil || user.PasswordHash == "" {
+		auth.CheckPassword(password, dummyPasswordHash())
+		return false
+	}
+	return auth.CheckPassword(password, user.PasswordHash)
+}
+
+// Check 账号处于锁定期时拒绝登录，锁定期内的尝试不计入失败次数
+func (s *LockoutService) Check(ctx context.Context, user *model.User, client ClientInfo) error {
+	if !user.IsLocked(time.Now()) {
+		return nil
+	}
+	slog.WarnContext(ctx, "Login attempt for locked account", "user_id", user.ID, "ip", client.IP)
+	return ErrAccountLocked
+}
+
+// RecordFailure 记录一次失败（密码或二次验证码错误），达到阈值后按指数退避锁定
+func (s *LockoutService) RecordFailure(ctx context.Context, user *model.User, client ClientInfo) {
+	now := time.Now()
+	failures, err := s.userRepo.RecordFailedLogin(ctx, user.ID, now, loginFailureWindow)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to record failed login", "user_id", user.ID, "error", err)
+		return
+	}
+
+	delay := lockoutDelay(failures)
+	if delay == 0 {
+		return
+	}
+
+	if err := s.userRepo.SetLockedUntil(ctx, user.ID, now.Add(delay)); err != nil {
+		slog.ErrorContext(ctx, "Failed to lock account", "user_id", user.ID, "error", err)
+		return
+	}
+
+	userID := user.ID
+	s.securityEventService.Record(ctx, &userID, model.SecurityEventAccountLocked, client,
+		fmt.Sprintf("failures=%d locked_for=%s", failures, delay))
+
+	// 只在首次锁定时提醒，避免攻击期间持续发送邮件
+	if failures == loginFreeAttempts {
+		s.securityEventService.AlertUser(ctx, userID, "账号已被临时锁定",
+			fmt.Sprintf("您的账号连续 %d 次登录失败，已被临时锁定。若不是您本人操作，建议尽快修改密码并启用两步验证。", failures), client)
+	}
+}
+
+// RecordSuccess 登录成功后清除失败计数；之前存在失败记录时记为异常以便排查
+func (s *LockoutService) RecordSuccess(ctx context.Context, user *model.User, client ClientInfo) {
+	if user.FailedLoginCount == 0 && user.LockedUntil == nil {
+		return
+	}
+
+	if err := s.userRepo.ResetFailedLogins(ctx, user.ID); err != nil {
+		slog.ErrorContext(ctx, "Failed to reset failed logins", "user_id", user.ID, "error", err)
+	}
+
+	userID := user.ID
+	s.securityEventService.Record(ctx, &userID, model.SecurityEventLoginAfterFailures, client,
+		fmt.Sprintf("previous_failures=%d", user.FailedLoginCount))
+}
+
+// Unlock 管理员解除锁定
+func (s *LockoutService) Unlock(ctx context.Context, userID uuid.UUID) error {
+	return s.userRepo.ResetFailedLogins(ctx, userID)
+}
@@ -0,0 +1,27 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLockoutDelay(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{loginFreeAttempts - 1, 0},
+		{loginFreeAttempts, loginBackoffBase},
+		{loginFreeAttempts + 1, 2 * loginBackoffBase},
+		{loginFreeAttempts + 3, 8 * loginBackoffBase},
+		{loginFreeAttempts + 7, loginMaxLockout},
+		{loginFreeAttempts + 100, loginMaxLockout},
+	}
+
+	for _, tt := range tests {
+		if got := lockoutDelay(tt.failures); got != tt.want {
+			t.Errorf("lockoutDelay(%d) = %s, want %s", tt.failures, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ysmmc/backend/internal/model"
+	"github.com/ysmmc/backend/internal/repository"
+)
+
+const maintenancePageSize = 500
+
+// orphanScanDirs 清理孤立文件时扫描的上传子目录；temp 与 exports 由各自的定时清理负责
+var orphanScanDirs = []string{"models", "images"}
+
+// MaintenanceService 运维任务：清理孤立文件、校验存储、重建搜索索引
+type MaintenanceService struct {
+	fileRepo       *repository.FileRepository
+	modelRepo      *repository.ModelRepository
+	versionRepo    *repository.ModelVersionRepository
+	storageService *StorageService
+}
+
+func NewMaintenanceService() *MaintenanceService {
+	return &MaintenanceService{
+		fileRepo:       repository.NewFileRepository(),
+		modelRepo:      repository.NewModelRepository(),
+		versionRepo:    repository.NewModelVersionRepository(),
+		storageService: NewStorageService(),
+	}
+}
+
+// OrphanDiskFile 上传目录中未被任何记录引用的文件
+type OrphanDiskFile struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+type OrphanReport struct {
+	// Files 数据库中未被引用的图片（不含内容）
+	Files     []model.File     `json:"files"`
+	DiskFiles []OrphanDiskFile `json:"disk_files"`
+	// Removed 实际删除的数据库文件数；dry run 时为 0
+	Removed int64 `json:"removed"`
+}
+
+// CollectOrphans 查找早于 minAge 且未被引用的文件，dryRun 为 false 时一并删除。
+// minAge 用于跳过刚上传、尚未提交到模型或资料中的文件
+func (s *MaintenanceService) CollectOrphans(ctx context.Context, minAge time.Duration, dryRun bool) (*OrphanReport, error) {
+	before := time.Now().Add(-minAge)
+	report := &OrphanReport{}
+
+	files, err := s.fileRepo.ListOrphans(ctx, before)
+	if err != nil {
+		return nil, err
+	}
+	report.Files = files
+
+	paths, err := s.fileRepo.ListReferencedPaths(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report.DiskFiles, err = findOrphanDiskFiles(s.storageService.GetUploadPath(), orphanScanDirs, referencedNames(paths), before)
+	if err != nil {
+		return nil, err
+	}
+
+	if dryRun {
+		return report, nil
+	}
+
+	ids := make([]uuid.UUID, len(files))
+	for i, f := range files {
+		ids[i] = f.ID
+	}
+	if report.Removed, err = s.fileRepo.DeleteOrphans(ctx, ids); err != nil {
+		return nil, err
+	}
+
+	// 删除前重新读取引用，跳过扫描期间被新记录引用的文件
+	if paths, err = s.fileRepo.ListReferencedPaths(ctx); err != nil {
+		return nil, err
+	}
+	referenced := referencedNames(paths)
+	remaining := report.DiskFiles[:0]
+	for _, f := range report.DiskFiles {
+		if referenced[filepath.Base(f.Path)] {
+			continue
+		}
+		if err := os.Remove(f.Path); err != nil && !os.IsNotExist(err) {
+			slog.WarnContext(ctx, "Failed to remove orphaned file", "path", f.Path, "error", err)
+			continue
+		}
+		remaining = append(remaining, f)
+	}
+	report.DiskFiles = remaining
+
+	if err := s.storageService.CleanTempFiles(); err != nil {
+		slog.ErrorContext(ctx, "Failed to clean temp files", "error", err)
+	}
+	return report, nil
+}
+
+// referencedNames 返回被引用文件的文件名集合。上传文件均以随机 UUID 命名，
+// 按文件名比较可以兼容历史数据中 /uploads/ 前缀、日期分区等不同的路径写法
+func referencedNames(paths []string) map[string]bool {
+	names := map[string]bool{}
+	for _, p := range paths {
+		p = strings.TrimSpace(p)
+		if p == "" || strings.Contains(p, "://") {
+			continue
+		}
+		names[filepath.Base(filepath.FromSlash(p))] = true
+	}
+	return names
+}
+
+func findOrphanDiskFiles(root string, dirs []string, referenced map[string]bool, before time.Time) ([]OrphanDiskFile, error) {
+	orphans := []OrphanDiskFile{}
+	for _, dir := range dirs {
+		err := filepath.WalkDir(filepath.Join(root, dir), func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if d.IsDir() || referenced[d.Name()] {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			if !info.ModTime().Before(before) {
+				return nil
+			}
+			orphans = append(orphans, OrphanDiskFile{Path: path, Size: info.Size(), ModTime: info.ModTime()})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(orphans, func(i, j int) bool { return orphans[i].Path < orphans[j].Path })
+	return orphans, nil
+}
+
+// StorageProblem 记录引用的文件缺失或与记录不一致
+type StorageProblem struct {
+	Kind    string    `json:"kind"`
+	ID      uuid.UUID `json:"id"`
+	Path    string    `json:"path"`
+	Problem string    `json:"problem"`
+}
+
+// VerifyStorage 检查全部模型与版本引用的文件是否存在、大小是否与记录一致，返回检查的文件数与发现的问题
+func (s *MaintenanceService) VerifyStorage(ctx context.Context) (int, []StorageProblem, error) {
+	checked := 0
+	problems := []StorageProblem{}
+	check := func(kind string, id uuid.UUID, stored string, size int64) {
+		if stored == "" || strings.Contains(stored, "://") {
+			return
+		}
+		checked++
+		path := s.storageService.ResolvePath(stored)
+		if problem := checkStoredFile(path, size); problem != "" {
+			problems = append(problems, StorageProblem{Kind: kind, ID: id, Path: path, Problem: problem})
+		}
+	}
+
+	for page := 1; ; page++ {
+		models, _, err := s.modelRepo.ListAll(ctx, page, maintenancePageSize, "", "")
+		if err != nil {
+			return checked, problems, err
+		}
+		for _, m := range models {
+			check("model", m.ID, m.FilePath, m.FileSize)
+			if m.ImageURL != nil {
+				check("model_image", m.ID, *m.ImageURL, 0)
+			}
+
+			versions, err := s.versionRepo.FindByModelID(ctx, m.ID)
+			if err != nil {
+				return checked, problems, err
+			}
+			for _, v := range versions {
+				check("version", v.ID, v.FilePath, v.FileSize)
+				if v.ImageURL != nil {
+					check("version_image", v.ID, *v.ImageURL, 0)
+				}
+			}
+		}
+		if len(models) < maintenancePageSize {
+			break
+		}
+	}
+	return checked, problems, nil
+}
+
+// checkStoredFile 返回文件的问题描述，正常时返回空字符串；size 为 0 表示记录中没有大小，不做比较
+func checkStoredFile(path string, size int64) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "file is missing"
+		}
+		return err.Error()
+	}
+	if info.IsDir() {
+		return "path is a directory"
+	}
+	if size > 0 && info.Size() != size {
+		return fmt.Sprintf("size mismatch: recorded %d bytes, found %d", size, info.Size())
+	}
+	return ""
+}
+
+// ReindexSearch 重建模型标题搜索索引
+func (s *MaintenanceService) ReindexSearch(ctx context.Context) error {
+	return s.modelRepo.ReindexSearch(ctx)
+}
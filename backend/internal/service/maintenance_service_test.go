@@ -0,0 +1,79 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReferencedNames(t *testing.T) {
+	names := referencedNames([]string{
+		"/uploads/models/2026-01/a.ysm",
+		"models/b.zip",
+		"https://cdn.example.com/c.png",
+		"",
+	})
+
+	for _, want := range []string{"a.ysm", "b.zip"} {
+		if !names[want] {
+			t.Errorf("expected %s to be referenced", want)
+		}
+	}
+	if names["c.png"] {
+		t.Error("remote urls should not reference local files")
+	}
+}
+
+func TestFindOrphanDiskFiles(t *testing.T) {
+	root := t.TempDir()
+	old := time.Now().Add(-48 * time.Hour)
+	write := func(rel string, modTime time.Time) string {
+		path := filepath.Join(root, rel)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte("data"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		os.Chtimes(path, modTime, modTime)
+		return path
+	}
+
+	write("models/2026-01/kept.ysm", old)
+	orphan := write("models/2026-01/orphan.ysm", old)
+	write("models/recent.ysm", time.Now())
+	write("temp/ignored.tmp", old)
+
+	orphans, err := findOrphanDiskFiles(root, orphanScanDirs, map[string]bool{"kept.ysm": true}, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("findOrphanDiskFiles() error = %v", err)
+	}
+	if len(orphans) != 1 || orphans[0].Path != orphan {
+		t.Fatalf("expected only %s, got %+v", orphan, orphans)
+	}
+}
+
+func TestFindOrphanDiskFiles_MissingDirectories(t *testing.T) {
+	orphans, err := findOrphanDiskFiles(t.TempDir(), orphanScanDirs, map[string]bool{}, time.Now())
+	if err != nil || len(orphans) != 0 {
+		t.Errorf("expected no orphans and no error, got %v, %v", orphans, err)
+	}
+}
+
+func TestCheckStoredFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "model.ysm")
+	os.WriteFile(path, []byte("12345"), 0644)
+
+	if problem := checkStoredFile(path, 5); problem != "" {
+		t.Errorf("expected no problem, got %q", problem)
+	}
+	if problem := checkStoredFile(path, 0); problem != "" {
+		t.Errorf("unknown size should not be compared, got %q", problem)
+	}
+	if problem := checkStoredFile(path, 6); !strings.Contains(problem, "size mismatch") {
+		t.Errorf("expected size mismatch, got %q", problem)
+	}
+	if problem := checkStoredFile(path+".missing", 0); problem != "file is missing" {
+		t.Errorf("expected missing file, got %q", problem)
+	}
+}
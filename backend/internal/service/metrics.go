@@ -0,0 +1,30 @@
+package service
+
+import "github.com/ysmmc/backend/pkg/metrics"
+
+// 业务指标，通过 /metrics 输出
+var (
+	storageDiskTotalBytes = metrics.NewGaugeVec("ysmmc_storage_disk_total_bytes",
+		"Capacity of the filesystem holding the upload directory.")
+	storageDiskFreeBytes = metrics.NewGaugeVec("ysmmc_storage_disk_free_bytes",
+		"Free space available on the filesystem holding the upload directory.")
+	storageDiskUsageRatio = metrics.NewGaugeVec("ysmmc_storage_disk_usage_ratio",
+		"Used fraction of the upload filesystem; uploads are refused at MAX_DISK_USAGE.")
+
+	emailDeliveries = metrics.NewCounterVec("ysmmc_email_deliveries_total",
+		"Email delivery attempts by category and resulting status (sent, retrying, failed).", "category", "status")
+
+	userRegistrations = metrics.NewCounterVec("ysmmc_user_registrations_total",
+		"Accounts created by self-service registration, by method (password, oidc).", "method")
+	modelReviews = metrics.NewCounterVec("ysmmc_model_reviews_total",
+		"Model review decisions by result (approved, rejected) and source (manual, bulk, automatic).", "result", "source")
+	modelDownloads = metrics.NewCounterVec("ysmmc_model_downloads_total",
+		"Model downloads by kind (model for the current file, version for a specific version).", "kind")
+)
+
+func reviewResult(approved bool) string {
+	if approved {
+		return "approved"
+	}
+	return "rejected"
+}
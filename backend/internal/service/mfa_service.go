@@ -0,0 +1,245 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ysmmc/backend/internal/model"
+	"github.com/ysmmc/backend/internal/repository"
+	"github.com/ysmmc/backend/pkg/auth"
+)
+
+const (
+	totpIssuer        = "YSMMC"
+	recoveryCodeCount = 10
+)
+
+var ErrInvalidMFACode = errors.New("invalid verification code")
+
+type MFAService struct {
+	userRepo     *repository.UserRepository
+	recoveryRepo *repository.RecoveryCodeRepository
+}
+
+func NewMFAService() *MFAService {
+	return &MFAService{
+		userRepo:     repository.NewUserRepository(),
+		recoveryRepo: repository.NewRecoveryCodeRepository(),
+	}
+}
+
+type MFASetupRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type MFADisableRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+type MFASetupResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type MFAStatus struct {
+	Enabled                bool  `json:"enabled"`
+	Required               bool  `json:"required"`
+	RecoveryCodesRemaining int64 `json:"recovery_codes_remaining"`
+}
+
+func (s *MFAService) Status(ctx context.Context, userID uuid.UUID) (*MFAStatus, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	status := &MFAStatus{
+		Enabled:  user.TOTPEnabled,
+		Required: RoleRequiresMFA(ctx, user.Role),
+	}
+	if user.TOTPEnabled {
+		status.RecoveryCodesRemaining, _ = s.recoveryRepo.CountUnused(ctx, userID)
+	}
+	return status, nil
+}
+
+// Setup 生成新的 TOTP 密钥，需调用 Enable 提交一次验证码后才会生效
+func (s *MFAService) Setup(ctx context.Context, userID uuid.UUID, req *MFASetupRequest) (*MFASetupResponse, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	if !auth.CheckPassword(req.Password, user.PasswordHash) {
+		return nil, errors.New("incorrect password")
+	}
+
+	if user.TOTPEnabled {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	user.TOTPSecret = &secret
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return &MFASetupResponse{
+		Secret:          secret,
+		ProvisioningURI: auth.TOTPProvisioningURI(totpIssuer, user.Email, secret),
+	}, nil
+}
+
+// Enable 校验认证器生成的验证码并启用两步验证，返回一次性恢复码（仅展示一次）
+func (s *MFAService) Enable(ctx context.Context, userID uuid.UUID, req *MFACodeRequest) ([]string, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	if user.TOTPEnabled {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	if user.TOTPSecret == nil {
+		return nil, errors.New("two-factor authentication has not been set up")
+	}
+
+	if err := s.verifyTOTP(ctx, user, req.Code); err != nil {
+		return nil, err
+	}
+
+	codes, err := s.replaceRecoveryCodes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	user.TOTPEnabled = true
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	// 启用后旧会话均未经过二次验证，统一注销
+	if err := RevokeUserTokens(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+func (s *MFAService) Disable(ctx context.Context, userID uuid.UUID, req *MFADisableRequest) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	if !user.TOTPEnabled {
+		return errors.New("two-factor authentication is not enabled")
+	}
+
+	if RoleRequiresMFA(ctx, user.Role) {
+		return errors.New("two-factor authentication is required for your role")
+	}
+
+	if !auth.CheckPassword(req.Password, user.PasswordHash) {
+		return errors.New("incorrect password")
+	}
+
+	if err := s.VerifySecondFactor(ctx, user, req.Code); err != nil {
+		return err
+	}
+
+	user.TOTPEnabled = false
+	user.TOTPSecret = nil
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+
+	return s.recoveryRepo.DeleteByUserID(ctx, userID)
+}
+
+func (s *MFAService) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, req *MFACodeRequest) ([]string, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	if !user.TOTPEnabled {
+		return nil, errors.New("two-factor authentication is not enabled")
+	}
+
+	if err := s.verifyTOTP(ctx, user, req.Code); err != nil {
+		return nil, err
+	}
+
+	return s.replaceRecoveryCodes(ctx, userID)
+}
+
+// VerifySecondFactor 接受认证器验证码或一次性恢复码
+func (s *MFAService) VerifySecondFactor(ctx context.Context, user *model.User, code string) error {
+	if err := s.verifyTOTP(ctx, user, code); err == nil {
+		return nil
+	}
+
+	normalized := auth.NormalizeRecoveryCode(code)
+	if normalized == "" {
+		return ErrInvalidMFACode
+	}
+
+	ok, err := s.recoveryRepo.Consume(ctx, user.ID, repository.HashToken(normalized))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+func (s *MFAService) verifyTOTP(ctx context.Context, user *model.User, code string) error {
+	if user.TOTPSecret == nil {
+		return ErrInvalidMFACode
+	}
+
+	step, ok := auth.ValidateTOTP(*user.TOTPSecret, code, time.Now())
+	if !ok {
+		return ErrInvalidMFACode
+	}
+
+	advanced, err := s.userRepo.AdvanceTOTPStep(ctx, user.ID, step)
+	if err != nil {
+		return err
+	}
+	if !advanced {
+		return errors.New("verification code has already been used")
+	}
+	return nil
+}
+
+func (s *MFAService) replaceRecoveryCodes(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	codes, err := auth.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = repository.HashToken(auth.NormalizeRecoveryCode(code))
+	}
+
+	if err := s.recoveryRepo.Replace(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
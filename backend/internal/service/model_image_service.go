@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"errors"
 
 	"github.com/google/uuid"
@@ -37,8 +38,8 @@ type UpdateImageOrderRequest struct {
 	Images []ImageOrderItem `json:"images" binding:"required"`
 }
 
-func (s *ModelImageService) AddImage(modelID, userID uuid.UUID, req *AddModelImageRequest, isAdmin bool) (*model.ModelImage, error) {
-	m, err := s.modelRepo.FindByID(modelID)
+func (s *ModelImageService) AddImage(ctx context.Context, modelID, userID uuid.UUID, req *AddModelImageRequest, isAdmin bool) (*model.ModelImage, error) {
+	m, err := s.modelRepo.FindByID(ctx, modelID)
 	if err != nil {
 		return nil, err
 	}
@@ -47,7 +48,7 @@ func (s *ModelImageService) AddImage(modelID, userID uuid.UUID, req *AddModelIma
 		return nil, errors.New("unauthorized")
 	}
 
-	file, err := s.fileService.GetFile(req.FileID)
+	file, err := s.fileService.GetFile(ctx, req.FileID)
 	if err != nil {
 		return nil, errors.New("file not found")
 	}
@@ -56,7 +57,7 @@ func (s *ModelImageService) AddImage(modelID, userID uuid.UUID, req *AddModelIma
 		return nil, errors.New("file is not an image")
 	}
 
-	count, err := s.imageRepo.CountByModelID(modelID)
+	count, err := s.imageRepo.CountByModelID(ctx, modelID)
 	if err != nil {
 		return nil, err
 	}
@@ -65,7 +66,7 @@ func (s *ModelImageService) AddImage(modelID, userID uuid.UUID, req *AddModelIma
 		return nil, errors.New("maximum number of images reached (10)")
 	}
 
-	exists, err := s.imageRepo.Exists(modelID, req.FileID)
+	exists, err := s.imageRepo.Exists(ctx, modelID, req.FileID)
 	if err != nil {
 		return nil, err
 	}
@@ -79,19 +80,19 @@ func (s *ModelImageService) AddImage(modelID, userID uuid.UUID, req *AddModelIma
 		SortOrder: int(count),
 	}
 
-	if err := s.imageRepo.Create(image); err != nil {
+	if err := s.imageRepo.Create(ctx, image); err != nil {
 		return nil, err
 	}
 
 	return image, nil
 }
 
-func (s *ModelImageService) ListImages(modelID uuid.UUID) ([]model.ModelImage, error) {
-	return s.imageRepo.FindByModelIDWithFile(modelID)
+func (s *ModelImageService) ListImages(ctx context.Context, modelID uuid.UUID) ([]model.ModelImage, error) {
+	return s.imageRepo.FindByModelIDWithFile(ctx, modelID)
 }
 
-func (s *ModelImageService) DeleteImage(modelID, fileID, userID uuid.UUID, isAdmin bool) error {
-	m, err := s.modelRepo.FindByID(modelID)
+func (s *ModelImageService) DeleteImage(ctx context.Context, modelID, fileID, userID uuid.UUID, isAdmin bool) error {
+	m, err := s.modelRepo.FindByID(ctx, modelID)
 	if err != nil {
 		return err
 	}
@@ -100,11 +101,11 @@ func (s *ModelImageService) DeleteImage(modelID, fileID, userID uuid.UUID, isAdm
 		return errors.New("unauthorized")
 	}
 
-	return s.imageRepo.Delete(modelID, fileID)
+	return s.imageRepo.Delete(ctx, modelID, fileID)
 }
 
-func (s *ModelImageService) UpdateOrder(modelID, userID uuid.UUID, req *UpdateImageOrderRequest, isAdmin bool) error {
-	m, err := s.modelRepo.FindByID(modelID)
+func (s *ModelImageService) UpdateOrder(ctx context.Context, modelID, userID uuid.UUID, req *UpdateImageOrderRequest, isAdmin bool) error {
+	m, err := s.modelRepo.FindByID(ctx, modelID)
 	if err != nil {
 		return err
 	}
@@ -114,12 +115,12 @@ func (s *ModelImageService) UpdateOrder(modelID, userID uuid.UUID, req *UpdateIm
 	}
 
 	for _, item := range req.Images {
-		image, err := s.imageRepo.FindByModelIDAndFileID(modelID, item.FileID)
+		image, err := s.imageRepo.FindByModelIDAndFileID(ctx, modelID, item.FileID)
 		if err != nil {
 			continue
 		}
 		image.SortOrder = item.SortOrder
-		if err := s.imageRepo.Update(image); err != nil {
+		if err := s.imageRepo.Update(ctx, image); err != nil {
 			return err
 		}
 	}
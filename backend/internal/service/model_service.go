@@ -1,8 +1,10 @@
 package service
 
 import (
+	"context"
 	"errors"
-	"log"
+	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
@@ -15,14 +17,16 @@ import (
 )
 
 type ModelService struct {
-	modelRepo *repository.ModelRepository
-	userRepo  *repository.UserRepository
+	modelRepo  *repository.ModelRepository
+	userRepo   *repository.UserRepository
+	jobService *JobService
 }
 
 func NewModelService() *ModelService {
 	return &ModelService{
-		modelRepo: repository.NewModelRepository(),
-		userRepo:  repository.NewUserRepository(),
+		modelRepo:  repository.NewModelRepository(),
+		userRepo:   repository.NewUserRepository(),
+		jobService: NewJobService(),
 	}
 }
 
@@ -48,7 +52,7 @@ type UpdateModelRequest struct {
 	IsPublic    *bool      `json:"is_public"`
 }
 
-func (s *ModelService) Create(userID uuid.UUID, req *CreateModelRequest) (*model.Model, error) {
+func (s *ModelService) Create(ctx context.Context, userID uuid.UUID, req *CreateModelRequest) (*model.Model, error) {
 	if req.ImageID == nil && req.ImageURL == nil {
 		return nil, errors.New("card preview image is required")
 	}
@@ -66,19 +70,20 @@ func (s *ModelService) Create(userID uuid.UUID, req *CreateModelRequest) (*model
 		Status:      "pending",
 	}
 
-	if err := s.modelRepo.Create(m); err != nil {
+	if err := s.modelRepo.Create(ctx, m); err != nil {
 		return nil, err
 	}
+	s.jobService.EnqueueArchiveInspection(ctx, InspectArchivePayload{ModelID: m.ID, FilePath: m.FilePath})
 
-	return s.modelRepo.FindByID(m.ID)
+	return s.modelRepo.FindByID(ctx, m.ID)
 }
 
-func (s *ModelService) GetByID(id uuid.UUID) (*model.Model, error) {
-	return s.modelRepo.FindByID(id)
+func (s *ModelService) GetByID(ctx context.Context, id uuid.UUID) (*model.Model, error) {
+	return s.modelRepo.FindByID(ctx, id)
 }
 
-func (s *ModelService) Update(modelID, userID uuid.UUID, req *UpdateModelRequest, isAdmin bool) (*model.Model, error) {
-	m, err := s.modelRepo.FindByID(modelID)
+func (s *ModelService) Update(ctx context.Context, modelID, userID uuid.UUID, req *UpdateModelRequest, isAdmin bool) (*model.Model, error) {
+	m, err := s.modelRepo.FindByID(ctx, modelID)
 	if err != nil {
 		return nil, err
 	}
@@ -125,55 +130,51 @@ func (s *ModelService) Update(modelID, userID uuid.UUID, req *UpdateModelRequest
 		m.UpdateStatus = "pending_review"
 	}
 
-	if err := s.modelRepo.Update(m); err != nil {
+	if err := s.modelRepo.Update(ctx, m); err != nil {
 		return nil, err
 	}
+	if !isAdmin && req.FilePath != nil {
+		s.jobService.EnqueueArchiveInspection(ctx, InspectArchivePayload{ModelID: m.ID, FilePath: *req.FilePath, PendingChange: true})
+	}
 
-	return s.modelRepo.FindByID(m.ID)
+	return s.modelRepo.FindByID(ctx, m.ID)
 }
 
-func (s *ModelService) Delete(modelID, userID uuid.UUID, isAdmin bool) error {
-	m, err := s.modelRepo.FindByID(modelID)
+func (s *ModelService) Delete(ctx context.Context, modelID, userID uuid.UUID, isAdmin bool) error {
+	m, err := s.modelRepo.FindByID(ctx, modelID)
 	if err != nil {
 		return err
 	}
 
-	log.Printf("Delete: modelID=%s, modelUserID=%s, requestUserID=%s, isAdmin=%v", modelID, m.UserID, userID, isAdmin)
-
 	if m.UserID != userID && !isAdmin {
 		return errors.New("unauthorized")
 	}
 
 	modelVersionRepo := repository.NewModelVersionRepository()
-	versions, _ := modelVersionRepo.FindByModelID(modelID)
+	versions, _ := modelVersionRepo.FindByModelID(ctx, modelID)
 
-	var fileIDsToDelete []uuid.UUID
-	for _, v := range versions {
-		if v.ImageID != nil {
-			fileIDsToDelete = append(fileIDsToDelete, *v.ImageID)
-		}
-	}
-	if m.ImageID != nil {
-		fileIDsToDelete = append(fileIDsToDelete, *m.ImageID)
+	fileIDsToDelete, err := s.modelRepo.FindFileIDs(ctx, modelID)
+	if err != nil {
+		return err
 	}
 
-	if err := s.modelRepo.ClearReferences(modelID); err != nil {
-		log.Printf("Delete: error clearing model references: %v", err)
+	if err := s.modelRepo.ClearReferences(ctx, modelID); err != nil {
+		slog.ErrorContext(ctx, "Delete model: failed to clear model references", "model_id", modelID, "error", err)
 		return err
 	}
 
-	if err := modelVersionRepo.ClearImageReferences(modelID); err != nil {
-		log.Printf("Delete: error clearing version image references: %v", err)
+	if err := modelVersionRepo.ClearImageReferences(ctx, modelID); err != nil {
+		slog.WarnContext(ctx, "Delete model: failed to clear version image references", "model_id", modelID, "error", err)
 	}
 
 	favoriteRepo := repository.NewFavoriteRepository()
-	if err := favoriteRepo.DeleteByModel(modelID); err != nil {
-		log.Printf("Delete: error deleting favorites: %v", err)
+	if err := favoriteRepo.DeleteByModel(ctx, modelID); err != nil {
+		slog.WarnContext(ctx, "Delete model: failed to delete favorites", "model_id", modelID, "error", err)
 	}
 
 	modelImageRepo := repository.NewModelImageRepository()
-	if err := modelImageRepo.DeleteByModelID(modelID); err != nil {
-		log.Printf("Delete: error deleting model images: %v", err)
+	if err := modelImageRepo.DeleteByModelID(ctx, modelID); err != nil {
+		slog.WarnContext(ctx, "Delete model: failed to delete model images", "model_id", modelID, "error", err)
 	}
 
 	for _, v := range versions {
@@ -182,23 +183,23 @@ func (s *ModelService) Delete(modelID, userID uuid.UUID, isAdmin bool) error {
 		}
 	}
 
-	if err := modelVersionRepo.DeleteByModelID(modelID); err != nil {
-		log.Printf("Delete: error deleting model versions: %v", err)
+	if err := modelVersionRepo.DeleteByModelID(ctx, modelID); err != nil {
+		slog.ErrorContext(ctx, "Delete model: failed to delete model versions", "model_id", modelID, "error", err)
 		return err
 	}
 
 	fileService := NewFileService()
 	for _, fileID := range fileIDsToDelete {
-		if err := fileService.DeleteFile(fileID); err != nil {
-			log.Printf("Delete: error deleting file %s: %v", fileID, err)
+		if err := fileService.DeleteFile(ctx, fileID); err != nil {
+			slog.WarnContext(ctx, "Delete model: failed to delete file", "model_id", modelID, "file_id", fileID, "error", err)
 		}
 	}
 
 	if err := deleteModelFiles(m); err != nil {
-		log.Printf("Delete: error deleting files: %v", err)
+		slog.WarnContext(ctx, "Delete model: failed to delete model files", "model_id", modelID, "error", err)
 	}
 
-	return s.modelRepo.Delete(modelID)
+	return s.modelRepo.Delete(ctx, modelID)
 }
 
 func deleteModelFiles(m *model.Model) error {
@@ -231,24 +232,70 @@ func deleteModelFiles(m *model.Model) error {
 	return nil
 }
 
-func (s *ModelService) ListPublic(page, pageSize int, search string) ([]model.Model, int64, error) {
-	return s.modelRepo.ListPublic(page, pageSize, search)
+func (s *ModelService) ListPublic(ctx context.Context, page, pageSize int, search string) ([]model.Model, int64, error) {
+	return s.modelRepo.ListPublic(ctx, page, pageSize, search)
+}
+
+func (s *ModelService) ListByUserID(ctx context.Context, userID uuid.UUID, page, pageSize int) ([]model.Model, int64, error) {
+	return s.modelRepo.ListByUserID(ctx, userID, page, pageSize)
 }
 
-func (s *ModelService) ListByUserID(userID uuid.UUID, page, pageSize int) ([]model.Model, int64, error) {
-	return s.modelRepo.ListByUserID(userID, page, pageSize)
+func (s *ModelService) IncrementDownloads(ctx context.Context, id uuid.UUID) error {
+	if err := s.modelRepo.IncrementDownloads(ctx, id); err != nil {
+		return err
+	}
+	modelDownloads.Inc("model")
+	return nil
 }
 
-func (s *ModelService) IncrementDownloads(id uuid.UUID) error {
-	return s.modelRepo.IncrementDownloads(id)
+func (s *ModelService) Approve(ctx context.Context, modelID uuid.UUID) error {
+	m, err := s.modelRepo.FindByID(ctx, modelID)
+	if err != nil {
+		return err
+	}
+
+	applyApproval(m)
+	if err := s.modelRepo.Update(ctx, m); err != nil {
+		return err
+	}
+	modelReviews.Inc("approved", "manual")
+
+	notifyReviewResult(ctx, m, true)
+	return nil
 }
 
-func (s *ModelService) Approve(modelID uuid.UUID) error {
-	m, err := s.modelRepo.FindByID(modelID)
+func (s *ModelService) Reject(ctx context.Context, modelID uuid.UUID, reason string) error {
+	m, err := s.modelRepo.FindByID(ctx, modelID)
 	if err != nil {
 		return err
 	}
 
+	applyRejection(m, reason)
+	if err := s.modelRepo.Update(ctx, m); err != nil {
+		return err
+	}
+	modelReviews.Inc("rejected", "manual")
+
+	notifyReviewResult(ctx, m, false)
+	return nil
+}
+
+// notifyReviewResult 将审核结果通知作者，失败只记录日志
+func notifyReviewResult(ctx context.Context, m *model.Model, approved bool) {
+	link := fmt.Sprintf("%s/model/%s", config.AppConfig.FrontendURL, m.ID)
+	title := "模型审核通过"
+	body := fmt.Sprintf("您的模型「%s」已通过审核。", m.Title)
+	if !approved {
+		title = "模型审核未通过"
+		body = fmt.Sprintf("您的模型「%s」未通过审核：%s", m.Title, *m.RejectionReason)
+	}
+
+	if err := NewNotificationService().Notify(ctx, m.UserID, model.NotificationModeration, title, body, &link); err != nil {
+		slog.ErrorContext(ctx, "Failed to notify user about review result", "model_id", m.ID, "error", err)
+	}
+}
+
+func applyApproval(m *model.Model) {
 	if m.UpdateStatus == "pending_review" && m.PendingChanges != nil {
 		if m.PendingChanges.Title != nil {
 			m.Title = *m.PendingChanges.Title
@@ -276,16 +323,9 @@ func (s *ModelService) Approve(modelID uuid.UUID) error {
 	} else {
 		m.Status = "approved"
 	}
-
-	return s.modelRepo.Update(m)
 }
 
-func (s *ModelService) Reject(modelID uuid.UUID, reason string) error {
-	m, err := s.modelRepo.FindByID(modelID)
-	if err != nil {
-		return err
-	}
-
+func applyRejection(m *model.Model, reason string) {
 	if m.UpdateStatus == "pending_review" {
 		m.PendingChanges = nil
 		m.UpdateStatus = "idle"
@@ -294,33 +334,32 @@ func (s *ModelService) Reject(modelID uuid.UUID, reason string) error {
 	}
 
 	m.RejectionReason = &reason
-	return s.modelRepo.Update(m)
 }
 
-func (s *ModelService) ListPending(page, pageSize int) ([]model.Model, int64, error) {
-	return s.modelRepo.ListPending(page, pageSize)
+func (s *ModelService) ListPending(ctx context.Context, page, pageSize int) ([]model.Model, int64, error) {
+	return s.modelRepo.ListPending(ctx, page, pageSize)
 }
 
-func (s *ModelService) ListPendingUpdates(page, pageSize int) ([]model.Model, int64, error) {
-	return s.modelRepo.ListPendingUpdates(page, pageSize)
+func (s *ModelService) ListPendingUpdates(ctx context.Context, page, pageSize int) ([]model.Model, int64, error) {
+	return s.modelRepo.ListPendingUpdates(ctx, page, pageSize)
 }
 
-func (s *ModelService) Count() (int64, error) {
-	return s.modelRepo.Count()
+func (s *ModelService) Count(ctx context.Context) (int64, error) {
+	return s.modelRepo.Count(ctx)
 }
 
-func (s *ModelService) CountByStatus(status string) (int64, error) {
-	return s.modelRepo.CountByStatus(status)
+func (s *ModelService) CountByStatus(ctx context.Context, status string) (int64, error) {
+	return s.modelRepo.CountByStatus(ctx, status)
 }
 
-func (s *ModelService) SumDownloads() (int64, error) {
-	return s.modelRepo.SumDownloads()
+func (s *ModelService) SumDownloads(ctx context.Context) (int64, error) {
+	return s.modelRepo.SumDownloads(ctx)
 }
 
-func (s *ModelService) ListAll(page, pageSize int, status, search string) ([]model.Model, int64, error) {
-	return s.modelRepo.ListAll(page, pageSize, status, search)
+func (s *ModelService) ListAll(ctx context.Context, page, pageSize int, status, search string) ([]model.Model, int64, error) {
+	return s.modelRepo.ListAll(ctx, page, pageSize, status, search)
 }
 
-func (s *ModelService) DeleteByAdmin(modelID uuid.UUID) error {
-	return s.modelRepo.Delete(modelID)
+func (s *ModelService) DeleteByAdmin(ctx context.Context, modelID uuid.UUID) error {
+	return s.modelRepo.Delete(ctx, modelID)
 }
@@ -1,8 +1,9 @@
 package service
 
 import (
+	"context"
 	"errors"
-	"log"
+	"log/slog"
 	"os"
 	"regexp"
 
@@ -14,12 +15,14 @@ import (
 type ModelVersionService struct {
 	versionRepo *repository.ModelVersionRepository
 	modelRepo   *repository.ModelRepository
+	jobService  *JobService
 }
 
 func NewModelVersionService() *ModelVersionService {
 	return &ModelVersionService{
 		versionRepo: repository.NewModelVersionRepository(),
 		modelRepo:   repository.NewModelRepository(),
+		jobService:  NewJobService(),
 	}
 }
 
@@ -42,8 +45,8 @@ type UpdateVersionRequest struct {
 
 var versionNumberRegex = regexp.MustCompile(`^\d+\.\d+\.\d+$`)
 
-func (s *ModelVersionService) CreateVersion(modelID, userID uuid.UUID, req *CreateVersionRequest) (*model.ModelVersion, error) {
-	m, err := s.modelRepo.FindByID(modelID)
+func (s *ModelVersionService) CreateVersion(ctx context.Context, modelID, userID uuid.UUID, req *CreateVersionRequest) (*model.ModelVersion, error) {
+	m, err := s.modelRepo.FindByID(ctx, modelID)
 	if err != nil {
 		return nil, err
 	}
@@ -56,7 +59,7 @@ func (s *ModelVersionService) CreateVersion(modelID, userID uuid.UUID, req *Crea
 		return nil, errors.New("invalid version number format, expected format: x.y.z (e.g., 1.0.0)")
 	}
 
-	exists, err := s.versionRepo.ExistsByVersion(modelID, req.VersionNumber)
+	exists, err := s.versionRepo.ExistsByVersion(ctx, modelID, req.VersionNumber)
 	if err != nil {
 		return nil, err
 	}
@@ -76,40 +79,46 @@ func (s *ModelVersionService) CreateVersion(modelID, userID uuid.UUID, req *Crea
 		IsCurrent:     false,
 	}
 
-	if err := s.versionRepo.Create(version); err != nil {
+	if err := s.versionRepo.Create(ctx, version); err != nil {
 		return nil, err
 	}
 
-	versionCount, err := s.versionRepo.CountByModelID(modelID)
+	versionCount, err := s.versionRepo.CountByModelID(ctx, modelID)
 	if err != nil {
-		log.Printf("Warning: failed to count versions: %v", err)
+		slog.WarnContext(ctx, "Failed to count versions", "model_id", modelID, "error", err)
 		versionCount = 1
 	}
 
-	if err := s.modelRepo.Update(&model.Model{
+	if err := s.modelRepo.Update(ctx, &model.Model{
 		ID:           modelID,
 		VersionCount: int(versionCount),
 	}); err != nil {
-		log.Printf("Warning: failed to update model version count: %v", err)
+		slog.WarnContext(ctx, "Failed to update model version count", "model_id", modelID, "error", err)
+	}
+
+	if m.Status == "approved" && m.IsPublic {
+		if _, err := s.jobService.Enqueue(ctx, JobNotifyFavorites, NotifyFavoritesPayload{ModelID: modelID, VersionID: version.ID}); err != nil {
+			slog.WarnContext(ctx, "Failed to queue favorite notifications", "model_id", modelID, "error", err)
+		}
 	}
 
 	return version, nil
 }
 
-func (s *ModelVersionService) ListVersions(modelID uuid.UUID) ([]model.ModelVersion, error) {
-	return s.versionRepo.FindByModelID(modelID)
+func (s *ModelVersionService) ListVersions(ctx context.Context, modelID uuid.UUID) ([]model.ModelVersion, error) {
+	return s.versionRepo.FindByModelID(ctx, modelID)
 }
 
-func (s *ModelVersionService) GetVersion(versionID uuid.UUID) (*model.ModelVersion, error) {
-	return s.versionRepo.FindByIDWithModel(versionID)
+func (s *ModelVersionService) GetVersion(ctx context.Context, versionID uuid.UUID) (*model.ModelVersion, error) {
+	return s.versionRepo.FindByIDWithModel(ctx, versionID)
 }
 
-func (s *ModelVersionService) GetVersionByNumber(modelID uuid.UUID, versionNumber string) (*model.ModelVersion, error) {
-	return s.versionRepo.FindByModelIDAndVersion(modelID, versionNumber)
+func (s *ModelVersionService) GetVersionByNumber(ctx context.Context, modelID uuid.UUID, versionNumber string) (*model.ModelVersion, error) {
+	return s.versionRepo.FindByModelIDAndVersion(ctx, modelID, versionNumber)
 }
 
-func (s *ModelVersionService) SetCurrentVersion(modelID, versionID, userID uuid.UUID, isAdmin bool) error {
-	m, err := s.modelRepo.FindByID(modelID)
+func (s *ModelVersionService) SetCurrentVersion(ctx context.Context, modelID, versionID, userID uuid.UUID, isAdmin bool) error {
+	m, err := s.modelRepo.FindByID(ctx, modelID)
 	if err != nil {
 		return err
 	}
@@ -118,7 +127,7 @@ func (s *ModelVersionService) SetCurrentVersion(modelID, versionID, userID uuid.
 		return errors.New("unauthorized")
 	}
 
-	version, err := s.versionRepo.FindByID(versionID)
+	version, err := s.versionRepo.FindByID(ctx, versionID)
 	if err != nil {
 		return err
 	}
@@ -127,11 +136,11 @@ func (s *ModelVersionService) SetCurrentVersion(modelID, versionID, userID uuid.
 		return errors.New("version does not belong to this model")
 	}
 
-	return s.versionRepo.SetCurrentVersion(modelID, versionID)
+	return s.versionRepo.SetCurrentVersion(ctx, modelID, versionID)
 }
 
-func (s *ModelVersionService) UpdateVersion(versionID, userID uuid.UUID, req *UpdateVersionRequest, isAdmin bool) (*model.ModelVersion, error) {
-	version, err := s.versionRepo.FindByIDWithModel(versionID)
+func (s *ModelVersionService) UpdateVersion(ctx context.Context, versionID, userID uuid.UUID, req *UpdateVersionRequest, isAdmin bool) (*model.ModelVersion, error) {
+	version, err := s.versionRepo.FindByIDWithModel(ctx, versionID)
 	if err != nil {
 		return nil, err
 	}
@@ -157,15 +166,15 @@ func (s *ModelVersionService) UpdateVersion(versionID, userID uuid.UUID, req *Up
 		version.Changelog = req.Changelog
 	}
 
-	if err := s.versionRepo.Update(version); err != nil {
+	if err := s.versionRepo.Update(ctx, version); err != nil {
 		return nil, err
 	}
 
-	return s.versionRepo.FindByID(versionID)
+	return s.versionRepo.FindByID(ctx, versionID)
 }
 
-func (s *ModelVersionService) DeleteVersion(modelID, versionID, userID uuid.UUID, isAdmin bool) error {
-	m, err := s.modelRepo.FindByID(modelID)
+func (s *ModelVersionService) DeleteVersion(ctx context.Context, modelID, versionID, userID uuid.UUID, isAdmin bool) error {
+	m, err := s.modelRepo.FindByID(ctx, modelID)
 	if err != nil {
 		return err
 	}
@@ -174,7 +183,7 @@ func (s *ModelVersionService) DeleteVersion(modelID, versionID, userID uuid.UUID
 		return errors.New("unauthorized")
 	}
 
-	version, err := s.versionRepo.FindByID(versionID)
+	version, err := s.versionRepo.FindByID(ctx, versionID)
 	if err != nil {
 		return err
 	}
@@ -183,7 +192,7 @@ func (s *ModelVersionService) DeleteVersion(modelID, versionID, userID uuid.UUID
 		return errors.New("version does not belong to this model")
 	}
 
-	count, err := s.versionRepo.CountByModelID(modelID)
+	count, err := s.versionRepo.CountByModelID(ctx, modelID)
 	if err != nil {
 		return err
 	}
@@ -198,34 +207,38 @@ func (s *ModelVersionService) DeleteVersion(modelID, versionID, userID uuid.UUID
 
 	if version.FilePath != "" {
 		if err := os.Remove(version.FilePath); err != nil && !os.IsNotExist(err) {
-			log.Printf("Warning: failed to delete version file: %v", err)
+			slog.WarnContext(ctx, "Failed to delete version file", "version_id", versionID, "error", err)
 		}
 	}
 
-	if err := s.versionRepo.Delete(versionID); err != nil {
+	if err := s.versionRepo.Delete(ctx, versionID); err != nil {
 		return err
 	}
 
-	versionCount, err := s.versionRepo.CountByModelID(modelID)
+	versionCount, err := s.versionRepo.CountByModelID(ctx, modelID)
 	if err != nil {
-		log.Printf("Warning: failed to count versions: %v", err)
+		slog.WarnContext(ctx, "Failed to count versions", "model_id", modelID, "error", err)
 		versionCount = 1
 	}
 
-	if err := s.modelRepo.Update(&model.Model{
+	if err := s.modelRepo.Update(ctx, &model.Model{
 		ID:           modelID,
 		VersionCount: int(versionCount),
 	}); err != nil {
-		log.Printf("Warning: failed to update model version count: %v", err)
+		slog.WarnContext(ctx, "Failed to update model version count", "model_id", modelID, "error", err)
 	}
 
 	return nil
 }
 
-func (s *ModelVersionService) IncrementDownloads(versionID uuid.UUID) error {
-	return s.versionRepo.IncrementDownloads(versionID)
+func (s *ModelVersionService) IncrementDownloads(ctx context.Context, versionID uuid.UUID) error {
+	if err := s.versionRepo.IncrementDownloads(ctx, versionID); err != nil {
+		return err
+	}
+	modelDownloads.Inc("version")
+	return nil
 }
 
-func (s *ModelVersionService) GetCurrentVersion(modelID uuid.UUID) (*model.ModelVersion, error) {
-	return s.versionRepo.FindCurrentVersion(modelID)
+func (s *ModelVersionService) GetCurrentVersion(ctx context.Context, modelID uuid.UUID) (*model.ModelVersion, error) {
+	return s.versionRepo.FindCurrentVersion(ctx, modelID)
 }
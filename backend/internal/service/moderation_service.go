@@ -0,0 +1,282 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ysmmc/backend/internal/database"
+	"github.com/ysmmc/backend/internal/model"
+	"github.com/ysmmc/backend/internal/repository"
+	"gorm.io/gorm"
+)
+
+const MaxBulkItems = 500
+
+type ModerationService struct {
+	actionRepo *repository.AdminActionRepository
+	modelRepo  *repository.ModelRepository
+	userRepo   *repository.UserRepository
+}
+
+func NewModerationService() *ModerationService {
+	return &ModerationService{
+		actionRepo: repository.NewAdminActionRepository(),
+		modelRepo:  repository.NewModelRepository(),
+		userRepo:   repository.NewUserRepository(),
+	}
+}
+
+type BulkModelFilter struct {
+	Status        string     `json:"status"`
+	UserID        *uuid.UUID `json:"user_id"`
+	CreatedAfter  *time.Time `json:"created_after"`
+	CreatedBefore *time.Time `json:"created_before"`
+}
+
+type BulkModelActionRequest struct {
+	Action string           `json:"action" binding:"required,oneof=approve reject delete"`
+	IDs    []uuid.UUID      `json:"ids"`
+	Filter *BulkModelFilter `json:"filter"`
+	Reason string           `json:"reason"`
+}
+
+type BulkUserFilter struct {
+	CreatedAfter  *time.Time `json:"created_after"`
+	CreatedBefore *time.Time `json:"created_before"`
+}
+
+type BulkBanRequest struct {
+	IDs           []uuid.UUID     `json:"ids"`
+	Filter        *BulkUserFilter `json:"filter"`
+	Reason        string          `json:"reason" binding:"required"`
+	DurationHours *int            `json:"duration_hours" binding:"omitempty,min=1"`
+}
+
+func (s *ModerationService) BulkModelAction(ctx context.Context, adminID uuid.UUID, req *BulkModelActionRequest) (*model.AdminAction, error) {
+	if req.Action == "reject" && req.Reason == "" {
+		return nil, errors.New("reason is required for reject")
+	}
+
+	ids, err := s.resolveModelIDs(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var removedFiles []string
+	var reviewed []uuid.UUID
+	action := newAdminAction(adminID, "model."+req.Action, "model", req.Reason)
+
+	err = database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		modelRepo := &repository.ModelRepository{DB: tx}
+
+		for i, id := range ids {
+			savepoint := fmt.Sprintf("bulk_item_%d", i)
+			// 保存点失败时单项错误会中止整个事务，只能让整批失败
+			if err := tx.SavePoint(savepoint).Error; err != nil {
+				return err
+			}
+
+			files, err := applyModelAction(ctx, modelRepo, id, req.Action, req.Reason)
+			if err != nil {
+				if rollbackErr := tx.RollbackTo(savepoint).Error; rollbackErr != nil {
+					return rollbackErr
+				}
+				action.AddResult(id, err)
+				continue
+			}
+
+			removedFiles = append(removedFiles, files...)
+			if req.Action != "delete" {
+				reviewed = append(reviewed, id)
+			}
+			action.AddResult(id, nil)
+		}
+
+		return (&repository.AdminActionRepository{DB: tx}).Create(ctx, action)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range removedFiles {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			slog.WarnContext(ctx, "Bulk model action: failed to remove file", "path", path, "error", err)
+		}
+	}
+
+	if len(reviewed) > 0 {
+		modelReviews.Add(float64(len(reviewed)), reviewResult(req.Action == "approve"), "bulk")
+	}
+	for _, id := range reviewed {
+		if m, err := s.modelRepo.FindByID(ctx, id); err == nil {
+			notifyReviewResult(ctx, m, req.Action == "approve")
+		}
+	}
+
+	return action, nil
+}
+
+func (s *ModerationService) BulkBan(ctx context.Context, adminID uuid.UUID, adminRole string, req *BulkBanRequest) (*model.AdminAction, error) {
+	ids := req.IDs
+	if len(ids) == 0 {
+		if req.Filter == nil || (req.Filter.CreatedAfter == nil && req.Filter.CreatedBefore == nil) {
+			return nil, errors.New("ids or filter is required")
+		}
+
+		var err error
+		ids, err = s.userRepo.FindIDsByCreatedRange(ctx, req.Filter.CreatedAfter, req.Filter.CreatedBefore, MaxBulkItems+1)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(ids) > MaxBulkItems {
+		return nil, fmt.Errorf("too many items, maximum is %d", MaxBulkItems)
+	}
+
+	action := newAdminAction(adminID, "user.ban", "user", req.Reason)
+	expiresAt := sanctionExpiry(req.DurationHours)
+	var banned []uuid.UUID
+
+	err := database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		userRepo := &repository.UserRepository{DB: tx}
+		sanctionRepo := &repository.SanctionRepository{DB: tx}
+		sessionRepo := &repository.SessionRepository{DB: tx}
+
+		for i, id := range ids {
+			savepoint := fmt.Sprintf("bulk_item_%d", i)
+			if err := tx.SavePoint(savepoint).Error; err != nil {
+				return err
+			}
+
+			_, err := issueSanction(ctx, userRepo, sanctionRepo, id, adminID, adminRole, model.SanctionBan, req.Reason, expiresAt)
+			if err == nil {
+				err = revokeTokens(ctx, userRepo, sessionRepo, id)
+			}
+			if err != nil {
+				if rollbackErr := tx.RollbackTo(savepoint).Error; rollbackErr != nil {
+					return rollbackErr
+				}
+				action.AddResult(id, err)
+				continue
+			}
+
+			banned = append(banned, id)
+			action.AddResult(id, nil)
+		}
+
+		return (&repository.AdminActionRepository{DB: tx}).Create(ctx, action)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tokenVersions.invalidate(banned...)
+
+	return action, nil
+}
+
+func (s *ModerationService) Record(ctx context.Context, adminID uuid.UUID, actionName, targetType string, targetID uuid.UUID, reason string) {
+	action := newAdminAction(adminID, actionName, targetType, reason)
+	action.AddResult(targetID, nil)
+
+	if err := s.actionRepo.Create(ctx, action); err != nil {
+		slog.WarnContext(ctx, "Failed to record admin action", "action", actionName, "error", err)
+	}
+}
+
+func (s *ModerationService) ListActions(ctx context.Context, page, pageSize int, action string) ([]model.AdminAction, int64, error) {
+	return s.actionRepo.List(ctx, page, pageSize, action)
+}
+
+func (s *ModerationService) GetAction(ctx context.Context, id uuid.UUID) (*model.AdminAction, error) {
+	return s.actionRepo.FindByID(ctx, id)
+}
+
+func (s *ModerationService) resolveModelIDs(ctx context.Context, req *BulkModelActionRequest) ([]uuid.UUID, error) {
+	ids := req.IDs
+	if len(ids) == 0 {
+		if req.Filter == nil {
+			return nil, errors.New("ids or filter is required")
+		}
+
+		f := req.Filter
+		// status 为 all 时不限制状态，不能算作条件，否则会选中任意模型
+		if (f.Status == "" || f.Status == "all") && f.UserID == nil && f.CreatedAfter == nil && f.CreatedBefore == nil {
+			return nil, errors.New("filter must contain at least one condition")
+		}
+
+		var err error
+		ids, err = s.modelRepo.FindIDsByFilter(ctx, repository.ModelFilter{
+			Status:        f.Status,
+			UserID:        f.UserID,
+			CreatedAfter:  f.CreatedAfter,
+			CreatedBefore: f.CreatedBefore,
+		}, MaxBulkItems+1)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(ids) > MaxBulkItems {
+		return nil, fmt.Errorf("too many items, maximum is %d", MaxBulkItems)
+	}
+
+	return ids, nil
+}
+
+func applyModelAction(ctx context.Context, modelRepo *repository.ModelRepository, id uuid.UUID, action, reason string) ([]string, error) {
+	m, err := modelRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("model not found")
+	}
+
+	switch action {
+	case "approve":
+		applyApproval(m)
+		return nil, modelRepo.Update(ctx, m)
+	case "reject":
+		applyRejection(m, reason)
+		return nil, modelRepo.Update(ctx, m)
+	case "delete":
+		var versions []model.ModelVersion
+		if err := modelRepo.DB.Where("model_id = ?", id).Find(&versions).Error; err != nil {
+			return nil, err
+		}
+
+		if err := modelRepo.DeleteWithDependents(ctx, id); err != nil {
+			return nil, err
+		}
+
+		files := []string{}
+		if m.FilePath != "" {
+			files = append(files, m.FilePath)
+		}
+		for _, v := range versions {
+			if v.FilePath != "" && v.FilePath != m.FilePath {
+				files = append(files, v.FilePath)
+			}
+		}
+		return files, nil
+	default:
+		return nil, errors.New("unsupported action")
+	}
+}
+
+func newAdminAction(adminID uuid.UUID, actionName, targetType, reason string) *model.AdminAction {
+	action := &model.AdminAction{
+		AdminID:    adminID,
+		Action:     actionName,
+		TargetType: targetType,
+		Results:    model.AdminActionResults{},
+	}
+	if reason != "" {
+		action.Reason = &reason
+	}
+	return action
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/ysmmc/backend/internal/database"
+	"github.com/ysmmc/backend/internal/model"
+)
+
+func TestBulkModelAction_Validation(t *testing.T) {
+	s := &ModerationService{}
+	ctx := context.Background()
+
+	if _, err := s.BulkModelAction(ctx, uuid.New(), &BulkModelActionRequest{Action: "reject", IDs: []uuid.UUID{uuid.New()}}); err == nil {
+		t.Error("expected reject without reason to fail")
+	}
+	if _, err := s.BulkModelAction(ctx, uuid.New(), &BulkModelActionRequest{Action: "approve"}); err == nil {
+		t.Error("expected request without ids or filter to fail")
+	}
+	if _, err := s.BulkModelAction(ctx, uuid.New(), &BulkModelActionRequest{Action: "approve", Filter: &BulkModelFilter{}}); err == nil {
+		t.Error("expected empty filter to fail")
+	}
+	if _, err := s.BulkModelAction(ctx, uuid.New(), &BulkModelActionRequest{Action: "delete", Filter: &BulkModelFilter{Status: "all"}}); err == nil {
+		t.Error("expected a filter with only status=all to fail")
+	}
+	tooMany := make([]uuid.UUID, MaxBulkItems+1)
+	if _, err := s.BulkModelAction(ctx, uuid.New(), &BulkModelActionRequest{Action: "approve", IDs: tooMany}); err == nil {
+		t.Error("expected more than MaxBulkItems ids to fail")
+	}
+}
+
+func TestBulkModelAction_PerItemResults(t *testing.T) {
+	setupTestDB(t)
+	ctx := context.Background()
+	admin := createTestUser(t, model.RoleAdmin)
+	author := createTestUser(t, model.RoleUser)
+	first := createTestModel(t, author.ID, "pending")
+	second := createTestModel(t, author.ID, "pending")
+	missing := uuid.New()
+
+	s := NewModerationService()
+	action, err := s.BulkModelAction(ctx, admin.ID, &BulkModelActionRequest{
+		Action: "approve",
+		IDs:    []uuid.UUID{first.ID, missing, second.ID},
+	})
+	if err != nil {
+		t.Fatalf("BulkModelAction() error = %v", err)
+	}
+	if action.Total != 3 || action.Succeeded != 2 || action.Failed != 1 {
+		t.Errorf("unexpected totals: total=%d succeeded=%d failed=%d", action.Total, action.Succeeded, action.Failed)
+	}
+	if action.Results[1].ID != missing || action.Results[1].Success {
+		t.Errorf("expected the missing model to be reported as failed, got %+v", action.Results[1])
+	}
+
+	for _, id := range []uuid.UUID{first.ID, second.ID} {
+		var m model.Model
+		database.DB.First(&m, "id = ?", id)
+		if m.Status != "approved" {
+			t.Errorf("expected model %s to be approved, got %s", id, m.Status)
+		}
+	}
+
+	logged, err := s.GetAction(ctx, action.ID)
+	if err != nil {
+		t.Fatalf("expected admin action to be recorded: %v", err)
+	}
+	if logged.Action != "model.approve" || logged.AdminID != admin.ID || len(logged.Results) != 3 {
+		t.Errorf("unexpected admin action log: %+v", logged)
+	}
+}
+
+func TestBulkModelAction_Filter(t *testing.T) {
+	setupTestDB(t)
+	ctx := context.Background()
+	admin := createTestUser(t, model.RoleAdmin)
+	target := createTestUser(t, model.RoleUser)
+	other := createTestUser(t, model.RoleUser)
+	pending := createTestModel(t, target.ID, "pending")
+	approved := createTestModel(t, target.ID, "approved")
+	otherPending := createTestModel(t, other.ID, "pending")
+
+	action, err := NewModerationService().BulkModelAction(ctx, admin.ID, &BulkModelActionRequest{
+		Action: "reject",
+		Filter: &BulkModelFilter{Status: "pending", UserID: &target.ID},
+		Reason: "spam",
+	})
+	if err != nil {
+		t.Fatalf("BulkModelAction() error = %v", err)
+	}
+	if action.Total != 1 || action.Results[0].ID != pending.ID {
+		t.Fatalf("expected only the matching model to be selected, got %+v", action.Results)
+	}
+
+	statuses := map[uuid.UUID]string{pending.ID: "rejected", approved.ID: "approved", otherPending.ID: "pending"}
+	for id, want := range statuses {
+		var m model.Model
+		database.DB.First(&m, "id = ?", id)
+		if m.Status != want {
+			t.Errorf("model %s: expected status %s, got %s", id, want, m.Status)
+		}
+	}
+}
+
+func TestBulkModelAction_DeleteRemovesImages(t *testing.T) {
+	setupTestDB(t)
+	ctx := context.Background()
+	admin := createTestUser(t, model.RoleAdmin)
+	author := createTestUser(t, model.RoleUser)
+
+	m := createTestModel(t, author.ID, "approved")
+	cover := createTestFile(t, author.ID)
+	versionCover := createTestFile(t, author.ID)
+	gallery := createTestFile(t, author.ID)
+	database.DB.Model(m).Update("image_id", cover.ID)
+	database.DB.Create(&model.ModelVersion{ModelID: m.ID, VersionNumber: "1.0.0", ImageID: &versionCover.ID})
+	database.DB.Create(&model.ModelImage{ModelID: m.ID, FileID: gallery.ID})
+
+	action, err := NewModerationService().BulkModelAction(ctx, admin.ID, &BulkModelActionRequest{
+		Action: "delete",
+		IDs:    []uuid.UUID{m.ID},
+	})
+	if err != nil {
+		t.Fatalf("BulkModelAction() error = %v", err)
+	}
+	if action.Succeeded != 1 {
+		t.Fatalf("expected delete to succeed, got %+v", action.Results)
+	}
+
+	var models, files int64
+	database.DB.Model(&model.Model{}).Where("id = ?", m.ID).Count(&models)
+	database.DB.Model(&model.File{}).Where("id IN ?", []uuid.UUID{cover.ID, versionCover.ID, gallery.ID}).Count(&files)
+	if models != 0 || files != 0 {
+		t.Errorf("expected model and its images to be deleted, %d models and %d files remain", models, files)
+	}
+}
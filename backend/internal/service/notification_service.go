@@ -0,0 +1,344 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ysmmc/backend/internal/config"
+	"github.com/ysmmc/backend/internal/database"
+	"github.com/ysmmc/backend/internal/model"
+	"github.com/ysmmc/backend/internal/repository"
+	"github.com/ysmmc/backend/pkg/auth"
+	"github.com/ysmmc/backend/pkg/email"
+	"gorm.io/gorm"
+)
+
+// UnsubscribeDigest 退订摘要邮件的 topic，其余 topic 为通知类型
+const UnsubscribeDigest = "digest"
+
+// digestMaxItems 单封摘要最多包含的通知数，超出的部分只在站内查看
+const digestMaxItems = 50
+
+type NotificationService struct {
+	notificationRepo *repository.NotificationRepository
+	settingsRepo     *repository.NotificationSettingsRepository
+	userRepo         *repository.UserRepository
+	emailService     *email.EmailService
+	outboxService    *EmailOutboxService
+}
+
+func NewNotificationService() *NotificationService {
+	return &NotificationService{
+		notificationRepo: repository.NewNotificationRepository(),
+		settingsRepo:     repository.NewNotificationSettingsRepository(),
+		userRepo:         repository.NewUserRepository(),
+		emailService:     email.NewEmailService(),
+		outboxService:    NewEmailOutboxService(),
+	}
+}
+
+type NotificationSettingsResponse struct {
+	// Preferences 每个可设置类型的当前渠道（含默认值）
+	Preferences map[string]string `json:"preferences"`
+	Digest      string            `json:"digest"`
+}
+
+type UpdateNotificationSettingsRequest struct {
+	Preferences map[string]string `json:"preferences"`
+	Digest      *string           `json:"digest"`
+}
+
+// Notify 按用户的通知偏好创建站内通知，渠道为 email 且未开启摘要时同时发送邮件
+func (s *NotificationService) Notify(ctx context.Context, userID uuid.UUID, notificationType, title, body string, link *string) error {
+	settings, err := s.settings(ctx, userID)
+	if err != nil {
+		return err
+	}
+	channel := settings.Channel(notificationType)
+	if channel == model.ChannelOff {
+		return nil
+	}
+
+	notification := &model.Notification{
+		UserID: userID,
+		Type:   notificationType,
+		Title:  title,
+		Body:   body,
+		Link:   link,
+	}
+	if err := s.notificationRepo.Create(ctx, notification); err != nil {
+		return err
+	}
+
+	if channel != model.ChannelEmail || settings.Digest != model.DigestOff || !s.emailService.IsConfigured() {
+		return nil
+	}
+	if err := s.emailNotification(ctx, notification); err != nil {
+		slog.ErrorContext(ctx, "Failed to queue notification email", "user_id", userID, "error", err)
+	}
+	return nil
+}
+
+func (s *NotificationService) emailNotification(ctx context.Context, n *model.Notification) error {
+	user, err := s.userRepo.FindByID(ctx, n.UserID)
+	if err != nil {
+		return err
+	}
+	unsubscribeLink, err := unsubscribeLink(user.ID, n.Type)
+	if err != nil {
+		return err
+	}
+
+	data := email.NotificationData{
+		Username:        user.Username,
+		Title:           n.Title,
+		Body:            n.Body,
+		SettingsLink:    notificationSettingsLink(),
+		UnsubscribeLink: unsubscribeLink,
+	}
+	if n.Link != nil {
+		data.Link = *n.Link
+	}
+	msg, err := s.emailService.NotificationMessage(user.Language, user.Email, data)
+	if err != nil {
+		return err
+	}
+	return s.outboxService.Queue(ctx, &user.ID, model.EmailCategoryNotification, msg)
+}
+
+func unsubscribeLink(userID uuid.UUID, topic string) (string, error) {
+	token, err := auth.GenerateUnsubscribeToken(userID, topic)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/unsubscribe?token=%s", config.AppConfig.FrontendURL, token), nil
+}
+
+func notificationSettingsLink() string {
+	return config.AppConfig.FrontendURL + "/profile"
+}
+
+// settings 读取用户的通知偏好，没有记录时返回默认值
+func (s *NotificationService) settings(ctx context.Context, userID uuid.UUID) (*model.NotificationSettings, error) {
+	settings, err := s.settingsRepo.FindByUserID(ctx, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &model.NotificationSettings{UserID: userID, Preferences: model.NotificationPreferences{}, Digest: model.DigestOff}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if settings.Preferences == nil {
+		settings.Preferences = model.NotificationPreferences{}
+	}
+	return settings, nil
+}
+
+func (s *NotificationService) GetSettings(ctx context.Context, userID uuid.UUID) (*NotificationSettingsResponse, error) {
+	settings, err := s.settings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &NotificationSettingsResponse{Preferences: map[string]string{}, Digest: settings.Digest}
+	for notificationType := range model.NotificationDefaults {
+		resp.Preferences[notificationType] = settings.Channel(notificationType)
+	}
+	return resp, nil
+}
+
+func (s *NotificationService) UpdateSettings(ctx context.Context, userID uuid.UUID, req *UpdateNotificationSettingsRequest) (*NotificationSettingsResponse, error) {
+	settings, err := s.settings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for notificationType, channel := range req.Preferences {
+		if _, ok := model.NotificationDefaults[notificationType]; !ok {
+			return nil, fmt.Errorf("unknown notification type: %s", notificationType)
+		}
+		switch channel {
+		case model.ChannelInApp, model.ChannelEmail, model.ChannelOff:
+		default:
+			return nil, fmt.Errorf("invalid channel for %s: %s", notificationType, channel)
+		}
+		settings.Preferences[notificationType] = channel
+	}
+	if req.Digest != nil {
+		switch *req.Digest {
+		case model.DigestOff, model.DigestDaily, model.DigestWeekly:
+		default:
+			return nil, errors.New("digest must be off, daily or weekly")
+		}
+		settings.Digest = *req.Digest
+	}
+
+	if err := s.settingsRepo.Save(ctx, settings); err != nil {
+		return nil, err
+	}
+	return s.GetSettings(ctx, userID)
+}
+
+// Unsubscribe 通过邮件中的退订链接关闭某类通知的邮件（保留站内通知）或摘要，无需登录
+func (s *NotificationService) Unsubscribe(ctx context.Context, token string) (string, error) {
+	userID, topic, err := auth.ParseUnsubscribeToken(token)
+	if err != nil {
+		return "", errors.New("invalid or expired unsubscribe link")
+	}
+	if _, err := s.userRepo.FindByID(ctx, userID); err != nil {
+		return "", errors.New("invalid or expired unsubscribe link")
+	}
+
+	settings, err := s.settings(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	if !applyUnsubscribe(settings, topic) {
+		return "", errors.New("invalid or expired unsubscribe link")
+	}
+
+	return topic, s.settingsRepo.Save(ctx, settings)
+}
+
+// applyUnsubscribe 按退订主题修改偏好，未知主题返回 false。
+// 退订摘要时同时把邮件渠道的通知改为站内通知，否则关闭摘要后这些通知会逐封发送邮件
+func applyUnsubscribe(settings *model.NotificationSettings, topic string) bool {
+	if topic == UnsubscribeDigest {
+		settings.Digest = model.DigestOff
+		for notificationType := range model.NotificationDefaults {
+			if settings.Channel(notificationType) == model.ChannelEmail {
+				settings.Preferences[notificationType] = model.ChannelInApp
+			}
+		}
+		return true
+	}
+	if _, ok := model.NotificationDefaults[topic]; ok {
+		settings.Preferences[topic] = model.ChannelInApp
+		return true
+	}
+	return false
+}
+
+// digestPeriod 摘要覆盖的时间范围
+func digestPeriod(frequency string) time.Duration {
+	if frequency == model.DigestWeekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// digestSince 确定摘要的起始时间：上一次摘要之后，但不早于一个周期之前
+func digestSince(settings *model.NotificationSettings, now time.Time) time.Time {
+	since := now.Add(-digestPeriod(settings.Digest))
+	if settings.LastDigestAt != nil && settings.LastDigestAt.After(since) {
+		since = *settings.LastDigestAt
+	}
+	return since
+}
+
+// SendDigests 为开启了该频率摘要的用户汇总渠道为 email 的通知并写入发件箱，返回发出的摘要数
+func (s *NotificationService) SendDigests(ctx context.Context, frequency string, now time.Time) (int, error) {
+	if !s.emailService.IsConfigured() {
+		return 0, nil
+	}
+	all, err := s.settingsRepo.ListByDigest(ctx, frequency)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for i := range all {
+		queued, err := s.sendDigest(ctx, &all[i], now)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to send digest", "frequency", frequency, "user_id", all[i].UserID, "error", err)
+			continue
+		}
+		if queued {
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+func (s *NotificationService) sendDigest(ctx context.Context, settings *model.NotificationSettings, now time.Time) (bool, error) {
+	var types []string
+	for notificationType := range model.NotificationDefaults {
+		if settings.Channel(notificationType) == model.ChannelEmail {
+			types = append(types, notificationType)
+		}
+	}
+
+	var notifications []model.Notification
+	if len(types) > 0 {
+		var err error
+		notifications, err = s.notificationRepo.ListSince(ctx, settings.UserID, types, digestSince(settings, now), digestMaxItems)
+		if err != nil {
+			return false, err
+		}
+	}
+	if len(notifications) == 0 {
+		return false, s.settingsRepo.SetLastDigestAt(ctx, settings.UserID, now)
+	}
+
+	user, err := s.userRepo.FindByID(ctx, settings.UserID)
+	if err != nil {
+		return false, err
+	}
+	unsubscribeLink, err := unsubscribeLink(user.ID, UnsubscribeDigest)
+	if err != nil {
+		return false, err
+	}
+
+	data := email.DigestData{
+		Username:        user.Username,
+		Frequency:       settings.Digest,
+		SettingsLink:    notificationSettingsLink(),
+		UnsubscribeLink: unsubscribeLink,
+	}
+	for _, n := range notifications {
+		item := email.DigestItem{Title: n.Title, Body: n.Body, Time: n.CreatedAt.Format("2006-01-02 15:04")}
+		if n.Link != nil {
+			item.Link = *n.Link
+		}
+		data.Items = append(data.Items, item)
+	}
+	msg, err := s.emailService.DigestMessage(user.Language, user.Email, data)
+	if err != nil {
+		return false, err
+	}
+
+	// 摘要与发送时间同一事务写入，任务重试时不会重复发送
+	err = database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := queueEmail(ctx, tx, &user.ID, model.EmailCategoryDigest, msg); err != nil {
+			return err
+		}
+		return (&repository.NotificationSettingsRepository{DB: tx}).SetLastDigestAt(ctx, user.ID, now)
+	})
+	return err == nil, err
+}
+
+func (s *NotificationService) List(ctx context.Context, userID uuid.UUID, page, pageSize int, unreadOnly bool) ([]model.Notification, int64, error) {
+	return s.notificationRepo.ListByUserID(ctx, userID, page, pageSize, unreadOnly)
+}
+
+func (s *NotificationService) CountUnread(ctx context.Context, userID uuid.UUID) (int64, error) {
+	return s.notificationRepo.CountUnread(ctx, userID)
+}
+
+func (s *NotificationService) MarkRead(ctx context.Context, id, userID uuid.UUID) error {
+	updated, err := s.notificationRepo.MarkRead(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return errors.New("notification not found")
+	}
+	return nil
+}
+
+func (s *NotificationService) MarkAllRead(ctx context.Context, userID uuid.UUID) error {
+	return s.notificationRepo.MarkAllRead(ctx, userID)
+}
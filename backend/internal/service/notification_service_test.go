@@ -0,0 +1,99 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ysmmc/backend/internal/model"
+)
+
+func TestNotificationSettings_Channel(t *testing.T) {
+	settings := &model.NotificationSettings{Preferences: model.NotificationPreferences{
+		model.NotificationFavoriteUpdate: model.ChannelOff,
+	}}
+
+	if got := settings.Channel(model.NotificationFavoriteUpdate); got != model.ChannelOff {
+		t.Errorf("explicit preference should win, got %s", got)
+	}
+	if got := settings.Channel(model.NotificationModeration); got != model.NotificationDefaults[model.NotificationModeration] {
+		t.Errorf("unset type should use its default, got %s", got)
+	}
+	settings.Preferences[model.NotificationSecurity] = model.ChannelOff
+	if got := settings.Channel(model.NotificationSecurity); got != model.ChannelInApp {
+		t.Errorf("security notifications cannot be turned off, got %s", got)
+	}
+}
+
+func TestDigestSince(t *testing.T) {
+	now := time.Date(2026, 3, 10, 8, 0, 0, 0, time.UTC)
+	recent := now.Add(-3 * time.Hour)
+	old := now.Add(-30 * 24 * time.Hour)
+
+	tests := []struct {
+		name     string
+		settings model.NotificationSettings
+		want     time.Time
+	}{
+		{"first daily digest", model.NotificationSettings{Digest: model.DigestDaily}, now.Add(-24 * time.Hour)},
+		{"first weekly digest", model.NotificationSettings{Digest: model.DigestWeekly}, now.Add(-7 * 24 * time.Hour)},
+		{"after a recent digest", model.NotificationSettings{Digest: model.DigestDaily, LastDigestAt: &recent}, recent},
+		{"long after the last digest", model.NotificationSettings{Digest: model.DigestWeekly, LastDigestAt: &old}, now.Add(-7 * 24 * time.Hour)},
+	}
+
+	for _, tt := range tests {
+		if got := digestSince(&tt.settings, now); !got.Equal(tt.want) {
+			t.Errorf("%s: digestSince() = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDigestFrequency(t *testing.T) {
+	weekly := "digest-weekly"
+	if got, err := digestFrequency(&model.Job{ScheduleName: &weekly}); err != nil || got != model.DigestWeekly {
+		t.Errorf("scheduled weekly job: got (%s, %v)", got, err)
+	}
+
+	payload, _ := json.Marshal(SendDigestsPayload{Frequency: model.DigestDaily})
+	if got, err := digestFrequency(&model.Job{Payload: payload}); err != nil || got != model.DigestDaily {
+		t.Errorf("manual daily job: got (%s, %v)", got, err)
+	}
+
+	payload, _ = json.Marshal(SendDigestsPayload{Frequency: "hourly"})
+	if _, err := digestFrequency(&model.Job{Payload: payload}); err == nil {
+		t.Error("unknown frequency should be rejected")
+	}
+}
+
+func TestApplyUnsubscribe(t *testing.T) {
+	settings := &model.NotificationSettings{Digest: model.DigestDaily, Preferences: model.NotificationPreferences{
+		model.NotificationFavoriteUpdate: model.ChannelEmail,
+	}}
+
+	if !applyUnsubscribe(settings, UnsubscribeDigest) {
+		t.Fatal("expected digest unsubscribe to be accepted")
+	}
+	if settings.Digest != model.DigestOff {
+		t.Errorf("expected digest to be turned off, got %s", settings.Digest)
+	}
+	for notificationType := range model.NotificationDefaults {
+		if got := settings.Channel(notificationType); got == model.ChannelEmail {
+			t.Errorf("expected %s to stop sending email after leaving the digest, got %s", notificationType, got)
+		}
+	}
+
+	settings = &model.NotificationSettings{Preferences: model.NotificationPreferences{
+		model.NotificationFavoriteUpdate: model.ChannelOff,
+	}}
+	applyUnsubscribe(settings, UnsubscribeDigest)
+	if got := settings.Channel(model.NotificationFavoriteUpdate); got != model.ChannelOff {
+		t.Errorf("expected a disabled type to stay off, got %s", got)
+	}
+
+	if !applyUnsubscribe(settings, model.NotificationModeration) || settings.Channel(model.NotificationModeration) != model.ChannelInApp {
+		t.Error("expected a type unsubscribe to switch it to in_app")
+	}
+	if applyUnsubscribe(settings, "unknown") {
+		t.Error("expected an unknown topic to be rejected")
+	}
+}
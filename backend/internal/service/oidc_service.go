@@ -0,0 +1,379 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/ysmmc/backend/internal/config"
+	"github.com/ysmmc/backend/internal/database"
+	"github.com/ysmmc/backend/internal/model"
+	"github.com/ysmmc/backend/internal/repository"
+	"github.com/ysmmc/backend/pkg/oidc"
+	"github.com/ysmmc/backend/pkg/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	// oidcStateTTL 从跳转到提供方到回调完成的最长时间
+	oidcStateTTL = 10 * time.Minute
+	// maxOIDCUsernameLength 自动生成的用户名上限，留出冲突时追加后缀的空间
+	maxOIDCUsernameLength = 40
+)
+
+var (
+	ErrOIDCProviderNotFound = errors.New("login provider not found")
+	ErrOIDCInvalidState     = errors.New("invalid or expired login request")
+	// ErrOIDCEmailInUse 第三方身份的邮箱已属于本站账号。为防止通过第三方账号接管他人账号，
+	// 不自动关联，需用户先登录本站账号再主动关联
+	ErrOIDCEmailInUse = errors.New("an account with this email already exists, sign in and link this provider from your account settings")
+)
+
+var oidcRegistry struct {
+	once      sync.Once
+	providers []*oidc.Provider
+	byName    map[string]*oidc.Provider
+}
+
+// loadOIDCProviders 首次使用时根据配置创建提供方，服务发现延迟到第一次登录请求
+func loadOIDCProviders() {
+	oidcRegistry.once.Do(func() {
+		oidcRegistry.byName = map[string]*oidc.Provider{}
+		for _, cfg := range config.AppConfig.OIDCProviders {
+			p := oidc.NewProvider(oidc.Config{
+				Name:         cfg.Name,
+				DisplayName:  cfg.DisplayName,
+				Issuer:       cfg.Issuer,
+				ClientID:     cfg.ClientID,
+				ClientSecret: cfg.ClientSecret,
+				Scopes:       cfg.Scopes,
+			}, nil)
+			oidcRegistry.providers = append(oidcRegistry.providers, p)
+			oidcRegistry.byName[cfg.Name] = p
+		}
+	})
+}
+
+func oidcProvider(name string) (*oidc.Provider, bool) {
+	loadOIDCProviders()
+	p, ok := oidcRegistry.byName[name]
+	return p, ok
+}
+
+type OIDCProviderInfo struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+}
+
+type OIDCAuthorization struct {
+	AuthorizationURL string `json:"authorization_url"`
+	// State 前端应保存并在回调时比对，确保回调来自本浏览器发起的请求
+	State string `json:"state"`
+}
+
+type OIDCCallbackRequest struct {
+	State string `json:"state" binding:"required"`
+	Code  string `json:"code" binding:"required"`
+}
+
+type OIDCService struct {
+	userRepo             *repository.UserRepository
+	identityRepo         *repository.UserIdentityRepository
+	authService          *AuthService
+	securityEventService *SecurityEventService
+	registrationService  *RegistrationService
+}
+
+func NewOIDCService() *OIDCService {
+	return &OIDCService{
+		userRepo:             repository.NewUserRepository(),
+		identityRepo:         repository.NewUserIdentityRepository(),
+		authService:          NewAuthService(),
+		securityEventService: NewSecurityEventService(),
+		registrationService:  NewRegistrationService(),
+	}
+}
+
+func (s *OIDCService) Providers() []OIDCProviderInfo {
+	loadOIDCProviders()
+	result := make([]OIDCProviderInfo, len(oidcRegistry.providers))
+	for i, p := range oidcRegistry.providers {
+		result[i] = OIDCProviderInfo{Name: p.Name(), DisplayName: p.DisplayName()}
+	}
+	return result
+}
+
+// BeginLogin 发起第三方登录，返回提供方授权地址
+func (s *OIDCService) BeginLogin(ctx context.Context, provider string) (*OIDCAuthorization, error) {
+	return s.begin(ctx, provider, nil)
+}
+
+// BeginLink 已登录用户发起关联第三方身份
+func (s *OIDCService) BeginLink(ctx context.Context, userID uuid.UUID, provider string) (*OIDCAuthorization, error) {
+	return s.begin(ctx, provider, &userID)
+}
+
+func (s *OIDCService) begin(ctx context.Context, providerName string, userID *uuid.UUID) (*OIDCAuthorization, error) {
+	provider, ok := oidcProvider(providerName)
+	if !ok {
+		return nil, ErrOIDCProviderNotFound
+	}
+
+	state, err := oidc.RandomToken()
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := oidc.RandomToken()
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := oidc.RandomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, config.AppConfig.OIDCRedirectURL, state, nonce, oidc.CodeChallengeS256(verifier))
+	if err != nil {
+		slog.ErrorContext(ctx, "OIDC provider unavailable", "provider", providerName, "error", err)
+		return nil, errors.New("login provider is unavailable")
+	}
+
+	s.identityRepo.DeleteExpiredStates(ctx)
+	if err := s.identityRepo.CreateState(ctx, &model.OIDCState{
+		StateHash:    repository.HashToken(state),
+		Provider:     providerName,
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		UserID:       userID,
+		ExpiresAt:    time.Now().Add(oidcStateTTL),
+	}); err != nil {
+		return nil, err
+	}
+
+	return &OIDCAuthorization{AuthorizationURL: authURL, State: state}, nil
+}
+
+// exchange 消费 state 并向提供方兑换授权码。userID 为 nil 表示登录流程，
+// 否则必须与发起关联时的用户一致
+func (s *OIDCService) exchange(ctx context.Context, req *OIDCCallbackRequest, userID *uuid.UUID) (string, *oidc.Identity, error) {
+	state, err := s.identityRepo.ConsumeState(ctx, repository.HashToken(req.State))
+	if err != nil {
+		return "", nil, ErrOIDCInvalidState
+	}
+
+	if (userID == nil) != (state.UserID == nil) || (userID != nil && *userID != *state.UserID) {
+		return "", nil, ErrOIDCInvalidState
+	}
+
+	provider, ok := oidcProvider(state.Provider)
+	if !ok {
+		return "", nil, ErrOIDCProviderNotFound
+	}
+
+	identity, err := provider.Exchange(ctx, req.Code, state.CodeVerifier, config.AppConfig.OIDCRedirectURL, state.Nonce)
+	if err != nil {
+		slog.WarnContext(ctx, "OIDC exchange failed", "provider", state.Provider, "error", err)
+		return "", nil, errors.New("failed to verify login with provider")
+	}
+	return state.Provider, identity, nil
+}
+
+// CompleteLogin 处理登录回调：已关联的身份直接登录；未关联且邮箱未被占用时创建新账号
+func (s *OIDCService) CompleteLogin(ctx context.Context, req *OIDCCallbackRequest, client ClientInfo) (*LoginResponse, error) {
+	provider, identity, err := s.exchange(ctx, req, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var user *model.User
+	linked, err := s.identityRepo.FindByProviderSubject(ctx, provider, identity.Subject)
+	if err == nil {
+		user, err = s.userRepo.FindByID(ctx, linked.UserID)
+		if err != nil {
+			return nil, errors.New("user not found")
+		}
+		s.identityRepo.TouchLogin(ctx, linked.ID, identity.Email, time.Now())
+	} else {
+		user, err = s.provision(ctx, provider, identity)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s.authService.startLogin(ctx, user, client)
+}
+
+func (s *OIDCService) provision(ctx context.Context, provider string, identity *oidc.Identity) (*model.User, error) {
+	if identity.Email == "" || !utils.ValidateEmail(identity.Email) {
+		return nil, errors.New("login provider did not return an email address")
+	}
+	if !identity.EmailVerified {
+		return nil, errors.New("email address is not verified by the login provider")
+	}
+	if s.userRepo.ExistsByEmailIgnoreCase(ctx, identity.Email) {
+		return nil, ErrOIDCEmailInUse
+	}
+	// 第三方登录无法携带邀请码，invite_only 与 closed 模式下不自动创建账号
+	if err := s.registrationService.CheckPolicy(ctx, identity.Email, false); err != nil {
+		return nil, err
+	}
+
+	username, err := s.uniqueUsername(ctx, oidcUsernameBase(identity))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	user := &model.User{
+		Email:         identity.Email,
+		Username:      username,
+		Role:          model.RoleUser,
+		ProfileStatus: "approved",
+		EmailVerified: true,
+	}
+	err = database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := (&repository.UserRepository{DB: tx}).Create(ctx, user); err != nil {
+			return err
+		}
+		return (&repository.UserIdentityRepository{DB: tx}).Create(ctx, &model.UserIdentity{
+			UserID:      user.ID,
+			Provider:    provider,
+			Subject:     identity.Subject,
+			Email:       identity.Email,
+			LastLoginAt: &now,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	userRegistrations.Inc("oidc")
+	return user, nil
+}
+
+func (s *OIDCService) uniqueUsername(ctx context.Context, base string) (string, error) {
+	if !s.userRepo.ExistsByUsername(ctx, base) {
+		return base, nil
+	}
+	for i := 0; i < 5; i++ {
+		n, err := rand.Int(rand.Reader, big.NewInt(10000))
+		if err != nil {
+			return "", err
+		}
+		candidate := fmt.Sprintf("%s_%04d", base, n.Int64())
+		if !s.userRepo.ExistsByUsername(ctx, candidate) {
+			return candidate, nil
+		}
+	}
+	return "", errors.New("failed to allocate a username, please try again")
+}
+
+// oidcUsernameBase 依次尝试 preferred_username、name 与邮箱前缀，去掉站内用户名不允许的字符
+func oidcUsernameBase(identity *oidc.Identity) string {
+	candidates := []string{identity.PreferredUsername, identity.Name}
+	if at := strings.IndexByte(identity.Email, '@'); at > 0 {
+		candidates = append(candidates, identity.Email[:at])
+	}
+
+	for _, candidate := range candidates {
+		var b strings.Builder
+		count := 0
+		for _, r := range strings.TrimSpace(candidate) {
+			if count >= maxOIDCUsernameLength {
+				break
+			}
+			switch {
+			case unicode.IsSpace(r) || r == '.':
+				r = '_'
+			case r == '_' || r == '-' || r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			case r >= 0x4e00 && r <= 0x9fa5:
+			default:
+				continue
+			}
+			b.WriteRune(r)
+			count++
+		}
+		if name := b.String(); utils.ValidateUsername(name) {
+			return name
+		}
+	}
+	return "user"
+}
+
+// CompleteLink 处理关联回调，将第三方身份关联到当前用户
+func (s *OIDCService) CompleteLink(ctx context.Context, userID uuid.UUID, req *OIDCCallbackRequest, client ClientInfo) (*model.UserIdentity, error) {
+	provider, identity, err := s.exchange(ctx, req, &userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing, err := s.identityRepo.FindByProviderSubject(ctx, provider, identity.Subject); err == nil {
+		if existing.UserID == userID {
+			return existing, nil
+		}
+		return nil, errors.New("this identity is already linked to another account")
+	}
+
+	identities, err := s.identityRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, i := range identities {
+		if i.Provider == provider {
+			return nil, errors.New("an identity from this provider is already linked, unlink it first")
+		}
+	}
+
+	linked := &model.UserIdentity{
+		UserID:   userID,
+		Provider: provider,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+	}
+	if err := s.identityRepo.Create(ctx, linked); err != nil {
+		return nil, err
+	}
+
+	s.securityEventService.Record(ctx, &userID, model.SecurityEventIdentityLinked, client, "provider="+provider)
+	return linked, nil
+}
+
+func (s *OIDCService) ListIdentities(ctx context.Context, userID uuid.UUID) ([]model.UserIdentity, error) {
+	return s.identityRepo.ListByUserID(ctx, userID)
+}
+
+// Unlink 解除关联。没有设置密码的账号至少保留一个第三方身份，否则将无法登录
+func (s *OIDCService) Unlink(ctx context.Context, userID, identityID uuid.UUID, client ClientInfo) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	if user.PasswordHash == "" {
+		count, err := s.identityRepo.CountByUserID(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if count <= 1 {
+			return errors.New("cannot unlink your only sign-in method, set a password first")
+		}
+	}
+
+	deleted, err := s.identityRepo.DeleteByIDAndUserID(ctx, identityID, userID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errors.New("identity not found")
+	}
+
+	s.securityEventService.Record(ctx, &userID, model.SecurityEventIdentityUnlinked, client, "identity="+identityID.String())
+	return nil
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/ysmmc/backend/pkg/oidc"
+)
+
+func TestOIDCUsernameBase(t *testing.T) {
+	tests := []struct {
+		name     string
+		identity oidc.Identity
+		want     string
+	}{
+		{"preferred username", oidc.Identity{PreferredUsername: "alice", Name: "Alice Liddell"}, "alice"},
+		{"name with spaces", oidc.Identity{Name: "Alice Liddell"}, "Alice_Liddell"},
+		{"chinese name", oidc.Identity{Name: "张三"}, "张三"},
+		{"strips symbols", oidc.Identity{PreferredUsername: "a!l@i#c$e"}, "alice"},
+		{"falls back to email", oidc.Identity{PreferredUsername: "!", Email: "bob.smith@example.com"}, "bob_smith"},
+		{"default", oidc.Identity{PreferredUsername: "é"}, "user"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := oidcUsernameBase(&tt.identity); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	long := oidcUsernameBase(&oidc.Identity{PreferredUsername: "abcdefghijklmnopqrstuvwxyzabcdefghijklmnopqrstuvwxyz"})
+	if len(long) != maxOIDCUsernameLength {
+		t.Errorf("expected username truncated to %d characters, got %d", maxOIDCUsernameLength, len(long))
+	}
+}
@@ -0,0 +1,313 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ysmmc/backend/internal/model"
+	"github.com/ysmmc/backend/internal/repository"
+	"gorm.io/gorm"
+)
+
+const (
+	// inviteMinAccountAge 普通用户注册满该时长后才能生成邀请码
+	inviteMinAccountAge = 7 * 24 * time.Hour
+	// inviteMaxUsable 普通用户同时持有的可用邀请码上限
+	inviteMaxUsable      = 5
+	inviteMaxUses        = 5
+	inviteMaxDays        = 30
+	inviteDefaultDays    = 7
+	adminInviteMaxUses   = 1000
+	adminInviteMaxDays   = 365
+	registrationCacheTTL = authCacheTTL
+)
+
+var (
+	ErrRegistrationClosed    = errors.New("registration is currently closed")
+	ErrInviteRequired        = errors.New("an invite code is required to register")
+	ErrInvalidInviteCode     = errors.New("invalid or expired invite code")
+	ErrEmailDomainNotAllowed = errors.New("registration is limited to approved email domains")
+)
+
+// registrationSettingsCache 缓存注册模式，管理员修改后其他实例最多延迟 registrationCacheTTL 生效
+type registrationSettingsCache struct {
+	mu       sync.Mutex
+	value    model.RegistrationSettings
+	loadedAt time.Time
+}
+
+var registrationSettings = &registrationSettingsCache{}
+
+func (c *registrationSettingsCache) get(load func() model.RegistrationSettings) model.RegistrationSettings {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.loadedAt.IsZero() || time.Since(c.loadedAt) >= registrationCacheTTL {
+		c.value = load()
+		c.loadedAt = time.Now()
+	}
+	return c.value
+}
+
+func (c *registrationSettingsCache) invalidate() {
+	c.mu.Lock()
+	c.loadedAt = time.Time{}
+	c.mu.Unlock()
+}
+
+// checkRegistrationPolicy 按注册模式判断是否允许注册；hasInvite 表示请求携带了邀请码（是否有效另行校验）。
+// 邀请码在 invite_only 与 domain_allowlist 模式下均可放行，closed 模式下一律拒绝
+func checkRegistrationPolicy(settings model.RegistrationSettings, email string, hasInvite bool) error {
+	switch settings.Mode {
+	case model.RegistrationClosed:
+		return ErrRegistrationClosed
+	case model.RegistrationInviteOnly:
+		if !hasInvite {
+			return ErrInviteRequired
+		}
+	case model.RegistrationDomainAllowlist:
+		if !hasInvite && !emailDomainAllowed(email, settings.AllowedDomains) {
+			return ErrEmailDomainNotAllowed
+		}
+	}
+	return nil
+}
+
+func emailDomainAllowed(email string, domains []string) bool {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	domain := strings.ToLower(email[at+1:])
+	for _, d := range domains {
+		if domain == d {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeDomains 统一为小写、去掉前导 @ 与重复项
+func normalizeDomains(domains []string) []string {
+	result := []string{}
+	seen := map[string]bool{}
+	for _, d := range domains {
+		d = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(d)), "@")
+		if d == "" || seen[d] {
+			continue
+		}
+		seen[d] = true
+		result = append(result, d)
+	}
+	return result
+}
+
+// NormalizeInviteCode 忽略大小写、空白与分隔符
+func NormalizeInviteCode(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+func generateInviteCode() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(buf)[:12], nil
+}
+
+type RegistrationService struct {
+	settingRepo     *repository.SettingRepository
+	inviteRepo      *repository.InviteCodeRepository
+	sanctionService *SanctionService
+}
+
+func NewRegistrationService() *RegistrationService {
+	return &RegistrationService{
+		settingRepo:     repository.NewSettingRepository(),
+		inviteRepo:      repository.NewInviteCodeRepository(),
+		sanctionService: NewSanctionService(),
+	}
+}
+
+// Settings 返回当前注册模式，未设置过时为 open
+func (s *RegistrationService) Settings(ctx context.Context) model.RegistrationSettings {
+	return registrationSettings.get(func() model.RegistrationSettings {
+		settings := model.RegistrationSettings{Mode: model.RegistrationOpen, AllowedDomains: []string{}}
+		setting, err := s.settingRepo.Get(ctx, model.SettingRegistration)
+		if err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				slog.ErrorContext(ctx, "Failed to load registration settings", "error", err)
+			}
+			return settings
+		}
+		if err := json.Unmarshal([]byte(setting.Value), &settings); err != nil || !model.IsValidRegistrationMode(settings.Mode) {
+			slog.WarnContext(ctx, "Invalid registration settings, falling back to open", "value", setting.Value)
+			return model.RegistrationSettings{Mode: model.RegistrationOpen, AllowedDomains: []string{}}
+		}
+		return settings
+	})
+}
+
+type UpdateRegistrationRequest struct {
+	Mode           string   `json:"mode" binding:"required"`
+	AllowedDomains []string `json:"allowed_domains"`
+}
+
+func (s *RegistrationService) UpdateSettings(ctx context.Context, adminID uuid.UUID, req *UpdateRegistrationRequest) (*model.RegistrationSettings, error) {
+	if !model.IsValidRegistrationMode(req.Mode) {
+		return nil, errors.New("invalid registration mode")
+	}
+
+	settings := model.RegistrationSettings{Mode: req.Mode, AllowedDomains: normalizeDomains(req.AllowedDomains)}
+	if settings.Mode == model.RegistrationDomainAllowlist && len(settings.AllowedDomains) == 0 {
+		return nil, errors.New("domain_allowlist mode requires at least one allowed domain")
+	}
+
+	value, err := json.Marshal(settings)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.settingRepo.Upsert(ctx, &model.Setting{
+		Key:       model.SettingRegistration,
+		Value:     string(value),
+		UpdatedBy: &adminID,
+		UpdatedAt: time.Now(),
+	}); err != nil {
+		return nil, err
+	}
+
+	registrationSettings.invalidate()
+	return &settings, nil
+}
+
+// CheckPolicy 在不占用邀请码的情况下检查当前模式是否允许该邮箱注册
+func (s *RegistrationService) CheckPolicy(ctx context.Context, email string, hasInvite bool) error {
+	return checkRegistrationPolicy(s.Settings(ctx), email, hasInvite)
+}
+
+// admit 检查注册模式并占用邀请码，须在创建用户的事务中调用，注册失败时占用随事务回滚
+func (s *RegistrationService) admit(ctx context.Context, tx *gorm.DB, email, inviteCode string) (*model.InviteCode, error) {
+	code := NormalizeInviteCode(inviteCode)
+	if err := s.CheckPolicy(ctx, email, code != ""); err != nil {
+		return nil, err
+	}
+	if code == "" {
+		return nil, nil
+	}
+
+	invite, err := (&repository.InviteCodeRepository{DB: tx}).Redeem(ctx, code, time.Now())
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidInviteCode
+	}
+	return invite, err
+}
+
+// CanInvite 普通用户需验证邮箱、注册满 7 天且没有生效中的处罚
+func (s *RegistrationService) CanInvite(ctx context.Context, user *model.User) error {
+	if !user.EmailVerified {
+		return errors.New("please verify your email before creating invite codes")
+	}
+	if time.Since(user.CreatedAt) < inviteMinAccountAge {
+		return fmt.Errorf("accounts must be at least %d days old to create invite codes", int(inviteMinAccountAge.Hours()/24))
+	}
+	sanctions, err := s.sanctionService.ListActive(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if len(sanctions) > 0 {
+		return errors.New("accounts under sanction cannot create invite codes")
+	}
+	return nil
+}
+
+type CreateInviteRequest struct {
+	MaxUses       int    `json:"max_uses" binding:"omitempty,min=1"`
+	ExpiresInDays int    `json:"expires_in_days" binding:"omitempty,min=1"`
+	Note          string `json:"note" binding:"max=255"`
+}
+
+// CreateInvite 生成邀请码；privileged 为拥有 registration.manage 权限的管理员，不受资格与数量限制
+func (s *RegistrationService) CreateInvite(ctx context.Context, user *model.User, privileged bool, req *CreateInviteRequest) (*model.InviteCode, error) {
+	maxUses, maxDays := inviteMaxUses, inviteMaxDays
+	if privileged {
+		maxUses, maxDays = adminInviteMaxUses, adminInviteMaxDays
+	} else {
+		if err := s.CanInvite(ctx, user); err != nil {
+			return nil, err
+		}
+		count, err := s.inviteRepo.CountUsableByCreator(ctx, user.ID, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		if count >= inviteMaxUsable {
+			return nil, fmt.Errorf("you can have at most %d active invite codes", inviteMaxUsable)
+		}
+	}
+
+	if req.MaxUses == 0 {
+		req.MaxUses = 1
+	}
+	if req.ExpiresInDays == 0 {
+		req.ExpiresInDays = inviteDefaultDays
+	}
+	if req.MaxUses > maxUses {
+		return nil, fmt.Errorf("max_uses cannot exceed %d", maxUses)
+	}
+	if req.ExpiresInDays > maxDays {
+		return nil, fmt.Errorf("expires_in_days cannot exceed %d", maxDays)
+	}
+
+	code, err := generateInviteCode()
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := time.Now().Add(time.Duration(req.ExpiresInDays) * 24 * time.Hour)
+	invite := &model.InviteCode{
+		Code:      code,
+		CreatedBy: user.ID,
+		Note:      strings.TrimSpace(req.Note),
+		MaxUses:   req.MaxUses,
+		ExpiresAt: &expiresAt,
+	}
+	if err := s.inviteRepo.Create(ctx, invite); err != nil {
+		return nil, err
+	}
+	return invite, nil
+}
+
+func (s *RegistrationService) ListInvites(ctx context.Context, userID uuid.UUID) ([]model.InviteCode, error) {
+	return s.inviteRepo.ListByCreator(ctx, userID)
+}
+
+func (s *RegistrationService) ListAllInvites(ctx context.Context, page, pageSize int, createdBy *uuid.UUID) ([]model.InviteCode, int64, error) {
+	return s.inviteRepo.List(ctx, page, pageSize, createdBy)
+}
+
+func (s *RegistrationService) ListInvitees(ctx context.Context, userID uuid.UUID) ([]model.User, error) {
+	return s.inviteRepo.ListInvitees(ctx, userID)
+}
+
+// RevokeInvite 吊销邀请码；ownerID 非空时只能吊销自己创建的
+func (s *RegistrationService) RevokeInvite(ctx context.Context, id uuid.UUID, ownerID *uuid.UUID) error {
+	invite, err := s.inviteRepo.FindByID(ctx, id)
+	if err != nil || (ownerID != nil && invite.CreatedBy != *ownerID) {
+		return errors.New("invite code not found")
+	}
+	revoked, err := s.inviteRepo.Revoke(ctx, id, time.Now())
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return errors.New("invite code already revoked")
+	}
+	return nil
+}
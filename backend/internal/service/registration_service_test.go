@@ -0,0 +1,60 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ysmmc/backend/internal/model"
+)
+
+func TestCheckRegistrationPolicy(t *testing.T) {
+	allowlist := model.RegistrationSettings{Mode: model.RegistrationDomainAllowlist, AllowedDomains: []string{"example.com"}}
+
+	tests := []struct {
+		name      string
+		settings  model.RegistrationSettings
+		email     string
+		hasInvite bool
+		want      error
+	}{
+		{"open", model.RegistrationSettings{Mode: model.RegistrationOpen}, "a@b.com", false, nil},
+		{"closed", model.RegistrationSettings{Mode: model.RegistrationClosed}, "a@b.com", false, ErrRegistrationClosed},
+		{"closed ignores invite", model.RegistrationSettings{Mode: model.RegistrationClosed}, "a@b.com", true, ErrRegistrationClosed},
+		{"invite only without code", model.RegistrationSettings{Mode: model.RegistrationInviteOnly}, "a@b.com", false, ErrInviteRequired},
+		{"invite only with code", model.RegistrationSettings{Mode: model.RegistrationInviteOnly}, "a@b.com", true, nil},
+		{"allowed domain", allowlist, "a@Example.com", false, nil},
+		{"subdomain is not allowed", allowlist, "a@mail.example.com", false, ErrEmailDomainNotAllowed},
+		{"other domain", allowlist, "a@b.com", false, ErrEmailDomainNotAllowed},
+		{"other domain with invite", allowlist, "a@b.com", true, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkRegistrationPolicy(tt.settings, tt.email, tt.hasInvite); !errors.Is(err, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestNormalizeDomains(t *testing.T) {
+	got := normalizeDomains([]string{" Example.com ", "@example.com", "", "corp.example.org"})
+	want := []string{"example.com", "corp.example.org"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestInviteCode(t *testing.T) {
+	code, err := generateInviteCode()
+	if err != nil {
+		t.Fatalf("failed to generate invite code: %v", err)
+	}
+	if len(code) != 12 || NormalizeInviteCode(code) != code {
+		t.Errorf("unexpected invite code format: %q", code)
+	}
+	if got := NormalizeInviteCode(" abcd-efgh-ijkl "); got != "ABCDEFGHIJKL" {
+		t.Errorf("expected normalized code ABCDEFGHIJKL, got %s", got)
+	}
+}
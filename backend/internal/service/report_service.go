@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ysmmc/backend/internal/config"
+	"github.com/ysmmc/backend/internal/database"
+	"github.com/ysmmc/backend/internal/model"
+	"github.com/ysmmc/backend/internal/repository"
+	"github.com/ysmmc/backend/pkg/utils"
+	"gorm.io/gorm"
+)
+
+type ReportService struct {
+	reportRepo  *repository.ReportRepository
+	modelRepo   *repository.ModelRepository
+	versionRepo *repository.ModelVersionRepository
+	userRepo    *repository.UserRepository
+	fileService *FileService
+}
+
+func NewReportService() *ReportService {
+	return &ReportService{
+		reportRepo:  repository.NewReportRepository(),
+		modelRepo:   repository.NewModelRepository(),
+		versionRepo: repository.NewModelVersionRepository(),
+		userRepo:    repository.NewUserRepository(),
+		fileService: NewFileService(),
+	}
+}
+
+type CreateReportRequest struct {
+	TargetType  string    `json:"target_type" binding:"required"`
+	TargetID    uuid.UUID `json:"target_id" binding:"required"`
+	Category    string    `json:"category" binding:"required"`
+	Description *string   `json:"description" binding:"omitempty,max=2000"`
+}
+
+type ReviewReportRequest struct {
+	Note string `json:"note"`
+}
+
+var ErrAlreadyReported = errors.New("you have already reported this content")
+
+func (s *ReportService) Create(ctx context.Context, reporterID uuid.UUID, req *CreateReportRequest) (*model.Report, error) {
+	if !model.IsValidReportTarget(req.TargetType) {
+		return nil, errors.New("invalid target type")
+	}
+
+	if !model.IsValidReportCategory(req.Category) {
+		return nil, errors.New("invalid report category")
+	}
+
+	if err := s.checkTarget(ctx, reporterID, req.TargetType, req.TargetID); err != nil {
+		return nil, err
+	}
+
+	if req.Description != nil {
+		description := utils.SanitizeString(*req.Description)
+		req.Description = &description
+	}
+
+	var report *model.Report
+	err := database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		reportRepo := &repository.ReportRepository{DB: tx}
+
+		existing, err := reportRepo.FindOrCreateOpenForUpdate(ctx, req.TargetType, req.TargetID, req.Category)
+		if err != nil {
+			return err
+		}
+		if reportRepo.EntryExists(ctx, existing.ID, reporterID) {
+			return ErrAlreadyReported
+		}
+
+		entry := &model.ReportEntry{
+			ReportID:    existing.ID,
+			ReporterID:  reporterID,
+			Category:    req.Category,
+			Description: req.Description,
+		}
+		if err := reportRepo.CreateEntry(ctx, entry); err != nil {
+			return err
+		}
+
+		existing.ReportCount++
+
+		threshold := config.AppConfig.ReportAutoHideThreshold
+		if existing.TargetType == "model" && !existing.AutoHidden && threshold > 0 && existing.ReportCount >= threshold {
+			if err := hideReportedModel(ctx, &repository.ModelRepository{DB: tx}, existing); err != nil {
+				return err
+			}
+		}
+
+		report = existing
+		return reportRepo.Update(ctx, existing)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+func (s *ReportService) List(ctx context.Context, page, pageSize int, status, targetType string) ([]model.Report, int64, error) {
+	return s.reportRepo.List(ctx, page, pageSize, status, targetType)
+}
+
+func (s *ReportService) GetByID(ctx context.Context, id uuid.UUID) (*model.Report, error) {
+	return s.reportRepo.FindByID(ctx, id)
+}
+
+func (s *ReportService) Resolve(ctx context.Context, id, adminID uuid.UUID, note string) (*model.Report, error) {
+	return s.close(ctx, id, adminID, "resolved", note)
+}
+
+func (s *ReportService) Dismiss(ctx context.Context, id, adminID uuid.UUID, note string) (*model.Report, error) {
+	return s.close(ctx, id, adminID, "dismissed", note)
+}
+
+func (s *ReportService) CountOpen(ctx context.Context) (int64, error) {
+	return s.reportRepo.CountByStatus(ctx, "open")
+}
+
+func (s *ReportService) close(ctx context.Context, id, adminID uuid.UUID, status, note string) (*model.Report, error) {
+	var report *model.Report
+	err := database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		reportRepo := &repository.ReportRepository{DB: tx}
+
+		r, err := reportRepo.FindByID(ctx, id)
+		if err != nil {
+			return errors.New("report not found")
+		}
+
+		if r.Status != "open" {
+			return errors.New("report has already been closed")
+		}
+
+		if status == "dismissed" && r.AutoHidden {
+			if err := restoreReportedModel(ctx, &repository.ModelRepository{DB: tx}, r); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		r.Status = status
+		r.ResolvedBy = &adminID
+		r.ResolvedAt = &now
+		if note != "" {
+			r.Resolution = &note
+		}
+
+		report = r
+		return reportRepo.Update(ctx, r)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+func (s *ReportService) checkTarget(ctx context.Context, reporterID uuid.UUID, targetType string, targetID uuid.UUID) error {
+	switch targetType {
+	case "model":
+		if _, err := s.modelRepo.FindByID(ctx, targetID); err != nil {
+			return errors.New("model not found")
+		}
+	case "version":
+		if _, err := s.versionRepo.FindByID(ctx, targetID); err != nil {
+			return errors.New("version not found")
+		}
+	case "user":
+		if targetID == reporterID {
+			return errors.New("cannot report yourself")
+		}
+		if _, err := s.userRepo.FindByID(ctx, targetID); err != nil {
+			return errors.New("user not found")
+		}
+	case "image":
+		if _, err := s.fileService.GetFile(ctx, targetID); err != nil {
+			return errors.New("image not found")
+		}
+	}
+	return nil
+}
+
+func hideReportedModel(ctx context.Context, modelRepo *repository.ModelRepository, report *model.Report) error {
+	m, err := modelRepo.FindByID(ctx, report.TargetID)
+	if err != nil {
+		return err
+	}
+
+	if m.Status == "hidden" {
+		return nil
+	}
+
+	previous := m.Status
+	report.PreviousStatus = &previous
+	report.AutoHidden = true
+
+	m.Status = "hidden"
+	return modelRepo.Update(ctx, m)
+}
+
+func restoreReportedModel(ctx context.Context, modelRepo *repository.ModelRepository, report *model.Report) error {
+	m, err := modelRepo.FindByID(ctx, report.TargetID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if m.Status != "hidden" || report.PreviousStatus == nil {
+		return nil
+	}
+
+	m.Status = *report.PreviousStatus
+	return modelRepo.Update(ctx, m)
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/ysmmc/backend/internal/config"
+	"github.com/ysmmc/backend/internal/database"
+	"github.com/ysmmc/backend/internal/model"
+)
+
+func TestReportService_Create_Validation(t *testing.T) {
+	s := NewReportService()
+	ctx := context.Background()
+
+	if _, err := s.Create(ctx, uuid.New(), createReportRequest("comment", "spam")); err == nil {
+		t.Error("expected unknown target type to be rejected")
+	}
+	if _, err := s.Create(ctx, uuid.New(), createReportRequest("model", "boring")); err == nil {
+		t.Error("expected unknown category to be rejected")
+	}
+}
+
+func TestReportService_Create_DeduplicatesByTarget(t *testing.T) {
+	setupTestDB(t)
+	ctx := context.Background()
+	author := createTestUser(t, model.RoleUser)
+	first := createTestUser(t, model.RoleUser)
+	second := createTestUser(t, model.RoleUser)
+	m := createTestModel(t, author.ID, "approved")
+	s := NewReportService()
+
+	req := createReportRequest("model", "spam")
+	req.TargetID = m.ID
+	report, err := s.Create(ctx, first.ID, req)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if report.ReportCount != 1 {
+		t.Errorf("expected count 1 after the first report, got %d", report.ReportCount)
+	}
+
+	if _, err := s.Create(ctx, first.ID, req); !errors.Is(err, ErrAlreadyReported) {
+		t.Errorf("expected ErrAlreadyReported for a repeated report, got %v", err)
+	}
+
+	again, err := s.Create(ctx, second.ID, req)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if again.ID != report.ID || again.ReportCount != 2 {
+		t.Errorf("expected the second reporter to join report %s with count 2, got %s with count %d", report.ID, again.ID, again.ReportCount)
+	}
+
+	var open int64
+	database.DB.Model(&model.Report{}).Where("target_id = ? AND status = ?", m.ID, "open").Count(&open)
+	if open != 1 {
+		t.Errorf("expected a single open report for the target, got %d", open)
+	}
+}
+
+func TestReportService_Create_AutoHide(t *testing.T) {
+	setupTestDB(t)
+	threshold := config.AppConfig.ReportAutoHideThreshold
+	config.AppConfig.ReportAutoHideThreshold = 2
+	defer func() { config.AppConfig.ReportAutoHideThreshold = threshold }()
+
+	ctx := context.Background()
+	author := createTestUser(t, model.RoleUser)
+	m := createTestModel(t, author.ID, "approved")
+	s := NewReportService()
+	req := createReportRequest("model", "nsfw")
+	req.TargetID = m.ID
+
+	if _, err := s.Create(ctx, createTestUser(t, model.RoleUser).ID, req); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	var current model.Model
+	database.DB.First(&current, "id = ?", m.ID)
+	if current.Status != "approved" {
+		t.Fatalf("expected model to stay visible below the threshold, got %s", current.Status)
+	}
+
+	report, err := s.Create(ctx, createTestUser(t, model.RoleUser).ID, req)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	database.DB.First(&current, "id = ?", m.ID)
+	if current.Status != "hidden" || !report.AutoHidden || report.PreviousStatus == nil || *report.PreviousStatus != "approved" {
+		t.Errorf("expected model to be hidden at the threshold, got status %s, report %+v", current.Status, report)
+	}
+}
+
+func createReportRequest(targetType, category string) *CreateReportRequest {
+	return &CreateReportRequest{TargetType: targetType, Category: category}
+}
@@ -0,0 +1,296 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/ysmmc/backend/internal/model"
+	"github.com/ysmmc/backend/internal/repository"
+)
+
+const rolePermissionCacheTTL = 30 * time.Second
+
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{1,19}$`)
+
+type rolePermissionCache struct {
+	ttl      time.Duration
+	load     func(context.Context) ([]model.Role, error)
+	mu       sync.Mutex
+	roles    map[string]model.Role
+	loadedAt time.Time
+}
+
+func newRolePermissionCache(ttl time.Duration, load func(context.Context) ([]model.Role, error)) *rolePermissionCache {
+	return &rolePermissionCache{ttl: ttl, load: load}
+}
+
+func (c *rolePermissionCache) lookup(ctx context.Context, name string) (model.Role, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.roles == nil || time.Since(c.loadedAt) >= c.ttl {
+		roles, err := c.load(ctx)
+		if err != nil {
+			if c.roles == nil {
+				return model.Role{}, false
+			}
+		} else {
+			byName := make(map[string]model.Role, len(roles))
+			for _, r := range roles {
+				byName[r.Name] = r
+			}
+			c.roles = byName
+			c.loadedAt = time.Now()
+		}
+	}
+
+	role, ok := c.roles[name]
+	return role, ok
+}
+
+func (c *rolePermissionCache) get(ctx context.Context, name string) ([]string, bool) {
+	role, ok := c.lookup(ctx, name)
+	return role.Permissions, ok
+}
+
+func (c *rolePermissionCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.roles = nil
+}
+
+var rolePermissions = newRolePermissionCache(rolePermissionCacheTTL, func(ctx context.Context) ([]model.Role, error) {
+	return repository.NewRoleRepository().List(ctx)
+})
+
+// HasPermission 判断角色是否拥有指定权限
+func HasPermission(ctx context.Context, role, perm string) bool {
+	perms, _ := rolePermissions.get(ctx, role)
+	return permissionsCover(perms, []string{perm})
+}
+
+// RoleRequiresMFA 判断该角色是否被要求启用两步验证
+func RoleRequiresMFA(ctx context.Context, role string) bool {
+	r, _ := rolePermissions.lookup(ctx, role)
+	return r.RequireMFA
+}
+
+// CanManageRole 判断 actor 角色是否可以授予或处置 target 角色：
+// 只有当 actor 的权限严格多于 target 时才允许，管理员不能提升、降级或处罚同级管理员
+func CanManageRole(ctx context.Context, actorRole, targetRole string) bool {
+	actorPerms, ok := rolePermissions.get(ctx, actorRole)
+	if !ok {
+		return false
+	}
+	targetPerms, ok := rolePermissions.get(ctx, targetRole)
+	if !ok {
+		return false
+	}
+	return permissionsExceed(actorPerms, targetPerms)
+}
+
+// permissionsExceed 判断 have 是否为 want 的真超集；持有 * 的角色不能被任何角色管理
+func permissionsExceed(have, want []string) bool {
+	for _, p := range want {
+		if p == model.PermAll {
+			return false
+		}
+	}
+	if !permissionsCover(have, want) {
+		return false
+	}
+	wanted := make(map[string]bool, len(want))
+	for _, p := range want {
+		wanted[p] = true
+	}
+	for _, p := range have {
+		if !wanted[p] {
+			return true
+		}
+	}
+	return false
+}
+
+func permissionsCover(have, want []string) bool {
+	set := make(map[string]bool, len(have))
+	for _, p := range have {
+		if p == model.PermAll {
+			return true
+		}
+		set[p] = true
+	}
+	for _, p := range want {
+		if !set[p] {
+			return false
+		}
+	}
+	return true
+}
+
+func normalizePermissions(perms []string) (pq.StringArray, error) {
+	seen := make(map[string]bool, len(perms))
+	result := pq.StringArray{}
+	for _, p := range perms {
+		if !model.IsValidPermission(p) {
+			return nil, errors.New("invalid permission: " + p)
+		}
+		if seen[p] {
+			continue
+		}
+		seen[p] = true
+		result = append(result, p)
+	}
+	return result, nil
+}
+
+type RoleService struct {
+	roleRepo *repository.RoleRepository
+	userRepo *repository.UserRepository
+}
+
+func NewRoleService() *RoleService {
+	return &RoleService{
+		roleRepo: repository.NewRoleRepository(),
+		userRepo: repository.NewUserRepository(),
+	}
+}
+
+type CreateRoleRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	Description string   `json:"description" binding:"max=255"`
+	Permissions []string `json:"permissions"`
+	RequireMFA  bool     `json:"require_mfa"`
+}
+
+type UpdateRoleRequest struct {
+	Description *string  `json:"description" binding:"omitempty,max=255"`
+	Permissions []string `json:"permissions"`
+	RequireMFA  *bool    `json:"require_mfa"`
+}
+
+func (s *RoleService) List(ctx context.Context) ([]model.Role, error) {
+	return s.roleRepo.List(ctx)
+}
+
+func (s *RoleService) GetByName(ctx context.Context, name string) (*model.Role, error) {
+	role, err := s.roleRepo.FindByName(ctx, name)
+	if err != nil {
+		return nil, errors.New("role not found")
+	}
+	return role, nil
+}
+
+func (s *RoleService) Create(ctx context.Context, actorRole string, req *CreateRoleRequest) (*model.Role, error) {
+	if !roleNamePattern.MatchString(req.Name) {
+		return nil, errors.New("role name must be 2-20 lowercase letters, digits or underscores")
+	}
+
+	if _, err := s.roleRepo.FindByName(ctx, req.Name); err == nil {
+		return nil, errors.New("role already exists")
+	}
+
+	perms, err := s.grantablePermissions(ctx, actorRole, req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	role := &model.Role{
+		Name:        req.Name,
+		Description: req.Description,
+		Permissions: perms,
+		RequireMFA:  req.RequireMFA,
+	}
+	if err := s.roleRepo.Create(ctx, role); err != nil {
+		return nil, err
+	}
+
+	rolePermissions.invalidate()
+	return role, nil
+}
+
+// Update 修改角色定义，只能修改权限严格少于自己的角色
+func (s *RoleService) Update(ctx context.Context, actorRole, name string, req *UpdateRoleRequest) (*model.Role, error) {
+	role, err := s.GetByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if !CanManageRole(ctx, actorRole, role.Name) {
+		return nil, errors.New("insufficient privileges to modify this role")
+	}
+
+	if model.IsSuperAdmin(role.Name) && req.Permissions != nil {
+		return nil, errors.New("cannot modify super admin permissions")
+	}
+
+	if req.Description != nil {
+		role.Description = *req.Description
+	}
+
+	if req.RequireMFA != nil {
+		role.RequireMFA = *req.RequireMFA
+	}
+
+	if req.Permissions != nil {
+		perms, err := s.grantablePermissions(ctx, actorRole, req.Permissions)
+		if err != nil {
+			return nil, err
+		}
+		role.Permissions = perms
+	}
+
+	if err := s.roleRepo.Update(ctx, role); err != nil {
+		return nil, err
+	}
+
+	rolePermissions.invalidate()
+	return role, nil
+}
+
+// Delete 删除角色，只能删除权限严格少于自己的角色
+func (s *RoleService) Delete(ctx context.Context, actorRole, name string) error {
+	role, err := s.GetByName(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	if !CanManageRole(ctx, actorRole, role.Name) {
+		return errors.New("insufficient privileges to delete this role")
+	}
+
+	if role.IsSystem {
+		return errors.New("cannot delete built-in role")
+	}
+
+	count, err := s.userRepo.CountByRole(ctx, name)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("role is still assigned to users")
+	}
+
+	if err := s.roleRepo.Delete(ctx, name); err != nil {
+		return err
+	}
+
+	rolePermissions.invalidate()
+	return nil
+}
+
+func (s *RoleService) grantablePermissions(ctx context.Context, actorRole string, requested []string) (pq.StringArray, error) {
+	perms, err := normalizePermissions(requested)
+	if err != nil {
+		return nil, err
+	}
+
+	actorPerms, _ := rolePermissions.get(ctx, actorRole)
+	if !permissionsCover(actorPerms, perms) {
+		return nil, errors.New("cannot grant permissions you do not have")
+	}
+	return perms, nil
+}
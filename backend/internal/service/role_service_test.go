@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/ysmmc/backend/internal/model"
+)
+
+func TestPermissionsCover(t *testing.T) {
+	tests := []struct {
+		name string
+		have []string
+		want []string
+		ok   bool
+	}{
+		{"wildcard covers everything", []string{model.PermAll}, []string{model.PermRolesManage, model.PermAll}, true},
+		{"subset", []string{model.PermModelsApprove, model.PermStatsView}, []string{model.PermStatsView}, true},
+		{"empty want", nil, nil, true},
+		{"missing permission", []string{model.PermModelsApprove}, []string{model.PermUsersBan}, false},
+		{"wildcard not covered", []string{model.PermModelsApprove}, []string{model.PermAll}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := permissionsCover(tt.have, tt.want); got != tt.ok {
+				t.Errorf("permissionsCover(%v, %v) = %v, want %v", tt.have, tt.want, got, tt.ok)
+			}
+		})
+	}
+}
+
+func TestNormalizePermissions(t *testing.T) {
+	perms, err := normalizePermissions([]string{model.PermStatsView, model.PermStatsView, model.PermUsersBan})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(perms) != 2 {
+		t.Errorf("expected duplicates removed, got %v", perms)
+	}
+
+	if _, err := normalizePermissions([]string{"models.explode"}); err == nil {
+		t.Error("expected error for unknown permission")
+	}
+}
+
+func TestRolePermissionCache(t *testing.T) {
+	loads := 0
+	fail := false
+	cache := newRolePermissionCache(time.Minute, func(context.Context) ([]model.Role, error) {
+		loads++
+		if fail {
+			return nil, errors.New("db down")
+		}
+		return []model.Role{{Name: "reviewer", Permissions: pq.StringArray{model.PermModelsApprove}}}, nil
+	})
+
+	perms, ok := cache.get(context.Background(), "reviewer")
+	if !ok || len(perms) != 1 {
+		t.Fatalf("expected reviewer permissions, got %v %v", perms, ok)
+	}
+	if _, ok := cache.get(context.Background(), "unknown"); ok {
+		t.Error("expected unknown role to be missing")
+	}
+	if loads != 1 {
+		t.Errorf("expected 1 load, got %d", loads)
+	}
+
+	cache.invalidate()
+	fail = true
+	if _, ok := cache.get(context.Background(), "reviewer"); ok {
+		t.Error("expected lookup to fail when nothing is cached and loading fails")
+	}
+}
+
+func TestPermissionsExceed(t *testing.T) {
+	admin := []string{model.PermModelsApprove, model.PermUsersBan, model.PermUsersRoles}
+	tests := []struct {
+		name string
+		have []string
+		want []string
+		ok   bool
+	}{
+		{"wildcard over admin", []string{model.PermAll}, admin, true},
+		{"wildcard over wildcard", []string{model.PermAll}, []string{model.PermAll}, false},
+		{"strict superset", admin, []string{model.PermModelsApprove}, true},
+		{"superset of no permissions", admin, nil, true},
+		{"equal sets", admin, []string{model.PermUsersRoles, model.PermUsersBan, model.PermModelsApprove}, false},
+		{"duplicates do not count", []string{model.PermStatsView, model.PermStatsView}, []string{model.PermStatsView}, false},
+		{"missing permission", []string{model.PermModelsApprove, model.PermStatsView}, []string{model.PermUsersBan}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := permissionsExceed(tt.have, tt.want); got != tt.ok {
+				t.Errorf("permissionsExceed(%v, %v) = %v, want %v", tt.have, tt.want, got, tt.ok)
+			}
+		})
+	}
+}
+
+func TestCanManageRole(t *testing.T) {
+	original := rolePermissions
+	rolePermissions = newRolePermissionCache(time.Minute, func(context.Context) ([]model.Role, error) {
+		return model.DefaultRoles(), nil
+	})
+	defer func() { rolePermissions = original }()
+
+	tests := []struct {
+		actor, target string
+		ok            bool
+	}{
+		{model.RoleSuperAdmin, model.RoleAdmin, true},
+		{model.RoleSuperAdmin, model.RoleSuperAdmin, false},
+		{model.RoleAdmin, model.RoleUser, true},
+		{model.RoleAdmin, "reviewer", true},
+		{model.RoleAdmin, model.RoleAdmin, false},
+		{model.RoleAdmin, model.RoleSuperAdmin, false},
+		{"reviewer", model.RoleAdmin, false},
+		{model.RoleAdmin, "unknown", false},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		if got := CanManageRole(ctx, tt.actor, tt.target); got != tt.ok {
+			t.Errorf("CanManageRole(%s, %s) = %v, want %v", tt.actor, tt.target, got, tt.ok)
+		}
+	}
+}
+
+func TestRoleService_UpdateAndDeleteRequireHigherRole(t *testing.T) {
+	setupTestDB(t)
+	rolePermissions.invalidate()
+	defer rolePermissions.invalidate()
+	ctx := context.Background()
+	s := NewRoleService()
+
+	peer, err := s.Create(ctx, model.RoleSuperAdmin, &CreateRoleRequest{
+		Name:        "moderator",
+		Permissions: []string{model.PermUsersBan, model.PermUsersRoles, model.PermRolesManage},
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	actor, err := s.Create(ctx, model.RoleSuperAdmin, &CreateRoleRequest{
+		Name:        "lead",
+		Permissions: []string{model.PermUsersBan, model.PermUsersRoles, model.PermRolesManage, model.PermStatsView},
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	requireMFA := true
+	if _, err := s.Update(ctx, actor.Name, model.RoleSuperAdmin, &UpdateRoleRequest{RequireMFA: &requireMFA}); err == nil {
+		t.Error("expected a lower role to be unable to change super_admin")
+	}
+	if _, err := s.Update(ctx, peer.Name, peer.Name, &UpdateRoleRequest{Permissions: []string{}}); err == nil {
+		t.Error("expected a role to be unable to strip its own permissions")
+	}
+	if err := s.Delete(ctx, peer.Name, actor.Name); err == nil {
+		t.Error("expected a lower role to be unable to delete a higher one")
+	}
+
+	if _, err := s.Update(ctx, actor.Name, peer.Name, &UpdateRoleRequest{Permissions: []string{model.PermUsersBan}}); err != nil {
+		t.Errorf("expected a higher role to edit a lower one, got %v", err)
+	}
+	if err := s.Delete(ctx, actor.Name, peer.Name); err != nil {
+		t.Errorf("expected a higher role to delete a lower one, got %v", err)
+	}
+}
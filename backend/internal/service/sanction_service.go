@@ -0,0 +1,279 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ysmmc/backend/internal/database"
+	"github.com/ysmmc/backend/internal/model"
+	"github.com/ysmmc/backend/internal/repository"
+	"gorm.io/gorm"
+)
+
+type SanctionService struct {
+	sanctionRepo *repository.SanctionRepository
+	userRepo     *repository.UserRepository
+}
+
+func NewSanctionService() *SanctionService {
+	return &SanctionService{
+		sanctionRepo: repository.NewSanctionRepository(),
+		userRepo:     repository.NewUserRepository(),
+	}
+}
+
+type IssueSanctionRequest struct {
+	Type          string `json:"type" binding:"required"`
+	Reason        string `json:"reason" binding:"required"`
+	DurationHours *int   `json:"duration_hours" binding:"omitempty,min=1"`
+}
+
+func (s *SanctionService) Issue(ctx context.Context, userID, adminID uuid.UUID, adminRole string, req *IssueSanctionRequest) (*model.UserSanction, error) {
+	if !model.IsValidSanctionType(req.Type) {
+		return nil, errors.New("invalid sanction type")
+	}
+
+	expiresAt := sanctionExpiry(req.DurationHours)
+
+	var sanction *model.UserSanction
+	err := database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		sanction, err = issueSanction(ctx,
+			&repository.UserRepository{DB: tx},
+			&repository.SanctionRepository{DB: tx},
+			userID, adminID, adminRole, req.Type, req.Reason, expiresAt,
+		)
+		if err != nil {
+			return err
+		}
+
+		if req.Type == model.SanctionBan {
+			return revokeTokens(ctx, &repository.UserRepository{DB: tx}, &repository.SessionRepository{DB: tx}, userID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tokenVersions.invalidate(userID)
+	return sanction, nil
+}
+
+// Revoke 撤销一条处罚；与施加处罚相同，只能处置权限严格少于自己的角色
+func (s *SanctionService) Revoke(ctx context.Context, sanctionID, adminID uuid.UUID, adminRole string) error {
+	return database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		sanctionRepo := &repository.SanctionRepository{DB: tx}
+		userRepo := &repository.UserRepository{DB: tx}
+
+		sanction, err := sanctionRepo.FindByID(ctx, sanctionID)
+		if err != nil {
+			return errors.New("sanction not found")
+		}
+
+		if !sanction.IsActive(time.Now()) {
+			return errors.New("sanction is not active")
+		}
+
+		user, err := userRepo.FindByID(ctx, sanction.UserID)
+		if err != nil {
+			return errors.New("user not found")
+		}
+		if !CanManageRole(ctx, adminRole, user.Role) {
+			return errors.New("insufficient privileges to revoke this sanction")
+		}
+
+		now := time.Now()
+		sanction.RevokedAt = &now
+		sanction.RevokedBy = &adminID
+		if err := sanctionRepo.Update(ctx, sanction); err != nil {
+			return err
+		}
+
+		if sanction.Type != model.SanctionBan {
+			return nil
+		}
+
+		if err := applyActiveBans(ctx, sanctionRepo, user); err != nil {
+			return err
+		}
+		return userRepo.Update(ctx, user)
+	})
+}
+
+func (s *SanctionService) Unban(ctx context.Context, userID, adminID uuid.UUID, adminRole string) error {
+	return database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		userRepo := &repository.UserRepository{DB: tx}
+
+		user, err := userRepo.FindByID(ctx, userID)
+		if err != nil {
+			return errors.New("user not found")
+		}
+
+		if !CanManageRole(ctx, adminRole, user.Role) {
+			return errors.New("insufficient privileges to unban this user")
+		}
+
+		if err := (&repository.SanctionRepository{DB: tx}).RevokeActive(ctx, userID, model.SanctionBan, &adminID); err != nil {
+			return err
+		}
+
+		clearBan(user)
+		return userRepo.Update(ctx, user)
+	})
+}
+
+func (s *SanctionService) ListByUser(ctx context.Context, userID uuid.UUID) ([]model.UserSanction, error) {
+	return s.sanctionRepo.ListByUserID(ctx, userID)
+}
+
+func (s *SanctionService) ListActive(ctx context.Context, userID uuid.UUID) ([]model.UserSanction, error) {
+	return s.sanctionRepo.ListActive(ctx, userID)
+}
+
+// CheckBan 校验账号封禁状态，已过期的临时封禁会被自动解除
+func (s *SanctionService) CheckBan(ctx context.Context, user *model.User) error {
+	now := time.Now()
+	if user.IsBanActive(now) {
+		if user.BanExpiresAt != nil {
+			return fmt.Errorf("your account has been banned until %s", user.BanExpiresAt.Format(time.RFC3339))
+		}
+		return errors.New("your account has been banned")
+	}
+
+	if user.IsBanned {
+		clearBan(user)
+		return s.userRepo.Update(ctx, user)
+	}
+
+	return nil
+}
+
+func (s *SanctionService) CheckRestriction(ctx context.Context, userID uuid.UUID, sanctionType string) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	if err := s.CheckBan(ctx, user); err != nil {
+		return err
+	}
+
+	sanction, err := s.sanctionRepo.FindActive(ctx, userID, sanctionType)
+	if err != nil {
+		return nil
+	}
+
+	action := "this action"
+	switch sanctionType {
+	case model.SanctionNoUpload:
+		action = "uploading"
+	case model.SanctionNoComment:
+		action = "commenting"
+	}
+
+	if sanction.ExpiresAt != nil {
+		return fmt.Errorf("%s is restricted for your account until %s", action, sanction.ExpiresAt.Format(time.RFC3339))
+	}
+	return fmt.Errorf("%s is restricted for your account", action)
+}
+
+func issueSanction(ctx context.Context, userRepo *repository.UserRepository, sanctionRepo *repository.SanctionRepository, userID, adminID uuid.UUID, adminRole, sanctionType, reason string, expiresAt *time.Time) (*model.UserSanction, error) {
+	if userID == adminID {
+		return nil, errors.New("cannot sanction yourself")
+	}
+
+	user, err := userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	if model.IsSuperAdmin(user.Role) {
+		return nil, errors.New("cannot sanction super admin")
+	}
+
+	if !CanManageRole(ctx, adminRole, user.Role) {
+		return nil, errors.New("insufficient privileges to sanction this user")
+	}
+
+	sanction := &model.UserSanction{
+		UserID:    userID,
+		Type:      sanctionType,
+		Reason:    reason,
+		IssuedBy:  &adminID,
+		ExpiresAt: expiresAt,
+	}
+	if err := sanctionRepo.Create(ctx, sanction); err != nil {
+		return nil, err
+	}
+
+	if sanctionType == model.SanctionBan {
+		if !user.IsBanActive(time.Now()) {
+			now := time.Now()
+			user.BannedAt = &now
+		}
+		if err := applyActiveBans(ctx, sanctionRepo, user); err != nil {
+			return nil, err
+		}
+		if err := userRepo.Update(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+
+	return sanction, nil
+}
+
+func sanctionExpiry(durationHours *int) *time.Time {
+	if durationHours == nil {
+		return nil
+	}
+	expiresAt := time.Now().Add(time.Duration(*durationHours) * time.Hour)
+	return &expiresAt
+}
+
+// applyActiveBans 按仍有效的封禁记录设置用户的封禁期限与原因，避免较短的封禁覆盖永久或更长的封禁；
+// 没有有效封禁时解除封禁
+func applyActiveBans(ctx context.Context, sanctionRepo *repository.SanctionRepository, user *model.User) error {
+	sanctions, err := sanctionRepo.ListActive(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	ban := effectiveBan(sanctions, time.Now())
+	if ban == nil {
+		clearBan(user)
+		return nil
+	}
+	user.IsBanned = true
+	user.BanExpiresAt = ban.ExpiresAt
+	user.BannedReason = &ban.Reason
+	return nil
+}
+
+// effectiveBan 返回决定封禁期限的记录：永久封禁优先，否则为到期最晚的封禁；没有有效封禁时返回 nil
+func effectiveBan(sanctions []model.UserSanction, now time.Time) *model.UserSanction {
+	var result *model.UserSanction
+	for i := range sanctions {
+		s := &sanctions[i]
+		if s.Type != model.SanctionBan || !s.IsActive(now) {
+			continue
+		}
+		if s.ExpiresAt == nil {
+			return s
+		}
+		if result == nil || s.ExpiresAt.After(*result.ExpiresAt) {
+			result = s
+		}
+	}
+	return result
+}
+
+func clearBan(user *model.User) {
+	user.IsBanned = false
+	user.BannedAt = nil
+	user.BannedReason = nil
+	user.BanExpiresAt = nil
+}
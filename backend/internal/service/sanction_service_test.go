@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ysmmc/backend/internal/database"
+	"github.com/ysmmc/backend/internal/model"
+)
+
+func TestEffectiveBan(t *testing.T) {
+	now := time.Now()
+	hours := func(h int) *time.Time {
+		at := now.Add(time.Duration(h) * time.Hour)
+		return &at
+	}
+
+	short := model.UserSanction{ID: uuid.New(), Type: model.SanctionBan, ExpiresAt: hours(24)}
+	long := model.UserSanction{ID: uuid.New(), Type: model.SanctionBan, ExpiresAt: hours(72)}
+	permanent := model.UserSanction{ID: uuid.New(), Type: model.SanctionBan}
+	expired := model.UserSanction{ID: uuid.New(), Type: model.SanctionBan, ExpiresAt: hours(-1)}
+	revoked := model.UserSanction{ID: uuid.New(), Type: model.SanctionBan, RevokedAt: &now}
+	noUpload := model.UserSanction{ID: uuid.New(), Type: model.SanctionNoUpload}
+
+	tests := []struct {
+		name      string
+		sanctions []model.UserSanction
+		want      *uuid.UUID
+	}{
+		{"none", nil, nil},
+		{"only other types", []model.UserSanction{noUpload}, nil},
+		{"expired and revoked", []model.UserSanction{expired, revoked}, nil},
+		{"longest timed ban", []model.UserSanction{short, long, expired}, &long.ID},
+		{"permanent wins over later timed ban", []model.UserSanction{permanent, short}, &permanent.ID},
+		{"permanent wins over longer timed ban", []model.UserSanction{long, permanent}, &permanent.ID},
+	}
+	for _, tt := range tests {
+		got := effectiveBan(tt.sanctions, now)
+		switch {
+		case tt.want == nil && got != nil:
+			t.Errorf("%s: expected no effective ban, got %s", tt.name, got.ID)
+		case tt.want != nil && (got == nil || got.ID != *tt.want):
+			t.Errorf("%s: expected ban %s, got %v", tt.name, *tt.want, got)
+		}
+	}
+}
+
+func TestSanctionService_OverlappingBans(t *testing.T) {
+	setupTestDB(t)
+	ctx := context.Background()
+	admin := createTestUser(t, model.RoleSuperAdmin)
+	user := createTestUser(t, model.RoleUser)
+	s := NewSanctionService()
+
+	permanent, err := s.Issue(ctx, user.ID, admin.ID, admin.Role, &IssueSanctionRequest{Type: model.SanctionBan, Reason: "abuse"})
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+	day := 24
+	short, err := s.Issue(ctx, user.ID, admin.ID, admin.Role, &IssueSanctionRequest{Type: model.SanctionBan, Reason: "spam", DurationHours: &day})
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+
+	current := reloadUser(t, user.ID)
+	if !current.IsBanned || current.BanExpiresAt != nil {
+		t.Fatalf("expected a shorter ban to keep the permanent ban, got banned=%v expires=%v", current.IsBanned, current.BanExpiresAt)
+	}
+
+	if err := s.Revoke(ctx, permanent.ID, admin.ID, admin.Role); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	current = reloadUser(t, user.ID)
+	if !current.IsBanned || current.BanExpiresAt == nil || !current.BanExpiresAt.Equal(*short.ExpiresAt) {
+		t.Fatalf("expected the remaining 24h ban to apply after revoking the permanent one, got banned=%v expires=%v", current.IsBanned, current.BanExpiresAt)
+	}
+	if current.BannedReason == nil || *current.BannedReason != "spam" {
+		t.Errorf("expected the reason of the remaining ban, got %v", current.BannedReason)
+	}
+
+	if err := s.Revoke(ctx, short.ID, admin.ID, admin.Role); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	if current = reloadUser(t, user.ID); current.IsBanned {
+		t.Error("expected the user to be unbanned after revoking every ban")
+	}
+}
+
+func TestSanctionService_TimedBanExpires(t *testing.T) {
+	setupTestDB(t)
+	ctx := context.Background()
+	user := createTestUser(t, model.RoleUser)
+	s := NewSanctionService()
+
+	past := time.Now().Add(-time.Hour)
+	database.DB.Create(&model.UserSanction{UserID: user.ID, Type: model.SanctionBan, Reason: "spam", ExpiresAt: &past})
+	database.DB.Model(user).Updates(map[string]interface{}{"is_banned": true, "ban_expires_at": past})
+
+	current := reloadUser(t, user.ID)
+	if err := s.CheckBan(ctx, current); err != nil {
+		t.Fatalf("expected an expired ban to allow login, got %v", err)
+	}
+	if current = reloadUser(t, user.ID); current.IsBanned {
+		t.Error("expected CheckBan to clear an expired ban")
+	}
+
+	admin := createTestUser(t, model.RoleSuperAdmin)
+	hours := 1
+	if _, err := s.Issue(ctx, user.ID, admin.ID, admin.Role, &IssueSanctionRequest{Type: model.SanctionBan, Reason: "spam", DurationHours: &hours}); err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+	if err := s.CheckBan(ctx, reloadUser(t, user.ID)); err == nil {
+		t.Error("expected an active timed ban to block login")
+	}
+}
+
+func TestSanctionService_CheckRestriction(t *testing.T) {
+	setupTestDB(t)
+	ctx := context.Background()
+	admin := createTestUser(t, model.RoleSuperAdmin)
+	user := createTestUser(t, model.RoleUser)
+	s := NewSanctionService()
+
+	sanction, err := s.Issue(ctx, user.ID, admin.ID, admin.Role, &IssueSanctionRequest{Type: model.SanctionNoUpload, Reason: "stolen models"})
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+	if err := s.CheckRestriction(ctx, user.ID, model.SanctionNoUpload); err == nil {
+		t.Error("expected no_upload to block uploading")
+	}
+	if err := s.CheckRestriction(ctx, user.ID, model.SanctionNoComment); err != nil {
+		t.Errorf("expected no_upload not to restrict commenting, got %v", err)
+	}
+
+	if err := s.Revoke(ctx, sanction.ID, admin.ID, admin.Role); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	if err := s.CheckRestriction(ctx, user.ID, model.SanctionNoUpload); err != nil {
+		t.Errorf("expected a revoked sanction to lift the restriction, got %v", err)
+	}
+}
+
+func reloadUser(t *testing.T, id uuid.UUID) *model.User {
+	t.Helper()
+	var user model.User
+	if err := database.DB.First(&user, "id = ?", id).Error; err != nil {
+		t.Fatalf("failed to reload user: %v", err)
+	}
+	return &user
+}
+
+func TestSanctionService_AdminCannotSanctionAdmin(t *testing.T) {
+	setupTestDB(t)
+	ctx := context.Background()
+	actor := createTestUser(t, model.RoleAdmin)
+	peer := createTestUser(t, model.RoleAdmin)
+	s := NewSanctionService()
+
+	if _, err := s.Issue(ctx, peer.ID, actor.ID, actor.Role, &IssueSanctionRequest{Type: model.SanctionBan, Reason: "coup"}); err == nil {
+		t.Error("expected an admin to be unable to ban another admin")
+	}
+	if current := reloadUser(t, peer.ID); current.IsBanned {
+		t.Error("expected the target admin to stay unbanned")
+	}
+
+	user := createTestUser(t, model.RoleUser)
+	if _, err := s.Issue(ctx, user.ID, actor.ID, actor.Role, &IssueSanctionRequest{Type: model.SanctionNoComment, Reason: "spam"}); err != nil {
+		t.Errorf("expected an admin to sanction a regular user, got %v", err)
+	}
+}
+
+func TestSanctionService_RevokeRequiresHigherRole(t *testing.T) {
+	setupTestDB(t)
+	ctx := context.Background()
+	superAdmin := createTestUser(t, model.RoleSuperAdmin)
+	admin := createTestUser(t, model.RoleAdmin)
+	peer := createTestUser(t, model.RoleAdmin)
+	s := NewSanctionService()
+
+	ban, err := s.Issue(ctx, peer.ID, superAdmin.ID, superAdmin.Role, &IssueSanctionRequest{Type: model.SanctionBan, Reason: "abuse"})
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+	if err := s.Revoke(ctx, ban.ID, admin.ID, admin.Role); err == nil {
+		t.Error("expected an admin to be unable to revoke a ban on another admin")
+	}
+	if err := s.Unban(ctx, peer.ID, admin.ID, admin.Role); err == nil {
+		t.Error("expected an admin to be unable to unban another admin")
+	}
+	if current := reloadUser(t, peer.ID); !current.IsBanned {
+		t.Fatal("expected the ban placed by the super admin to stay in effect")
+	}
+
+	if err := s.Unban(ctx, peer.ID, superAdmin.ID, superAdmin.Role); err != nil {
+		t.Errorf("expected the super admin to unban, got %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ysmmc/backend/internal/config"
+	"github.com/ysmmc/backend/internal/model"
+	"github.com/ysmmc/backend/internal/repository"
+	"github.com/ysmmc/backend/pkg/email"
+	"github.com/ysmmc/backend/pkg/utils"
+)
+
+type SecurityEventService struct {
+	eventRepo           *repository.SecurityEventRepository
+	userRepo            *repository.UserRepository
+	notificationService *NotificationService
+	emailService        *email.EmailService
+	outboxService       *EmailOutboxService
+}
+
+func NewSecurityEventService() *SecurityEventService {
+	return &SecurityEventService{
+		eventRepo:           repository.NewSecurityEventRepository(),
+		userRepo:            repository.NewUserRepository(),
+		notificationService: NewNotificationService(),
+		emailService:        email.NewEmailService(),
+		outboxService:       NewEmailOutboxService(),
+	}
+}
+
+// Record 写入安全事件并输出日志
+func (s *SecurityEventService) Record(ctx context.Context, userID *uuid.UUID, eventType string, client ClientInfo, details string) {
+	slog.InfoContext(ctx, "Security event", "event", eventType, "user_id", userID, "ip", client.IP, "details", details)
+
+	event := &model.SecurityEvent{
+		UserID:    userID,
+		Type:      eventType,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+		Details:   details,
+	}
+	if err := s.eventRepo.Create(ctx, event); err != nil {
+		slog.ErrorContext(ctx, "Failed to record security event", "event", eventType, "error", err)
+	}
+}
+
+// AlertUser 向用户发送站内通知，并在配置了 SMTP 时发送邮件
+func (s *SecurityEventService) AlertUser(ctx context.Context, userID uuid.UUID, title, message string, client ClientInfo) {
+	link := config.AppConfig.FrontendURL + "/profile"
+	if err := s.notificationService.Notify(ctx, userID, model.NotificationSecurity, title, message, &link); err != nil {
+		slog.ErrorContext(ctx, "Failed to create security notification", "user_id", userID, "error", err)
+	}
+
+	if !s.emailService.IsConfigured() {
+		return
+	}
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return
+	}
+
+	data := email.SecurityAlertData{
+		Username:   user.Username,
+		Title:      title,
+		Message:    message,
+		Time:       time.Now().Format("2006-01-02 15:04:05"),
+		IP:         client.IP,
+		Device:     utils.DeviceLabel(client.UserAgent),
+		ActionLink: link,
+	}
+	msg, err := s.emailService.SecurityAlertMessage(user.Language, user.Email, data)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to render security alert email", "user_id", userID, "error", err)
+		return
+	}
+	if err := s.outboxService.Queue(ctx, &user.ID, model.EmailCategorySecurityAlert, msg); err != nil {
+		slog.ErrorContext(ctx, "Failed to queue security alert email", "user_id", userID, "error", err)
+	}
+}
+
+func (s *SecurityEventService) List(ctx context.Context, page, pageSize int, userID *uuid.UUID, eventType string) ([]model.SecurityEvent, int64, error) {
+	return s.eventRepo.List(ctx, page, pageSize, userID, eventType)
+}
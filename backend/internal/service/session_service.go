@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ysmmc/backend/internal/model"
+	"github.com/ysmmc/backend/internal/repository"
+	"github.com/ysmmc/backend/pkg/utils"
+)
+
+// ClientInfo 发起登录或刷新请求的客户端信息，记录在会话上供用户辨认设备
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+func (ci ClientInfo) apply(session *model.Session) {
+	session.IP = ci.IP
+	session.UserAgent = ci.UserAgent
+	session.DeviceLabel = utils.DeviceLabel(ci.UserAgent)
+}
+
+// sessionTouchInterval 限制会话 last_used_at 的写入频率，每个会话在每个进程内最多这么久更新一次
+const sessionTouchInterval = 5 * time.Minute
+
+var activeSessions = newLoaderCache(authCacheTTL, func(ctx context.Context, sessionID uuid.UUID) (bool, error) {
+	return repository.NewSessionRepository().IsActive(ctx, sessionID, time.Now())
+})
+
+// sessionTouches 借用 loaderCache 做节流：缓存期内不再重复写入最近使用时间
+var sessionTouches = newLoaderCache(sessionTouchInterval, func(ctx context.Context, sessionID uuid.UUID) (struct{}, error) {
+	return struct{}{}, repository.NewSessionRepository().Touch(ctx, sessionID, time.Now())
+})
+
+// IsSessionActive 判断 access token 所属的会话是否仍然存在，并按节流间隔记录最近使用时间；
+// 未携带会话 ID 的旧 token 仅依赖 token 版本号校验
+func IsSessionActive(ctx context.Context, sessionID uuid.UUID) bool {
+	if sessionID == uuid.Nil {
+		return true
+	}
+	active, err := activeSessions.get(ctx, sessionID)
+	if err != nil || !active {
+		return false
+	}
+	sessionTouches.get(ctx, sessionID)
+	return true
+}
+
+type SessionInfo struct {
+	model.Session
+	Current bool `json:"current"`
+}
+
+type SessionService struct {
+	sessionRepo *repository.SessionRepository
+}
+
+func NewSessionService() *SessionService {
+	return &SessionService{
+		sessionRepo: repository.NewSessionRepository(),
+	}
+}
+
+// List 列出用户的有效会话，currentID 对应的会话标记为当前设备
+func (s *SessionService) List(ctx context.Context, userID, currentID uuid.UUID) ([]SessionInfo, error) {
+	sessions, err := s.sessionRepo.ListActiveByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]SessionInfo, len(sessions))
+	for i, session := range sessions {
+		result[i] = SessionInfo{Session: session, Current: session.ID == currentID}
+	}
+	return result, nil
+}
+
+func (s *SessionService) Revoke(ctx context.Context, userID, sessionID uuid.UUID) error {
+	deleted, err := s.sessionRepo.DeleteByIDAndUserID(ctx, sessionID, userID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errors.New("session not found")
+	}
+
+	activeSessions.invalidate(sessionID)
+	return nil
+}
+
+// RevokeOthers 注销除当前会话外的所有会话，返回注销数量
+func (s *SessionService) RevokeOthers(ctx context.Context, userID, currentID uuid.UUID) (int, error) {
+	ids, err := s.sessionRepo.DeleteOthers(ctx, userID, currentID)
+	if err != nil {
+		return 0, err
+	}
+
+	activeSessions.invalidate(ids...)
+	return len(ids), nil
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ysmmc/backend/internal/database"
+	"github.com/ysmmc/backend/internal/model"
+)
+
+func TestIsSessionActive_ThrottlesTouch(t *testing.T) {
+	setupTestDB(t)
+	ctx := context.Background()
+	user := createTestUser(t, model.RoleUser)
+	session := &model.Session{UserID: user.ID, TokenHash: uuid.NewString(), ExpiresAt: time.Now().Add(time.Hour)}
+	if err := database.DB.Create(session).Error; err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	if !IsSessionActive(ctx, session.ID) {
+		t.Fatal("expected a fresh session to be active")
+	}
+	var current model.Session
+	database.DB.First(&current, "id = ?", session.ID)
+	if current.LastUsedAt == nil {
+		t.Fatal("expected the first check to record last_used_at")
+	}
+
+	database.DB.Model(session).UpdateColumn("last_used_at", nil)
+	activeSessions.invalidate(session.ID)
+	if !IsSessionActive(ctx, session.ID) {
+		t.Fatal("expected the session to stay active")
+	}
+	database.DB.First(&current, "id = ?", session.ID)
+	if current.LastUsedAt != nil {
+		t.Error("expected last_used_at not to be written again within the touch interval")
+	}
+
+	database.DB.Model(session).UpdateColumn("expires_at", time.Now().Add(-time.Minute))
+	activeSessions.invalidate(session.ID)
+	if IsSessionActive(ctx, session.ID) {
+		t.Error("expected an expired session to be inactive")
+	}
+	if IsSessionActive(ctx, uuid.New()) {
+		t.Error("expected an unknown session to be inactive")
+	}
+}
+
+func TestRefreshToken_RotatesWithinOneSecond(t *testing.T) {
+	setupTestDB(t)
+	setTestSigningKey(t)
+
+	ctx := context.Background()
+	user := createTestUser(t, model.RoleUser)
+	s := NewAuthService()
+	client := ClientInfo{IP: "127.0.0.1", UserAgent: "test"}
+
+	first, err := s.IssueTokens(ctx, user, client)
+	if err != nil {
+		t.Fatalf("IssueTokens() error = %v", err)
+	}
+	if _, err := s.IssueTokens(ctx, user, client); err != nil {
+		t.Fatalf("expected a second login in the same second to get its own session, got %v", err)
+	}
+
+	rotated, err := s.RefreshToken(ctx, first.RefreshToken, client)
+	if err != nil {
+		t.Fatalf("RefreshToken() error = %v", err)
+	}
+	if _, err := s.RefreshToken(ctx, rotated.RefreshToken, client); err != nil {
+		t.Fatalf("expected a second rotation within one second to succeed, got %v", err)
+	}
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ysmmc/backend/internal/database"
+	"github.com/ysmmc/backend/internal/model"
+	"github.com/ysmmc/backend/internal/repository"
+	"gorm.io/gorm"
+)
+
+// SiteConfigVersion 站点配置导出格式的版本，格式不兼容时递增
+const SiteConfigVersion = 1
+
+// SiteConfig 可在实例之间迁移的站点配置：角色、注册模式与公告。不包含用户与模型数据
+type SiteConfig struct {
+	Version       int                        `json:"version"`
+	ExportedAt    time.Time                  `json:"exported_at"`
+	Roles         []model.Role               `json:"roles"`
+	Registration  model.RegistrationSettings `json:"registration"`
+	Announcements []model.Announcement       `json:"announcements"`
+}
+
+// SiteConfigImportResult 导入时新建与更新的条目数
+type SiteConfigImportResult struct {
+	RolesCreated         int `json:"roles_created"`
+	RolesUpdated         int `json:"roles_updated"`
+	AnnouncementsCreated int `json:"announcements_created"`
+	AnnouncementsUpdated int `json:"announcements_updated"`
+}
+
+type SiteConfigService struct {
+	roleService         *RoleService
+	registrationService *RegistrationService
+	announcementService *AnnouncementService
+}
+
+func NewSiteConfigService() *SiteConfigService {
+	return &SiteConfigService{
+		roleService:         NewRoleService(),
+		registrationService: NewRegistrationService(),
+		announcementService: NewAnnouncementService(),
+	}
+}
+
+func (s *SiteConfigService) Export(ctx context.Context) (*SiteConfig, error) {
+	roles, err := s.roleService.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	announcements, err := s.listAnnouncements(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SiteConfig{
+		Version:       SiteConfigVersion,
+		ExportedAt:    time.Now(),
+		Roles:         roles,
+		Registration:  s.registrationService.Settings(ctx),
+		Announcements: announcements,
+	}, nil
+}
+
+// Import 以超级管理员身份导入配置：同名角色与同 ID（或同标题）公告会被更新，其余新建；
+// 不会删除目标实例中已有而配置中没有的条目。全部条目在同一事务中写入，任一条目失败时不做任何修改
+func (s *SiteConfigService) Import(ctx context.Context, actor *model.User, cfg *SiteConfig) (*SiteConfigImportResult, error) {
+	if !model.IsSuperAdmin(actor.Role) {
+		return nil, errors.New("site configuration can only be imported by a super admin")
+	}
+	if cfg.Version != SiteConfigVersion {
+		return nil, fmt.Errorf("unsupported site configuration version %d", cfg.Version)
+	}
+
+	result := &SiteConfigImportResult{}
+	err := database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return s.withTx(tx).importAll(ctx, actor, cfg, result)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 提交后再使缓存失效，避免其他请求在提交前重新载入旧数据并缓存
+	rolePermissions.invalidate()
+	registrationSettings.invalidate()
+	return result, nil
+}
+
+// withTx 返回在事务 tx 中读写的副本
+func (s *SiteConfigService) withTx(tx *gorm.DB) *SiteConfigService {
+	return &SiteConfigService{
+		roleService: &RoleService{
+			roleRepo: &repository.RoleRepository{DB: tx},
+			userRepo: &repository.UserRepository{DB: tx},
+		},
+		registrationService: &RegistrationService{
+			settingRepo:     &repository.SettingRepository{DB: tx},
+			inviteRepo:      &repository.InviteCodeRepository{DB: tx},
+			sanctionService: s.registrationService.sanctionService,
+		},
+		announcementService: &AnnouncementService{
+			announcementRepo: &repository.AnnouncementRepository{DB: tx},
+		},
+	}
+}
+
+func (s *SiteConfigService) importAll(ctx context.Context, actor *model.User, cfg *SiteConfig, result *SiteConfigImportResult) error {
+	for _, role := range cfg.Roles {
+		created, err := s.importRole(ctx, actor.Role, role)
+		if err != nil {
+			return fmt.Errorf("role %s: %w", role.Name, err)
+		}
+		if created {
+			result.RolesCreated++
+		} else {
+			result.RolesUpdated++
+		}
+	}
+
+	if cfg.Registration.Mode != "" {
+		if _, err := s.registrationService.UpdateSettings(ctx, actor.ID, &UpdateRegistrationRequest{
+			Mode:           cfg.Registration.Mode,
+			AllowedDomains: cfg.Registration.AllowedDomains,
+		}); err != nil {
+			return fmt.Errorf("registration: %w", err)
+		}
+	}
+
+	existing, err := s.listAnnouncements(ctx)
+	if err != nil {
+		return err
+	}
+	for _, a := range cfg.Announcements {
+		created, err := s.importAnnouncement(ctx, existing, a)
+		if err != nil {
+			return fmt.Errorf("announcement %q: %w", a.Title, err)
+		}
+		if created {
+			result.AnnouncementsCreated++
+		} else {
+			result.AnnouncementsUpdated++
+		}
+	}
+	return nil
+}
+
+func (s *SiteConfigService) importRole(ctx context.Context, actorRole string, role model.Role) (bool, error) {
+	if _, err := s.roleService.GetByName(ctx, role.Name); err != nil {
+		_, err := s.roleService.Create(ctx, actorRole, &CreateRoleRequest{
+			Name:        role.Name,
+			Description: role.Description,
+			Permissions: role.Permissions,
+			RequireMFA:  role.RequireMFA,
+		})
+		return true, err
+	}
+
+	req := &UpdateRoleRequest{Description: &role.Description, RequireMFA: &role.RequireMFA}
+	// 超级管理员的权限固定为全部权限，不能修改
+	if !model.IsSuperAdmin(role.Name) {
+		req.Permissions = role.Permissions
+		if req.Permissions == nil {
+			req.Permissions = []string{}
+		}
+	}
+	_, err := s.roleService.Update(ctx, actorRole, role.Name, req)
+	return false, err
+}
+
+func (s *SiteConfigService) importAnnouncement(ctx context.Context, existing []model.Announcement, a model.Announcement) (bool, error) {
+	if a.Title == "" || a.Content == "" {
+		return false, errors.New("title and content are required")
+	}
+
+	if id := matchAnnouncement(existing, a); id != uuid.Nil {
+		_, err := s.announcementService.Update(ctx, id, &UpdateAnnouncementRequest{
+			Title:    &a.Title,
+			Content:  &a.Content,
+			IsActive: &a.IsActive,
+		})
+		return false, err
+	}
+
+	created, err := s.announcementService.Create(ctx, &CreateAnnouncementRequest{Title: a.Title, Content: a.Content})
+	if err != nil {
+		return true, err
+	}
+	if !a.IsActive {
+		_, err = s.announcementService.Update(ctx, created.ID, &UpdateAnnouncementRequest{IsActive: &a.IsActive})
+	}
+	return true, err
+}
+
+// matchAnnouncement 按 ID 查找已有公告，找不到时按标题匹配，使同一份配置重复导入不会产生重复公告
+func matchAnnouncement(existing []model.Announcement, a model.Announcement) uuid.UUID {
+	for _, e := range existing {
+		if a.ID != uuid.Nil && e.ID == a.ID {
+			return e.ID
+		}
+	}
+	for _, e := range existing {
+		if e.Title == a.Title {
+			return e.ID
+		}
+	}
+	return uuid.Nil
+}
+
+func (s *SiteConfigService) listAnnouncements(ctx context.Context) ([]model.Announcement, error) {
+	all := []model.Announcement{}
+	for page := 1; ; page++ {
+		batch, _, err := s.announcementService.List(ctx, page, maintenancePageSize)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, batch...)
+		if len(batch) < maintenancePageSize {
+			return all, nil
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/ysmmc/backend/internal/model"
+)
+
+func TestMatchAnnouncement(t *testing.T) {
+	welcome := model.Announcement{ID: uuid.New(), Title: "Welcome"}
+	rules := model.Announcement{ID: uuid.New(), Title: "Rules"}
+	existing := []model.Announcement{welcome, rules}
+
+	tests := []struct {
+		name string
+		in   model.Announcement
+		want uuid.UUID
+	}{
+		{"same id", model.Announcement{ID: rules.ID, Title: "Renamed"}, rules.ID},
+		{"same title from another instance", model.Announcement{ID: uuid.New(), Title: "Welcome"}, welcome.ID},
+		{"new", model.Announcement{ID: uuid.New(), Title: "Maintenance"}, uuid.Nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchAnnouncement(existing, tt.in); got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSiteConfigService_ImportIsAtomic(t *testing.T) {
+	setupTestDB(t)
+	ctx := context.Background()
+	actor := createTestUser(t, model.RoleSuperAdmin)
+	s := NewSiteConfigService()
+
+	cfg := &SiteConfig{
+		Version:       SiteConfigVersion,
+		Roles:         []model.Role{{Name: "curator", Description: "Curates models", Permissions: []string{model.PermModelsApprove}}},
+		Registration:  model.RegistrationSettings{Mode: model.RegistrationClosed},
+		Announcements: []model.Announcement{{Title: "Welcome", Content: ""}},
+	}
+	if _, err := s.Import(ctx, actor, cfg); err == nil {
+		t.Fatal("expected an invalid announcement to fail the import")
+	}
+
+	if _, err := NewRoleService().GetByName(ctx, "curator"); err == nil {
+		t.Error("expected the role created before the failure to be rolled back")
+	}
+	registrationSettings.invalidate()
+	if mode := NewRegistrationService().Settings(ctx).Mode; mode == model.RegistrationClosed {
+		t.Error("expected the registration mode change to be rolled back")
+	}
+
+	cfg.Announcements[0].Content = "Hello"
+	result, err := s.Import(ctx, actor, cfg)
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if result.RolesCreated != 1 || result.AnnouncementsCreated != 1 {
+		t.Errorf("expected one role and one announcement to be created, got %+v", result)
+	}
+	if mode := NewRegistrationService().Settings(ctx).Mode; mode != model.RegistrationClosed {
+		t.Errorf("expected the registration cache to be refreshed after commit, got %s", mode)
+	}
+}
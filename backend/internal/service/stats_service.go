@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/ysmmc/backend/internal/model"
+	"github.com/ysmmc/backend/internal/repository"
+)
+
+// MaxStatsHistoryDays 统计历史一次最多返回的天数
+const MaxStatsHistoryDays = 366
+
+type StatsService struct {
+	statRepo *repository.DailyStatRepository
+}
+
+func NewStatsService() *StatsService {
+	return &StatsService{
+		statRepo: repository.NewDailyStatRepository(),
+	}
+}
+
+// Rollup 计算 day 所在自然日（服务器时区）的快照并写入，重复执行会覆盖当天数据
+func (s *StatsService) Rollup(ctx context.Context, day time.Time) (*model.DailyStat, error) {
+	start := startOfDay(day)
+	stat, err := s.statRepo.Collect(ctx, start, start.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.statRepo.Upsert(ctx, stat); err != nil {
+		return nil, err
+	}
+	return stat, nil
+}
+
+// History 返回最近 days 天（含今天）已汇总的快照
+func (s *StatsService) History(ctx context.Context, days int) ([]model.DailyStat, error) {
+	if days < 1 || days > MaxStatsHistoryDays {
+		days = 30
+	}
+	return s.statRepo.ListSince(ctx, startOfDay(time.Now()).AddDate(0, 0, 1-days))
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
@@ -41,7 +42,7 @@ func (s *StorageService) Initialize() error {
 	}
 
 	absPath, _ := filepath.Abs(s.uploadPath)
-	fmt.Printf("Storage initialized at: %s\n", absPath)
+	slog.Info("Storage initialized", "path", absPath)
 
 	return nil
 }
@@ -50,6 +51,27 @@ func (s *StorageService) CheckDiskSpace() error {
 	return s.checkDiskSpace()
 }
 
+// checkDiskSpace 检查磁盘使用率是否超过 MAX_DISK_USAGE，并更新磁盘用量指标；
+// 无法获取磁盘信息时不阻止上传
+func (s *StorageService) checkDiskSpace() error {
+	totalBytes, freeBytes, ok := s.diskUsage()
+	if !ok {
+		return nil
+	}
+
+	usedBytes := totalBytes - freeBytes
+	storageDiskTotalBytes.Set(float64(totalBytes))
+	storageDiskFreeBytes.Set(float64(freeBytes))
+	storageDiskUsageRatio.Set(float64(usedBytes) / float64(totalBytes))
+
+	usagePercent := int((usedBytes * 100) / totalBytes)
+	if usagePercent >= s.maxDiskUsage {
+		return errors.New("disk usage exceeds maximum allowed percentage")
+	}
+
+	return nil
+}
+
 func (s *StorageService) SaveFile(category, filename string, content io.Reader) (string, error) {
 	subDir := ""
 	if s.enableDatePartition {
@@ -119,6 +141,11 @@ func (s *StorageService) FileExists(category, filename string) bool {
 	return !os.IsNotExist(err)
 }
 
+// ResolvePath 将数据库中保存的文件路径（/uploads/ 开头的 URL 路径或相对上传目录的路径）转换为磁盘路径
+func (s *StorageService) ResolvePath(stored string) string {
+	return filepath.Join(s.uploadPath, strings.TrimPrefix(stored, "/uploads/"))
+}
+
 func (s *StorageService) GetUploadPath() string {
 	return s.uploadPath
 }
@@ -13,7 +13,6 @@ import (
 
 func init() {
 	os.Setenv("DB_PASSWORD", "test_password")
-	os.Setenv("JWT_SECRET", "test_jwt_secret_key_for_testing_purposes_32")
 	os.Setenv("UPLOAD_PATH", "./test_uploads")
 	os.Setenv("MAX_DISK_USAGE", "90")
 	os.Setenv("ENABLE_DATE_PARTITION", "false")
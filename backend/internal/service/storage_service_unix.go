@@ -4,13 +4,13 @@
 package service
 
 import (
-	"errors"
 	"os"
 
 	"golang.org/x/sys/unix"
 )
 
-func (s *StorageService) checkDiskSpace() error {
+// diskUsage 返回上传目录所在文件系统的总容量与可用空间，无法获取时 ok 为 false
+func (s *StorageService) diskUsage() (totalBytes, freeBytes uint64, ok bool) {
 	uploadPath := s.uploadPath
 
 	if _, err := os.Stat(uploadPath); os.IsNotExist(err) {
@@ -19,17 +19,10 @@ func (s *StorageService) checkDiskSpace() error {
 
 	var stat unix.Statfs_t
 	if err := unix.Statfs(uploadPath, &stat); err != nil {
-		return nil
+		return 0, 0, false
 	}
 
-	totalBytes := stat.Blocks * uint64(stat.Bsize)
-	freeBytes := stat.Bavail * uint64(stat.Bsize)
-	usedBytes := totalBytes - freeBytes
-	usagePercent := int((usedBytes * 100) / totalBytes)
-
-	if usagePercent >= s.maxDiskUsage {
-		return errors.New("disk usage exceeds maximum allowed percentage")
-	}
-
-	return nil
+	totalBytes = stat.Blocks * uint64(stat.Bsize)
+	freeBytes = stat.Bavail * uint64(stat.Bsize)
+	return totalBytes, freeBytes, totalBytes > 0
 }
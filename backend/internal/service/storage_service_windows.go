@@ -4,13 +4,13 @@
 package service
 
 import (
-	"errors"
 	"os"
 	"syscall"
 	"unsafe"
 )
 
-func (s *StorageService) checkDiskSpace() error {
+// diskUsage 返回上传目录所在磁盘的总容量与可用空间，无法获取时 ok 为 false
+func (s *StorageService) diskUsage() (totalBytes, freeBytes uint64, ok bool) {
 	uploadPath := s.uploadPath
 
 	if _, err := os.Stat(uploadPath); os.IsNotExist(err) {
@@ -19,12 +19,11 @@ func (s *StorageService) checkDiskSpace() error {
 
 	pathPtr, err := syscall.UTF16PtrFromString(uploadPath)
 	if err != nil {
-		return nil
+		return 0, 0, false
 	}
 
 	var freeBytesAvailable uint64
-	var totalBytes uint64
-	var freeBytes uint64
+	var totalNumberOfFreeBytes uint64
 
 	kernel32 := syscall.NewLazyDLL("kernel32.dll")
 	getDiskFreeSpaceEx := kernel32.NewProc("GetDiskFreeSpaceExW")
@@ -33,19 +32,12 @@ func (s *StorageService) checkDiskSpace() error {
 		uintptr(unsafe.Pointer(pathPtr)),
 		uintptr(unsafe.Pointer(&freeBytesAvailable)),
 		uintptr(unsafe.Pointer(&totalBytes)),
-		uintptr(unsafe.Pointer(&freeBytes)),
+		uintptr(unsafe.Pointer(&totalNumberOfFreeBytes)),
 	)
 
 	if ret == 0 {
-		return nil
+		return 0, 0, false
 	}
 
-	usedBytes := totalBytes - freeBytesAvailable
-	usagePercent := int((usedBytes * 100) / totalBytes)
-
-	if usagePercent >= s.maxDiskUsage {
-		return errors.New("disk usage exceeds maximum allowed percentage")
-	}
-
-	return nil
+	return totalBytes, freeBytesAvailable, totalBytes > 0
 }
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/ysmmc/backend/internal/config"
+	"github.com/ysmmc/backend/internal/database"
+	"github.com/ysmmc/backend/internal/model"
+	"github.com/ysmmc/backend/pkg/auth"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var (
+	testDBOnce sync.Once
+	testDB     *gorm.DB
+	testDBErr  error
+)
+
+// setupTestDB 连接 TEST_DATABASE_URL 指定的 Postgres，执行迁移并写入内置角色，未设置时跳过测试。
+// 每个测试在一个事务中运行，结束时回滚；服务内部的事务以保存点嵌套其中
+func setupTestDB(t *testing.T) {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	testDBOnce.Do(func() {
+		if config.AppConfig == nil {
+			config.LoadConfig()
+		}
+		testDB, testDBErr = gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+		if testDBErr != nil {
+			return
+		}
+		database.DB = testDB
+		if testDBErr = database.Migrate(); testDBErr != nil {
+			return
+		}
+		testDBErr = database.Seed()
+	})
+	if testDBErr != nil {
+		t.Fatalf("failed to prepare test database: %v", testDBErr)
+	}
+
+	tx := testDB.Begin()
+	if tx.Error != nil {
+		t.Fatalf("failed to begin test transaction: %v", tx.Error)
+	}
+	database.DB = tx
+	t.Cleanup(func() {
+		tx.Rollback()
+		database.DB = testDB
+	})
+}
+
+// createTestUser 创建指定角色的用户
+func createTestUser(t *testing.T, role string) *model.User {
+	t.Helper()
+	name := "u" + uuid.NewString()[:8]
+	user := &model.User{
+		Email:        name + "@example.com",
+		Username:     name,
+		PasswordHash: "x",
+		Role:         role,
+	}
+	if err := database.DB.Create(user).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	return user
+}
+
+// createTestModel 创建指定状态的模型
+func createTestModel(t *testing.T, userID uuid.UUID, status string) *model.Model {
+	t.Helper()
+	m := &model.Model{
+		UserID:   userID,
+		Title:    "model " + uuid.NewString()[:8],
+		FilePath: "",
+		Status:   status,
+	}
+	if err := database.DB.Create(m).Error; err != nil {
+		t.Fatalf("failed to create model: %v", err)
+	}
+	return m
+}
+
+// createTestFile 创建一条图片文件记录
+func createTestFile(t *testing.T, userID uuid.UUID) *model.File {
+	t.Helper()
+	f := &model.File{Name: "cover.png", MimeType: "image/png", Size: 1, Data: []byte{0}, Category: "model_image", UserID: &userID}
+	if err := database.DB.Create(f).Error; err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+	return f
+}
+
+// setTestSigningKey 加载一把临时签名密钥，供需要签发 token 的测试使用
+func setTestSigningKey(t *testing.T) {
+	t.Helper()
+	key, err := auth.GenerateSigningKey(auth.AlgEdDSA)
+	if err != nil {
+		t.Fatalf("failed to generate signing key: %v", err)
+	}
+	ks, err := auth.NewKeySet(key)
+	if err != nil {
+		t.Fatalf("failed to load signing key: %v", err)
+	}
+	auth.SetKeySet(ks)
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ysmmc/backend/internal/repository"
+)
+
+const (
+	authCacheTTL  = 30 * time.Second
+	authCacheSize = 10000
+)
+
+type loaderCacheEntry[V any] struct {
+	value    V
+	loadedAt time.Time
+}
+
+// loaderCache 按 ID 缓存鉴权中间件需要的数据库状态（token 版本号、会话是否有效），
+// 过期后通过 load 重新读取；状态变更时调用 invalidate 立即生效
+type loaderCache[V any] struct {
+	ttl     time.Duration
+	size    int
+	load    func(context.Context, uuid.UUID) (V, error)
+	mu      sync.Mutex
+	entries map[uuid.UUID]loaderCacheEntry[V]
+}
+
+func newLoaderCache[V any](ttl time.Duration, load func(context.Context, uuid.UUID) (V, error)) *loaderCache[V] {
+	return &loaderCache[V]{
+		ttl:     ttl,
+		size:    authCacheSize,
+		load:    load,
+		entries: make(map[uuid.UUID]loaderCacheEntry[V]),
+	}
+}
+
+func (c *loaderCache[V]) get(ctx context.Context, id uuid.UUID) (V, error) {
+	now := time.Now()
+
+	c.mu.Lock()
+	entry, ok := c.entries[id]
+	c.mu.Unlock()
+
+	if ok && now.Sub(entry.loadedAt) < c.ttl {
+		return entry.value, nil
+	}
+
+	value, err := c.load(ctx, id)
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+
+	c.mu.Lock()
+	if _, cached := c.entries[id]; !cached && len(c.entries) >= c.size {
+		for key, e := range c.entries {
+			if now.Sub(e.loadedAt) >= c.ttl {
+				delete(c.entries, key)
+			}
+		}
+		// 仍然全部有效时随机淘汰一项，保证缓存大小不超过上限
+		for key := range c.entries {
+			if len(c.entries) < c.size {
+				break
+			}
+			delete(c.entries, key)
+		}
+	}
+	c.entries[id] = loaderCacheEntry[V]{value: value, loadedAt: now}
+	c.mu.Unlock()
+
+	return value, nil
+}
+
+func (c *loaderCache[V]) invalidate(ids ...uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, id := range ids {
+		delete(c.entries, id)
+	}
+}
+
+var tokenVersions = newLoaderCache(authCacheTTL, func(ctx context.Context, userID uuid.UUID) (int, error) {
+	return repository.NewUserRepository().GetTokenVersion(ctx, userID)
+})
+
+// IsTokenVersionCurrent 判断 access token 中的版本号是否仍然有效
+func IsTokenVersionCurrent(ctx context.Context, userID uuid.UUID, version int) bool {
+	current, err := tokenVersions.get(ctx, userID)
+	if err != nil {
+		return false
+	}
+	return current == version
+}
+
+// RevokeUserTokens 使用户现有的 access token 与 refresh token 全部失效
+func RevokeUserTokens(ctx context.Context, userID uuid.UUID) error {
+	err := revokeTokens(ctx, repository.NewUserRepository(), repository.NewSessionRepository(), userID)
+	tokenVersions.invalidate(userID)
+	return err
+}
+
+func revokeTokens(ctx context.Context, userRepo *repository.UserRepository, sessionRepo *repository.SessionRepository, userID uuid.UUID) error {
+	if err := userRepo.IncrementTokenVersion(ctx, userID); err != nil {
+		return err
+	}
+	return sessionRepo.DeleteByUserID(ctx, userID)
+}
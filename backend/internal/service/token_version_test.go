@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestLoaderCache_CachesWithinTTL(t *testing.T) {
+	loads := 0
+	cache := newLoaderCache(time.Minute, func(context.Context, uuid.UUID) (int, error) {
+		loads++
+		return 3, nil
+	})
+
+	userID := uuid.New()
+	for i := 0; i < 3; i++ {
+		version, err := cache.get(context.Background(), userID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if version != 3 {
+			t.Errorf("expected version 3, got %d", version)
+		}
+	}
+
+	if loads != 1 {
+		t.Errorf("expected 1 load, got %d", loads)
+	}
+}
+
+func TestLoaderCache_Invalidate(t *testing.T) {
+	current := 1
+	cache := newLoaderCache(time.Minute, func(context.Context, uuid.UUID) (int, error) {
+		return current, nil
+	})
+
+	userID := uuid.New()
+	cache.get(context.Background(), userID)
+
+	current = 2
+	if version, _ := cache.get(context.Background(), userID); version != 1 {
+		t.Errorf("expected cached version 1, got %d", version)
+	}
+
+	cache.invalidate(userID)
+	if version, _ := cache.get(context.Background(), userID); version != 2 {
+		t.Errorf("expected reloaded version 2, got %d", version)
+	}
+}
+
+func TestLoaderCache_ExpiredEntryReloads(t *testing.T) {
+	loads := 0
+	cache := newLoaderCache(0, func(context.Context, uuid.UUID) (int, error) {
+		loads++
+		return loads, nil
+	})
+
+	userID := uuid.New()
+	cache.get(context.Background(), userID)
+	version, _ := cache.get(context.Background(), userID)
+
+	if loads != 2 || version != 2 {
+		t.Errorf("expected entry to reload after ttl, loads=%d version=%d", loads, version)
+	}
+}
+
+func TestLoaderCache_LoadError(t *testing.T) {
+	cache := newLoaderCache(time.Minute, func(context.Context, uuid.UUID) (int, error) {
+		return 0, errors.New("user not found")
+	})
+
+	if _, err := cache.get(context.Background(), uuid.New()); err == nil {
+		t.Error("expected load error to be returned")
+	}
+}
+
+func TestLoaderCache_BoundedSize(t *testing.T) {
+	cache := newLoaderCache(time.Minute, func(context.Context, uuid.UUID) (int, error) {
+		return 1, nil
+	})
+	cache.size = 3
+
+	for i := 0; i < 10; i++ {
+		cache.get(context.Background(), uuid.New())
+	}
+	if len(cache.entries) != cache.size {
+		t.Errorf("expected the cache to stay at %d entries, got %d", cache.size, len(cache.entries))
+	}
+}
@@ -1,6 +1,9 @@
 package service
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
 	"errors"
 	"os"
 	"path/filepath"
@@ -11,17 +14,21 @@ import (
 	"github.com/ysmmc/backend/internal/model"
 	"github.com/ysmmc/backend/internal/repository"
 	"github.com/ysmmc/backend/pkg/auth"
+	"github.com/ysmmc/backend/pkg/email"
+	"github.com/ysmmc/backend/pkg/utils"
 )
 
 type UserService struct {
 	userRepo  *repository.UserRepository
 	modelRepo *repository.ModelRepository
+	roleRepo  *repository.RoleRepository
 }
 
 func NewUserService() *UserService {
 	return &UserService{
 		userRepo:  repository.NewUserRepository(),
 		modelRepo: repository.NewModelRepository(),
+		roleRepo:  repository.NewRoleRepository(),
 	}
 }
 
@@ -32,17 +39,124 @@ type UpdateProfileRequest struct {
 	AvatarID  *uuid.UUID `json:"avatar_id"`
 }
 
+type UpdateLanguageRequest struct {
+	Language string `json:"language" binding:"required"`
+}
+
 type ChangePasswordRequest struct {
 	OldPassword string `json:"old_password" binding:"required"`
 	NewPassword string `json:"new_password" binding:"required,min=6"`
 }
 
-func (s *UserService) GetByID(id uuid.UUID) (*model.User, error) {
-	return s.userRepo.FindByID(id)
+type CreateAccountRequest struct {
+	Email    string
+	Username string
+	Role     string
+	// Password 为空时生成随机密码，并要求首次登录后修改
+	Password string
+}
+
+func (s *UserService) GetByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	return s.userRepo.FindByID(ctx, id)
 }
 
-func (s *UserService) GetPublicProfile(id uuid.UUID) (*model.User, error) {
-	user, err := s.userRepo.FindByID(id)
+func (s *UserService) GetByEmail(ctx context.Context, email string) (*model.User, error) {
+	return s.userRepo.FindByEmail(ctx, email)
+}
+
+// CreateAccount 由运维直接创建已验证邮箱的账号，不受注册模式限制，返回实际使用的密码
+func (s *UserService) CreateAccount(ctx context.Context, req *CreateAccountRequest) (*model.User, string, error) {
+	email := utils.SanitizeString(req.Email)
+	username := utils.SanitizeString(req.Username)
+
+	if !utils.ValidateEmail(email) {
+		return nil, "", errors.New("invalid email format")
+	}
+	if !utils.ValidateUsername(username) {
+		return nil, "", errors.New("username can only contain letters, numbers, underscores, hyphens, and Chinese characters")
+	}
+	if _, err := s.roleRepo.FindByName(ctx, req.Role); err != nil {
+		return nil, "", errors.New("invalid role")
+	}
+	if s.userRepo.ExistsByEmailIgnoreCase(ctx, email) {
+		return nil, "", errors.New("email already registered")
+	}
+	if s.userRepo.ExistsByUsername(ctx, username) {
+		return nil, "", errors.New("username already taken")
+	}
+
+	password, generated, err := passwordOrRandom(req.Password)
+	if err != nil {
+		return nil, "", err
+	}
+	passwordHash, err := auth.HashPassword(password)
+	if err != nil {
+		return nil, "", err
+	}
+
+	user := &model.User{
+		Email:              email,
+		PasswordHash:       passwordHash,
+		Username:           username,
+		Role:               req.Role,
+		ProfileStatus:      "approved",
+		EmailVerified:      true,
+		MustChangePassword: generated,
+	}
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return nil, "", err
+	}
+	return user, password, nil
+}
+
+// SetPassword 由运维重置密码：解除登录锁定并吊销全部登录会话，password 为空时生成随机密码并要求登录后修改
+func (s *UserService) SetPassword(ctx context.Context, userID uuid.UUID, password string) (string, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+
+	password, generated, err := passwordOrRandom(password)
+	if err != nil {
+		return "", err
+	}
+	passwordHash, err := auth.HashPassword(password)
+	if err != nil {
+		return "", err
+	}
+
+	user.PasswordHash = passwordHash
+	user.MustChangePassword = generated
+	user.ResetToken = nil
+	user.ResetExpires = nil
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return "", err
+	}
+	if err := s.userRepo.ResetFailedLogins(ctx, userID); err != nil {
+		return "", err
+	}
+
+	return password, RevokeUserTokens(ctx, userID)
+}
+
+// passwordOrRandom 校验给定的密码；为空时生成随机密码，generated 表示密码是生成的
+func passwordOrRandom(password string) (string, bool, error) {
+	if password != "" {
+		if !utils.ValidatePassword(password) {
+			return "", false, errors.New("password must be at least 6 characters")
+		}
+		return password, false, nil
+	}
+
+	buf := make([]byte, 18)
+	if _, err := rand.Read(buf); err != nil {
+		return "", false, err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), true, nil
+}
+
+func (s *UserService) GetPublicProfile(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	user, err := s.userRepo.FindByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -50,14 +164,14 @@ func (s *UserService) GetPublicProfile(id uuid.UUID) (*model.User, error) {
 	return user, nil
 }
 
-func (s *UserService) UpdateProfile(userID uuid.UUID, req *UpdateProfileRequest, isAdmin bool) (*model.User, error) {
-	user, err := s.userRepo.FindByID(userID)
+func (s *UserService) UpdateProfile(ctx context.Context, userID uuid.UUID, req *UpdateProfileRequest, isAdmin bool) (*model.User, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
 
 	if req.Username != nil && *req.Username != user.Username {
-		if s.userRepo.ExistsByUsername(*req.Username) {
+		if s.userRepo.ExistsByUsername(ctx, *req.Username) {
 			return nil, errors.New("username already taken")
 		}
 	}
@@ -85,11 +199,26 @@ func (s *UserService) UpdateProfile(userID uuid.UUID, req *UpdateProfileRequest,
 		user.ProfileStatus = "pending_review"
 	}
 
-	return user, s.userRepo.Update(user)
+	return user, s.userRepo.Update(ctx, user)
+}
+
+// UpdateLanguage 修改邮件语言，无需审核
+func (s *UserService) UpdateLanguage(ctx context.Context, userID uuid.UUID, language string) (*model.User, error) {
+	if !email.IsSupportedLocale(language) {
+		return nil, errors.New("unsupported language")
+	}
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Language = language
+	return user, s.userRepo.Update(ctx, user)
 }
 
-func (s *UserService) ChangePassword(userID uuid.UUID, req *ChangePasswordRequest) error {
-	user, err := s.userRepo.FindByID(userID)
+func (s *UserService) ChangePassword(ctx context.Context, userID uuid.UUID, req *ChangePasswordRequest) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
 	if err != nil {
 		return err
 	}
@@ -105,39 +234,54 @@ func (s *UserService) ChangePassword(userID uuid.UUID, req *ChangePasswordReques
 
 	user.PasswordHash = passwordHash
 	user.MustChangePassword = false
-	return s.userRepo.Update(user)
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+
+	return RevokeUserTokens(ctx, userID)
 }
 
-func (s *UserService) List(page, pageSize int) ([]model.User, int64, error) {
-	return s.userRepo.List(page, pageSize)
+func (s *UserService) List(ctx context.Context, page, pageSize int) ([]model.User, int64, error) {
+	return s.userRepo.List(ctx, page, pageSize)
 }
 
-func (s *UserService) UpdateRole(userID uuid.UUID, role string) error {
-	user, err := s.userRepo.FindByID(userID)
+func (s *UserService) UpdateRole(ctx context.Context, userID uuid.UUID, role string) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
 	if err != nil {
 		return err
 	}
 
-	if role != "user" && role != "admin" && role != "super_admin" {
+	if _, err := s.roleRepo.FindByName(ctx, role); err != nil {
 		return errors.New("invalid role")
 	}
 
-	user.Role = role
-	return s.userRepo.Update(user)
+	return s.setRole(ctx, user, role)
 }
 
-func (s *UserService) SetRole(userID uuid.UUID, role string) error {
-	user, err := s.userRepo.FindByID(userID)
+func (s *UserService) SetRole(ctx context.Context, userID uuid.UUID, role string) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
 	if err != nil {
 		return err
 	}
 
+	return s.setRole(ctx, user, role)
+}
+
+func (s *UserService) setRole(ctx context.Context, user *model.User, role string) error {
+	if user.Role == role {
+		return nil
+	}
+
 	user.Role = role
-	return s.userRepo.Update(user)
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+
+	return RevokeUserTokens(ctx, user.ID)
 }
 
-func (s *UserService) ApproveProfile(userID uuid.UUID) error {
-	user, err := s.userRepo.FindByID(userID)
+func (s *UserService) ApproveProfile(ctx context.Context, userID uuid.UUID) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
 	if err != nil {
 		return err
 	}
@@ -159,25 +303,25 @@ func (s *UserService) ApproveProfile(userID uuid.UUID) error {
 	}
 
 	user.ProfileStatus = "approved"
-	return s.userRepo.Update(user)
+	return s.userRepo.Update(ctx, user)
 }
 
-func (s *UserService) RejectProfile(userID uuid.UUID) error {
-	user, err := s.userRepo.FindByID(userID)
+func (s *UserService) RejectProfile(ctx context.Context, userID uuid.UUID) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
 	if err != nil {
 		return err
 	}
 
 	user.PendingChanges = nil
 	user.ProfileStatus = "approved"
-	return s.userRepo.Update(user)
+	return s.userRepo.Update(ctx, user)
 }
 
-func (s *UserService) ListPendingProfiles(page, pageSize int) ([]model.User, int64, error) {
-	return s.userRepo.ListPendingProfiles(page, pageSize)
+func (s *UserService) ListPendingProfiles(ctx context.Context, page, pageSize int) ([]model.User, int64, error) {
+	return s.userRepo.ListPendingProfiles(ctx, page, pageSize)
 }
 
-func (s *UserService) Delete(userID uuid.UUID) error {
+func (s *UserService) Delete(ctx context.Context, userID uuid.UUID) error {
 	// First, fetch all user models to delete their files
 	// We fetch a large number to cover most cases. If a user has > 10000 models, this might miss some files,
 	// but the DB records will still be deleted by cascade.
@@ -185,7 +329,7 @@ func (s *UserService) Delete(userID uuid.UUID) error {
 	page := 1
 	pageSize := 1000
 	for {
-		models, _, err := s.modelRepo.ListByUserID(userID, page, pageSize)
+		models, _, err := s.modelRepo.ListByUserID(ctx, userID, page, pageSize)
 		if err != nil {
 			// If we fail to list models, we should probably still try to delete the user
 			// but maybe log the error? For now, let's return error to be safe.
@@ -211,7 +355,7 @@ func (s *UserService) Delete(userID uuid.UUID) error {
 	}
 
 	// Delete user avatar if it's a local file
-	user, err := s.userRepo.FindByID(userID)
+	user, err := s.userRepo.FindByID(ctx, userID)
 	if err == nil && user.AvatarURL != nil && *user.AvatarURL != "" {
 		cfg := config.AppConfig
 		avatarURL := *user.AvatarURL
@@ -225,21 +369,21 @@ func (s *UserService) Delete(userID uuid.UUID) error {
 	}
 
 	// Delete user record (will cascade delete models, favorites, etc. in DB)
-	return s.userRepo.Delete(userID)
+	return s.userRepo.Delete(ctx, userID)
 }
 
-func (s *UserService) Count() (int64, error) {
-	return s.userRepo.Count()
+func (s *UserService) Count(ctx context.Context) (int64, error) {
+	return s.userRepo.Count(ctx)
 }
 
-func (s *UserService) GetSuperAdmin() (*model.User, error) {
-	users, _, err := s.userRepo.ListByRole("super_admin", 1, 1)
+func (s *UserService) GetSuperAdmin(ctx context.Context) (*model.User, error) {
+	users, _, err := s.userRepo.ListByRole(ctx, model.RoleSuperAdmin, 1, 1)
 	if err != nil || len(users) == 0 {
 		return nil, errors.New("super admin not found")
 	}
 	return &users[0], nil
 }
 
-func (s *UserService) Update(user *model.User) error {
-	return s.userRepo.Update(user)
+func (s *UserService) Update(ctx context.Context, user *model.User) error {
+	return s.userRepo.Update(ctx, user)
 }
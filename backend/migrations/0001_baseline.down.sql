@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS
+    sessions,
+    announcements,
+    favorites,
+    model_images,
+    model_versions,
+    models,
+    users,
+    files
+CASCADE;
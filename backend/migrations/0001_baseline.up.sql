@@ -0,0 +1,151 @@
+-- Baseline schema, equivalent to what GORM AutoMigrate produced for the original release.
+-- Every statement is idempotent so databases created by that release can adopt it unchanged;
+-- columns and tables added later are brought in by 0002_pre_migration_schema.
+
+CREATE TABLE IF NOT EXISTS files (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL,
+    mime_type VARCHAR(100) NOT NULL,
+    size BIGINT NOT NULL,
+    data BYTEA NOT NULL,
+    category VARCHAR(50) NOT NULL,
+    user_id UUID,
+    created_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_files_category ON files (category);
+CREATE INDEX IF NOT EXISTS idx_files_user_id ON files (user_id);
+CREATE INDEX IF NOT EXISTS idx_files_created_at ON files (created_at);
+
+CREATE TABLE IF NOT EXISTS users (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    email VARCHAR(255) NOT NULL,
+    password_hash VARCHAR(255) NOT NULL,
+    username VARCHAR(50) NOT NULL,
+    avatar_id UUID,
+    avatar_url TEXT,
+    bio TEXT,
+    role VARCHAR(20) DEFAULT 'user',
+    profile_status VARCHAR(20) DEFAULT 'approved',
+    pending_changes JSONB,
+    email_verified BOOLEAN DEFAULT FALSE,
+    verification_token VARCHAR(255),
+    reset_token VARCHAR(255),
+    reset_token_expires TIMESTAMPTZ,
+    new_email VARCHAR(255),
+    email_change_token VARCHAR(255),
+    email_change_token_expires TIMESTAMPTZ,
+    must_change_password BOOLEAN DEFAULT FALSE,
+    is_banned BOOLEAN DEFAULT FALSE,
+    banned_at TIMESTAMPTZ,
+    banned_reason TEXT,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username ON users (username);
+
+CREATE TABLE IF NOT EXISTS models (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    description TEXT,
+    file_path VARCHAR(500) NOT NULL,
+    file_size BIGINT DEFAULT 0,
+    image_id UUID,
+    image_url TEXT,
+    tags TEXT[],
+    is_public BOOLEAN DEFAULT TRUE,
+    status VARCHAR(20) DEFAULT 'pending',
+    update_status VARCHAR(20) DEFAULT 'idle',
+    pending_changes JSONB,
+    downloads BIGINT DEFAULT 0,
+    rejection_reason TEXT,
+    current_version_id UUID,
+    version_count BIGINT DEFAULT 1,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_models_user_id ON models (user_id);
+CREATE INDEX IF NOT EXISTS idx_models_status ON models (status);
+CREATE INDEX IF NOT EXISTS idx_models_created_at ON models (created_at);
+
+CREATE TABLE IF NOT EXISTS model_versions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    model_id UUID NOT NULL,
+    version_number VARCHAR(50) NOT NULL,
+    description TEXT,
+    file_path VARCHAR(500) NOT NULL,
+    file_size BIGINT DEFAULT 0,
+    image_id UUID,
+    image_url TEXT,
+    changelog TEXT,
+    is_current BOOLEAN DEFAULT FALSE,
+    downloads BIGINT DEFAULT 0,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_model_versions_model_id ON model_versions (model_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_model_version ON model_versions (model_id, version_number);
+CREATE INDEX IF NOT EXISTS idx_model_versions_is_current ON model_versions (is_current);
+CREATE INDEX IF NOT EXISTS idx_model_versions_created_at ON model_versions (created_at);
+
+CREATE TABLE IF NOT EXISTS model_images (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    model_id UUID NOT NULL,
+    file_id UUID NOT NULL,
+    sort_order BIGINT DEFAULT 0,
+    created_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_model_images_model_id ON model_images (model_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_model_file ON model_images (model_id, file_id);
+CREATE INDEX IF NOT EXISTS idx_model_images_sort_order ON model_images (sort_order);
+
+CREATE TABLE IF NOT EXISTS favorites (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    model_id UUID NOT NULL,
+    created_at TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_model ON favorites (user_id, model_id);
+CREATE INDEX IF NOT EXISTS idx_favorites_model_id ON favorites (model_id);
+
+CREATE TABLE IF NOT EXISTS announcements (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    title VARCHAR(255) NOT NULL,
+    content TEXT NOT NULL,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    token_hash VARCHAR(255) NOT NULL,
+    expires_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions (user_id);
+
+-- Foreign keys use the names AutoMigrate gave them, so existing constraints are detected and kept.
+DO $$
+DECLARE
+    fk RECORD;
+BEGIN
+    FOR fk IN SELECT * FROM (VALUES
+        ('fk_users_avatar', 'users', 'avatar_id', 'files'),
+        ('fk_models_user', 'models', 'user_id', 'users'),
+        ('fk_models_image', 'models', 'image_id', 'files'),
+        ('fk_models_current_version', 'models', 'current_version_id', 'model_versions'),
+        ('fk_models_versions', 'model_versions', 'model_id', 'models'),
+        ('fk_model_versions_image', 'model_versions', 'image_id', 'files'),
+        ('fk_models_images', 'model_images', 'model_id', 'models'),
+        ('fk_model_images_file', 'model_images', 'file_id', 'files'),
+        ('fk_favorites_model', 'favorites', 'model_id', 'models')
+    ) AS t(name, tbl, col, ref)
+    LOOP
+        IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = fk.name) THEN
+            EXECUTE format('ALTER TABLE %I ADD CONSTRAINT %I FOREIGN KEY (%I) REFERENCES %I (id)',
+                fk.tbl, fk.name, fk.col, fk.ref);
+        END IF;
+    END LOOP;
+END $$;
@@ -0,0 +1,35 @@
+DROP TABLE IF EXISTS
+    data_exports,
+    invite_codes,
+    settings,
+    oidc_states,
+    user_identities,
+    notifications,
+    security_events,
+    api_tokens,
+    recovery_codes,
+    roles,
+    user_sanctions,
+    report_entries,
+    reports,
+    admin_actions,
+    consumed_refresh_tokens
+CASCADE;
+
+ALTER TABLE sessions DROP COLUMN IF EXISTS last_used_at;
+ALTER TABLE sessions DROP COLUMN IF EXISTS ip;
+ALTER TABLE sessions DROP COLUMN IF EXISTS user_agent;
+ALTER TABLE sessions DROP COLUMN IF EXISTS device_label;
+ALTER TABLE users DROP COLUMN IF EXISTS deletion_model_action;
+ALTER TABLE users DROP COLUMN IF EXISTS deletion_scheduled_at;
+ALTER TABLE users DROP COLUMN IF EXISTS invite_code_id;
+ALTER TABLE users DROP COLUMN IF EXISTS invited_by;
+ALTER TABLE users DROP COLUMN IF EXISTS locked_until;
+ALTER TABLE users DROP COLUMN IF EXISTS last_failed_login_at;
+ALTER TABLE users DROP COLUMN IF EXISTS failed_login_count;
+ALTER TABLE users DROP COLUMN IF EXISTS totp_last_step;
+ALTER TABLE users DROP COLUMN IF EXISTS totp_enabled;
+ALTER TABLE users DROP COLUMN IF EXISTS totp_secret;
+ALTER TABLE users DROP COLUMN IF EXISTS token_version;
+ALTER TABLE users DROP COLUMN IF EXISTS ban_expires_at;
+ALTER TABLE users DROP COLUMN IF EXISTS verification_token_expires;
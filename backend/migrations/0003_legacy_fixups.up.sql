@@ -0,0 +1,16 @@
+-- Data fixups that used to run in Go on every startup.
+
+-- The seeded administrator predates the super_admin role.
+UPDATE users SET role = 'super_admin' WHERE email = 'admin@ysmmc.local' AND role = 'admin';
+
+-- Models created before the version system get a 1.0.0 version mirroring their current file.
+INSERT INTO model_versions (id, model_id, version_number, description, file_path, file_size, image_id, image_url, is_current, downloads, created_at, updated_at)
+SELECT gen_random_uuid(), m.id, '1.0.0', m.description, m.file_path, m.file_size, m.image_id, m.image_url, TRUE, m.downloads, NOW(), NOW()
+FROM models m
+WHERE m.current_version_id IS NULL
+  AND NOT EXISTS (SELECT 1 FROM model_versions v WHERE v.model_id = m.id);
+
+UPDATE models m
+SET current_version_id = v.id, version_count = 1
+FROM model_versions v
+WHERE m.current_version_id IS NULL AND v.model_id = m.id AND v.is_current;
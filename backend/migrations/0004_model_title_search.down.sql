@@ -0,0 +1,2 @@
+-- The extension is left installed because other schemas in the database may use it.
+DROP INDEX IF EXISTS idx_models_title_trgm;
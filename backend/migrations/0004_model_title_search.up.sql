@@ -0,0 +1,5 @@
+-- Trigram index backing the case-insensitive substring search on model titles (title ILIKE '%term%').
+-- pg_trgm is a trusted extension since PostgreSQL 13, so the database owner can create it.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_models_title_trgm ON models USING gin (title gin_trgm_ops);
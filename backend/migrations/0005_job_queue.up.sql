@@ -0,0 +1,49 @@
+-- Durable background job queue. Workers claim due rows with FOR UPDATE SKIP LOCKED,
+-- failed jobs are retried with backoff and end up as 'dead' after max_attempts.
+CREATE TABLE IF NOT EXISTS jobs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    kind VARCHAR(64) NOT NULL,
+    payload JSONB,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL DEFAULT 5,
+    run_at TIMESTAMPTZ NOT NULL,
+    locked_by VARCHAR(100),
+    locked_at TIMESTAMPTZ,
+    last_error TEXT,
+    schedule_name VARCHAR(64),
+    completed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+-- Partial index so claiming only scans jobs that can actually run.
+CREATE INDEX IF NOT EXISTS idx_jobs_due ON jobs (run_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_jobs_status_updated_at ON jobs (status, updated_at);
+CREATE INDEX IF NOT EXISTS idx_jobs_kind ON jobs (kind);
+
+-- Cron-style recurring jobs. Each row enqueues one job per due tick; the row lock keeps
+-- several server instances from enqueueing the same tick twice.
+CREATE TABLE IF NOT EXISTS job_schedules (
+    name VARCHAR(64) PRIMARY KEY,
+    spec VARCHAR(100) NOT NULL,
+    kind VARCHAR(64) NOT NULL,
+    next_run_at TIMESTAMPTZ NOT NULL,
+    last_run_at TIMESTAMPTZ,
+    last_job_id UUID,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+
+-- Daily snapshot of site totals written by the stats.rollup job.
+CREATE TABLE IF NOT EXISTS daily_stats (
+    date DATE PRIMARY KEY,
+    total_users BIGINT NOT NULL DEFAULT 0,
+    new_users BIGINT NOT NULL DEFAULT 0,
+    total_models BIGINT NOT NULL DEFAULT 0,
+    new_models BIGINT NOT NULL DEFAULT 0,
+    approved_models BIGINT NOT NULL DEFAULT 0,
+    total_downloads BIGINT NOT NULL DEFAULT 0,
+    new_reports BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
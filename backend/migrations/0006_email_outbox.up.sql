@@ -0,0 +1,21 @@
+-- Outbox for rendered emails. Rows are written in the same transaction as the change that
+-- triggers them and delivered by the email.deliver job, so a rolled back change sends nothing
+-- and a crash after commit still delivers the message.
+CREATE TABLE IF NOT EXISTS email_outbox (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID,
+    category VARCHAR(50) NOT NULL,
+    to_address VARCHAR(255) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    body TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'queued',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    last_attempt_at TIMESTAMPTZ,
+    sent_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_email_outbox_user_id ON email_outbox (user_id);
+CREATE INDEX IF NOT EXISTS idx_email_outbox_status ON email_outbox (status);
+CREATE INDEX IF NOT EXISTS idx_email_outbox_created_at ON email_outbox (created_at);
@@ -0,0 +1,5 @@
+-- Preferred language for emails, one of the locales under templates/emails.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS language VARCHAR(10) NOT NULL DEFAULT 'zh-CN';
+
+-- Plain-text alternative sent alongside the HTML body.
+ALTER TABLE email_outbox ADD COLUMN IF NOT EXISTS text_body TEXT NOT NULL DEFAULT '';
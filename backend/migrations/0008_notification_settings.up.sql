@@ -0,0 +1,13 @@
+-- Per-user notification preferences. A missing row means every type uses its default channel.
+-- preferences maps a notification type to in_app, email or off.
+CREATE TABLE IF NOT EXISTS notification_settings (
+    user_id UUID PRIMARY KEY,
+    preferences JSONB NOT NULL DEFAULT '{}',
+    digest VARCHAR(10) NOT NULL DEFAULT 'off',
+    last_digest_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_notification_settings_digest ON notification_settings (digest) WHERE digest <> 'off';
+
+-- Digest queries read a user's recent notifications by type.
+CREATE INDEX IF NOT EXISTS idx_notifications_user_created ON notifications (user_id, created_at);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_reports_open_target;
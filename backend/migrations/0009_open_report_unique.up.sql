@@ -0,0 +1,37 @@
+-- At most one open report per target. Concurrent first reports used to create
+-- separate open reports, so merge existing duplicates into the oldest one first.
+CREATE TEMPORARY TABLE report_merges ON COMMIT DROP AS
+SELECT id, keep_id
+FROM (
+    SELECT id, FIRST_VALUE(id) OVER (PARTITION BY target_type, target_id ORDER BY created_at, id) AS keep_id
+    FROM reports
+    WHERE status = 'open'
+) ranked
+WHERE id <> keep_id;
+
+-- A reporter may appear in several duplicates; keep the entry on the surviving
+-- report, otherwise their earliest one.
+DELETE FROM report_entries e
+USING report_merges m
+WHERE e.report_id = m.id
+  AND EXISTS (
+      SELECT 1 FROM report_entries k
+      WHERE k.reporter_id = e.reporter_id AND k.id <> e.id
+        AND (k.report_id = m.keep_id
+             OR (k.report_id IN (SELECT id FROM report_merges WHERE keep_id = m.keep_id)
+                 AND (k.created_at, k.id) < (e.created_at, e.id)))
+  );
+
+UPDATE report_entries e SET report_id = m.keep_id
+FROM report_merges m
+WHERE e.report_id = m.id;
+
+UPDATE reports r SET
+    report_count = (SELECT COUNT(*) FROM report_entries e WHERE e.report_id = r.id),
+    auto_hidden = r.auto_hidden OR EXISTS (SELECT 1 FROM reports d JOIN report_merges m ON m.id = d.id WHERE m.keep_id = r.id AND d.auto_hidden),
+    previous_status = COALESCE(r.previous_status, (SELECT d.previous_status FROM reports d JOIN report_merges m ON m.id = d.id WHERE m.keep_id = r.id AND d.previous_status IS NOT NULL LIMIT 1))
+WHERE r.id IN (SELECT keep_id FROM report_merges);
+
+DELETE FROM reports WHERE id IN (SELECT id FROM report_merges);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reports_open_target ON reports (target_type, target_id) WHERE status = 'open';
@@ -0,0 +1,7 @@
+-- Proof-of-work challenges that have been used, keyed by the challenge jti.
+-- Shared by every instance so a solved challenge cannot be replayed against another replica or after a restart.
+CREATE TABLE IF NOT EXISTS consumed_challenges (
+    id VARCHAR(64) PRIMARY KEY,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_consumed_challenges_expires_at ON consumed_challenges (expires_at);
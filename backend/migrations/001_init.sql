@@ -1,93 +0,0 @@
--- Create users table
-CREATE TABLE IF NOT EXISTS users (
-    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
-    email VARCHAR(255) UNIQUE NOT NULL,
-    password_hash VARCHAR(255) NOT NULL,
-    username VARCHAR(50) UNIQUE NOT NULL,
-    avatar_url TEXT,
-    bio TEXT,
-    role VARCHAR(20) DEFAULT 'user',
-    profile_status VARCHAR(20) DEFAULT 'approved',
-    pending_changes JSONB,
-    email_verified BOOLEAN DEFAULT FALSE,
-    verification_token VARCHAR(255),
-    reset_token VARCHAR(255),
-    reset_token_expires TIMESTAMP,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-);
-
--- Create models table
-CREATE TABLE IF NOT EXISTS models (
-    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
-    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
-    title VARCHAR(255) NOT NULL,
-    description TEXT,
-    file_path VARCHAR(500) NOT NULL,
-    file_size BIGINT DEFAULT 0,
-    image_url TEXT,
-    tags TEXT[],
-    is_public BOOLEAN DEFAULT TRUE,
-    status VARCHAR(20) DEFAULT 'pending',
-    update_status VARCHAR(20) DEFAULT 'idle',
-    pending_changes JSONB,
-    downloads INTEGER DEFAULT 0,
-    rejection_reason TEXT,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-);
-
--- Create favorites table
-CREATE TABLE IF NOT EXISTS favorites (
-    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
-    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
-    model_id UUID REFERENCES models(id) ON DELETE CASCADE,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    UNIQUE(user_id, model_id)
-);
-
--- Create announcements table
-CREATE TABLE IF NOT EXISTS announcements (
-    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
-    title VARCHAR(255) NOT NULL,
-    content TEXT NOT NULL,
-    is_active BOOLEAN DEFAULT TRUE,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-);
-
--- Create sessions table
-CREATE TABLE IF NOT EXISTS sessions (
-    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
-    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
-    token_hash VARCHAR(255) NOT NULL,
-    expires_at TIMESTAMP NOT NULL,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-);
-
--- Create indexes
-CREATE INDEX IF NOT EXISTS idx_models_user_id ON models(user_id);
-CREATE INDEX IF NOT EXISTS idx_models_status ON models(status);
-CREATE INDEX IF NOT EXISTS idx_models_created_at ON models(created_at DESC);
-CREATE INDEX IF NOT EXISTS idx_favorites_user_id ON favorites(user_id);
-CREATE INDEX IF NOT EXISTS idx_favorites_model_id ON favorites(model_id);
-CREATE INDEX IF NOT EXISTS idx_users_email ON users(email);
-CREATE INDEX IF NOT EXISTS idx_users_username ON users(username);
-
--- Insert default admin user (password: admin123)
-INSERT INTO users (email, password_hash, username, role, profile_status, email_verified)
-VALUES (
-    'admin@ysmmc.local',
-    '$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy',
-    'admin',
-    'admin',
-    'approved',
-    true
-) ON CONFLICT (email) DO NOTHING;
-
--- Insert sample announcement
-INSERT INTO announcements (title, content, is_active)
-VALUES (
-    'Welcome to YSM Model Station',
-    'This is a model sharing platform where you can upload, download and share your model works.',
-    true
-) ON CONFLICT DO NOTHING;
@@ -0,0 +1,10 @@
+// Package migrations 嵌入数据库迁移脚本，由 pkg/migrate 按版本号执行。
+//
+// 新增迁移时在本目录添加 <下一个版本号>_<名称>.up.sql 与对应的 .down.sql；
+// 已发布的脚本不可再修改，否则启动时会因校验和不一致而拒绝迁移。
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,23 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
+// APITokenPrefix 个人访问令牌的固定前缀，便于与 JWT 区分以及在代码仓库中被扫描识别
+const APITokenPrefix = "ysm_pat_"
+
+// GenerateAPIToken 生成新的个人访问令牌明文
+func GenerateAPIToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return APITokenPrefix + hex.EncodeToString(buf), nil
+}
+
+func IsAPIToken(token string) bool {
+	return strings.HasPrefix(token, APITokenPrefix)
+}
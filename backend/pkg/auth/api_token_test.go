@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateAPIToken(t *testing.T) {
+	token1, err := GenerateAPIToken()
+	if err != nil {
+		t.Fatalf("failed to generate api token: %v", err)
+	}
+	token2, _ := GenerateAPIToken()
+
+	if !strings.HasPrefix(token1, APITokenPrefix) {
+		t.Errorf("expected prefix %s, got %s", APITokenPrefix, token1)
+	}
+	if len(token1) != len(APITokenPrefix)+64 {
+		t.Errorf("unexpected token length %d", len(token1))
+	}
+	if token1 == token2 {
+		t.Error("tokens should be unique")
+	}
+}
+
+func TestIsAPIToken(t *testing.T) {
+	if !IsAPIToken(APITokenPrefix + "abc") {
+		t.Error("expected prefixed token to be recognized")
+	}
+	if IsAPIToken("eyJhbGciOiJIUzI1NiJ9.payload.sig") {
+		t.Error("jwt should not be recognized as api token")
+	}
+}
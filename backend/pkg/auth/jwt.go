@@ -10,51 +10,93 @@ import (
 )
 
 type Claims struct {
-	UserID uuid.UUID `json:"user_id"`
-	Email  string    `json:"email"`
-	Role   string    `json:"role"`
+	UserID       uuid.UUID `json:"user_id"`
+	Email        string    `json:"email"`
+	Role         string    `json:"role"`
+	TokenVersion int       `json:"tv"`
+	MFA          bool      `json:"mfa,omitempty"`
+	SessionID    uuid.UUID `json:"sid"`
 	jwt.RegisteredClaims
 }
 
+// RefreshClaims refresh token 的声明。ID 为随机 jti，保证同一秒内多次签发的 token 也互不相同，
+// 会话表与已消费记录按 token 哈希唯一索引
+type RefreshClaims struct {
+	SessionID uuid.UUID `json:"sid"`
+	jwt.RegisteredClaims
+}
+
+// TokenSubject 描述签发 access token 所需的用户与会话信息
+type TokenSubject struct {
+	UserID       uuid.UUID
+	Email        string
+	Role         string
+	TokenVersion int
+	MFA          bool
+	SessionID    uuid.UUID
+}
+
 type TokenPair struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
 	ExpiresIn    int64  `json:"expires_in"`
 }
 
-func GenerateToken(userID uuid.UUID, email, role string) (*TokenPair, error) {
+const (
+	accessIssuer  = "ysmmc"
+	refreshIssuer = "ysmmc-refresh"
+	mfaIssuer     = "ysmmc-mfa"
+	// challengeIssuer 工作量证明挑战，与登录 token 互不通用
+	challengeIssuer = "ysmmc-pow"
+	// unsubscribeIssuer 邮件中的退订链接
+	unsubscribeIssuer = "ysmmc-unsubscribe"
+
+	// MFAChallengeTTL 两步登录中密码验证通过后，提交验证码的有效时间
+	MFAChallengeTTL = 5 * time.Minute
+	// UnsubscribeTokenTTL 退订链接的有效期，签名密钥被移除后也会提前失效
+	UnsubscribeTokenTTL = 90 * 24 * time.Hour
+)
+
+func GenerateToken(subject TokenSubject) (*TokenPair, error) {
 	cfg := config.AppConfig
 
 	now := time.Now()
 	expiresAt := now.Add(time.Duration(cfg.JWTExpireHours) * time.Hour)
 
 	claims := &Claims{
-		UserID: userID,
-		Email:  email,
-		Role:   role,
+		UserID:       subject.UserID,
+		Email:        subject.Email,
+		Role:         subject.Role,
+		TokenVersion: subject.TokenVersion,
+		MFA:          subject.MFA,
+		SessionID:    subject.SessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			IssuedAt:  jwt.NewNumericDate(now),
 			NotBefore: jwt.NewNumericDate(now),
-			Issuer:    "ysmmc",
+			Issuer:    accessIssuer,
+			Audience:  jwt.ClaimStrings{cfg.JWTAudience},
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	accessToken, err := token.SignedString([]byte(cfg.JWTSecret))
+	accessToken, err := signClaims(claims)
 	if err != nil {
 		return nil, err
 	}
 
-	refreshClaims := &jwt.RegisteredClaims{
-		Subject:   userID.String(),
-		ExpiresAt: jwt.NewNumericDate(now.Add(time.Duration(cfg.JWTRefreshExpireDays) * 24 * time.Hour)),
-		IssuedAt:  jwt.NewNumericDate(now),
-		Issuer:    "ysmmc-refresh",
+	refreshClaims := &RefreshClaims{
+		SessionID: subject.SessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Subject:   subject.UserID.String(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Duration(cfg.JWTRefreshExpireDays) * 24 * time.Hour)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Issuer:    refreshIssuer,
+			Audience:  jwt.ClaimStrings{cfg.JWTAudience},
+		},
 	}
 
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
-	refreshTokenString, err := refreshToken.SignedString([]byte(cfg.JWTSecret))
+	refreshTokenString, err := signClaims(refreshClaims)
 	if err != nil {
 		return nil, err
 	}
@@ -66,13 +108,46 @@ func GenerateToken(userID uuid.UUID, email, role string) (*TokenPair, error) {
 	}, nil
 }
 
-func ParseToken(tokenString string) (*Claims, error) {
-	cfg := config.AppConfig
+// signClaims 使用当前签名密钥签名，并在头部写入 kid 供验证方选择公钥
+func signClaims(claims jwt.Claims) (string, error) {
+	ks, err := currentKeySet()
+	if err != nil {
+		return "", err
+	}
 
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(cfg.JWTSecret), nil
-	})
+	key := ks.Active()
+	token := jwt.NewWithClaims(key.method(), claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.private)
+}
 
+// parseClaims 严格校验：算法必须与 kid 对应密钥的算法一致，签发者、受众与过期时间必须匹配
+func parseClaims(tokenString string, claims jwt.Claims, issuer string) (*jwt.Token, error) {
+	ks, err := currentKeySet()
+	if err != nil {
+		return nil, err
+	}
+
+	return jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := ks.Lookup(kid)
+		if !ok {
+			return nil, errors.New("unknown signing key")
+		}
+		if token.Method.Alg() != key.Algorithm {
+			return nil, errors.New("unexpected signing algorithm")
+		}
+		return key.public, nil
+	},
+		jwt.WithValidMethods([]string{AlgEdDSA, AlgRS256}),
+		jwt.WithIssuer(issuer),
+		jwt.WithAudience(config.AppConfig.JWTAudience),
+		jwt.WithExpirationRequired(),
+	)
+}
+
+func ParseToken(tokenString string) (*Claims, error) {
+	token, err := parseClaims(tokenString, &Claims{}, accessIssuer)
 	if err != nil {
 		return nil, err
 	}
@@ -85,19 +160,126 @@ func ParseToken(tokenString string) (*Claims, error) {
 }
 
 func ParseRefreshToken(tokenString string) (uuid.UUID, error) {
-	cfg := config.AppConfig
-
-	token, err := jwt.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(cfg.JWTSecret), nil
-	})
-
+	token, err := parseClaims(tokenString, &RefreshClaims{}, refreshIssuer)
 	if err != nil {
 		return uuid.Nil, err
 	}
 
-	if claims, ok := token.Claims.(*jwt.RegisteredClaims); ok && token.Valid {
+	if claims, ok := token.Claims.(*RefreshClaims); ok && token.Valid {
 		return uuid.Parse(claims.Subject)
 	}
 
 	return uuid.Nil, errors.New("invalid refresh token")
 }
+
+// GenerateMFAToken 签发两步登录的挑战 token，仅能用于提交二次验证码
+func GenerateMFAToken(userID uuid.UUID, tokenVersion int) (string, error) {
+	now := time.Now()
+	claims := &Claims{
+		UserID:       userID,
+		TokenVersion: tokenVersion,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   userID.String(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(MFAChallengeTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Issuer:    mfaIssuer,
+			Audience:  jwt.ClaimStrings{config.AppConfig.JWTAudience},
+		},
+	}
+
+	return signClaims(claims)
+}
+
+func ParseMFAToken(tokenString string) (*Claims, error) {
+	token, err := parseClaims(tokenString, &Claims{}, mfaIssuer)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
+		return claims, nil
+	}
+
+	return nil, errors.New("invalid mfa token")
+}
+
+// ChallengeClaims 工作量证明挑战，ID 为一次性随机数，签名保证难度与用途不可被客户端篡改
+type ChallengeClaims struct {
+	Purpose    string `json:"purpose"`
+	Difficulty int    `json:"difficulty"`
+	jwt.RegisteredClaims
+}
+
+func GenerateChallengeToken(purpose string, difficulty int, ttl time.Duration) (string, *ChallengeClaims, error) {
+	now := time.Now()
+	claims := &ChallengeClaims{
+		Purpose:    purpose,
+		Difficulty: difficulty,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Issuer:    challengeIssuer,
+			Audience:  jwt.ClaimStrings{config.AppConfig.JWTAudience},
+		},
+	}
+
+	token, err := signClaims(claims)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, claims, nil
+}
+
+func ParseChallengeToken(tokenString string) (*ChallengeClaims, error) {
+	token, err := parseClaims(tokenString, &ChallengeClaims{}, challengeIssuer)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(*ChallengeClaims); ok && token.Valid && claims.ID != "" {
+		return claims, nil
+	}
+
+	return nil, errors.New("invalid challenge token")
+}
+
+// UnsubscribeClaims 退订链接，Topic 为要退订的通知类型或摘要
+type UnsubscribeClaims struct {
+	Topic string `json:"topic"`
+	jwt.RegisteredClaims
+}
+
+// GenerateUnsubscribeToken 签发无需登录即可使用的退订 token
+func GenerateUnsubscribeToken(userID uuid.UUID, topic string) (string, error) {
+	now := time.Now()
+	claims := &UnsubscribeClaims{
+		Topic: topic,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   userID.String(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(UnsubscribeTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Issuer:    unsubscribeIssuer,
+			Audience:  jwt.ClaimStrings{config.AppConfig.JWTAudience},
+		},
+	}
+
+	return signClaims(claims)
+}
+
+func ParseUnsubscribeToken(tokenString string) (uuid.UUID, string, error) {
+	token, err := parseClaims(tokenString, &UnsubscribeClaims{}, unsubscribeIssuer)
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+
+	claims, ok := token.Claims.(*UnsubscribeClaims)
+	if !ok || !token.Valid || claims.Topic == "" {
+		return uuid.Nil, "", errors.New("invalid unsubscribe token")
+	}
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return uuid.Nil, "", errors.New("invalid unsubscribe token")
+	}
+	return userID, claims.Topic, nil
+}
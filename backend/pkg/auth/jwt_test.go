@@ -11,8 +11,14 @@ import (
 
 func init() {
 	os.Setenv("DB_PASSWORD", "test_password")
-	os.Setenv("JWT_SECRET", "test_jwt_secret_key_for_testing_purposes_32")
 	config.LoadConfig()
+
+	key, err := GenerateSigningKey(AlgEdDSA)
+	if err != nil {
+		panic(err)
+	}
+	ks, _ := NewKeySet(key)
+	SetKeySet(ks)
 }
 
 func TestGenerateToken(t *testing.T) {
@@ -20,7 +26,7 @@ func TestGenerateToken(t *testing.T) {
 	email := "test@example.com"
 	role := "user"
 	
-	tokens, err := GenerateToken(userID, email, role)
+	tokens, err := GenerateToken(TokenSubject{UserID: userID, Email: email, Role: role})
 	if err != nil {
 		t.Fatalf("failed to generate token: %v", err)
 	}
@@ -41,7 +47,7 @@ func TestParseToken_Valid(t *testing.T) {
 	email := "test@example.com"
 	role := "admin"
 	
-	tokens, err := GenerateToken(userID, email, role)
+	tokens, err := GenerateToken(TokenSubject{UserID: userID, Email: email, Role: role})
 	if err != nil {
 		t.Fatalf("failed to generate token: %v", err)
 	}
@@ -62,6 +68,22 @@ func TestParseToken_Valid(t *testing.T) {
 	}
 }
 
+func TestParseToken_TokenVersion(t *testing.T) {
+	tokens, err := GenerateToken(TokenSubject{UserID: uuid.New(), Email: "test@example.com", Role: "user", TokenVersion: 7})
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	claims, err := ParseToken(tokens.AccessToken)
+	if err != nil {
+		t.Fatalf("failed to parse token: %v", err)
+	}
+
+	if claims.TokenVersion != 7 {
+		t.Errorf("expected token version 7, got %d", claims.TokenVersion)
+	}
+}
+
 func TestParseToken_Invalid(t *testing.T) {
 	invalidTokens := []string{
 		"",
@@ -82,7 +104,7 @@ func TestParseRefreshToken(t *testing.T) {
 	email := "test@example.com"
 	role := "user"
 	
-	tokens, err := GenerateToken(userID, email, role)
+	tokens, err := GenerateToken(TokenSubject{UserID: userID, Email: email, Role: role})
 	if err != nil {
 		t.Fatalf("failed to generate token: %v", err)
 	}
@@ -116,7 +138,7 @@ func TestTokenExpiration(t *testing.T) {
 	email := "test@example.com"
 	role := "user"
 	
-	tokens, err := GenerateToken(userID, email, role)
+	tokens, err := GenerateToken(TokenSubject{UserID: userID, Email: email, Role: role})
 	if err != nil {
 		t.Fatalf("failed to generate token: %v", err)
 	}
@@ -138,3 +160,119 @@ func TestTokenExpiration(t *testing.T) {
 		t.Errorf("token expiry time is not within expected range")
 	}
 }
+
+func TestParseToken_RejectsOtherTokenTypes(t *testing.T) {
+	userID := uuid.New()
+
+	tokens, err := GenerateToken(TokenSubject{UserID: userID, Email: "test@example.com", Role: "user"})
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	if _, err := ParseToken(tokens.RefreshToken); err == nil {
+		t.Error("refresh token should not be accepted as access token")
+	}
+
+	mfaToken, err := GenerateMFAToken(userID, 0)
+	if err != nil {
+		t.Fatalf("failed to generate mfa token: %v", err)
+	}
+	if _, err := ParseToken(mfaToken); err == nil {
+		t.Error("mfa token should not be accepted as access token")
+	}
+	if _, err := ParseMFAToken(tokens.AccessToken); err == nil {
+		t.Error("access token should not be accepted as mfa token")
+	}
+
+	claims, err := ParseMFAToken(mfaToken)
+	if err != nil {
+		t.Fatalf("failed to parse mfa token: %v", err)
+	}
+	if claims.UserID != userID {
+		t.Errorf("expected userID %s, got %s", userID, claims.UserID)
+	}
+}
+
+func TestParseToken_SessionID(t *testing.T) {
+	sessionID := uuid.New()
+
+	tokens, err := GenerateToken(TokenSubject{UserID: uuid.New(), Email: "test@example.com", Role: "user", SessionID: sessionID})
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	claims, err := ParseToken(tokens.AccessToken)
+	if err != nil {
+		t.Fatalf("failed to parse token: %v", err)
+	}
+	if claims.SessionID != sessionID {
+		t.Errorf("expected session id %s, got %s", sessionID, claims.SessionID)
+	}
+}
+
+func TestChallengeToken(t *testing.T) {
+	token, issued, err := GenerateChallengeToken("register", 18, time.Minute)
+	if err != nil {
+		t.Fatalf("failed to generate challenge token: %v", err)
+	}
+
+	claims, err := ParseChallengeToken(token)
+	if err != nil {
+		t.Fatalf("failed to parse challenge token: %v", err)
+	}
+	if claims.ID != issued.ID || claims.Purpose != "register" || claims.Difficulty != 18 {
+		t.Errorf("unexpected challenge claims: %+v", claims)
+	}
+
+	if _, err := ParseToken(token); err == nil {
+		t.Error("challenge token should not be accepted as access token")
+	}
+	if _, err := ParseMFAToken(token); err == nil {
+		t.Error("challenge token should not be accepted as mfa token")
+	}
+}
+
+func TestUnsubscribeToken(t *testing.T) {
+	userID := uuid.New()
+	token, err := GenerateUnsubscribeToken(userID, "favorite_update")
+	if err != nil {
+		t.Fatalf("failed to generate unsubscribe token: %v", err)
+	}
+
+	gotUser, topic, err := ParseUnsubscribeToken(token)
+	if err != nil {
+		t.Fatalf("failed to parse unsubscribe token: %v", err)
+	}
+	if gotUser != userID || topic != "favorite_update" {
+		t.Errorf("unexpected unsubscribe claims: %s %s", gotUser, topic)
+	}
+
+	if _, err := ParseToken(token); err == nil {
+		t.Error("unsubscribe token should not be accepted as access token")
+	}
+	access, _ := GenerateToken(TokenSubject{UserID: userID, Email: "a@b.c", Role: "user"})
+	if _, _, err := ParseUnsubscribeToken(access.AccessToken); err == nil {
+		t.Error("access token should not be accepted as unsubscribe token")
+	}
+}
+
+func TestGenerateToken_RefreshTokenUnique(t *testing.T) {
+	subject := TokenSubject{UserID: uuid.New(), Email: "test@example.com", Role: "user", SessionID: uuid.New()}
+
+	first, err := GenerateToken(subject)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	second, err := GenerateToken(subject)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	if first.RefreshToken == second.RefreshToken {
+		t.Error("expected refresh tokens issued within the same second to differ")
+	}
+	for _, token := range []string{first.RefreshToken, second.RefreshToken} {
+		if userID, err := ParseRefreshToken(token); err != nil || userID != subject.UserID {
+			t.Errorf("expected refresh token to parse to %s, got %s (%v)", subject.UserID, userID, err)
+		}
+	}
+}
@@ -0,0 +1,437 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgEdDSA = "EdDSA"
+	AlgRS256 = "RS256"
+
+	rsaKeyBits = 2048
+	// activeKeyFile 密钥目录中记录当前签名密钥 kid 的文件
+	activeKeyFile = "active"
+)
+
+func validKeyID(kid string) bool {
+	if kid == "" || len(kid) > 64 {
+		return false
+	}
+	for _, r := range kid {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// SigningKey 一把 JWT 密钥。只有公钥的密钥仅用于验证（例如已停用、等待过期的旧密钥）
+type SigningKey struct {
+	ID        string
+	Algorithm string
+	private   crypto.Signer
+	public    crypto.PublicKey
+}
+
+func (k *SigningKey) CanSign() bool {
+	return k.private != nil
+}
+
+func (k *SigningKey) method() jwt.SigningMethod {
+	if k.Algorithm == AlgEdDSA {
+		return jwt.SigningMethodEdDSA
+	}
+	return jwt.SigningMethodRS256
+}
+
+// GenerateSigningKey 生成新的签名密钥，kid 由日期和随机后缀组成，便于辨认轮换顺序
+func GenerateSigningKey(alg string) (*SigningKey, error) {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, err
+	}
+	key := &SigningKey{
+		ID:        time.Now().UTC().Format("20060102") + "-" + hex.EncodeToString(suffix),
+		Algorithm: alg,
+	}
+
+	switch alg {
+	case AlgEdDSA:
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		key.private, key.public = priv, pub
+	case AlgRS256:
+		priv, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+		if err != nil {
+			return nil, err
+		}
+		key.private, key.public = priv, &priv.PublicKey
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q, use %s or %s", alg, AlgEdDSA, AlgRS256)
+	}
+	return key, nil
+}
+
+func newSigningKey(kid string, private crypto.Signer, public crypto.PublicKey) (*SigningKey, error) {
+	key := &SigningKey{ID: kid, private: private, public: public}
+	switch public.(type) {
+	case ed25519.PublicKey:
+		key.Algorithm = AlgEdDSA
+	case *rsa.PublicKey:
+		key.Algorithm = AlgRS256
+	default:
+		return nil, fmt.Errorf("key %s: unsupported key type %T", kid, public)
+	}
+	return key, nil
+}
+
+func parseKeyPEM(kid string, data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("key %s: no PEM block found", kid)
+	}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", kid, err)
+		}
+		signer, ok := parsed.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("key %s: unsupported private key type %T", kid, parsed)
+		}
+		return newSigningKey(kid, signer, signer.Public())
+	case "PUBLIC KEY":
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", kid, err)
+		}
+		return newSigningKey(kid, nil, parsed)
+	default:
+		return nil, fmt.Errorf("key %s: unexpected PEM block %q", kid, block.Type)
+	}
+}
+
+// KeySet 当前签名密钥及所有可用于验证的密钥
+type KeySet struct {
+	active *SigningKey
+	keys   map[string]*SigningKey
+}
+
+// NewKeySet 由内存中的密钥构建密钥集，active 必须包含私钥
+func NewKeySet(active *SigningKey, others ...*SigningKey) (*KeySet, error) {
+	if active == nil || !active.CanSign() {
+		return nil, errors.New("active key must include a private key")
+	}
+	ks := &KeySet{active: active, keys: map[string]*SigningKey{active.ID: active}}
+	for _, k := range others {
+		ks.keys[k.ID] = k
+	}
+	return ks, nil
+}
+
+func (ks *KeySet) Active() *SigningKey {
+	return ks.active
+}
+
+func (ks *KeySet) Lookup(kid string) (*SigningKey, bool) {
+	k, ok := ks.keys[kid]
+	return k, ok
+}
+
+// Keys 按 kid 排序返回全部密钥
+func (ks *KeySet) Keys() []*SigningKey {
+	result := make([]*SigningKey, 0, len(ks.keys))
+	for _, k := range ks.keys {
+		result = append(result, k)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+// LoadKeySet 读取密钥目录：每个 <kid>.pem 为一把密钥（PKCS#8 私钥或 PKIX 公钥），
+// active 文件记录当前用于签名的 kid；目录中只有一把私钥时可省略
+func LoadKeySet(dir string) (*KeySet, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := map[string]*SigningKey{}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".pem" {
+			continue
+		}
+		kid := strings.TrimSuffix(entry.Name(), ".pem")
+		if !validKeyID(kid) {
+			return nil, fmt.Errorf("invalid key id %q", kid)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		key, err := parseKeyPEM(kid, data)
+		if err != nil {
+			return nil, err
+		}
+		keys[kid] = key
+	}
+
+	activeID, err := readActiveKeyID(dir)
+	if err != nil {
+		return nil, err
+	}
+	if activeID == "" {
+		for kid, k := range keys {
+			if !k.CanSign() {
+				continue
+			}
+			if activeID != "" {
+				return nil, errors.New("multiple private keys found, set the active key first")
+			}
+			activeID = kid
+		}
+	}
+	if activeID == "" {
+		return nil, fmt.Errorf("no signing key found in %s", dir)
+	}
+
+	active, ok := keys[activeID]
+	if !ok || !active.CanSign() {
+		return nil, fmt.Errorf("active key %s has no private key in %s", activeID, dir)
+	}
+	return &KeySet{active: active, keys: keys}, nil
+}
+
+func readActiveKeyID(dir string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, activeKeyFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// SaveSigningKey 将私钥以 PKCS#8 PEM 写入密钥目录（权限 0600）
+func SaveSigningKey(dir string, key *SigningKey) error {
+	if !key.CanSign() {
+		return errors.New("only keys with a private part can be saved")
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key.private)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	path := filepath.Join(dir, key.ID+".pem")
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if err := pem.Encode(f, &pem.Block{Type: "PRIVATE KEY", Bytes: der}); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// SetActiveKey 切换签名密钥。新密钥应先在所有实例上作为验证密钥加载，再切换签名
+func SetActiveKey(dir, kid string) error {
+	if !validKeyID(kid) {
+		return fmt.Errorf("invalid key id %q", kid)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, kid+".pem"))
+	if err != nil {
+		return fmt.Errorf("key %s not found", kid)
+	}
+	key, err := parseKeyPEM(kid, data)
+	if err != nil {
+		return err
+	}
+	if !key.CanSign() {
+		return fmt.Errorf("key %s has no private key", kid)
+	}
+
+	tmp := filepath.Join(dir, activeKeyFile+".tmp")
+	if err := os.WriteFile(tmp, []byte(kid+"\n"), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(dir, activeKeyFile))
+}
+
+// RetireKey 删除不再需要的密钥。应在停用后至少等待一个 access token 有效期再执行
+func RetireKey(dir, kid string) error {
+	if !validKeyID(kid) {
+		return fmt.Errorf("invalid key id %q", kid)
+	}
+	activeID, err := readActiveKeyID(dir)
+	if err != nil {
+		return err
+	}
+	if activeID == "" {
+		return errors.New("no active key recorded, set the active key first")
+	}
+	if kid == activeID {
+		return errors.New("cannot retire the active signing key")
+	}
+	if err := os.Remove(filepath.Join(dir, kid+".pem")); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("key %s not found", kid)
+		}
+		return err
+	}
+	return nil
+}
+
+// JWK RFC 7517 公钥表示
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS 返回全部验证公钥，供其他服务校验本站签发的 token
+func (ks *KeySet) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	for _, k := range ks.Keys() {
+		jwk := JWK{Use: "sig", Alg: k.Algorithm, Kid: k.ID}
+		switch pub := k.public.(type) {
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+var (
+	keysMu sync.RWMutex
+	keys   *KeySet
+)
+
+// SetKeySet 替换进程使用的密钥集
+func SetKeySet(ks *KeySet) {
+	keysMu.Lock()
+	defer keysMu.Unlock()
+	keys = ks
+}
+
+func currentKeySet() (*KeySet, error) {
+	keysMu.RLock()
+	defer keysMu.RUnlock()
+	if keys == nil {
+		return nil, errors.New("jwt signing keys are not loaded")
+	}
+	return keys, nil
+}
+
+// PublicJWKS 返回当前加载的验证公钥
+func PublicJWKS() JWKSet {
+	ks, err := currentKeySet()
+	if err != nil {
+		return JWKSet{Keys: []JWK{}}
+	}
+	return ks.JWKS()
+}
+
+// InitKeys 加载密钥目录。目录为空且 generate 为 true 时（开发环境）自动生成一把 Ed25519 密钥
+func InitKeys(dir string, generate bool) error {
+	ks, err := LoadKeySet(dir)
+	if err != nil && generate && isEmptyKeyDir(dir) {
+		key, genErr := GenerateSigningKey(AlgEdDSA)
+		if genErr != nil {
+			return genErr
+		}
+		if err := SaveSigningKey(dir, key); err != nil {
+			return err
+		}
+		ks, err = LoadKeySet(dir)
+	}
+	if err != nil {
+		return err
+	}
+
+	SetKeySet(ks)
+	return nil
+}
+
+// ReloadKeys 重新读取密钥目录，使其他实例或命令行工具的轮换无需重启即可生效；
+// 读取失败时保留当前密钥集
+func ReloadKeys(dir string) (changed bool, err error) {
+	ks, err := LoadKeySet(dir)
+	if err != nil {
+		return false, err
+	}
+
+	old, _ := currentKeySet()
+	SetKeySet(ks)
+	return old == nil || !sameKeySet(old, ks), nil
+}
+
+func sameKeySet(a, b *KeySet) bool {
+	if a.active.ID != b.active.ID || len(a.keys) != len(b.keys) {
+		return false
+	}
+	for kid := range a.keys {
+		if _, ok := b.keys[kid]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func isEmptyKeyDir(dir string) bool {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return true
+	}
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		if filepath.Ext(entry.Name()) == ".pem" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,221 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+func mustGenerateKey(t *testing.T, alg string) *SigningKey {
+	t.Helper()
+	key, err := GenerateSigningKey(alg)
+	if err != nil {
+		t.Fatalf("failed to generate %s key: %v", alg, err)
+	}
+	return key
+}
+
+// useKeySet 临时替换全局密钥集，测试结束后恢复
+func useKeySet(t *testing.T, ks *KeySet) {
+	t.Helper()
+	prev, _ := currentKeySet()
+	SetKeySet(ks)
+	t.Cleanup(func() { SetKeySet(prev) })
+}
+
+func TestLoadKeySet_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	ed := mustGenerateKey(t, AlgEdDSA)
+	rs := mustGenerateKey(t, AlgRS256)
+	for _, k := range []*SigningKey{ed, rs} {
+		if err := SaveSigningKey(dir, k); err != nil {
+			t.Fatalf("failed to save key: %v", err)
+		}
+	}
+
+	if _, err := LoadKeySet(dir); err == nil {
+		t.Error("expected error when several private keys exist without an active key")
+	}
+
+	if err := SetActiveKey(dir, rs.ID); err != nil {
+		t.Fatalf("failed to set active key: %v", err)
+	}
+
+	ks, err := LoadKeySet(dir)
+	if err != nil {
+		t.Fatalf("failed to load key set: %v", err)
+	}
+	if ks.Active().ID != rs.ID || ks.Active().Algorithm != AlgRS256 {
+		t.Errorf("expected active key %s (RS256), got %s (%s)", rs.ID, ks.Active().ID, ks.Active().Algorithm)
+	}
+	if k, ok := ks.Lookup(ed.ID); !ok || k.Algorithm != AlgEdDSA {
+		t.Errorf("expected Ed25519 key %s to be loaded", ed.ID)
+	}
+
+	jwks := ks.JWKS()
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("expected 2 keys in JWKS, got %d", len(jwks.Keys))
+	}
+	for _, jwk := range jwks.Keys {
+		switch jwk.Kid {
+		case ed.ID:
+			if jwk.Kty != "OKP" || jwk.Crv != "Ed25519" || jwk.X == "" {
+				t.Errorf("unexpected Ed25519 JWK: %+v", jwk)
+			}
+		case rs.ID:
+			if jwk.Kty != "RSA" || jwk.N == "" || jwk.E != "AQAB" {
+				t.Errorf("unexpected RSA JWK: %+v", jwk)
+			}
+		default:
+			t.Errorf("unexpected kid %s", jwk.Kid)
+		}
+	}
+}
+
+func TestLoadKeySet_SingleKeyNeedsNoActiveFile(t *testing.T) {
+	dir := t.TempDir()
+	key := mustGenerateKey(t, AlgEdDSA)
+	if err := SaveSigningKey(dir, key); err != nil {
+		t.Fatalf("failed to save key: %v", err)
+	}
+
+	ks, err := LoadKeySet(dir)
+	if err != nil {
+		t.Fatalf("failed to load key set: %v", err)
+	}
+	if ks.Active().ID != key.ID {
+		t.Errorf("expected active key %s, got %s", key.ID, ks.Active().ID)
+	}
+}
+
+func TestRetireKey(t *testing.T) {
+	dir := t.TempDir()
+	oldKey := mustGenerateKey(t, AlgEdDSA)
+	newKey := mustGenerateKey(t, AlgEdDSA)
+	SaveSigningKey(dir, oldKey)
+	SaveSigningKey(dir, newKey)
+	SetActiveKey(dir, newKey.ID)
+
+	if err := RetireKey(dir, newKey.ID); err == nil {
+		t.Error("expected error when retiring the active key")
+	}
+	if err := RetireKey(dir, "../active"); err == nil {
+		t.Error("expected error for invalid key id")
+	}
+	if err := RetireKey(dir, oldKey.ID); err != nil {
+		t.Fatalf("failed to retire key: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, oldKey.ID+".pem")); !os.IsNotExist(err) {
+		t.Error("retired key file should be removed")
+	}
+}
+
+func TestParseToken_KeyRotation(t *testing.T) {
+	oldKey := mustGenerateKey(t, AlgEdDSA)
+	newKey := mustGenerateKey(t, AlgRS256)
+
+	oldSet, _ := NewKeySet(oldKey)
+	useKeySet(t, oldSet)
+	oldTokens, err := GenerateToken(TokenSubject{UserID: uuid.New(), Role: "user"})
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	rotated, _ := NewKeySet(newKey, oldKey)
+	SetKeySet(rotated)
+	if _, err := ParseToken(oldTokens.AccessToken); err != nil {
+		t.Errorf("token signed by previous key should still verify: %v", err)
+	}
+
+	newTokens, err := GenerateToken(TokenSubject{UserID: uuid.New(), Role: "user"})
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	parsed, _, err := jwt.NewParser().ParseUnverified(newTokens.AccessToken, &Claims{})
+	if err != nil {
+		t.Fatalf("failed to decode token: %v", err)
+	}
+	if parsed.Header["kid"] != newKey.ID || parsed.Method.Alg() != AlgRS256 {
+		t.Errorf("expected kid %s with RS256, got %v with %s", newKey.ID, parsed.Header["kid"], parsed.Method.Alg())
+	}
+
+	retired, _ := NewKeySet(newKey)
+	SetKeySet(retired)
+	if _, err := ParseToken(oldTokens.AccessToken); err == nil {
+		t.Error("token signed by retired key should be rejected")
+	}
+	if _, err := ParseToken(newTokens.AccessToken); err != nil {
+		t.Errorf("token signed by active key should verify: %v", err)
+	}
+}
+
+func TestParseToken_RejectsAlgorithmConfusion(t *testing.T) {
+	ks, _ := currentKeySet()
+	active := ks.Active()
+	pub := active.public.(ed25519.PublicKey)
+
+	now := time.Now()
+	claims := &Claims{
+		UserID: uuid.New(),
+		Role:   "super_admin",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+			Issuer:    accessIssuer,
+			Audience:  jwt.ClaimStrings{"ysmmc-api"},
+		},
+	}
+
+	hs := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	hs.Header["kid"] = active.ID
+	forged, err := hs.SignedString([]byte(pub))
+	if err != nil {
+		t.Fatalf("failed to sign forged token: %v", err)
+	}
+	if _, err := ParseToken(forged); err == nil {
+		t.Error("HS256 token signed with the public key should be rejected")
+	}
+
+	none := jwt.NewWithClaims(jwt.SigningMethodNone, claims)
+	none.Header["kid"] = active.ID
+	unsigned, _ := none.SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if _, err := ParseToken(unsigned); err == nil {
+		t.Error("unsigned token should be rejected")
+	}
+}
+
+func TestParseToken_RejectsWrongAudienceAndMissingExpiry(t *testing.T) {
+	now := time.Now()
+	wrongAudience, err := signClaims(&Claims{
+		UserID: uuid.New(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+			Issuer:    accessIssuer,
+			Audience:  jwt.ClaimStrings{"another-service"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	if _, err := ParseToken(wrongAudience); err == nil {
+		t.Error("token for another audience should be rejected")
+	}
+
+	noExpiry, err := signClaims(&Claims{
+		UserID: uuid.New(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:   accessIssuer,
+			Audience: jwt.ClaimStrings{"ysmmc-api"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	if _, err := ParseToken(noExpiry); err == nil {
+		t.Error("token without expiry should be rejected")
+	}
+}
@@ -3,40 +3,85 @@ package email
 import (
 	"bytes"
 	"crypto/tls"
+	"errors"
 	"fmt"
-	"html/template"
+	"io"
+	"io/fs"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
 	"net/smtp"
-	"os"
-	"path/filepath"
+	"net/textproto"
+	"strconv"
+	"time"
 
 	"github.com/ysmmc/backend/internal/config"
 )
 
+// sendTimeout 单封邮件从建立连接到发送完成的最长时间
+const sendTimeout = 30 * time.Second
+
+// ErrNotConfigured 未配置 SMTP 服务器
+var ErrNotConfigured = errors.New("SMTP not configured")
+
 type EmailService struct {
-	host     string
-	port     int
-	user     string
-	password string
-	from     string
+	host      string
+	port      int
+	user      string
+	password  string
+	from      string
+	templates fs.FS
 }
 
-func NewEmailService() *EmailService {
+// Config SMTP 服务器连接参数与模板覆盖目录
+type Config struct {
+	Host     string
+	Port     int
+	User     string
+	Password string
+	From     string
+	// TemplateDir 不为空时优先使用该目录下的模板，结构与内置模板相同
+	TemplateDir string
+}
+
+func New(cfg Config) *EmailService {
 	return &EmailService{
-		host:     config.AppConfig.SMTPHost,
-		port:     config.AppConfig.SMTPPort,
-		user:     config.AppConfig.SMTPUser,
-		password: config.AppConfig.SMTPPassword,
-		from:     config.AppConfig.SMTPFrom,
+		host:      cfg.Host,
+		port:      cfg.Port,
+		user:      cfg.User,
+		password:  cfg.Password,
+		from:      cfg.From,
+		templates: newTemplateFS(cfg.TemplateDir),
 	}
 }
 
+func NewEmailService() *EmailService {
+	return New(Config{
+		Host:        config.AppConfig.SMTPHost,
+		Port:        config.AppConfig.SMTPPort,
+		User:        config.AppConfig.SMTPUser,
+		Password:    config.AppConfig.SMTPPassword,
+		From:        config.AppConfig.SMTPFrom,
+		TemplateDir: config.AppConfig.EmailTemplateDir,
+	})
+}
+
 type ResetPasswordData struct {
 	ResetLink string
 }
 
 type WelcomeData struct {
-	Username   string
-	VerifyLink string
+	Username    string
+	VerifyLink  string
+	ExpireHours int
+}
+
+type EmailChangeData struct {
+	Username    string
+	NewEmail    string
+	VerifyLink  string
+	ExpireHours int
 }
 
 type ModelReviewData struct {
@@ -47,25 +92,132 @@ type ModelReviewData struct {
 	ModelLink string
 }
 
+type SecurityAlertData struct {
+	Username   string
+	Title      string
+	Message    string
+	Time       string
+	IP         string
+	Device     string
+	ActionLink string
+}
+
+// NotificationData 站内通知的邮件副本
+type NotificationData struct {
+	Username        string
+	Title           string
+	Body            string
+	Link            string
+	SettingsLink    string
+	UnsubscribeLink string
+}
+
+// DigestData 汇总一段时间内通知的摘要邮件，Frequency 为 daily 或 weekly
+type DigestData struct {
+	Username        string
+	Frequency       string
+	Items           []DigestItem
+	SettingsLink    string
+	UnsubscribeLink string
+}
+
+type DigestItem struct {
+	Title string
+	Body  string
+	Link  string
+	Time  string
+}
+
+// Message 渲染完成、待投递的邮件，可序列化后放入任务队列
+type Message struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	// Body HTML 正文
+	Body string `json:"body"`
+	// Text 纯文本正文，不为空时以 multipart/alternative 发送
+	Text string `json:"text,omitempty"`
+}
+
 func (s *EmailService) Send(to, subject, body string) error {
+	return s.SendMessage(Message{To: to, Subject: subject, Body: body})
+}
+
+// buildMessage 生成邮件头与正文，正文使用 quoted-printable 编码
+func (s *EmailService) buildMessage(m Message) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", s.from)
+	fmt.Fprintf(&buf, "To: %s\r\n", m.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", m.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+
+	if m.Text == "" {
+		buf.WriteString("Content-Type: text/html; charset=UTF-8\r\nContent-Transfer-Encoding: quoted-printable\r\n\r\n")
+		if err := writeQuotedPrintable(&buf, m.Body); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	mw := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", mw.Boundary())
+	parts := []struct{ contentType, content string }{
+		{"text/plain; charset=UTF-8", m.Text},
+		{"text/html; charset=UTF-8", m.Body},
+	}
+	for _, part := range parts {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQuotedPrintable(w, part.content); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeQuotedPrintable(w io.Writer, content string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(content)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+func (s *EmailService) SendMessage(m Message) error {
 	if s.host == "" || s.user == "" {
-		return fmt.Errorf("SMTP not configured")
+		return ErrNotConfigured
 	}
 
 	auth := smtp.PlainAuth("", s.user, s.password, s.host)
 
-	msg := fmt.Sprintf("From: %s\r\n", s.from)
-	msg += fmt.Sprintf("To: %s\r\n", to)
-	msg += fmt.Sprintf("Subject: %s\r\n", subject)
-	msg += "MIME-version: 1.0;\r\nContent-Type: text/html; charset=\"UTF-8\";\r\n\r\n"
-	msg += body
+	msg, err := s.buildMessage(m)
+	if err != nil {
+		return fmt.Errorf("failed to build message: %w", err)
+	}
+	to := m.To
 
-	addr := fmt.Sprintf("%s:%d", s.host, s.port)
+	addr := net.JoinHostPort(s.host, strconv.Itoa(s.port))
 
-	client, err := smtp.Dial(addr)
+	conn, err := net.DialTimeout("tcp", addr, sendTimeout)
 	if err != nil {
 		return fmt.Errorf("failed to connect to SMTP server: %w", err)
 	}
+	// 整个会话共用一个期限，避免服务器无响应时占住 worker
+	conn.SetDeadline(time.Now().Add(sendTimeout))
+
+	client, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
 	defer client.Close()
 
 	if ok, _ := client.Extension("STARTTLS"); ok {
@@ -92,7 +244,7 @@ func (s *EmailService) Send(to, subject, body string) error {
 		return fmt.Errorf("failed to get data writer: %w", err)
 	}
 
-	_, err = w.Write([]byte(msg))
+	_, err = w.Write(msg)
 	if err != nil {
 		return fmt.Errorf("failed to write message: %w", err)
 	}
@@ -105,69 +257,51 @@ func (s *EmailService) Send(to, subject, body string) error {
 	return client.Quit()
 }
 
-func (s *EmailService) renderTemplate(name string, data interface{}) (string, error) {
-	templatePath := filepath.Join("templates", "emails", name+".html")
-	
-	content, err := os.ReadFile(templatePath)
-	if err != nil {
-		return "", fmt.Errorf("failed to read template: %w", err)
-	}
-
-	tmpl, err := template.New(name).Parse(string(content))
-	if err != nil {
-		return "", fmt.Errorf("failed to parse template: %w", err)
-	}
-
-	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, data); err != nil {
-		return "", fmt.Errorf("failed to execute template: %w", err)
+// IsPermanent 判断发送错误是否重试也不会成功：未配置 SMTP，或服务器返回 5xx 永久性错误
+// （收件人不存在、认证失败等）。连接失败、超时与 4xx 临时错误可以稍后重试
+func IsPermanent(err error) bool {
+	if errors.Is(err, ErrNotConfigured) {
+		return true
 	}
+	var protoErr *textproto.Error
+	return errors.As(err, &protoErr) && protoErr.Code >= 500 && protoErr.Code < 600
+}
 
-	return buf.String(), nil
+func (s *EmailService) ResetPasswordMessage(locale, to, resetLink string) (Message, error) {
+	return s.Render(locale, TemplateResetPassword, to, ResetPasswordData{ResetLink: resetLink})
 }
 
-func (s *EmailService) SendResetPassword(to, resetLink string) error {
-	data := ResetPasswordData{ResetLink: resetLink}
-	body, err := s.renderTemplate("reset_password", data)
-	if err != nil {
-		body = fmt.Sprintf(`
-			<html>
-			<body>
-				<h2>重置密码</h2>
-				<p>请点击以下链接重置您的密码：</p>
-				<p><a href="%s">%s</a></p>
-				<p>此链接将在1小时后失效。</p>
-			</body>
-			</html>
-		`, resetLink, resetLink)
+func (s *EmailService) WelcomeMessage(locale, to, username, verifyLink string, expireHours int) (Message, error) {
+	data := WelcomeData{
+		Username:    username,
+		VerifyLink:  verifyLink,
+		ExpireHours: expireHours,
 	}
-
-	return s.Send(to, "重置密码 - YSM模型站", body)
+	return s.Render(locale, TemplateWelcome, to, data)
 }
 
-func (s *EmailService) SendWelcome(to, username, verifyLink string) error {
+// VerifyEmailMessage 重新发送的邮箱验证链接
+func (s *EmailService) VerifyEmailMessage(locale, to, username, verifyLink string, expireHours int) (Message, error) {
 	data := WelcomeData{
-		Username:   username,
-		VerifyLink: verifyLink,
-	}
-	body, err := s.renderTemplate("welcome", data)
-	if err != nil {
-		body = fmt.Sprintf(`
-			<html>
-			<body>
-				<h2>欢迎注册 YSM模型站</h2>
-				<p>您好，%s！</p>
-				<p>请点击以下链接验证您的邮箱：</p>
-				<p><a href="%s">%s</a></p>
-			</body>
-			</html>
-		`, username, verifyLink, verifyLink)
+		Username:    username,
+		VerifyLink:  verifyLink,
+		ExpireHours: expireHours,
 	}
+	return s.Render(locale, TemplateVerifyEmail, to, data)
+}
 
-	return s.Send(to, "欢迎注册 - YSM模型站", body)
+// EmailChangeMessage 发往新邮箱的验证链接
+func (s *EmailService) EmailChangeMessage(locale, newEmail, username, verifyLink string, expireHours int) (Message, error) {
+	data := EmailChangeData{
+		Username:    username,
+		NewEmail:    newEmail,
+		VerifyLink:  verifyLink,
+		ExpireHours: expireHours,
+	}
+	return s.Render(locale, TemplateEmailChange, newEmail, data)
 }
 
-func (s *EmailService) SendModelReview(to, username, modelTitle, status, reason, modelLink string) error {
+func (s *EmailService) ModelReviewMessage(locale, to, username, modelTitle, status, reason, modelLink string) (Message, error) {
 	data := ModelReviewData{
 		Username:   username,
 		ModelTitle: modelTitle,
@@ -175,30 +309,19 @@ func (s *EmailService) SendModelReview(to, username, modelTitle, status, reason,
 		Reason:     reason,
 		ModelLink:  modelLink,
 	}
-	body, err := s.renderTemplate("model_review", data)
-	if err != nil {
-		statusText := "已通过"
-		if status == "rejected" {
-			statusText = "未通过"
-		}
-		body = fmt.Sprintf(`
-			<html>
-			<body>
-				<h2>模型审核通知</h2>
-				<p>您好，%s！</p>
-				<p>您的模型「%s」审核%s。</p>
-				<p><a href="%s">查看详情</a></p>
-			</body>
-			</html>
-		`, username, modelTitle, statusText, modelLink)
-	}
+	return s.Render(locale, TemplateModelReview, to, data)
+}
 
-	subject := "模型审核通过 - YSM模型站"
-	if status == "rejected" {
-		subject = "模型审核未通过 - YSM模型站"
-	}
+func (s *EmailService) SecurityAlertMessage(locale, to string, data SecurityAlertData) (Message, error) {
+	return s.Render(locale, TemplateSecurityAlert, to, data)
+}
+
+func (s *EmailService) NotificationMessage(locale, to string, data NotificationData) (Message, error) {
+	return s.Render(locale, TemplateNotification, to, data)
+}
 
-	return s.Send(to, subject, body)
+func (s *EmailService) DigestMessage(locale, to string, data DigestData) (Message, error) {
+	return s.Render(locale, TemplateDigest, to, data)
 }
 
 func (s *EmailService) IsConfigured() bool {
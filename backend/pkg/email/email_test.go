@@ -0,0 +1,105 @@
+package email
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ysmmc/backend/pkg/email/emailtest"
+)
+
+func newTestService(srv *emailtest.Server) *EmailService {
+	return New(Config{
+		Host:     srv.Host(),
+		Port:     srv.Port(),
+		User:     "mailer@ysmmc.local",
+		Password: "secret",
+		From:     "YSM <mailer@ysmmc.local>",
+	})
+}
+
+func TestSend_DeliversMessage(t *testing.T) {
+	srv := emailtest.NewServer(t)
+	svc := newTestService(srv)
+
+	if err := svc.SendMessage(Message{To: "steve@example.com", Subject: "Hello", Body: "<p>hi</p>"}); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+
+	messages := srv.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(messages))
+	}
+	got := messages[0]
+	if len(got.To) != 1 || got.To[0] != "steve@example.com" {
+		t.Errorf("unexpected recipients %v", got.To)
+	}
+	if !strings.Contains(got.Data, "Subject: Hello") || !strings.Contains(got.Data, "<p>hi</p>") {
+		t.Errorf("unexpected message data %q", got.Data)
+	}
+}
+
+func TestSend_PermanentRejection(t *testing.T) {
+	srv := emailtest.NewServer(t)
+	srv.RejectRecipients("550 5.1.1 user unknown")
+
+	err := newTestService(srv).Send("nobody@example.com", "Hello", "body")
+	if err == nil {
+		t.Fatal("expected rejected recipient to fail")
+	}
+	if !IsPermanent(err) {
+		t.Errorf("550 should be permanent, got %v", err)
+	}
+}
+
+func TestSend_TemporaryRejection(t *testing.T) {
+	srv := emailtest.NewServer(t)
+	srv.RejectRecipients("451 4.7.1 greylisted, try again later")
+
+	err := newTestService(srv).Send("steve@example.com", "Hello", "body")
+	if err == nil {
+		t.Fatal("expected greylisted recipient to fail")
+	}
+	if IsPermanent(err) {
+		t.Errorf("451 should be retried, got %v", err)
+	}
+}
+
+func TestSend_ServerUnavailable(t *testing.T) {
+	srv := emailtest.NewServer(t)
+	svc := newTestService(srv)
+	srv.Close()
+
+	err := svc.Send("steve@example.com", "Hello", "body")
+	if err == nil || IsPermanent(err) {
+		t.Errorf("connection failure should be retried, got %v", err)
+	}
+}
+
+func TestIsPermanent(t *testing.T) {
+	if !IsPermanent(New(Config{}).Send("a@b.c", "s", "b")) {
+		t.Error("missing SMTP configuration should be permanent")
+	}
+	if IsPermanent(fmt.Errorf("wrapped: %w", errors.New("i/o timeout"))) {
+		t.Error("timeouts should be retried")
+	}
+}
+
+func TestSend_MultipartAlternative(t *testing.T) {
+	srv := emailtest.NewServer(t)
+	msg, err := newTestService(srv).Preview(LocaleZhCN, TemplateResetPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := newTestService(srv).SendMessage(msg); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+
+	data := srv.Messages()[0].Data
+	for _, want := range []string{"multipart/alternative", "Content-Type: text/plain; charset=UTF-8", "Content-Type: text/html; charset=UTF-8", "Subject: =?UTF-8?q?"} {
+		if !strings.Contains(data, want) {
+			t.Errorf("message is missing %q", want)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+// Package emailtest 提供测试用的进程内 SMTP 服务器，记录收到的邮件并可模拟服务器拒收。
+package emailtest
+
+import (
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Message 服务器收到的一封邮件
+type Message struct {
+	From string
+	To   []string
+	Data string
+}
+
+// Server 只实现发送邮件所需的 EHLO、AUTH PLAIN、MAIL、RCPT、DATA 命令，不支持 STARTTLS
+type Server struct {
+	ln net.Listener
+
+	mu       sync.Mutex
+	messages []Message
+	// rcptReply 不为空时以该应答拒绝 RCPT 命令，例如 "550 5.1.1 user unknown"
+	rcptReply string
+}
+
+// NewServer 在 127.0.0.1 的随机端口启动服务器，测试结束时自动关闭
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("emailtest: failed to listen: %v", err)
+	}
+	s := &Server{ln: ln}
+	go s.serve()
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *Server) Host() string {
+	return s.ln.Addr().(*net.TCPAddr).IP.String()
+}
+
+func (s *Server) Port() int {
+	return s.ln.Addr().(*net.TCPAddr).Port
+}
+
+func (s *Server) Close() {
+	s.ln.Close()
+}
+
+// RejectRecipients 之后的 RCPT 命令都以 reply 应答；传入空字符串恢复正常
+func (s *Server) RejectRecipients(reply string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rcptReply = reply
+}
+
+// Messages 返回已收到的邮件
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+func (s *Server) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	reply := func(line string) bool {
+		return tp.PrintfLine("%s", line) == nil
+	}
+
+	if !reply("220 localhost ESMTP emailtest") {
+		return
+	}
+
+	var current Message
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			reply("250-localhost")
+			reply("250 AUTH PLAIN")
+		case "AUTH":
+			reply("235 2.7.0 authenticated")
+		case "MAIL":
+			current = Message{From: addressOf(arg)}
+			reply("250 2.1.0 ok")
+		case "RCPT":
+			s.mu.Lock()
+			rejection := s.rcptReply
+			s.mu.Unlock()
+			if rejection != "" {
+				reply(rejection)
+				continue
+			}
+			current.To = append(current.To, addressOf(arg))
+			reply("250 2.1.5 ok")
+		case "DATA":
+			reply("354 end data with <CR><LF>.<CR><LF>")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			current.Data = string(data)
+			s.mu.Lock()
+			s.messages = append(s.messages, current)
+			s.mu.Unlock()
+			reply("250 2.0.0 queued as " + strconv.Itoa(len(s.Messages())))
+		case "RSET", "NOOP":
+			reply("250 2.0.0 ok")
+		case "QUIT":
+			reply("221 2.0.0 bye")
+			return
+		default:
+			reply("502 5.5.2 command not implemented")
+		}
+	}
+}
+
+// addressOf 从 "FROM:<a@b.c>" 形式的参数中取出地址
+func addressOf(arg string) string {
+	if i := strings.Index(arg, "<"); i >= 0 {
+		if j := strings.Index(arg[i:], ">"); j >= 0 {
+			return arg[i+1 : i+j]
+		}
+	}
+	return arg
+}
@@ -0,0 +1,221 @@
+package email
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/ysmmc/backend/templates"
+)
+
+// 支持的邮件语言
+const (
+	LocaleZhCN    = "zh-CN"
+	LocaleEn      = "en"
+	DefaultLocale = LocaleZhCN
+)
+
+// Locales 所有支持的语言
+var Locales = []string{LocaleZhCN, LocaleEn}
+
+// 邮件模板名称，对应 templates/emails/<语言>/<名称>.html 与 .txt
+const (
+	TemplateWelcome       = "welcome"
+	TemplateVerifyEmail   = "verify_email"
+	TemplateEmailChange   = "email_change"
+	TemplateResetPassword = "reset_password"
+	TemplateModelReview   = "model_review"
+	TemplateSecurityAlert = "security_alert"
+	TemplateNotification  = "notification"
+	TemplateDigest        = "digest"
+)
+
+// ErrUnknownTemplate 模板名称不存在
+var ErrUnknownTemplate = errors.New("unknown email template")
+
+// sampleData 各模板的示例数据，用于预览
+var sampleData = map[string]interface{}{
+	TemplateWelcome: WelcomeData{
+		Username:    "Steve",
+		VerifyLink:  "https://example.com/verify-email?token=sample",
+		ExpireHours: 24,
+	},
+	TemplateVerifyEmail: WelcomeData{
+		Username:    "Steve",
+		VerifyLink:  "https://example.com/verify-email?token=sample",
+		ExpireHours: 24,
+	},
+	TemplateEmailChange: EmailChangeData{
+		Username:    "Steve",
+		NewEmail:    "steve@example.com",
+		VerifyLink:  "https://example.com/verify-email-change?token=sample",
+		ExpireHours: 1,
+	},
+	TemplateResetPassword: ResetPasswordData{
+		ResetLink: "https://example.com/update-password?token=sample",
+	},
+	TemplateModelReview: ModelReviewData{
+		Username:   "Steve",
+		ModelTitle: "Sample Model",
+		Status:     "rejected",
+		Reason:     "Preview image is missing",
+		ModelLink:  "https://example.com/model/sample",
+	},
+	TemplateSecurityAlert: SecurityAlertData{
+		Username:   "Steve",
+		Title:      "New sign-in",
+		Message:    "Your account was signed in from a new device.",
+		Time:       "2026-01-01 12:00:00",
+		IP:         "203.0.113.7",
+		Device:     "Chrome on Windows",
+		ActionLink: "https://example.com/profile",
+	},
+	TemplateNotification: NotificationData{
+		Username:        "Steve",
+		Title:           "Model approved",
+		Body:            "Your model \"Sample Model\" has been approved.",
+		Link:            "https://example.com/model/sample",
+		SettingsLink:    "https://example.com/settings/notifications",
+		UnsubscribeLink: "https://example.com/unsubscribe?token=sample",
+	},
+	TemplateDigest: DigestData{
+		Username:  "Steve",
+		Frequency: "daily",
+		Items: []DigestItem{
+			{Title: "New version", Body: "Sample Model 1.2.0 is available.", Link: "https://example.com/model/sample", Time: "2026-01-01 09:30"},
+			{Title: "Model approved", Body: "Your model \"Another Model\" has been approved.", Time: "2026-01-01 15:12"},
+		},
+		SettingsLink:    "https://example.com/settings/notifications",
+		UnsubscribeLink: "https://example.com/unsubscribe?token=sample",
+	},
+}
+
+// TemplateNames 所有模板名称
+func TemplateNames() []string {
+	return []string{
+		TemplateWelcome, TemplateVerifyEmail, TemplateEmailChange, TemplateResetPassword,
+		TemplateModelReview, TemplateSecurityAlert, TemplateNotification, TemplateDigest,
+	}
+}
+
+// IsSupportedLocale 判断是否为支持的语言
+func IsSupportedLocale(locale string) bool {
+	for _, l := range Locales {
+		if l == locale {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeLocale 将 "en-US"、"zh" 或 Accept-Language 头映射为支持的语言，
+// 按出现顺序取第一个可识别的，都无法识别时返回默认语言
+func NormalizeLocale(tags string) string {
+	for _, tag := range strings.Split(tags, ",") {
+		tag, _, _ = strings.Cut(tag, ";")
+		base, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		switch base {
+		case "zh":
+			return LocaleZhCN
+		case "en":
+			return LocaleEn
+		}
+	}
+	return DefaultLocale
+}
+
+// templateFS 依次在覆盖目录与内置模板中查找文件
+type templateFS []fs.FS
+
+func newTemplateFS(overrideDir string) templateFS {
+	embedded, err := fs.Sub(templates.FS, "emails")
+	if err != nil {
+		panic(err)
+	}
+	if overrideDir == "" {
+		return templateFS{embedded}
+	}
+	return templateFS{os.DirFS(overrideDir), embedded}
+}
+
+func (t templateFS) Open(name string) (fs.File, error) {
+	var err error
+	for _, fsys := range t {
+		var f fs.File
+		if f, err = fsys.Open(name); err == nil || !errors.Is(err, fs.ErrNotExist) {
+			return f, err
+		}
+	}
+	return nil, err
+}
+
+// readTemplate 读取指定语言的模板，该语言缺少此文件时使用默认语言
+func (s *EmailService) readTemplate(locale, file string) (string, error) {
+	content, err := fs.ReadFile(s.templates, path.Join(locale, file))
+	if errors.Is(err, fs.ErrNotExist) && locale != DefaultLocale {
+		content, err = fs.ReadFile(s.templates, path.Join(DefaultLocale, file))
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read template %s: %w", file, err)
+	}
+	return string(content), nil
+}
+
+// Render 渲染模板：.txt 提供标题与纯文本正文，.html 提供 HTML 正文
+func (s *EmailService) Render(locale, name, to string, data interface{}) (Message, error) {
+	if _, ok := sampleData[name]; !ok {
+		return Message{}, ErrUnknownTemplate
+	}
+	locale = NormalizeLocale(locale)
+
+	htmlSrc, err := s.readTemplate(locale, name+".html")
+	if err != nil {
+		return Message{}, err
+	}
+	textSrc, err := s.readTemplate(locale, name+".txt")
+	if err != nil {
+		return Message{}, err
+	}
+
+	htmlTmpl, err := htmltemplate.New(name).Parse(htmlSrc)
+	if err != nil {
+		return Message{}, fmt.Errorf("failed to parse template %s.html: %w", name, err)
+	}
+	textTmpl, err := texttemplate.New(name).Parse(textSrc)
+	if err != nil {
+		return Message{}, fmt.Errorf("failed to parse template %s.txt: %w", name, err)
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := textTmpl.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, fmt.Errorf("failed to render subject of %s: %w", name, err)
+	}
+	if err := textTmpl.Execute(&text, data); err != nil {
+		return Message{}, fmt.Errorf("failed to render template %s.txt: %w", name, err)
+	}
+	if err := htmlTmpl.Execute(&html, data); err != nil {
+		return Message{}, fmt.Errorf("failed to render template %s.html: %w", name, err)
+	}
+
+	return Message{
+		To:      to,
+		Subject: strings.TrimSpace(subject.String()),
+		Body:    html.String(),
+		Text:    text.String(),
+	}, nil
+}
+
+// Preview 使用示例数据渲染模板
+func (s *EmailService) Preview(locale, name string) (Message, error) {
+	data, ok := sampleData[name]
+	if !ok {
+		return Message{}, ErrUnknownTemplate
+	}
+	return s.Render(locale, name, "steve@example.com", data)
+}
@@ -0,0 +1,84 @@
+package email
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRender_AllTemplates(t *testing.T) {
+	svc := New(Config{})
+	for _, locale := range Locales {
+		for _, name := range TemplateNames() {
+			msg, err := svc.Preview(locale, name)
+			if err != nil {
+				t.Errorf("Preview(%s, %s) error = %v", locale, name, err)
+				continue
+			}
+			if msg.Subject == "" || strings.Contains(msg.Subject, "\n") {
+				t.Errorf("%s/%s: bad subject %q", locale, name, msg.Subject)
+			}
+			if !strings.Contains(msg.Body, "<html") || strings.TrimSpace(msg.Text) == "" {
+				t.Errorf("%s/%s: missing html or text body", locale, name)
+			}
+			if strings.Contains(msg.Text, "subject") {
+				t.Errorf("%s/%s: subject definition leaked into text body", locale, name)
+			}
+		}
+	}
+}
+
+func TestRender_LocaleFallback(t *testing.T) {
+	svc := New(Config{})
+	en, _ := svc.Preview("en-US", TemplateWelcome)
+	zh, _ := svc.Preview("fr", TemplateWelcome)
+	if en.Subject != "Welcome to YSM Models" {
+		t.Errorf("en-US should use English template, got %q", en.Subject)
+	}
+	if zh.Subject != "欢迎注册 - YSM模型站" {
+		t.Errorf("unsupported locale should fall back to zh-CN, got %q", zh.Subject)
+	}
+	if _, err := svc.Preview(LocaleEn, "missing"); !errors.Is(err, ErrUnknownTemplate) {
+		t.Errorf("expected ErrUnknownTemplate, got %v", err)
+	}
+}
+
+func TestRender_OverrideDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, LocaleEn), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	override := `{{define "subject"}}Custom welcome{{end}}Hello {{.Username}}`
+	if err := os.WriteFile(filepath.Join(dir, LocaleEn, "welcome.txt"), []byte(override), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := New(Config{TemplateDir: dir}).Preview(LocaleEn, TemplateWelcome)
+	if err != nil {
+		t.Fatalf("Preview() error = %v", err)
+	}
+	if msg.Subject != "Custom welcome" || msg.Text != "Hello Steve" {
+		t.Errorf("override not applied: %q / %q", msg.Subject, msg.Text)
+	}
+	if !strings.Contains(msg.Body, "Verify email") {
+		t.Error("files missing from the override dir should come from the embedded templates")
+	}
+}
+
+func TestNormalizeLocale(t *testing.T) {
+	tests := map[string]string{
+		"":                        DefaultLocale,
+		"en":                      LocaleEn,
+		"EN-gb":                   LocaleEn,
+		"zh-TW":                   LocaleZhCN,
+		"fr-FR,en;q=0.8,zh;q=0.5": LocaleEn,
+		"de":                      DefaultLocale,
+	}
+	for in, want := range tests {
+		if got := NormalizeLocale(in); got != want {
+			t.Errorf("NormalizeLocale(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
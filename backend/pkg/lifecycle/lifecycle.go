@@ -0,0 +1,137 @@
+// Package lifecycle 管理后台任务的生命周期。
+//
+// 任务通过 Group.Go 启动并获得一个 context，Shutdown 开始时立即取消该 context，
+// 然后在关闭期限内等待全部任务返回。任务应在 context 取消后尽快退出；
+// 需要在重启后继续或失败后重试的一次性工作（如发送邮件）应放入任务队列，而不是依赖这里执行完毕。
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Group struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	wg      sync.WaitGroup
+	running map[string]int
+	closed  bool
+}
+
+func NewGroup() *Group {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Group{ctx: ctx, cancel: cancel, running: map[string]int{}}
+}
+
+// Go 在新的 goroutine 中执行 fn。Shutdown 开始后不再启动新的 goroutine，fn 会在当前 goroutine 中
+// 以已取消的 context 同步执行，依赖该 context 的工作会直接失败
+func (g *Group) Go(name string, fn func(ctx context.Context)) {
+	g.mu.Lock()
+	if g.closed {
+		g.mu.Unlock()
+		g.run(name, fn)
+		return
+	}
+	g.wg.Add(1)
+	g.running[name]++
+	g.mu.Unlock()
+
+	go func() {
+		defer func() {
+			g.mu.Lock()
+			g.running[name]--
+			if g.running[name] == 0 {
+				delete(g.running, name)
+			}
+			g.mu.Unlock()
+			g.wg.Done()
+		}()
+		g.run(name, fn)
+	}()
+}
+
+// run 执行任务并捕获 panic，避免单个后台任务导致整个服务退出
+func (g *Group) run(name string, fn func(ctx context.Context)) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("Background task panicked", "task", name, "panic", r, "stack", string(debug.Stack()))
+		}
+	}()
+	fn(g.ctx)
+}
+
+// Every 启动周期任务：立即执行一次 fn，此后每隔 interval 执行，context 取消后退出
+func (g *Group) Every(name string, interval time.Duration, fn func(ctx context.Context)) {
+	g.Go(name, func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			fn(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+// Shutdown 取消全部任务的 context 并等待其返回；ctx 先到期时返回仍在运行的任务名
+func (g *Group) Shutdown(ctx context.Context) error {
+	g.mu.Lock()
+	g.closed = true
+	g.mu.Unlock()
+	g.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("background tasks still running: %s", g.runningNames())
+	}
+}
+
+func (g *Group) runningNames() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	names := make([]string, 0, len(g.running))
+	for name, n := range g.running {
+		if n > 1 {
+			name = fmt.Sprintf("%s (%d)", name, n)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// Default 服务进程使用的任务组
+var Default = NewGroup()
+
+func Go(name string, fn func(ctx context.Context)) {
+	Default.Go(name, fn)
+}
+
+func Every(name string, interval time.Duration, fn func(ctx context.Context)) {
+	Default.Every(name, interval, fn)
+}
+
+func Shutdown(ctx context.Context) error {
+	return Default.Shutdown(ctx)
+}
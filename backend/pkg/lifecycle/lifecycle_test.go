@@ -0,0 +1,103 @@
+package lifecycle
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestShutdown_WaitsForRunningTasks(t *testing.T) {
+	g := NewGroup()
+	var finished atomic.Bool
+	started := make(chan struct{})
+
+	g.Go("send", func(ctx context.Context) {
+		close(started)
+		<-ctx.Done()
+		time.Sleep(20 * time.Millisecond)
+		finished.Store(true)
+	})
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := g.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if !finished.Load() {
+		t.Error("Shutdown returned before the task finished")
+	}
+}
+
+func TestShutdown_ReportsStuckTasks(t *testing.T) {
+	g := NewGroup()
+	release := make(chan struct{})
+	defer close(release)
+
+	g.Go("stuck", func(ctx context.Context) { <-release })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := g.Shutdown(ctx)
+	if err == nil || !strings.Contains(err.Error(), "stuck") {
+		t.Errorf("expected error naming the stuck task, got %v", err)
+	}
+}
+
+func TestEvery_RunsImmediatelyAndStopsOnShutdown(t *testing.T) {
+	g := NewGroup()
+	var runs atomic.Int32
+	first := make(chan struct{}, 1)
+
+	g.Every("tick", time.Hour, func(ctx context.Context) {
+		runs.Add(1)
+		select {
+		case first <- struct{}{}:
+		default:
+		}
+	})
+
+	select {
+	case <-first:
+	case <-time.After(time.Second):
+		t.Fatal("periodic task did not run immediately")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := g.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if runs.Load() != 1 {
+		t.Errorf("expected 1 run, got %d", runs.Load())
+	}
+}
+
+func TestGo_AfterShutdownRunsSynchronously(t *testing.T) {
+	g := NewGroup()
+	g.Shutdown(context.Background())
+
+	ran := false
+	g.Go("late", func(ctx context.Context) {
+		if ctx.Err() == nil {
+			t.Error("late task should receive a cancelled context")
+		}
+		ran = true
+	})
+	if !ran {
+		t.Error("task submitted after shutdown should run before Go returns")
+	}
+}
+
+func TestGo_RecoversPanics(t *testing.T) {
+	g := NewGroup()
+	g.Go("boom", func(ctx context.Context) { panic("boom") })
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := g.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+}
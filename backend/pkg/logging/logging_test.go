@@ -0,0 +1,58 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestNew_AddsContextFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, slog.LevelInfo)
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	ctx = With(ctx, slog.String("job_kind", "email.deliver"))
+	logger.InfoContext(ctx, "hello", "count", 3)
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("expected a JSON log line, got %q: %v", buf.String(), err)
+	}
+	if entry["msg"] != "hello" || entry["request_id"] != "req-1" || entry["job_kind"] != "email.deliver" || entry["count"] != float64(3) {
+		t.Errorf("unexpected log entry %v", entry)
+	}
+}
+
+func TestNew_FiltersByLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, slog.LevelWarn)
+
+	logger.Info("ignored")
+	if buf.Len() != 0 {
+		t.Errorf("expected INFO to be dropped at WARN level, got %q", buf.String())
+	}
+	logger.Warn("kept")
+	if buf.Len() == 0 {
+		t.Error("expected WARN to be logged")
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for input, want := range map[string]slog.Level{"debug": slog.LevelDebug, "INFO": slog.LevelInfo, "warn": slog.LevelWarn, "error": slog.LevelError} {
+		got, err := ParseLevel(input)
+		if err != nil || got != want {
+			t.Errorf("ParseLevel(%q) = %v, %v; want %v", input, got, err, want)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("expected unknown level to be rejected")
+	}
+}
+
+func TestRequestID_Missing(t *testing.T) {
+	if id := RequestID(context.Background()); id != "" {
+		t.Errorf("expected empty request id, got %q", id)
+	}
+}
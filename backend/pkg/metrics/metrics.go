@@ -0,0 +1,360 @@
+// Package metrics 提供计数器、仪表与直方图，并以 Prometheus 文本格式（0.0.4）输出。
+//
+// 指标在使用处以包级变量定义，创建时注册到 Default；抓取时按名称排序输出，
+// 标签值相同的样本合并为同一条序列。标签只应使用取值有限的字段（路由模板、状态码等），
+// 不要使用用户 ID、请求路径等，避免序列数量无限增长。
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType Prometheus 文本格式的 Content-Type
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets 以秒为单位的耗时分桶
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Collector 一组同名的指标
+type Collector interface {
+	Name() string
+	write(b *strings.Builder)
+}
+
+// Registry 保存已注册的指标
+type Registry struct {
+	mu         sync.Mutex
+	collectors map[string]Collector
+	hooks      []func()
+}
+
+// Default 默认的 Registry，New* 函数创建的指标注册在这里
+var Default = NewRegistry()
+
+func NewRegistry() *Registry {
+	return &Registry{collectors: make(map[string]Collector)}
+}
+
+// Register 注册指标，名称重复时 panic
+func (r *Registry) Register(c Collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.collectors[c.Name()]; exists {
+		panic("metrics: duplicate metric " + c.Name())
+	}
+	r.collectors[c.Name()] = c
+}
+
+// OnCollect 注册每次输出前执行的函数，用于刷新需要主动采集的仪表（如磁盘用量）
+func (r *Registry) OnCollect(fn func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hooks = append(r.hooks, fn)
+}
+
+// WriteText 以文本格式输出全部指标
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	hooks := append([]func(){}, r.hooks...)
+	collectors := make([]Collector, 0, len(r.collectors))
+	for _, c := range r.collectors {
+		collectors = append(collectors, c)
+	}
+	r.mu.Unlock()
+
+	for _, hook := range hooks {
+		hook()
+	}
+	sort.Slice(collectors, func(i, j int) bool { return collectors[i].Name() < collectors[j].Name() })
+
+	var b strings.Builder
+	for _, c := range collectors {
+		c.write(&b)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Handler 输出 Default 中指标的 http.Handler
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		Default.WriteText(w)
+	})
+}
+
+// desc 指标的名称、说明与标签名
+type desc struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+}
+
+func (d *desc) Name() string {
+	return d.name
+}
+
+func (d *desc) writeHeader(b *strings.Builder) {
+	fmt.Fprintf(b, "# HELP %s %s\n", d.name, escapeHelp(d.help))
+	fmt.Fprintf(b, "# TYPE %s %s\n", d.name, d.kind)
+}
+
+func (d *desc) key(values []string) string {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", d.name, len(d.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// vec 按标签值保存序列
+type vec[T any] struct {
+	desc
+	mu     sync.Mutex
+	series map[string]*T
+	values map[string][]string
+	newFn  func() *T
+}
+
+func (v *vec[T]) get(values []string) *T {
+	key := v.key(values)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	s, ok := v.series[key]
+	if !ok {
+		s = v.newFn()
+		v.series[key] = s
+		v.values[key] = append([]string(nil), values...)
+	}
+	return s
+}
+
+// each 按标签值排序遍历序列，调用时持有锁
+func (v *vec[T]) each(fn func(labels string, s *T)) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	keys := make([]string, 0, len(v.series))
+	for key := range v.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fn(formatLabels(v.labels, v.values[key]), v.series[key])
+	}
+}
+
+// init 初始化序列集合；没有标签时预先创建唯一的序列，未观测时也输出 0
+func (v *vec[T]) init(kind, name, help string, labels []string, newFn func() *T) {
+	v.desc = desc{name: name, help: help, kind: kind, labels: labels}
+	v.series = make(map[string]*T)
+	v.values = make(map[string][]string)
+	v.newFn = newFn
+	if len(labels) == 0 {
+		v.series[""] = newFn()
+	}
+}
+
+type value struct {
+	v float64
+}
+
+// CounterVec 只增不减的计数器
+type CounterVec struct {
+	vec[value]
+}
+
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{}
+	c.init("counter", name, help, labels, func() *value { return &value{} })
+	Default.Register(c)
+	return c
+}
+
+// Inc 计数加一，参数为与标签名一一对应的标签值
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add 计数增加 delta，delta 不能为负
+func (c *CounterVec) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		panic("metrics: counter " + c.name + " cannot decrease")
+	}
+	s := c.get(labelValues)
+	c.mu.Lock()
+	s.v += delta
+	c.mu.Unlock()
+}
+
+func (c *CounterVec) write(b *strings.Builder) {
+	c.writeHeader(b)
+	c.each(func(labels string, s *value) {
+		writeSample(b, c.name, labels, s.v)
+	})
+}
+
+// GaugeVec 可增可减的仪表
+type GaugeVec struct {
+	vec[value]
+}
+
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{}
+	g.init("gauge", name, help, labels, func() *value { return &value{} })
+	Default.Register(g)
+	return g
+}
+
+func (g *GaugeVec) Set(v float64, labelValues ...string) {
+	s := g.get(labelValues)
+	g.mu.Lock()
+	s.v = v
+	g.mu.Unlock()
+}
+
+func (g *GaugeVec) Add(delta float64, labelValues ...string) {
+	s := g.get(labelValues)
+	g.mu.Lock()
+	s.v += delta
+	g.mu.Unlock()
+}
+
+func (g *GaugeVec) write(b *strings.Builder) {
+	g.writeHeader(b)
+	g.each(func(labels string, s *value) {
+		writeSample(b, g.name, labels, s.v)
+	})
+}
+
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// HistogramVec 按分桶统计观测值的分布
+type HistogramVec struct {
+	vec[histogram]
+	buckets []float64
+}
+
+// NewHistogramVec 创建直方图，buckets 为升序的分桶上界，+Inf 自动添加
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if !sort.Float64sAreSorted(buckets) {
+		panic("metrics: buckets of " + name + " must be sorted")
+	}
+	h := &HistogramVec{buckets: buckets}
+	h.init("histogram", name, help, labels, func() *histogram { return &histogram{counts: make([]uint64, len(buckets))} })
+	Default.Register(h)
+	return h
+}
+
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	s := h.get(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.sum += v
+	s.count++
+}
+
+func (h *HistogramVec) write(b *strings.Builder) {
+	h.writeHeader(b)
+	h.each(func(labels string, s *histogram) {
+		var cumulative uint64
+		for i, upper := range h.buckets {
+			cumulative += s.counts[i]
+			writeSample(b, h.name+"_bucket", withLabel(labels, "le", formatFloat(upper)), float64(cumulative))
+		}
+		writeSample(b, h.name+"_bucket", withLabel(labels, "le", "+Inf"), float64(s.count))
+		writeSample(b, h.name+"_sum", labels, s.sum)
+		writeSample(b, h.name+"_count", labels, float64(s.count))
+	})
+}
+
+// Func 抓取时调用函数取值的指标，用于连接池等已有统计
+type Func struct {
+	desc
+	fn func() float64
+}
+
+// NewGaugeFunc 创建抓取时取值的仪表
+func NewGaugeFunc(name, help string, fn func() float64) *Func {
+	f := &Func{desc: desc{name: name, help: help, kind: "gauge"}, fn: fn}
+	Default.Register(f)
+	return f
+}
+
+// NewCounterFunc 创建抓取时取值的计数器，fn 的返回值不应减小
+func NewCounterFunc(name, help string, fn func() float64) *Func {
+	f := &Func{desc: desc{name: name, help: help, kind: "counter"}, fn: fn}
+	Default.Register(f)
+	return f
+}
+
+func (f *Func) write(b *strings.Builder) {
+	f.writeHeader(b)
+	writeSample(b, f.name, "", f.fn())
+}
+
+func writeSample(b *strings.Builder, name, labels string, v float64) {
+	b.WriteString(name)
+	if labels != "" {
+		b.WriteString("{" + labels + "}")
+	}
+	b.WriteString(" " + formatFloat(v) + "\n")
+}
+
+func formatLabels(names, values []string) string {
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + `="` + escapeLabel(values[i]) + `"`
+	}
+	return strings.Join(pairs, ",")
+}
+
+func withLabel(labels, name, value string) string {
+	pair := name + `="` + value + `"`
+	if labels == "" {
+		return pair
+	}
+	return labels + "," + pair
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
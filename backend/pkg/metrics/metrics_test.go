@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteText_CounterAndGauge(t *testing.T) {
+	requests := NewCounterVec("test_requests_total", "Requests handled.", "method", "status")
+	requests.Inc("GET", "200")
+	requests.Inc("GET", "200")
+	requests.Add(3, "POST", "500")
+	inFlight := NewGaugeVec("test_in_flight", "Requests in flight.")
+	inFlight.Add(2)
+	inFlight.Add(-1)
+
+	registry := NewRegistry()
+	registry.Register(requests)
+	registry.Register(inFlight)
+
+	var buf bytes.Buffer
+	if err := registry.WriteText(&buf); err != nil {
+		t.Fatalf("WriteText failed: %v", err)
+	}
+	want := `# HELP test_in_flight Requests in flight.
+# TYPE test_in_flight gauge
+test_in_flight 1
+# HELP test_requests_total Requests handled.
+# TYPE test_requests_total counter
+test_requests_total{method="GET",status="200"} 2
+test_requests_total{method="POST",status="500"} 3
+`
+	if buf.String() != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestWriteText_Histogram(t *testing.T) {
+	durations := NewHistogramVec("test_duration_seconds", "Durations.", []float64{0.1, 1}, "route")
+	durations.Observe(0.05, "/a")
+	durations.Observe(0.1, "/a")
+	durations.Observe(0.5, "/a")
+	durations.Observe(5, "/a")
+
+	registry := NewRegistry()
+	registry.Register(durations)
+
+	var buf bytes.Buffer
+	registry.WriteText(&buf)
+	for _, line := range []string{
+		`test_duration_seconds_bucket{route="/a",le="0.1"} 2`,
+		`test_duration_seconds_bucket{route="/a",le="1"} 3`,
+		`test_duration_seconds_bucket{route="/a",le="+Inf"} 4`,
+		`test_duration_seconds_sum{route="/a"} 5.65`,
+		`test_duration_seconds_count{route="/a"} 4`,
+	} {
+		if !strings.Contains(buf.String(), line+"\n") {
+			t.Errorf("expected line %q in output:\n%s", line, buf.String())
+		}
+	}
+}
+
+func TestWriteText_EscapesLabelValues(t *testing.T) {
+	errors := NewCounterVec("test_errors_total", "Errors.\nSecond line.", "message")
+	errors.Inc("say \"hi\"\n\\")
+
+	registry := NewRegistry()
+	registry.Register(errors)
+
+	var buf bytes.Buffer
+	registry.WriteText(&buf)
+	if !strings.Contains(buf.String(), `# HELP test_errors_total Errors.\nSecond line.`) {
+		t.Errorf("expected escaped help, got:\n%s", buf.String())
+	}
+	if !strings.Contains(buf.String(), `test_errors_total{message="say \"hi\"\n\\"} 1`) {
+		t.Errorf("expected escaped label value, got:\n%s", buf.String())
+	}
+}
+
+func TestWriteText_RunsCollectHooks(t *testing.T) {
+	usage := NewGaugeVec("test_usage_ratio", "Usage.")
+	registry := NewRegistry()
+	registry.Register(usage)
+	registry.OnCollect(func() { usage.Set(0.5) })
+
+	var buf bytes.Buffer
+	registry.WriteText(&buf)
+	if !strings.Contains(buf.String(), "test_usage_ratio 0.5\n") {
+		t.Errorf("expected hook to refresh gauge, got:\n%s", buf.String())
+	}
+}
+
+func TestRegister_DuplicatePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected duplicate registration to panic")
+		}
+	}()
+	NewGaugeFunc("test_duplicate", "Duplicate.", func() float64 { return 1 })
+	NewGaugeFunc("test_duplicate", "Duplicate.", func() float64 { return 1 })
+}
+
+func TestCounterVec_WrongLabelCountPanics(t *testing.T) {
+	counter := NewCounterVec("test_labels_total", "Labels.", "kind")
+	defer func() {
+		if recover() == nil {
+			t.Error("expected wrong label count to panic")
+		}
+	}()
+	counter.Inc()
+}
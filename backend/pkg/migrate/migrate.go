@@ -0,0 +1,373 @@
+// Package migrate 按版本号顺序执行嵌入的 SQL 迁移脚本。
+//
+// 脚本命名为 <版本号>_<名称>.up.sql 与 <版本号>_<名称>.down.sql，每个版本在独立事务中执行，
+// 已执行的版本及其 up 脚本的 SHA-256 记录在 schema_migrations 表中。
+// 执行期间持有 PostgreSQL advisory lock，多个实例同时启动时只有一个会执行迁移。
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultLockKey 迁移使用的 advisory lock 键
+	DefaultLockKey int64 = 0x7973_6d6d_6300
+	DefaultTable         = "schema_migrations"
+)
+
+var fileNamePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+func (m Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+// Status 单个迁移的执行状态。Modified 表示已执行后脚本内容被修改；
+// Missing 表示数据库中记录的版本在当前程序中不存在（数据库比程序新）
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt *time.Time
+	Modified  bool
+	Missing   bool
+}
+
+type appliedRecord struct {
+	Version   int64
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// Load 读取 fsys 根目录下的迁移脚本并按版本号排序；每个版本必须同时有 up 与 down 脚本
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+		match := fileNamePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %q", entry.Name())
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration version in %q", entry.Name())
+		}
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(content)
+			m.Checksum = checksum(m.Up)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Checksum == "" {
+			return nil, fmt.Errorf("migration %s has no up script", m)
+		}
+		if m.Down == "" {
+			return nil, fmt.Errorf("migration %s has no down script", m)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+func checksum(script string) string {
+	sum := sha256.Sum256([]byte(script))
+	return hex.EncodeToString(sum[:])
+}
+
+// hasStatements 判断脚本在去掉注释与空白后是否还有内容，只有注释的 down 脚本表示无需回滚操作
+func hasStatements(script string) bool {
+	for _, line := range strings.Split(script, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "--") {
+			return true
+		}
+	}
+	return false
+}
+
+// verify 检查已执行的迁移脚本是否被修改
+func verify(migrations []Migration, applied map[int64]appliedRecord) error {
+	for _, m := range migrations {
+		record, ok := applied[m.Version]
+		if ok && record.Checksum != m.Checksum {
+			return fmt.Errorf("migration %s was modified after it was applied (checksum %s, expected %s)", m, m.Checksum[:12], record.Checksum[:12])
+		}
+	}
+	return nil
+}
+
+func pending(migrations []Migration, applied map[int64]appliedRecord) []Migration {
+	result := []Migration{}
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; !ok {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+func statuses(migrations []Migration, applied map[int64]appliedRecord) []Status {
+	result := []Status{}
+	known := map[int64]bool{}
+	for _, m := range migrations {
+		known[m.Version] = true
+		s := Status{Migration: m}
+		if record, ok := applied[m.Version]; ok {
+			at := record.AppliedAt
+			s.Applied, s.AppliedAt = true, &at
+			s.Modified = record.Checksum != m.Checksum
+		}
+		result = append(result, s)
+	}
+	for version, record := range applied {
+		if known[version] {
+			continue
+		}
+		at := record.AppliedAt
+		result = append(result, Status{
+			Migration: Migration{Version: version, Name: record.Name, Checksum: record.Checksum},
+			Applied:   true,
+			AppliedAt: &at,
+			Missing:   true,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Version < result[j].Version })
+	return result
+}
+
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+	Table      string
+	LockKey    int64
+}
+
+func New(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations, Table: DefaultTable, LockKey: DefaultLockKey}, nil
+}
+
+// Up 依次执行全部未执行的迁移，返回本次执行的迁移
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	done := []Migration{}
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := verify(m.migrations, applied); err != nil {
+			return err
+		}
+
+		for _, mg := range pending(m.migrations, applied) {
+			err := m.run(ctx, conn, mg.Up, func(tx *sql.Tx) error {
+				_, err := tx.ExecContext(ctx, "INSERT INTO "+m.Table+" (version, name, checksum) VALUES ($1, $2, $3)", mg.Version, mg.Name, mg.Checksum)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("migration %s: %w", mg, err)
+			}
+			done = append(done, mg)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down 按版本号倒序回滚最近执行的 steps 个迁移
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	if steps <= 0 {
+		return nil, errors.New("steps must be positive")
+	}
+
+	done := []Migration{}
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := verify(m.migrations, applied); err != nil {
+			return err
+		}
+
+		byVersion := map[int64]Migration{}
+		for _, mg := range m.migrations {
+			byVersion[mg.Version] = mg
+		}
+		versions := make([]int64, 0, len(applied))
+		for version := range applied {
+			versions = append(versions, version)
+		}
+		sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+
+		for i := 0; i < steps && i < len(versions); i++ {
+			mg, ok := byVersion[versions[i]]
+			if !ok {
+				return fmt.Errorf("migration %d is applied but unknown to this build", versions[i])
+			}
+			err := m.run(ctx, conn, mg.Down, func(tx *sql.Tx) error {
+				_, err := tx.ExecContext(ctx, "DELETE FROM "+m.Table+" WHERE version = $1", mg.Version)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("rollback %s: %w", mg, err)
+			}
+			done = append(done, mg)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status 返回全部迁移（包括数据库中存在但程序中缺失的版本）的执行状态
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var result []Status
+	err := m.withConn(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		result = statuses(m.migrations, applied)
+		return nil
+	})
+	return result, err
+}
+
+// Pending 返回尚未执行的迁移，已执行的脚本被修改时返回错误
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	var result []Migration
+	err := m.withConn(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := verify(m.migrations, applied); err != nil {
+			return err
+		}
+		result = pending(m.migrations, applied)
+		return nil
+	})
+	return result, err
+}
+
+func (m *Migrator) run(ctx context.Context, conn *sql.Conn, script string, record func(*sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if hasStatements(script) {
+		if _, err := tx.ExecContext(ctx, script); err != nil {
+			return err
+		}
+	}
+	if err := record(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (m *Migrator) withConn(ctx context.Context, fn func(*sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return fn(conn)
+}
+
+// withLock 在同一连接上持有会话级 advisory lock，其他实例会阻塞等待直到本次迁移完成
+func (m *Migrator) withLock(ctx context.Context, fn func(*sql.Conn) error) error {
+	return m.withConn(ctx, func(conn *sql.Conn) error {
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", m.LockKey); err != nil {
+			return fmt.Errorf("acquire migration lock: %w", err)
+		}
+		defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", m.LockKey)
+
+		if _, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+m.Table+` (
+			version BIGINT PRIMARY KEY,
+			name TEXT NOT NULL,
+			checksum CHAR(64) NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)`); err != nil {
+			return fmt.Errorf("create %s: %w", m.Table, err)
+		}
+		return fn(conn)
+	})
+}
+
+// applied 读取已执行的迁移；迁移表尚不存在时视为全部未执行
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) (map[int64]appliedRecord, error) {
+	applied := map[int64]appliedRecord{}
+
+	var exists bool
+	if err := conn.QueryRowContext(ctx, "SELECT to_regclass($1) IS NOT NULL", m.Table).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return applied, nil
+	}
+
+	rows, err := conn.QueryContext(ctx, "SELECT version, name, checksum, applied_at FROM "+m.Table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r appliedRecord
+		if err := rows.Scan(&r.Version, &r.Name, &r.Checksum, &r.AppliedAt); err != nil {
+			return nil, err
+		}
+		applied[r.Version] = r
+	}
+	return applied, rows.Err()
+}
@@ -0,0 +1,123 @@
+package migrate
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"0002_add_bio.up.sql":    {Data: []byte("ALTER TABLE users ADD COLUMN bio TEXT;")},
+		"0002_add_bio.down.sql":  {Data: []byte("ALTER TABLE users DROP COLUMN bio;")},
+		"0001_init.up.sql":       {Data: []byte("CREATE TABLE users (id UUID PRIMARY KEY);")},
+		"0001_init.down.sql":     {Data: []byte("DROP TABLE users;")},
+		"0010_backfill.up.sql":   {Data: []byte("UPDATE users SET bio = '';")},
+		"0010_backfill.down.sql": {Data: []byte("-- irreversible data migration\n")},
+		"README.md":              {Data: []byte("ignored")},
+	}
+}
+
+func TestLoad_SortsByVersion(t *testing.T) {
+	migrations, err := Load(testFS())
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if len(migrations) != 3 {
+		t.Fatalf("expected 3 migrations, got %d", len(migrations))
+	}
+
+	want := []string{"0001_init", "0002_add_bio", "0010_backfill"}
+	for i, m := range migrations {
+		if m.String() != want[i] {
+			t.Errorf("migration %d: expected %s, got %s", i, want[i], m)
+		}
+		if len(m.Checksum) != 64 {
+			t.Errorf("migration %s: expected sha256 checksum, got %q", m, m.Checksum)
+		}
+	}
+}
+
+func TestLoad_RejectsInvalidFiles(t *testing.T) {
+	tests := map[string]fstest.MapFS{
+		"bad name": {
+			"init.up.sql": {Data: []byte("SELECT 1;")},
+		},
+		"missing down": {
+			"0001_init.up.sql": {Data: []byte("SELECT 1;")},
+		},
+		"missing up": {
+			"0001_init.down.sql": {Data: []byte("SELECT 1;")},
+		},
+		"conflicting names": {
+			"0001_init.up.sql":    {Data: []byte("SELECT 1;")},
+			"0001_other.down.sql": {Data: []byte("SELECT 1;")},
+		},
+	}
+
+	for name, fsys := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Load(fsys); err == nil {
+				t.Errorf("expected load to fail for %s", name)
+			}
+		})
+	}
+}
+
+func TestPendingAndVerify(t *testing.T) {
+	migrations, _ := Load(testFS())
+	applied := map[int64]appliedRecord{
+		1: {Version: 1, Name: "init", Checksum: migrations[0].Checksum},
+	}
+
+	if err := verify(migrations, applied); err != nil {
+		t.Fatalf("unexpected verify error: %v", err)
+	}
+
+	p := pending(migrations, applied)
+	if len(p) != 2 || p[0].Version != 2 || p[1].Version != 10 {
+		t.Errorf("unexpected pending migrations: %v", p)
+	}
+
+	applied[1] = appliedRecord{Version: 1, Name: "init", Checksum: strings.Repeat("0", 64)}
+	if err := verify(migrations, applied); err == nil {
+		t.Error("expected verify to detect a modified migration")
+	}
+}
+
+func TestStatuses(t *testing.T) {
+	migrations, _ := Load(testFS())
+	now := time.Now()
+	applied := map[int64]appliedRecord{
+		1:  {Version: 1, Name: "init", Checksum: migrations[0].Checksum, AppliedAt: now},
+		2:  {Version: 2, Name: "add_bio", Checksum: strings.Repeat("0", 64), AppliedAt: now},
+		99: {Version: 99, Name: "from_newer_build", Checksum: strings.Repeat("1", 64), AppliedAt: now},
+	}
+
+	result := statuses(migrations, applied)
+	if len(result) != 4 {
+		t.Fatalf("expected 4 statuses, got %d", len(result))
+	}
+	if !result[0].Applied || result[0].Modified {
+		t.Errorf("0001 should be applied and unmodified: %+v", result[0])
+	}
+	if !result[1].Modified {
+		t.Errorf("0002 should be reported as modified")
+	}
+	if result[2].Applied {
+		t.Errorf("0010 should be pending")
+	}
+	if result[3].Version != 99 || !result[3].Missing {
+		t.Errorf("unknown applied version should be reported as missing: %+v", result[3])
+	}
+}
+
+func TestHasStatements(t *testing.T) {
+	if hasStatements("-- nothing to undo\n\n  -- data migration\n") {
+		t.Error("comment-only script should have no statements")
+	}
+	if !hasStatements("-- drop\nDROP TABLE users;") {
+		t.Error("script with a statement should be detected")
+	}
+}
@@ -0,0 +1,322 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testClientID     = "ysmmc-test"
+	testClientSecret = "s3cret"
+	testRedirectURI  = "http://localhost:5173/oauth/callback"
+)
+
+// mockProvider 本地模拟的 OIDC 提供方：发现文档、JWKS、授权码兑换
+type mockProvider struct {
+	t      *testing.T
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	kid    string
+
+	mu     sync.Mutex
+	codes  map[string]mockGrant
+	claims func(jwt.MapClaims)
+}
+
+type mockGrant struct {
+	challenge string
+	nonce     string
+}
+
+func newMockProvider(t *testing.T) *mockProvider {
+	t.Helper()
+	m := &mockProvider{t: t, codes: map[string]mockGrant{}}
+	m.rotateKey("key-1")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                           m.server.URL,
+			"authorization_endpoint":           m.server.URL + "/authorize",
+			"token_endpoint":                   m.server.URL + "/token",
+			"jwks_uri":                         m.server.URL + "/jwks",
+			"code_challenge_methods_supported": []string{"S256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": m.kid,
+				"use": "sig",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(m.key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(m.key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", m.handleToken)
+
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+func (m *mockProvider) rotateKey(kid string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		m.t.Fatalf("failed to generate rsa key: %v", err)
+	}
+	m.mu.Lock()
+	m.key, m.kid = key, kid
+	m.mu.Unlock()
+}
+
+// authorize 模拟用户在提供方完成授权，返回回调中的授权码
+func (m *mockProvider) authorize(authURL string) string {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		m.t.Fatalf("invalid authorization url: %v", err)
+	}
+	q := u.Query()
+	if q.Get("client_id") != testClientID || q.Get("redirect_uri") != testRedirectURI {
+		m.t.Fatalf("unexpected authorization request: %s", authURL)
+	}
+	if q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		m.t.Fatalf("authorization request must use PKCE S256: %s", authURL)
+	}
+
+	code, _ := RandomToken()
+	m.mu.Lock()
+	m.codes[code] = mockGrant{challenge: q.Get("code_challenge"), nonce: q.Get("nonce")}
+	m.mu.Unlock()
+	return code
+}
+
+func (m *mockProvider) handleToken(w http.ResponseWriter, r *http.Request) {
+	id, secret, ok := r.BasicAuth()
+	if !ok || id != testClientID || secret != testClientSecret {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	r.ParseForm()
+	m.mu.Lock()
+	grant, ok := m.codes[r.PostForm.Get("code")]
+	delete(m.codes, r.PostForm.Get("code"))
+	m.mu.Unlock()
+
+	if !ok || CodeChallengeS256(r.PostForm.Get("code_verifier")) != grant.challenge {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{
+		"access_token": "at",
+		"token_type":   "Bearer",
+		"id_token":     m.idToken(grant.nonce),
+	})
+}
+
+func (m *mockProvider) idToken(nonce string) string {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":            m.server.URL,
+		"sub":            "user-123",
+		"aud":            testClientID,
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"iat":            now.Unix(),
+		"nonce":          nonce,
+		"email":          "Alice@Example.com",
+		"email_verified": true,
+		"name":           "Alice",
+	}
+	if m.claims != nil {
+		m.claims(claims)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = m.kid
+	signed, err := token.SignedString(m.key)
+	if err != nil {
+		m.t.Fatalf("failed to sign id token: %v", err)
+	}
+	return signed
+}
+
+func (m *mockProvider) provider() *Provider {
+	return NewProvider(Config{
+		Name:         "mock",
+		Issuer:       m.server.URL,
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+	}, m.server.Client())
+}
+
+// login 走完整授权码流程，返回兑换结果
+func login(t *testing.T, m *mockProvider, p *Provider, verifierOverride string) (*Identity, error) {
+	t.Helper()
+	ctx := context.Background()
+	state, _ := RandomToken()
+	nonce, _ := RandomToken()
+	verifier, _ := RandomToken()
+
+	authURL, err := p.AuthCodeURL(ctx, testRedirectURI, state, nonce, CodeChallengeS256(verifier))
+	if err != nil {
+		t.Fatalf("failed to build authorization url: %v", err)
+	}
+	if !strings.Contains(authURL, "state="+state) {
+		t.Errorf("authorization url should carry state")
+	}
+
+	code := m.authorize(authURL)
+	if verifierOverride != "" {
+		verifier = verifierOverride
+	}
+	return p.Exchange(ctx, code, verifier, testRedirectURI, nonce)
+}
+
+func TestExchange_Success(t *testing.T) {
+	m := newMockProvider(t)
+
+	identity, err := login(t, m, m.provider(), "")
+	if err != nil {
+		t.Fatalf("exchange failed: %v", err)
+	}
+	if identity.Subject != "user-123" {
+		t.Errorf("expected subject user-123, got %s", identity.Subject)
+	}
+	if identity.Email != "alice@example.com" || !identity.EmailVerified {
+		t.Errorf("unexpected email claims: %+v", identity)
+	}
+}
+
+func TestExchange_RejectsWrongCodeVerifier(t *testing.T) {
+	m := newMockProvider(t)
+
+	wrong, _ := RandomToken()
+	if _, err := login(t, m, m.provider(), wrong); err == nil {
+		t.Error("exchange with a wrong PKCE verifier should fail")
+	}
+}
+
+func TestExchange_StringEmailVerified(t *testing.T) {
+	m := newMockProvider(t)
+	m.claims = func(c jwt.MapClaims) { c["email_verified"] = "true" }
+
+	identity, err := login(t, m, m.provider(), "")
+	if err != nil {
+		t.Fatalf("exchange failed: %v", err)
+	}
+	if !identity.EmailVerified {
+		t.Error("string email_verified should be accepted")
+	}
+}
+
+func TestVerifyIDToken_RejectsInvalidClaims(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(jwt.MapClaims)
+	}{
+		{"wrong audience", func(c jwt.MapClaims) { c["aud"] = "someone-else" }},
+		{"wrong issuer", func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }},
+		{"expired", func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() }},
+		{"missing expiry", func(c jwt.MapClaims) { delete(c, "exp") }},
+		{"nonce mismatch", func(c jwt.MapClaims) { c["nonce"] = "replayed" }},
+		{"foreign azp", func(c jwt.MapClaims) {
+			c["aud"] = []string{testClientID, "other"}
+			c["azp"] = "other"
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMockProvider(t)
+			m.claims = tt.modify
+			if _, err := login(t, m, m.provider(), ""); err == nil {
+				t.Errorf("expected id token with %s to be rejected", tt.name)
+			}
+		})
+	}
+}
+
+func TestVerifyIDToken_RejectsSymmetricAlgorithms(t *testing.T) {
+	m := newMockProvider(t)
+	p := m.provider()
+
+	claims := jwt.MapClaims{
+		"iss":   m.server.URL,
+		"sub":   "attacker",
+		"aud":   testClientID,
+		"exp":   time.Now().Add(time.Minute).Unix(),
+		"iat":   time.Now().Unix(),
+		"nonce": "n",
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = m.kid
+	forged, _ := token.SignedString([]byte(testClientSecret))
+
+	if _, err := p.VerifyIDToken(context.Background(), forged, "n"); err == nil {
+		t.Error("HS256 id token should be rejected")
+	}
+}
+
+func TestVerifyIDToken_RefetchesKeysAfterRotation(t *testing.T) {
+	m := newMockProvider(t)
+	p := m.provider()
+
+	if _, err := login(t, m, p, ""); err != nil {
+		t.Fatalf("first exchange failed: %v", err)
+	}
+
+	m.rotateKey("key-2")
+	// 模拟距上次拉取已超过刷新间隔
+	p.mu.Lock()
+	p.keysFetchedAt = time.Now().Add(-2 * keyRefreshInterval)
+	p.mu.Unlock()
+
+	if _, err := login(t, m, p, ""); err != nil {
+		t.Fatalf("exchange after key rotation failed: %v", err)
+	}
+}
+
+func TestDiscovery_RejectsIssuerMismatch(t *testing.T) {
+	m := newMockProvider(t)
+	p := NewProvider(Config{
+		Name:     "mock",
+		Issuer:   m.server.URL + "/other",
+		ClientID: testClientID,
+	}, m.server.Client())
+
+	if _, err := p.AuthCodeURL(context.Background(), testRedirectURI, "s", "n", "c"); err == nil {
+		t.Error("expected discovery to fail when issuer does not match")
+	}
+}
+
+func TestCodeChallengeS256(t *testing.T) {
+	// RFC 7636 附录 B
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	want := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+	if got := CodeChallengeS256(verifier); got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
@@ -0,0 +1,49 @@
+// Package pow 实现 hashcash 风格的工作量证明：客户端寻找 solution，
+// 使 SHA-256(challenge + ":" + solution) 的前 difficulty 位均为 0。
+package pow
+
+import (
+	"crypto/sha256"
+	"math/bits"
+	"strconv"
+)
+
+// MaxDifficulty 难度上限，超过该值客户端在合理时间内无法求解
+const MaxDifficulty = 32
+
+// Hash 计算 challenge 与 solution 拼接后的摘要
+func Hash(challenge, solution string) [sha256.Size]byte {
+	return sha256.Sum256([]byte(challenge + ":" + solution))
+}
+
+// LeadingZeroBits 返回摘要开头连续为 0 的位数
+func LeadingZeroBits(sum []byte) int {
+	n := 0
+	for _, b := range sum {
+		if b != 0 {
+			return n + bits.LeadingZeros8(b)
+		}
+		n += 8
+	}
+	return n
+}
+
+// Verify 检查 solution 是否满足难度要求
+func Verify(challenge, solution string, difficulty int) bool {
+	if solution == "" || len(solution) > 64 {
+		return false
+	}
+	sum := Hash(challenge, solution)
+	return LeadingZeroBits(sum[:]) >= difficulty
+}
+
+// Solve 从 0 开始递增计数器直到满足难度，返回十进制形式的 solution。
+// 服务端不调用，供测试与命令行客户端使用
+func Solve(challenge string, difficulty int) string {
+	for i := uint64(0); ; i++ {
+		solution := strconv.FormatUint(i, 10)
+		if Verify(challenge, solution, difficulty) {
+			return solution
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package pow
+
+import "testing"
+
+func TestLeadingZeroBits(t *testing.T) {
+	tests := []struct {
+		sum  []byte
+		want int
+	}{
+		{[]byte{0x80}, 0},
+		{[]byte{0x01}, 7},
+		{[]byte{0x00, 0x10}, 11},
+		{[]byte{0x00, 0x00}, 16},
+	}
+
+	for _, tt := range tests {
+		if got := LeadingZeroBits(tt.sum); got != tt.want {
+			t.Errorf("LeadingZeroBits(%x) = %d, want %d", tt.sum, got, tt.want)
+		}
+	}
+}
+
+func TestSolveAndVerify(t *testing.T) {
+	challenge := "test-challenge"
+	solution := Solve(challenge, 12)
+
+	if !Verify(challenge, solution, 12) {
+		t.Fatalf("solution %q should satisfy difficulty 12", solution)
+	}
+	if Verify("other-challenge", solution, 12) && Verify("another-challenge", solution, 12) {
+		t.Error("solution should be bound to its challenge")
+	}
+}
+
+func TestVerify_RejectsEmptyAndOversizedSolution(t *testing.T) {
+	if Verify("c", "", 0) {
+		t.Error("empty solution should be rejected")
+	}
+	long := make([]byte, 65)
+	for i := range long {
+		long[i] = 'a'
+	}
+	if Verify("c", string(long), 0) {
+		t.Error("oversized solution should be rejected")
+	}
+}
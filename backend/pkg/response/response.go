@@ -4,12 +4,15 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/ysmmc/backend/pkg/logging"
 )
 
 type Response struct {
 	Code    int         `json:"code"`
 	Message string      `json:"message"`
 	Data    interface{} `json:"data,omitempty"`
+	// RequestID 只在错误响应中返回，便于用户反馈问题时对照日志
+	RequestID string `json:"request_id,omitempty"`
 }
 
 type PaginatedData struct {
@@ -37,10 +40,14 @@ func SuccessWithMessage(c *gin.Context, message string, data interface{}) {
 }
 
 func Error(c *gin.Context, httpStatus int, code int, message string) {
-	c.JSON(httpStatus, Response{
+	resp := Response{
 		Code:    code,
 		Message: message,
-	})
+	}
+	if c.Request != nil {
+		resp.RequestID = logging.RequestID(c.Request.Context())
+	}
+	c.JSON(httpStatus, resp)
 }
 
 func BadRequest(c *gin.Context, message string) {
@@ -0,0 +1,10 @@
+// Package templates 嵌入邮件模板，按语言分目录存放：emails/<语言>/<名称>.html 与 .txt。
+//
+// .txt 为纯文本正文，并以 {{define "subject"}} 定义邮件标题；新增模板时两种格式、
+// 所有语言都需提供，部署时可通过 EMAIL_TEMPLATE_DIR 按相同结构覆盖任意文件。
+package templates
+
+import "embed"
+
+//go:embed emails
+var FS embed.FS